@@ -30,5 +30,59 @@ func chainCommand(pHostURL *string) *cobra.Command {
 			}
 		},
 	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "header [height|hash]",
+		Short: "returns the header of the height or the hash",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			res, err := DoRequest((*pHostURL), "chain.header", []interface{}{args[0]})
+			if err != nil {
+				fmt.Println("error :", err)
+			} else {
+				bs, err := json.MarshalIndent(res, "", "\t")
+				if err != nil {
+					fmt.Println("error :", err)
+				} else {
+					fmt.Println(string(bs))
+				}
+			}
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "block [height|hash]",
+		Short: "returns the block of the height or the hash",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			res, err := DoRequest((*pHostURL), "chain.block", []interface{}{args[0]})
+			if err != nil {
+				fmt.Println("error :", err)
+			} else {
+				bs, err := json.MarshalIndent(res, "", "\t")
+				if err != nil {
+					fmt.Println("error :", err)
+				} else {
+					fmt.Println(string(bs))
+				}
+			}
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "account [address]",
+		Short: "returns the account of the address",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			res, err := DoRequest((*pHostURL), "chain.account", []interface{}{args[0]})
+			if err != nil {
+				fmt.Println("error :", err)
+			} else {
+				bs, err := json.MarshalIndent(res, "", "\t")
+				if err != nil {
+					fmt.Println("error :", err)
+				} else {
+					fmt.Println(string(bs))
+				}
+			}
+		},
+	})
 	return cmd
 }
@@ -1,42 +1,15 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"net/http"
+	"context"
 
-	uuid "github.com/satori/go.uuid"
-
-	"github.com/fletaio/fleta_v1/service/apiserver"
+	"github.com/fletaio/fleta_v1/service/apiclient"
 )
 
 func DoRequest(hostURL string, Method string, Params []interface{}) (interface{}, error) {
-	id := uuid.NewV1().String()
-	req := &apiserver.JRPCRequest{
-		JSONRPC: "2.0",
-		ID:      id,
-		Method:  Method,
-		Params:  Params,
-	}
-	bs, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	r, err := http.Post(hostURL+"/api/endpoints/http", "application/json", bytes.NewReader(bs))
-	if err != nil {
+	var result interface{}
+	if err := apiclient.NewHTTPClient(hostURL).Call(context.Background(), Method, &result, Params...); err != nil {
 		return nil, err
 	}
-	defer r.Body.Close()
-
-	var res apiserver.JRPCResponse
-	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
-		return nil, err
-	}
-	if res.Error != nil {
-		return nil, errors.New(fmt.Sprint(res.Error))
-	} else {
-		return res.Result, nil
-	}
+	return result, nil
 }
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/fletaio/fleta_v1/core/types"
 
@@ -23,11 +24,14 @@ import (
 	"github.com/fletaio/fleta_v1/core/chain"
 	"github.com/fletaio/fleta_v1/pof"
 	"github.com/fletaio/fleta_v1/process/admin"
+	"github.com/fletaio/fleta_v1/process/consensus"
 	"github.com/fletaio/fleta_v1/process/formulator"
 	"github.com/fletaio/fleta_v1/process/gateway"
 	"github.com/fletaio/fleta_v1/process/payment"
 	"github.com/fletaio/fleta_v1/process/vault"
 	"github.com/fletaio/fleta_v1/service/apiserver"
+	"github.com/fletaio/fleta_v1/service/monitor"
+	"github.com/fletaio/fleta_v1/service/txpoolapi"
 )
 
 // Config is a configuration for the cmd
@@ -43,6 +47,11 @@ type Config struct {
 	RLogHost       string
 	RLogPath       string
 	UseRLog        bool
+	LeaseFile      string
+	LeaseTTLMs     uint32
+
+	ReadyMaxHeightLag uint32
+	ReadyMinPeers     int
 }
 
 func main() {
@@ -169,8 +178,17 @@ func main() {
 	cn.MustAddProcess(formulator.NewFormulator(3))
 	cn.MustAddProcess(gateway.NewGateway(4))
 	cn.MustAddProcess(payment.NewPayment(5))
+	cn.MustAddProcess(consensus.NewConsensus(6))
 	as := apiserver.NewAPIServer()
 	cn.MustAddService(as)
+	tpa := txpoolapi.NewTxPoolAPI()
+	cn.MustAddService(tpa)
+	mn := monitor.NewMonitor()
+	mn.SetConfig(monitor.Config{
+		MaxHeightLag: cfg.ReadyMaxHeightLag,
+		MinPeers:     cfg.ReadyMinPeers,
+	})
+	cn.MustAddService(mn)
 	if err := cn.Init(); err != nil {
 		panic(err)
 	}
@@ -199,6 +217,17 @@ func main() {
 	if err := fr.Init(); err != nil {
 		panic(err)
 	}
+	tpa.SetNode(fr)
+	mn.SetNode(fr)
+	if len(cfg.LeaseFile) > 0 {
+		fl, err := pof.NewFileLease(cfg.LeaseFile, time.Duration(cfg.LeaseTTLMs)*time.Millisecond)
+		if err != nil {
+			panic(err)
+		}
+		fr.SetLeaderElector(fl)
+		go fl.Run()
+		defer fl.Close()
+	}
 	cm.RemoveAll()
 	cm.Add("formulator", fr)
 
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/fletaio/fleta_v1/core/pile"
 
@@ -19,15 +20,24 @@ import (
 	"github.com/fletaio/fleta_v1/core/backend"
 	_ "github.com/fletaio/fleta_v1/core/backend/buntdb_driver"
 	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/core/txpool"
 	"github.com/fletaio/fleta_v1/core/types"
 	"github.com/fletaio/fleta_v1/pof"
 	"github.com/fletaio/fleta_v1/process/admin"
+	"github.com/fletaio/fleta_v1/process/consensus"
 	"github.com/fletaio/fleta_v1/process/formulator"
 	"github.com/fletaio/fleta_v1/process/gateway"
 	"github.com/fletaio/fleta_v1/process/payment"
 	"github.com/fletaio/fleta_v1/process/vault"
 	"github.com/fletaio/fleta_v1/service/apiserver"
+	"github.com/fletaio/fleta_v1/service/chainapi"
+	"github.com/fletaio/fleta_v1/service/formulatorstats"
+	"github.com/fletaio/fleta_v1/service/graphqlapi"
+	"github.com/fletaio/fleta_v1/service/monitor"
 	"github.com/fletaio/fleta_v1/service/p2p"
+	"github.com/fletaio/fleta_v1/service/p2p/nat"
+	"github.com/fletaio/fleta_v1/service/txapi"
+	"github.com/fletaio/fleta_v1/service/txpoolapi"
 )
 
 // Config is a configuration for the cmd
@@ -41,6 +51,46 @@ type Config struct {
 	RLogHost     string
 	RLogPath     string
 	UseRLog      bool
+
+	StatsRangeSize uint32
+
+	ForkWebhookURL     string
+	ForkBanDurationSec uint32
+
+	PenaltyInvalidBlock     *int
+	PenaltyInvalidTx        *int
+	PenaltyOversizedMessage *int
+	PenaltyHandshakeFailure *int
+	PenaltyBanThreshold     int
+	PenaltyHalfLifeSec      uint32
+	PenaltyBanDurationSec   uint32
+
+	AdvertiseAddress string
+	NAT              string
+	UserAgent        string
+
+	BandwidthGlobalUpload   int64
+	BandwidthGlobalDownload int64
+	BandwidthPeerUpload     int64
+	BandwidthPeerDownload   int64
+
+	TxPoolMaxSize       int
+	TxPoolMaxPerAddress int
+	TxPoolTTLSec        uint32
+	TxPoolPriceBump     int64
+
+	APIKeys              map[string]string
+	APIJWTSecret         string
+	APIACL               map[string][]string
+	APIAllowOrigins      []string
+	APITrustedProxies    []string
+	APIPrivatePort       int
+	APIPrivateNamespaces []string
+	APIRateLimit         float64
+	APIRateBurst         int
+
+	ReadyMaxHeightLag uint32
+	ReadyMinPeers     int
 }
 
 func main() {
@@ -156,13 +206,51 @@ func main() {
 	cn.MustAddProcess(formulator.NewFormulator(3))
 	cn.MustAddProcess(gateway.NewGateway(4))
 	cn.MustAddProcess(payment.NewPayment(5))
+	cn.MustAddProcess(consensus.NewConsensus(6))
 	as := apiserver.NewAPIServer()
+	if cfg.APIPrivatePort > 0 && len(cfg.APIPrivateNamespaces) == 0 {
+		cfg.APIPrivateNamespaces = []string{"p2p"}
+	}
+	as.SetConfig(&apiserver.Config{
+		APIKeys:           cfg.APIKeys,
+		JWTSecret:         cfg.APIJWTSecret,
+		ACL:               cfg.APIACL,
+		AllowOrigins:      cfg.APIAllowOrigins,
+		TrustedProxies:    cfg.APITrustedProxies,
+		PrivateNamespaces: cfg.APIPrivateNamespaces,
+		RateLimit:         cfg.APIRateLimit,
+		RateBurst:         cfg.APIRateBurst,
+	})
 	cn.MustAddService(as)
+	fs := formulatorstats.NewFormulatorStats(cs, cfg.StoreRoot+"/formulatorstats", cfg.StatsRangeSize)
+	cn.MustAddService(fs)
+	fm := p2p.NewForkMonitor(cfg.ForkWebhookURL, time.Duration(cfg.ForkBanDurationSec)*time.Second)
+	cn.MustAddService(fm)
+	pa := p2p.NewPeerAdmin()
+	cn.MustAddService(pa)
+	ta := txapi.NewTxAPI()
+	cn.MustAddService(ta)
+	tpa := txpoolapi.NewTxPoolAPI()
+	cn.MustAddService(tpa)
+	cn.MustAddService(chainapi.NewChainAPI())
+	cn.MustAddService(graphqlapi.NewGraphQLAPI())
+	mn := monitor.NewMonitor()
+	mn.SetConfig(monitor.Config{
+		MaxHeightLag: cfg.ReadyMaxHeightLag,
+		MinPeers:     cfg.ReadyMinPeers,
+	})
+	cn.MustAddService(mn)
 	if err := cn.Init(); err != nil {
 		panic(err)
 	}
 	cm.RemoveAll()
 	cm.Add("chain", cn)
+	cm.Add("formulatorstats", fs)
+	go func() {
+		if err := st.BuildHashIndex(); err != nil {
+			rlog.Println("BuildHashIndex", err)
+		}
+	}()
 
 	if err := st.IterBlockAfterContext(func(b *types.Block) error {
 		if cm.IsClosed() {
@@ -180,14 +268,65 @@ func main() {
 	}
 
 	nd := p2p.NewNode(ndkey, SeedNodeMap, cn, cfg.StoreRoot+"/peer")
+	nd.SetForkMonitor(fm)
+	nd.SetPeerAdmin(pa)
+	ta.SetNode(nd)
+	tpa.SetNode(nd)
+	mn.SetNode(nd)
+	nd.SetPenaltyConfig(&p2p.PenaltyConfig{
+		InvalidBlock:     penaltyPoint(cfg.PenaltyInvalidBlock),
+		InvalidTx:        penaltyPoint(cfg.PenaltyInvalidTx),
+		OversizedMessage: penaltyPoint(cfg.PenaltyOversizedMessage),
+		HandshakeFailure: penaltyPoint(cfg.PenaltyHandshakeFailure),
+		BanThreshold:     cfg.PenaltyBanThreshold,
+		HalfLife:         time.Duration(cfg.PenaltyHalfLifeSec) * time.Second,
+		BanDuration:      time.Duration(cfg.PenaltyBanDurationSec) * time.Second,
+	})
+	nd.SetBandwidthConfig(p2p.BandwidthConfig{
+		GlobalUpload:   cfg.BandwidthGlobalUpload,
+		GlobalDownload: cfg.BandwidthGlobalDownload,
+		PeerUpload:     cfg.BandwidthPeerUpload,
+		PeerDownload:   cfg.BandwidthPeerDownload,
+	})
+	nd.SetTxPoolConfig(txpool.Config{
+		MaxSize:       cfg.TxPoolMaxSize,
+		MaxPerAddress: cfg.TxPoolMaxPerAddress,
+		TTL:           time.Duration(cfg.TxPoolTTLSec) * time.Second,
+		PriceBump:     cfg.TxPoolPriceBump,
+	})
+	if len(cfg.UserAgent) > 0 {
+		nd.SetUserAgent(cfg.UserAgent)
+	} else {
+		nd.SetUserAgent("fleta-node")
+	}
+	if len(cfg.AdvertiseAddress) > 0 {
+		nd.SetAdvertiseAddress(cfg.AdvertiseAddress)
+	}
+	if n, err := nat.Parse(cfg.NAT); err != nil {
+		panic(err)
+	} else if n != nil {
+		nd.SetNAT(n)
+	}
 	if err := nd.Init(); err != nil {
 		panic(err)
 	}
 	cm.RemoveAll()
 	cm.Add("node", nd)
+	cm.Add("formulatorstats", fs)
 
 	go nd.Run(":" + strconv.Itoa(cfg.Port))
 	go as.Run(":" + strconv.Itoa(cfg.APIPort))
+	if cfg.APIPrivatePort > 0 {
+		go as.RunPrivate("127.0.0.1:" + strconv.Itoa(cfg.APIPrivatePort))
+	}
 
 	cm.Wait()
 }
+
+// penaltyPoint returns the penalty point of the config, the default point is used when it is not given and zero disables the penalty
+func penaltyPoint(v *int) int {
+	if v == nil {
+		return -1
+	}
+	return *v
+}
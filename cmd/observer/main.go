@@ -22,11 +22,13 @@ import (
 	"github.com/fletaio/fleta_v1/core/chain"
 	"github.com/fletaio/fleta_v1/pof"
 	"github.com/fletaio/fleta_v1/process/admin"
+	"github.com/fletaio/fleta_v1/process/consensus"
 	"github.com/fletaio/fleta_v1/process/formulator"
 	"github.com/fletaio/fleta_v1/process/gateway"
 	"github.com/fletaio/fleta_v1/process/payment"
 	"github.com/fletaio/fleta_v1/process/vault"
 	"github.com/fletaio/fleta_v1/service/apiserver"
+	"github.com/fletaio/fleta_v1/service/monitor"
 )
 
 // Config is a configuration for the cmd
@@ -40,6 +42,9 @@ type Config struct {
 	RLogHost       string
 	RLogPath       string
 	UseRLog        bool
+
+	ReadyMaxHeightLag uint32
+	ReadyMinPeers     int
 }
 
 func main() {
@@ -126,8 +131,15 @@ func main() {
 	cn.MustAddProcess(formulator.NewFormulator(3))
 	cn.MustAddProcess(gateway.NewGateway(4))
 	cn.MustAddProcess(payment.NewPayment(5))
+	cn.MustAddProcess(consensus.NewConsensus(6))
 	as := apiserver.NewAPIServer()
 	cn.MustAddService(as)
+	mn := monitor.NewMonitor()
+	mn.SetConfig(monitor.Config{
+		MaxHeightLag: cfg.ReadyMaxHeightLag,
+		MinPeers:     cfg.ReadyMinPeers,
+	})
+	cn.MustAddService(mn)
 	if err := cn.Init(); err != nil {
 		panic(err)
 	}
@@ -149,10 +161,15 @@ func main() {
 		panic(err)
 	}
 
-	ob := pof.NewObserverNode(obkey, NetAddressMap, cs)
+	sg, err := pof.NewSignGuard(cfg.StoreRoot + "/sign_guard")
+	if err != nil {
+		panic(err)
+	}
+	ob := pof.NewObserverNode(obkey, NetAddressMap, cs, sg)
 	if err := ob.Init(); err != nil {
 		panic(err)
 	}
+	mn.SetNode(ob)
 	cm.RemoveAll()
 	cm.Add("observer", ob)
 
@@ -24,12 +24,18 @@ import (
 	"github.com/fletaio/fleta_v1/core/types"
 	"github.com/fletaio/fleta_v1/pof"
 	"github.com/fletaio/fleta_v1/process/admin"
+	"github.com/fletaio/fleta_v1/process/consensus"
 	"github.com/fletaio/fleta_v1/process/formulator"
 	"github.com/fletaio/fleta_v1/process/gateway"
 	"github.com/fletaio/fleta_v1/process/payment"
 	"github.com/fletaio/fleta_v1/process/vault"
 	"github.com/fletaio/fleta_v1/service/apiserver"
+	"github.com/fletaio/fleta_v1/service/chainapi"
+	"github.com/fletaio/fleta_v1/service/graphqlapi"
+	"github.com/fletaio/fleta_v1/service/monitor"
 	"github.com/fletaio/fleta_v1/service/p2p"
+	"github.com/fletaio/fleta_v1/service/txapi"
+	"github.com/fletaio/fleta_v1/service/txpoolapi"
 )
 
 // Config is a configuration for the cmd
@@ -43,6 +49,19 @@ type Config struct {
 	RLogHost     string
 	RLogPath     string
 	UseRLog      bool
+
+	APIKeys              map[string]string
+	APIJWTSecret         string
+	APIACL               map[string][]string
+	APIAllowOrigins      []string
+	APITrustedProxies    []string
+	APIPrivatePort       int
+	APIPrivateNamespaces []string
+	APIRateLimit         float64
+	APIRateBurst         int
+
+	ReadyMaxHeightLag uint32
+	ReadyMinPeers     int
 }
 
 func main() {
@@ -160,7 +179,21 @@ func main() {
 	cn.MustAddProcess(fp)
 	cn.MustAddProcess(gateway.NewGateway(4))
 	cn.MustAddProcess(payment.NewPayment(5))
+	cn.MustAddProcess(consensus.NewConsensus(6))
 	as := apiserver.NewAPIServer()
+	if cfg.APIPrivatePort > 0 && len(cfg.APIPrivateNamespaces) == 0 {
+		cfg.APIPrivateNamespaces = []string{"bank"}
+	}
+	as.SetConfig(&apiserver.Config{
+		APIKeys:           cfg.APIKeys,
+		JWTSecret:         cfg.APIJWTSecret,
+		ACL:               cfg.APIACL,
+		AllowOrigins:      cfg.APIAllowOrigins,
+		TrustedProxies:    cfg.APITrustedProxies,
+		PrivateNamespaces: cfg.APIPrivateNamespaces,
+		RateLimit:         cfg.APIRateLimit,
+		RateBurst:         cfg.APIRateBurst,
+	})
 	cn.MustAddService(as)
 	keyStore, err := backend.Create("buntdb", cfg.StoreRoot+"/keystore")
 	if err != nil {
@@ -168,6 +201,18 @@ func main() {
 	}
 	bp := bank.NewBank(keyStore, cfg.StoreRoot+"/bank")
 	cn.MustAddService(bp)
+	ta := txapi.NewTxAPI()
+	cn.MustAddService(ta)
+	tpa := txpoolapi.NewTxPoolAPI()
+	cn.MustAddService(tpa)
+	cn.MustAddService(chainapi.NewChainAPI())
+	cn.MustAddService(graphqlapi.NewGraphQLAPI())
+	mn := monitor.NewMonitor()
+	mn.SetConfig(monitor.Config{
+		MaxHeightLag: cfg.ReadyMaxHeightLag,
+		MinPeers:     cfg.ReadyMinPeers,
+	})
+	cn.MustAddService(mn)
 	if err := cn.Init(); err != nil {
 		panic(err)
 	}
@@ -176,6 +221,11 @@ func main() {
 	}
 	cm.RemoveAll()
 	cm.Add("chain", cn)
+	go func() {
+		if err := st.BuildHashIndex(); err != nil {
+			rlog.Println("BuildHashIndex", err)
+		}
+	}()
 
 	if err := st.IterBlockAfterContext(func(b *types.Block) error {
 		if cm.IsClosed() {
@@ -198,11 +248,17 @@ func main() {
 		panic(err)
 	}
 	bp.SetNode(nd)
+	ta.SetNode(nd)
+	tpa.SetNode(nd)
+	mn.SetNode(nd)
 	cm.RemoveAll()
 	cm.Add("node", nd)
 
 	go nd.Run(":" + strconv.Itoa(cfg.Port))
 	go as.Run(":" + strconv.Itoa(cfg.APIPort))
+	if cfg.APIPrivatePort > 0 {
+		go as.RunPrivate("127.0.0.1:" + strconv.Itoa(cfg.APIPrivatePort))
+	}
 
 	cm.Wait()
 }
@@ -24,6 +24,11 @@ func CompressPubkey(pubkey *ecdsa.PublicKey) []byte {
 	return ecrypto.CompressPubkey(pubkey)
 }
 
+// DecompressPubkey parses a public key in the 33-byte compressed format.
+func DecompressPubkey(pubkey []byte) (*ecdsa.PublicKey, error) {
+	return ecrypto.DecompressPubkey(pubkey)
+}
+
 // S256 returns an instance of the secp256k1 curve.
 func S256() elliptic.Curve {
 	return ecrypto.S256()
@@ -7,4 +7,5 @@ var (
 	ErrExistType     = errors.New("exist type")
 	ErrExistTypeName = errors.New("exist type name")
 	ErrUnknownType   = errors.New("unknown type")
+	ErrAmbiguousType = errors.New("ambiguous type")
 )
@@ -2,6 +2,7 @@ package factory
 
 import (
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/fletaio/fleta_v1/common/hash"
@@ -83,6 +84,32 @@ func (fc *Factory) TypeName(t uint16) (string, error) {
 	return name, nil
 }
 
+// TypeByName returns the type of the name, the name can be the full name or the short name like vault.Transfer
+// The short name is ambiguous when types of different packages have it, the full name should be used for them
+func (fc *Factory) TypeByName(name string) (uint16, error) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	if t, has := fc.nameTypeMap[name]; has {
+		return t, nil
+	}
+	var found bool
+	var ft uint16
+	for t, v := range fc.typeNameMap {
+		if idx := strings.LastIndex(v, "/"); idx >= 0 && v[idx+1:] == name {
+			if found {
+				return 0, ErrAmbiguousType
+			}
+			found = true
+			ft = t
+		}
+	}
+	if !found {
+		return 0, ErrUnknownType
+	}
+	return ft, nil
+}
+
 func typeNameOf(rt reflect.Type) string {
 	for rt.Kind() == reflect.Ptr {
 		rt = rt.Elem()
@@ -0,0 +1,54 @@
+package factory
+
+import (
+	"testing"
+)
+
+type testType struct {
+	Value uint64
+}
+
+type otherType struct {
+	Value string
+}
+
+func TestFactoryTypeByName(t *testing.T) {
+	fc := NewFactory()
+	if err := fc.Register(1, &testType{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.Register(2, &otherType{}); err != nil {
+		t.Fatal(err)
+	}
+	name, err := fc.TypeName(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp, err := fc.TypeByName(name); err != nil || tp != 1 {
+		t.Fatalf("invalid type of the full name: %v %v", tp, err)
+	}
+	if tp, err := fc.TypeByName("factory.testType"); err != nil || tp != 1 {
+		t.Fatalf("invalid type of the short name: %v %v", tp, err)
+	}
+	if tp, err := fc.TypeByName("factory.otherType"); err != nil || tp != 2 {
+		t.Fatalf("invalid type of the short name: %v %v", tp, err)
+	}
+	if _, err := fc.TypeByName("factory.unknownType"); err != ErrUnknownType {
+		t.Fatalf("found the unknown type: %v", err)
+	}
+
+	// a type of the other package that has the same short name
+	fc.nameTypeMap["example.com/other/factory.testType"] = 3
+	fc.typeNameMap[3] = "example.com/other/factory.testType"
+	for i := 0; i < 10; i++ {
+		if _, err := fc.TypeByName("factory.testType"); err != ErrAmbiguousType {
+			t.Fatalf("found the ambiguous type: %v", err)
+		}
+	}
+	if tp, err := fc.TypeByName(name); err != nil || tp != 1 {
+		t.Fatalf("invalid type of the full name: %v %v", tp, err)
+	}
+	if tp, err := fc.TypeByName("example.com/other/factory.testType"); err != nil || tp != 3 {
+		t.Fatalf("invalid type of the full name: %v %v", tp, err)
+	}
+}
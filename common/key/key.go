@@ -3,12 +3,14 @@ package key
 import (
 	"github.com/fletaio/fleta_v1/common"
 	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/schnorr"
 )
 
 // Key defines crypto key functions
 type Key interface {
 	Sign(h hash.Hash256) (common.Signature, error)
 	SignWithPassphrase(h hash.Hash256, passphrase []byte) (common.Signature, error)
+	SchnorrSign(h hash.Hash256) (schnorr.Signature, error)
 	Verify(h hash.Hash256, sig common.Signature) bool
 	PublicKey() common.PublicKey
 	Clear()
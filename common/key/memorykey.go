@@ -10,6 +10,7 @@ import (
 	"github.com/fletaio/fleta_v1/common"
 	ecrypto "github.com/fletaio/fleta_v1/common/crypto"
 	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/schnorr"
 )
 
 func init() {
@@ -106,6 +107,11 @@ func (ac *MemoryKey) Sign(h hash.Hash256) (common.Signature, error) {
 	return sig, nil
 }
 
+// SchnorrSign generates the schnorr signature of the target hash
+func (ac *MemoryKey) SchnorrSign(h hash.Hash256) (schnorr.Signature, error) {
+	return schnorr.Sign(ac.PrivKey.D, h)
+}
+
 // SignWithPassphrase doesn't implemented yet
 func (ac *MemoryKey) SignWithPassphrase(h hash.Hash256, passphrase []byte) (common.Signature, error) {
 	return common.Signature{}, nil
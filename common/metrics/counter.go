@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"bufio"
+	"strconv"
+	"sync/atomic"
+)
+
+// Counter is the value that only increases like sent bytes
+type Counter struct {
+	value uint64
+}
+
+// Inc increases the counter by one
+func (c *Counter) Inc() {
+	atomic.AddUint64(&c.value, 1)
+}
+
+// Add increases the counter by n
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.value, n)
+}
+
+// Value returns the current value
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.value)
+}
+
+func (c *Counter) write(w *bufio.Writer, name string, labels string) {
+	writeSample(w, name, labels, strconv.FormatUint(c.Value(), 10))
+}
@@ -0,0 +1,11 @@
+package metrics
+
+import (
+	"errors"
+)
+
+// errors
+var (
+	ErrInvalidLabels    = errors.New("invalid labels")
+	ErrMismatchedMetric = errors.New("mismatched metric")
+)
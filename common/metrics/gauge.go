@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Gauge is the value that goes up and down like the size of the pool
+type Gauge struct {
+	bits uint64
+}
+
+// Set sets the value
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// Add adds the delta to the value, the delta can be negative
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&g.bits)
+		v := math.Float64frombits(old) + delta
+		if atomic.CompareAndSwapUint64(&g.bits, old, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+// Value returns the current value
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+func (g *Gauge) write(w *bufio.Writer, name string, labels string) {
+	writeSample(w, name, labels, formatFloat(g.Value()))
+}
+
+// gaugeFunc is the gauge that is evaluated when it is written
+type gaugeFunc struct {
+	sync.Mutex
+	fn func() float64
+}
+
+func (g *gaugeFunc) set(fn func() float64) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.fn = fn
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer, name string, labels string) {
+	g.Lock()
+	fn := g.fn
+	g.Unlock()
+
+	if fn != nil {
+		writeSample(w, name, labels, formatFloat(fn()))
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are upper bounds of buckets in seconds that fit latencies of blocks and rounds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observed values in buckets like the latency of the block connection
+type Histogram struct {
+	sync.Mutex
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func newHistogram(Buckets []float64) *Histogram {
+	if len(Buckets) == 0 {
+		Buckets = DefaultBuckets
+	}
+	upperBounds := make([]float64, len(Buckets))
+	copy(upperBounds, Buckets)
+	sort.Float64s(upperBounds)
+	return &Histogram{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)),
+	}
+}
+
+// Observe adds the value to the histogram
+func (h *Histogram) Observe(v float64) {
+	idx := sort.SearchFloat64s(h.upperBounds, v)
+
+	h.Lock()
+	defer h.Unlock()
+
+	if idx < len(h.counts) {
+		h.counts[idx]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveDuration adds the duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// ObserveSince adds the elapsed time from the begin in seconds
+func (h *Histogram) ObserveSince(begin time.Time) {
+	h.Observe(time.Since(begin).Seconds())
+}
+
+// Count returns the number of observed values
+func (h *Histogram) Count() uint64 {
+	h.Lock()
+	defer h.Unlock()
+
+	return h.count
+}
+
+func (h *Histogram) write(w *bufio.Writer, name string, labels string) {
+	h.Lock()
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	count := h.count
+	sum := h.sum
+	h.Unlock()
+
+	var cumulative uint64
+	for i, ub := range h.upperBounds {
+		cumulative += counts[i]
+		writeSample(w, name+"_bucket", withLabel(labels, "le", formatFloat(ub)), strconv.FormatUint(cumulative, 10))
+	}
+	writeSample(w, name+"_bucket", withLabel(labels, "le", formatFloat(math.Inf(1))), strconv.FormatUint(count, 10))
+	writeSample(w, name+"_sum", labels, formatFloat(sum))
+	writeSample(w, name+"_count", labels, strconv.FormatUint(count, 10))
+}
@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric types
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// DefaultRegistry is the registry that metrics of the chain, the txpool, p2p and consensus are registered
+var DefaultRegistry = NewRegistry()
+
+type metric interface {
+	write(w *bufio.Writer, name string, labels string)
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	metrics map[string]metric
+}
+
+// Registry keeps metrics by names and labels and writes them in the prometheus text format
+// The same metric is returned when it is registered again by the same name and labels
+type Registry struct {
+	sync.Mutex
+	familyMap map[string]*family
+}
+
+// NewRegistry returns a Registry
+func NewRegistry() *Registry {
+	r := &Registry{
+		familyMap: map[string]*family{},
+	}
+	return r
+}
+
+// NewCounter returns the counter of the default registry, labels are pairs of the key and the value
+func NewCounter(Name string, Help string, Labels ...string) *Counter {
+	return DefaultRegistry.Counter(Name, Help, Labels...)
+}
+
+// NewGauge returns the gauge of the default registry
+func NewGauge(Name string, Help string, Labels ...string) *Gauge {
+	return DefaultRegistry.Gauge(Name, Help, Labels...)
+}
+
+// NewGaugeFunc registers the gauge that is evaluated when metrics are written to the default registry
+func NewGaugeFunc(Name string, Help string, fn func() float64, Labels ...string) {
+	DefaultRegistry.GaugeFunc(Name, Help, fn, Labels...)
+}
+
+// NewHistogram returns the histogram of the default registry, DefaultBuckets is used when buckets are empty
+func NewHistogram(Name string, Help string, Buckets []float64, Labels ...string) *Histogram {
+	return DefaultRegistry.Histogram(Name, Help, Buckets, Labels...)
+}
+
+// Counter returns the counter of the name and labels
+func (r *Registry) Counter(Name string, Help string, Labels ...string) *Counter {
+	c, is := r.register(Name, Help, counterType, Labels, func() metric {
+		return &Counter{}
+	}).(*Counter)
+	if !is {
+		panic(ErrMismatchedMetric)
+	}
+	return c
+}
+
+// Gauge returns the gauge of the name and labels
+func (r *Registry) Gauge(Name string, Help string, Labels ...string) *Gauge {
+	g, is := r.register(Name, Help, gaugeType, Labels, func() metric {
+		return &Gauge{}
+	}).(*Gauge)
+	if !is {
+		panic(ErrMismatchedMetric)
+	}
+	return g
+}
+
+// GaugeFunc registers the gauge that is evaluated when metrics are written, it replaces the function of the same name and labels
+func (r *Registry) GaugeFunc(Name string, Help string, fn func() float64, Labels ...string) {
+	gf, is := r.register(Name, Help, gaugeType, Labels, func() metric {
+		return &gaugeFunc{}
+	}).(*gaugeFunc)
+	if !is {
+		panic(ErrMismatchedMetric)
+	}
+	gf.set(fn)
+}
+
+// Histogram returns the histogram of the name and labels
+func (r *Registry) Histogram(Name string, Help string, Buckets []float64, Labels ...string) *Histogram {
+	h, is := r.register(Name, Help, histogramType, Labels, func() metric {
+		return newHistogram(Buckets)
+	}).(*Histogram)
+	if !is {
+		panic(ErrMismatchedMetric)
+	}
+	return h
+}
+
+func (r *Registry) register(Name string, Help string, typ string, Labels []string, fn func() metric) metric {
+	if len(Labels)%2 != 0 {
+		panic(ErrInvalidLabels)
+	}
+	key := labelString(Labels)
+
+	r.Lock()
+	defer r.Unlock()
+
+	f, has := r.familyMap[Name]
+	if !has {
+		f = &family{
+			name:    Name,
+			help:    Help,
+			typ:     typ,
+			metrics: map[string]metric{},
+		}
+		r.familyMap[Name] = f
+	} else if f.typ != typ {
+		panic(ErrMismatchedMetric)
+	}
+	m, has := f.metrics[key]
+	if !has {
+		m = fn()
+		f.metrics[key] = m
+	}
+	return m
+}
+
+// WritePrometheus writes metrics in the prometheus text format, families and labels are sorted
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.Lock()
+	families := make([]*family, 0, len(r.familyMap))
+	for _, f := range r.familyMap {
+		families = append(families, f)
+	}
+	keysMap := map[string][]string{}
+	metricsMap := map[string]map[string]metric{}
+	for _, f := range families {
+		keys := make([]string, 0, len(f.metrics))
+		mp := make(map[string]metric, len(f.metrics))
+		for k, m := range f.metrics {
+			keys = append(keys, k)
+			mp[k] = m
+		}
+		sort.Strings(keys)
+		keysMap[f.name] = keys
+		metricsMap[f.name] = mp
+	}
+	r.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		bw.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		bw.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+		for _, k := range keysMap[f.name] {
+			metricsMap[f.name][k].write(bw, f.name, k)
+		}
+	}
+	return bw.Flush()
+}
+
+// labelString returns labels in the form of {key="value",...} that is sorted by keys
+func labelString(Labels []string) string {
+	if len(Labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(Labels)/2)
+	for i := 0; i < len(Labels); i += 2 {
+		pairs = append(pairs, Labels[i]+`="`+escapeLabel(Labels[i+1])+`"`)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel appends the label to the label string
+func withLabel(labels string, key string, value string) string {
+	pair := key + `="` + escapeLabel(value) + `"`
+	if len(labels) == 0 {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func escapeLabel(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `"`, `\"`, -1)
+	return strings.Replace(v, "\n", `\n`, -1)
+}
+
+func escapeHelp(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	return strings.Replace(v, "\n", `\n`, -1)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels string, value string) {
+	w.WriteString(name)
+	w.WriteString(labels)
+	w.WriteByte(' ')
+	w.WriteString(value)
+	w.WriteByte('\n')
+}
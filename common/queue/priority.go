@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// queue errors
+var (
+	ErrQueueTimeout = errors.New("queue timeout")
+	ErrQueueFull    = errors.New("queue full")
+	ErrQueueClosed  = errors.New("queue closed")
+)
+
+// PriorityQueue pops items of the lower priority value first, items of the same priority are popped in order
+// The total weight of items is limited, so Push waits until items are popped when the queue is full
+type PriorityQueue struct {
+	sync.Mutex
+	cond      *sync.Cond
+	queues    []*Queue
+	weight    int
+	maxWeight int
+	isClose   bool
+}
+
+// NewPriorityQueue returns a PriorityQueue that has the count of priorities and the max weight
+// The weight of the queue is not limited when the max weight is not positive
+func NewPriorityQueue(PriorityCount int, MaxWeight int) *PriorityQueue {
+	q := &PriorityQueue{
+		queues:    make([]*Queue, PriorityCount),
+		maxWeight: MaxWeight,
+	}
+	q.cond = sync.NewCond(&q.Mutex)
+	for i := range q.queues {
+		q.queues[i] = NewQueue()
+	}
+	return q
+}
+
+type weightedItem struct {
+	item   interface{}
+	weight int
+}
+
+// Push inserts the item to the queue of the priority, it waits while the queue is full
+// It returns ErrQueueTimeout when the queue is still full after the timeout, it waits forever when the timeout is not positive
+// An item that is heavier than the max weight is pushed when the queue is empty
+func (q *PriorityQueue) Push(item interface{}, Priority int, Weight int, timeout time.Duration) error {
+	if Priority < 0 {
+		Priority = 0
+	} else if Priority >= len(q.queues) {
+		Priority = len(q.queues) - 1
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if q.maxWeight > 0 {
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+			timer := time.AfterFunc(timeout, func() {
+				q.Lock()
+				q.cond.Broadcast()
+				q.Unlock()
+			})
+			defer timer.Stop()
+		}
+		for !q.isClose && q.weight > 0 && q.weight+Weight > q.maxWeight {
+			if timeout > 0 && !time.Now().Before(deadline) {
+				return ErrQueueTimeout
+			}
+			q.cond.Wait()
+		}
+	}
+	if q.isClose {
+		return ErrQueueClosed
+	}
+	q.queues[Priority].Push(&weightedItem{item: item, weight: Weight})
+	q.weight += Weight
+	q.cond.Broadcast()
+	return nil
+}
+
+// TryPush inserts the item to the queue of the priority without waiting
+// It returns ErrQueueFull when the queue is full
+func (q *PriorityQueue) TryPush(item interface{}, Priority int, Weight int) error {
+	if Priority < 0 {
+		Priority = 0
+	} else if Priority >= len(q.queues) {
+		Priority = len(q.queues) - 1
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if q.isClose {
+		return ErrQueueClosed
+	}
+	if q.maxWeight > 0 && q.weight > 0 && q.weight+Weight > q.maxWeight {
+		return ErrQueueFull
+	}
+	q.queues[Priority].Push(&weightedItem{item: item, weight: Weight})
+	q.weight += Weight
+	q.cond.Broadcast()
+	return nil
+}
+
+// Pop returns the item of the highest priority, it returns nil when the queue is empty
+func (q *PriorityQueue) Pop() interface{} {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.pop()
+}
+
+// PopWait returns the item of the highest priority, it waits while the queue is empty
+// It returns nil when the queue is closed
+func (q *PriorityQueue) PopWait() interface{} {
+	q.Lock()
+	defer q.Unlock()
+
+	for !q.isClose {
+		if v := q.pop(); v != nil {
+			return v
+		}
+		q.cond.Wait()
+	}
+	return nil
+}
+
+func (q *PriorityQueue) pop() interface{} {
+	for _, pq := range q.queues {
+		if v := pq.Pop(); v != nil {
+			wi := v.(*weightedItem)
+			q.weight -= wi.weight
+			q.cond.Broadcast()
+			return wi.item
+		}
+	}
+	return nil
+}
+
+// Close wakes up waiting calls, PopWait returns nil and Push returns ErrQueueClosed after it
+func (q *PriorityQueue) Close() {
+	q.Lock()
+	defer q.Unlock()
+
+	q.isClose = true
+	q.cond.Broadcast()
+}
+
+// Size returns the number of items
+func (q *PriorityQueue) Size() int {
+	q.Lock()
+	defer q.Unlock()
+
+	size := 0
+	for _, pq := range q.queues {
+		size += pq.Size()
+	}
+	return size
+}
+
+// Weight returns the total weight of items
+func (q *PriorityQueue) Weight() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.weight
+}
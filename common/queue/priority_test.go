@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueTryPush(t *testing.T) {
+	q := NewPriorityQueue(2, 10)
+	if err := q.TryPush("low", 1, 6); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryPush("high", 0, 5); err != ErrQueueFull {
+		t.Fatalf("pushed to the full queue: %v", err)
+	}
+	if err := q.TryPush("high", 0, 4); err != nil {
+		t.Fatal(err)
+	}
+	if v := q.Pop(); v != "high" {
+		t.Fatalf("invalid priority order: %v", v)
+	}
+	if v := q.Pop(); v != "low" {
+		t.Fatalf("invalid priority order: %v", v)
+	}
+
+	// an item that is heavier than the max weight is pushed when the queue is empty
+	if err := q.TryPush("heavy", 0, 20); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := q.Push("wait", 0, 1, 50*time.Millisecond); err != ErrQueueTimeout {
+		t.Fatalf("pushed to the full queue: %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("returned before the timeout")
+	}
+}
+
+func TestPriorityQueuePopWait(t *testing.T) {
+	q := NewPriorityQueue(2, 10)
+	ch := make(chan interface{}, 2)
+	go func() {
+		for {
+			v := q.PopWait()
+			ch <- v
+			if v == nil {
+				return
+			}
+		}
+	}()
+	select {
+	case v := <-ch:
+		t.Fatalf("popped from the empty queue: %v", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := q.TryPush("item", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-ch:
+		if v != "item" {
+			t.Fatalf("invalid item: %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting pop is not woken up by the push")
+	}
+
+	q.Close()
+	select {
+	case v := <-ch:
+		if v != nil {
+			t.Fatalf("popped after the close: %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting pop is not woken up by the close")
+	}
+	if err := q.Push("closed", 0, 1, 0); err != ErrQueueClosed {
+		t.Fatalf("pushed to the closed queue: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package schnorr
+
+import (
+	"math/big"
+
+	ecrypto "github.com/fletaio/fleta_v1/common/crypto"
+)
+
+// secp256k1 parameters
+var (
+	curveP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	curveN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	curveGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	curveGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	curveB     = big.NewInt(7)
+	sqrtExp    = new(big.Int).Rsh(new(big.Int).Add(curveP, big.NewInt(1)), 2)
+)
+
+// point is a point of the curve in the jacobian coordinates
+type point struct {
+	X *big.Int
+	Y *big.Int
+	Z *big.Int
+}
+
+func newInfinity() *point {
+	return &point{X: big.NewInt(1), Y: big.NewInt(1), Z: new(big.Int)}
+}
+
+func newAffinePoint(x *big.Int, y *big.Int) *point {
+	return &point{X: new(big.Int).Set(x), Y: new(big.Int).Set(y), Z: big.NewInt(1)}
+}
+
+func basePoint() *point {
+	return newAffinePoint(curveGx, curveGy)
+}
+
+func (pt *point) isInfinity() bool {
+	return pt.Z.Sign() == 0
+}
+
+func modP(v *big.Int) *big.Int {
+	return v.Mod(v, curveP)
+}
+
+func (pt *point) double() *point {
+	if pt.isInfinity() || pt.Y.Sign() == 0 {
+		return newInfinity()
+	}
+	A := modP(new(big.Int).Mul(pt.X, pt.X))
+	B := modP(new(big.Int).Mul(pt.Y, pt.Y))
+	C := modP(new(big.Int).Mul(B, B))
+	D := new(big.Int).Add(pt.X, B)
+	D.Mul(D, D)
+	D.Sub(D, A)
+	D.Sub(D, C)
+	D.Lsh(D, 1)
+	modP(D)
+	E := modP(new(big.Int).Mul(A, big.NewInt(3)))
+	F := modP(new(big.Int).Mul(E, E))
+
+	X3 := new(big.Int).Sub(F, new(big.Int).Lsh(D, 1))
+	modP(X3)
+	Y3 := new(big.Int).Sub(D, X3)
+	Y3.Mul(Y3, E)
+	Y3.Sub(Y3, new(big.Int).Lsh(C, 3))
+	modP(Y3)
+	Z3 := new(big.Int).Mul(pt.Y, pt.Z)
+	Z3.Lsh(Z3, 1)
+	modP(Z3)
+	return &point{X: X3, Y: Y3, Z: Z3}
+}
+
+func (pt *point) add(q *point) *point {
+	if pt.isInfinity() {
+		return q
+	}
+	if q.isInfinity() {
+		return pt
+	}
+	Z1Z1 := modP(new(big.Int).Mul(pt.Z, pt.Z))
+	Z2Z2 := modP(new(big.Int).Mul(q.Z, q.Z))
+	U1 := modP(new(big.Int).Mul(pt.X, Z2Z2))
+	U2 := modP(new(big.Int).Mul(q.X, Z1Z1))
+	S1 := modP(new(big.Int).Mul(new(big.Int).Mul(pt.Y, q.Z), Z2Z2))
+	S2 := modP(new(big.Int).Mul(new(big.Int).Mul(q.Y, pt.Z), Z1Z1))
+	H := modP(new(big.Int).Sub(U2, U1))
+	R := modP(new(big.Int).Sub(S2, S1))
+	if H.Sign() == 0 {
+		if R.Sign() == 0 {
+			return pt.double()
+		}
+		return newInfinity()
+	}
+	HH := modP(new(big.Int).Mul(H, H))
+	HHH := modP(new(big.Int).Mul(H, HH))
+	V := modP(new(big.Int).Mul(U1, HH))
+
+	X3 := new(big.Int).Mul(R, R)
+	X3.Sub(X3, HHH)
+	X3.Sub(X3, new(big.Int).Lsh(V, 1))
+	modP(X3)
+	Y3 := new(big.Int).Sub(V, X3)
+	Y3.Mul(Y3, R)
+	Y3.Sub(Y3, new(big.Int).Mul(S1, HHH))
+	modP(Y3)
+	Z3 := new(big.Int).Mul(pt.Z, q.Z)
+	Z3.Mul(Z3, H)
+	modP(Z3)
+	return &point{X: X3, Y: Y3, Z: Z3}
+}
+
+// affine returns the affine coordinates of the point
+func (pt *point) affine() (*big.Int, *big.Int) {
+	if pt.isInfinity() {
+		return new(big.Int), new(big.Int)
+	}
+	zinv := new(big.Int).ModInverse(pt.Z, curveP)
+	zinv2 := modP(new(big.Int).Mul(zinv, zinv))
+	x := modP(new(big.Int).Mul(pt.X, zinv2))
+	y := modP(new(big.Int).Mul(new(big.Int).Mul(pt.Y, zinv2), zinv))
+	return x, y
+}
+
+// multiScalarMult returns sum(scalars[i] * points[i]) by sharing the doublings
+// It is not constant time, so it should be used only with public scalars of the verification
+func multiScalarMult(points []*point, scalars []*big.Int) *point {
+	acc := newInfinity()
+	for bit := 255; bit >= 0; bit-- {
+		acc = acc.double()
+		for i, k := range scalars {
+			if k.Bit(bit) == 1 {
+				acc = acc.add(points[i])
+			}
+		}
+	}
+	return acc
+}
+
+// scalarBaseMult returns k * G by the constant time multiplication of libsecp256k1
+// It is used with secret scalars like the private key and the nonce, so the time should not depend on them
+func scalarBaseMult(k *big.Int) *point {
+	x, y := ecrypto.S256().ScalarBaseMult(bytes32(k))
+	if x == nil {
+		return newInfinity()
+	}
+	return newAffinePoint(x, y)
+}
+
+// liftX returns the point that has the x coordinate and the even y coordinate
+func liftX(x *big.Int) (*point, error) {
+	if x.Cmp(curveP) >= 0 {
+		return nil, ErrInvalidPoint
+	}
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	y2.Add(y2, curveB)
+	modP(y2)
+	y := new(big.Int).Exp(y2, sqrtExp, curveP)
+	if modP(new(big.Int).Mul(y, y)).Cmp(y2) != 0 {
+		return nil, ErrInvalidPoint
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(curveP, y)
+	}
+	return newAffinePoint(x, y), nil
+}
+
+// decompress parses the 33 bytes compressed public key
+func decompress(bs []byte) (*point, error) {
+	if len(bs) != 33 || (bs[0] != 2 && bs[0] != 3) {
+		return nil, ErrInvalidPublicKey
+	}
+	pt, err := liftX(new(big.Int).SetBytes(bs[1:]))
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	if bs[0] == 3 {
+		pt.Y.Sub(curveP, pt.Y)
+	}
+	return pt, nil
+}
+
+func bytes32(v *big.Int) []byte {
+	bs := make([]byte, 32)
+	vb := v.Bytes()
+	copy(bs[32-len(vb):], vb)
+	return bs
+}
@@ -0,0 +1,13 @@
+package schnorr
+
+import "errors"
+
+// errors
+var (
+	ErrInvalidPoint         = errors.New("invalid point")
+	ErrInvalidPublicKey     = errors.New("invalid public key")
+	ErrInvalidPrivateKey    = errors.New("invalid private key")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrInvalidSignerCount   = errors.New("invalid signer count")
+	ErrInvalidAggregateData = errors.New("invalid aggregate data")
+)
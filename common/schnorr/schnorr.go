@@ -0,0 +1,192 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+)
+
+// SignatureSize is 64 bytes
+const SignatureSize = 64
+
+// Signature is the x coordinate of the nonce point and the scalar
+type Signature [SignatureSize]byte
+
+var (
+	tagNonce     = sha256.Sum256([]byte("fleta/schnorr/nonce"))
+	tagChallenge = sha256.Sum256([]byte("fleta/schnorr/challenge"))
+	tagAggregate = sha256.Sum256([]byte("fleta/schnorr/aggregate"))
+)
+
+func taggedHash(tag [32]byte, data ...[]byte) *big.Int {
+	h := sha256.New()
+	h.Write(tag[:])
+	h.Write(tag[:])
+	for _, v := range data {
+		h.Write(v)
+	}
+	v := new(big.Int).SetBytes(h.Sum(nil))
+	return v.Mod(v, curveN)
+}
+
+func challenge(Rx []byte, pubkey common.PublicKey, h hash.Hash256) *big.Int {
+	return taggedHash(tagChallenge, Rx, pubkey[:], h[:])
+}
+
+// Sign generates the schnorr signature of the hash by the private key
+func Sign(priv *big.Int, h hash.Hash256) (Signature, error) {
+	if priv.Sign() <= 0 || priv.Cmp(curveN) >= 0 {
+		return Signature{}, ErrInvalidPrivateKey
+	}
+	pubkey := PublicKey(priv)
+
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return Signature{}, err
+	}
+	k := taggedHash(tagNonce, bytes32(priv), pubkey[:], h[:], aux)
+	if k.Sign() == 0 {
+		return Signature{}, ErrInvalidSignature
+	}
+	Rx, Ry := scalarBaseMult(k).affine()
+	if Ry.Bit(0) == 1 {
+		k.Sub(curveN, k)
+	}
+	RxBytes := bytes32(Rx)
+	e := challenge(RxBytes, pubkey, h)
+
+	s := new(big.Int).Mul(e, priv)
+	s.Add(s, k)
+	s.Mod(s, curveN)
+
+	var sig Signature
+	copy(sig[:32], RxBytes)
+	copy(sig[32:], bytes32(s))
+	return sig, nil
+}
+
+// PublicKey returns the compressed public key of the private key
+func PublicKey(priv *big.Int) common.PublicKey {
+	x, y := scalarBaseMult(priv).affine()
+	var pubkey common.PublicKey
+	pubkey[0] = byte(2 + y.Bit(0))
+	copy(pubkey[1:], bytes32(x))
+	return pubkey
+}
+
+// Verify checks that the signature is generated by the hash and the public key or not
+func Verify(pubkey common.PublicKey, h hash.Hash256, sig Signature) error {
+	P, err := decompress(pubkey[:])
+	if err != nil {
+		return err
+	}
+	R, err := liftX(new(big.Int).SetBytes(sig[:32]))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(curveN) >= 0 {
+		return ErrInvalidSignature
+	}
+	e := challenge(sig[:32], pubkey, h)
+
+	// s*G - e*P - R should be the infinity
+	sum := multiScalarMult([]*point{basePoint(), P}, []*big.Int{s, new(big.Int).Sub(curveN, e)})
+	Rx, Ry := R.affine()
+	x, y := sum.affine()
+	if sum.isInfinity() || x.Cmp(Rx) != 0 || y.Cmp(Ry) != 0 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// AggregateSignature is the half aggregated signature of the signatures on the same hash
+// It keeps the nonce of each signer and sums the scalars with the random coefficients
+type AggregateSignature struct {
+	Rs [][32]byte
+	S  [32]byte
+}
+
+func coefficients(pubkeys []common.PublicKey, h hash.Hash256, Rs [][32]byte) []*big.Int {
+	data := make([][]byte, 0, len(Rs)+len(pubkeys)+2)
+	for _, R := range Rs {
+		R := R
+		data = append(data, R[:])
+	}
+	for _, pubkey := range pubkeys {
+		pubkey := pubkey
+		data = append(data, pubkey[:])
+	}
+	data = append(data, h[:])
+	zs := make([]*big.Int, len(Rs))
+	zs[0] = big.NewInt(1)
+	for i := 1; i < len(Rs); i++ {
+		bs := make([]byte, 4)
+		binary.BigEndian.PutUint32(bs, uint32(i))
+		zs[i] = taggedHash(tagAggregate, append(data, bs)...)
+	}
+	return zs
+}
+
+// Aggregate aggregates the signatures of the public keys on the same hash
+func Aggregate(pubkeys []common.PublicKey, h hash.Hash256, sigs []Signature) (*AggregateSignature, error) {
+	if len(pubkeys) == 0 || len(pubkeys) != len(sigs) {
+		return nil, ErrInvalidSignerCount
+	}
+	as := &AggregateSignature{
+		Rs: make([][32]byte, len(sigs)),
+	}
+	for i, sig := range sigs {
+		copy(as.Rs[i][:], sig[:32])
+	}
+	zs := coefficients(pubkeys, h, as.Rs)
+	S := new(big.Int)
+	for i, sig := range sigs {
+		s := new(big.Int).SetBytes(sig[32:])
+		S.Add(S, s.Mul(s, zs[i]))
+	}
+	S.Mod(S, curveN)
+	copy(as.S[:], bytes32(S))
+	return as, nil
+}
+
+// VerifyAggregate checks that the aggregated signature is generated by the hash and the public keys or not
+func VerifyAggregate(pubkeys []common.PublicKey, h hash.Hash256, as *AggregateSignature) error {
+	if len(pubkeys) == 0 || len(pubkeys) != len(as.Rs) {
+		return ErrInvalidSignerCount
+	}
+	S := new(big.Int).SetBytes(as.S[:])
+	if S.Cmp(curveN) >= 0 {
+		return ErrInvalidSignature
+	}
+	zs := coefficients(pubkeys, h, as.Rs)
+
+	// sum(z_i*R_i) + sum(z_i*e_i*P_i) - S*G should be the infinity
+	points := make([]*point, 0, len(pubkeys)*2+1)
+	scalars := make([]*big.Int, 0, len(pubkeys)*2+1)
+	for i, pubkey := range pubkeys {
+		P, err := decompress(pubkey[:])
+		if err != nil {
+			return err
+		}
+		R, err := liftX(new(big.Int).SetBytes(as.Rs[i][:]))
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		e := challenge(as.Rs[i][:], pubkey, h)
+		ze := new(big.Int).Mul(zs[i], e)
+		ze.Mod(ze, curveN)
+		points = append(points, R, P)
+		scalars = append(scalars, zs[i], ze)
+	}
+	points = append(points, basePoint())
+	scalars = append(scalars, new(big.Int).Sub(curveN, S))
+	if !multiScalarMult(points, scalars).isInfinity() {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package schnorr
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common"
+	ecrypto "github.com/fletaio/fleta_v1/common/crypto"
+	"github.com/fletaio/fleta_v1/common/hash"
+)
+
+type testKey struct {
+	priv   *big.Int
+	pubkey common.PublicKey
+}
+
+func newTestKey(t *testing.T) *testKey {
+	PrivKey, err := ecrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &testKey{
+		priv: PrivKey.D,
+	}
+	copy(k.pubkey[:], ecrypto.CompressPubkey(&PrivKey.PublicKey))
+	return k
+}
+
+func TestPublicKey(t *testing.T) {
+	k := newTestKey(t)
+	if PublicKey(k.priv) != k.pubkey {
+		t.Errorf("public key mismatch")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	k := newTestKey(t)
+	h := hash.Hash([]byte("TestSignVerify"))
+	sig, err := Sign(k.priv, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(k.pubkey, h, sig); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := Verify(k.pubkey, hash.Hash([]byte("other")), sig); err != ErrInvalidSignature {
+		t.Errorf("Verify() with other hash = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	h := hash.Hash([]byte("TestAggregate"))
+	pubkeys := []common.PublicKey{}
+	sigs := []Signature{}
+	for i := 0; i < 4; i++ {
+		k := newTestKey(t)
+		sig, err := Sign(k.priv, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubkeys = append(pubkeys, k.pubkey)
+		sigs = append(sigs, sig)
+	}
+	as, err := Aggregate(pubkeys, h, sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAggregate(pubkeys, h, as); err != nil {
+		t.Errorf("VerifyAggregate() = %v", err)
+	}
+	pubkeys[0], pubkeys[1] = pubkeys[1], pubkeys[0]
+	if err := VerifyAggregate(pubkeys, h, as); err != ErrInvalidSignature {
+		t.Errorf("VerifyAggregate() with swapped keys = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestScalarBaseMult(t *testing.T) {
+	scalars := []*big.Int{
+		big.NewInt(1),
+		big.NewInt(2),
+		new(big.Int).Sub(curveN, big.NewInt(1)),
+	}
+	for i := 0; i < 8; i++ {
+		scalars = append(scalars, newTestKey(t).priv)
+	}
+	for _, k := range scalars {
+		x, y := scalarBaseMult(k).affine()
+		ex, ey := multiScalarMult([]*point{basePoint()}, []*big.Int{k}).affine()
+		if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			t.Fatalf("scalar base mult mismatch of %v", k)
+		}
+	}
+	if !scalarBaseMult(new(big.Int)).isInfinity() {
+		t.Fatal("zero scalar is not the infinity")
+	}
+}
@@ -35,7 +35,12 @@ func VerifySignature(pubkey PublicKey, h hash.Hash256, sig Signature) error {
 
 // ValidateSignaturesMajority validates signatures with the signed hash and checks majority
 func ValidateSignaturesMajority(signedHash hash.Hash256, sigs []Signature, KeyMap map[PublicHash]bool) error {
-	if len(sigs) != len(KeyMap)/2+1 {
+	return ValidateSignaturesQuorum(signedHash, sigs, KeyMap, len(KeyMap)/2+1)
+}
+
+// ValidateSignaturesQuorum validates signatures with the signed hash and checks the quorum
+func ValidateSignaturesQuorum(signedHash hash.Hash256, sigs []Signature, KeyMap map[PublicHash]bool, Quorum int) error {
+	if len(sigs) != Quorum {
 		return ErrInsufficientSignature
 	}
 	sigMap := map[PublicHash]bool{}
@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/metrics"
+)
+
+// meteredBackend records latencies and errors of transactions of the backend
+type meteredBackend struct {
+	StoreBackend
+	viewLatency   *metrics.Histogram
+	updateLatency *metrics.Histogram
+	viewErrors    *metrics.Counter
+	updateErrors  *metrics.Counter
+}
+
+func newMeteredBackend(Name string, Path string, back StoreBackend) *meteredBackend {
+	Store := filepath.Base(Path)
+	return &meteredBackend{
+		StoreBackend:  back,
+		viewLatency:   metrics.NewHistogram("fleta_backend_transaction_seconds", "The time of transactions of the store backend", nil, "driver", Name, "store", Store, "op", "view"),
+		updateLatency: metrics.NewHistogram("fleta_backend_transaction_seconds", "The time of transactions of the store backend", nil, "driver", Name, "store", Store, "op", "update"),
+		viewErrors:    metrics.NewCounter("fleta_backend_transaction_errors_total", "The number of failed transactions of the store backend", "driver", Name, "store", Store, "op", "view"),
+		updateErrors:  metrics.NewCounter("fleta_backend_transaction_errors_total", "The number of failed transactions of the store backend", "driver", Name, "store", Store, "op", "update"),
+	}
+}
+
+// View executes the read transaction and records its latency
+func (mb *meteredBackend) View(fn func(txn StoreReader) error) error {
+	begin := time.Now()
+	err := mb.StoreBackend.View(fn)
+	mb.viewLatency.ObserveSince(begin)
+	if err != nil && err != ErrNotExistKey {
+		mb.viewErrors.Inc()
+	}
+	return err
+}
+
+// Update executes the write transaction and records its latency
+func (mb *meteredBackend) Update(fn func(txn StoreWriter) error) error {
+	begin := time.Now()
+	err := mb.StoreBackend.Update(fn)
+	mb.updateLatency.ObserveSince(begin)
+	if err != nil {
+		mb.updateErrors.Inc()
+	}
+	return err
+}
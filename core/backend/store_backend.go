@@ -31,5 +31,9 @@ func Create(Name string, Path string) (StoreBackend, error) {
 	if !has {
 		return nil, ErrNotExistDriver
 	}
-	return fn(Path)
+	back, err := fn(Path)
+	if err != nil {
+		return nil, err
+	}
+	return newMeteredBackend(Name, Path, back), nil
 }
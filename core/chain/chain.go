@@ -4,6 +4,7 @@ import (
 	"log"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/fletaio/fleta_v1/common"
 	"github.com/fletaio/fleta_v1/common/hash"
@@ -120,6 +121,7 @@ func (cn *Chain) Init() error {
 	}
 
 	log.Println("Chain loaded", cn.store.Height(), ctx.LastHash().String())
+	heightGauge.Set(float64(cn.store.Height()))
 
 	cn.isInit = true
 	return nil
@@ -130,6 +132,11 @@ func (cn *Chain) Provider() types.Provider {
 	return cn.store
 }
 
+// Consensus returns the consensus of the chain
+func (cn *Chain) Consensus() Consensus {
+	return cn.consensus
+}
+
 // Close terminates and cleans the chain
 func (cn *Chain) Close() {
 	cn.closeLock.Lock()
@@ -244,6 +251,16 @@ func (cn *Chain) ConnectBlock(b *types.Block, SigMap map[hash.Hash256][]common.P
 	cn.Lock()
 	defer cn.Unlock()
 
+	begin := time.Now()
+	if err := cn.connectBlock(b, SigMap); err != nil {
+		connectBlockFailures.Inc()
+		return err
+	}
+	connectBlockLatency.ObserveSince(begin)
+	return nil
+}
+
+func (cn *Chain) connectBlock(b *types.Block, SigMap map[hash.Hash256][]common.PublicHash) error {
 	if err := cn.validateHeader(&b.Header); err != nil {
 		return err
 	}
@@ -293,9 +310,14 @@ func (cn *Chain) connectBlockWithContext(b *types.Block, ctx *types.Context) err
 	}
 
 	top := ctx.Top()
+	begin := time.Now()
 	if err := cn.store.StoreBlock(b, top); err != nil {
 		return err
 	}
+	saveBlockLatency.ObserveSince(begin)
+	heightGauge.Set(float64(b.Header.Height))
+	connectedBlocks.Inc()
+	connectedTxs.Add(uint64(len(b.Transactions)))
 	for _, s := range cn.services {
 		s.OnBlockConnected(b, top.Events, ctx)
 	}
@@ -0,0 +1,15 @@
+package chain
+
+import (
+	"github.com/fletaio/fleta_v1/common/metrics"
+)
+
+// metrics of the chain
+var (
+	heightGauge          = metrics.NewGauge("fleta_chain_height", "The height of the last connected block")
+	connectBlockLatency  = metrics.NewHistogram("fleta_chain_connect_block_seconds", "The time to validate, execute and store the block", nil)
+	saveBlockLatency     = metrics.NewHistogram("fleta_chain_save_block_seconds", "The time to save the executed block to the store", nil)
+	connectedBlocks      = metrics.NewCounter("fleta_chain_connected_blocks_total", "The number of connected blocks")
+	connectedTxs         = metrics.NewCounter("fleta_chain_connected_transactions_total", "The number of transactions in connected blocks")
+	connectBlockFailures = metrics.NewCounter("fleta_chain_connect_block_failures_total", "The number of blocks that are failed to connect")
+)
@@ -309,6 +309,146 @@ func (st *Store) Block(height uint32) (*types.Block, error) {
 	return &b, nil
 }
 
+// Signatures returns the signatures of the block by height
+// Blocks that are stored before the signatures are indexed are loaded to get them
+func (st *Store) Signatures(height uint32) ([]common.Signature, error) {
+	st.closeLock.RLock()
+	if st.isClose {
+		st.closeLock.RUnlock()
+		return nil, ErrStoreClosed
+	}
+	if height < 1 {
+		st.closeLock.RUnlock()
+		return nil, backend.ErrNotExistKey
+	}
+	if st.cache.cached {
+		if st.cache.height == height {
+			sigs := st.cache.heightBlock.Signatures
+			st.closeLock.RUnlock()
+			return sigs, nil
+		}
+	}
+
+	var sigs []common.Signature
+	err := st.db.View(func(txn backend.StoreReader) error {
+		value, err := txn.Get(toHeightSignaturesKey(height))
+		if err != nil {
+			return err
+		}
+		return encoding.Unmarshal(value, &sigs)
+	})
+	st.closeLock.RUnlock()
+	if err == backend.ErrNotExistKey {
+		b, err := st.Block(height)
+		if err != nil {
+			return nil, err
+		}
+		return b.Signatures, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return sigs, nil
+}
+
+// HeightByHash returns the height of the block hash
+func (st *Store) HeightByHash(h hash.Hash256) (uint32, error) {
+	st.closeLock.RLock()
+	defer st.closeLock.RUnlock()
+	if st.isClose {
+		return 0, ErrStoreClosed
+	}
+
+	if st.cache.cached {
+		if st.cache.heightHash == h {
+			return st.cache.height, nil
+		}
+	}
+
+	var height uint32
+	if err := st.db.View(func(txn backend.StoreReader) error {
+		value, err := txn.Get(toHashHeightKey(h))
+		if err != nil {
+			return err
+		}
+		height = binutil.LittleEndian.Uint32(value)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// BuildHashIndex indexes heights of block hashes that are stored before the index is introduced
+// It continues from the last indexed height, so it is cheap after the first run
+func (st *Store) BuildHashIndex() error {
+	const BatchSize = 10000
+
+	Height := st.Height()
+	From, err := st.hashIndexHeight()
+	if err != nil {
+		return err
+	}
+	for From <= Height {
+		To := From + BatchSize - 1
+		if To > Height {
+			To = Height
+		}
+		if err := st.buildHashIndexBatch(From, To); err != nil {
+			return err
+		}
+		From = To + 1
+	}
+	return nil
+}
+
+// hashIndexHeight returns the height that the hash index should be built from
+func (st *Store) hashIndexHeight() (uint32, error) {
+	st.closeLock.RLock()
+	defer st.closeLock.RUnlock()
+	if st.isClose {
+		return 0, ErrStoreClosed
+	}
+
+	var From uint32
+	if err := st.db.View(func(txn backend.StoreReader) error {
+		value, err := txn.Get(tagHashIndexHeight)
+		if err != nil {
+			return err
+		}
+		From = binutil.LittleEndian.Uint32(value) + 1
+		return nil
+	}); err != nil && err != backend.ErrNotExistKey {
+		return 0, err
+	}
+	return From, nil
+}
+
+// buildHashIndexBatch indexes heights of block hashes from From to To
+// The lock is held only for the batch, so writers are not blocked during the whole scan
+func (st *Store) buildHashIndexBatch(From uint32, To uint32) error {
+	st.closeLock.RLock()
+	defer st.closeLock.RUnlock()
+	if st.isClose {
+		return ErrStoreClosed
+	}
+
+	return st.db.Update(func(txn backend.StoreWriter) error {
+		for i := From; i <= To; i++ {
+			h, err := st.cdb.GetHash(i)
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(toHashHeightKey(h), binutil.LittleEndian.Uint32ToBytes(i)); err != nil {
+				return err
+			}
+		}
+		if err := txn.Set(tagHashIndexHeight, binutil.LittleEndian.Uint32ToBytes(To)); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
 // Height returns the current height of the target chain
 func (st *Store) Height() uint32 {
 	st.closeLock.RLock()
@@ -743,6 +883,9 @@ func (st *Store) StoreGenesis(genHash hash.Hash256, ctd *types.ContextData) erro
 			if err := txn.Set(toHeightHashKey(0), genHash[:]); err != nil {
 				return err
 			}
+			if err := txn.Set(toHashHeightKey(genHash), binutil.LittleEndian.Uint32ToBytes(0)); err != nil {
+				return err
+			}
 			bsHeight := binutil.LittleEndian.Uint32ToBytes(0)
 			if err := txn.Set(tagHeight, bsHeight); err != nil {
 				return err
@@ -818,6 +961,14 @@ func (st *Store) StoreBlock(b *types.Block, ctd *types.ContextData) error {
 			if err := txn.Set(tagHeight, bsHeight); err != nil {
 				return err
 			}
+			if err := txn.Set(toHashHeightKey(DataHash), bsHeight); err != nil {
+				return err
+			}
+		}
+		if data, err := encoding.Marshal(b.Signatures); err != nil {
+			return err
+		} else if err := txn.Set(toHeightSignaturesKey(b.Header.Height), data); err != nil {
+			return err
 		}
 		if err := applyContextData(txn, ctd); err != nil {
 			return err
@@ -839,6 +990,19 @@ func (st *Store) StoreBlock(b *types.Block, ctd *types.ContextData) error {
 	return nil
 }
 
+// CheckWritable writes the current time to the health check key to check that the store accepts updates
+func (st *Store) CheckWritable() error {
+	st.closeLock.RLock()
+	defer st.closeLock.RUnlock()
+	if st.isClose {
+		return ErrStoreClosed
+	}
+
+	return st.db.Update(func(txn backend.StoreWriter) error {
+		return txn.Set(tagHealthCheck, binutil.LittleEndian.Uint64ToBytes(uint64(time.Now().UnixNano())))
+	})
+}
+
 func (st *Store) IterBlockAfterContext(fn func(b *types.Block) error) error {
 	for h := st.Height() + 1; ; h++ {
 		b, err := st.Block(h)
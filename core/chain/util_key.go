@@ -12,6 +12,8 @@ var (
 	tagHeightHeader        = []byte{1, 2}
 	tagHeightBlock         = []byte{1, 3}
 	tagHashHeight          = []byte{1, 4}
+	tagHashIndexHeight     = []byte{1, 5}
+	tagHeightSignatures    = []byte{1, 6}
 	tagAccount             = []byte{2, 0}
 	tagAccountName         = []byte{2, 1}
 	tagAccountSeq          = []byte{2, 2}
@@ -21,6 +23,7 @@ var (
 	tagEvent               = []byte{5, 0}
 	tagLockedBalance       = []byte{6, 0}
 	tagLockedBalanceHeight = []byte{6, 1}
+	tagHealthCheck         = []byte{7, 0}
 )
 
 func toHeightBlockKey(height uint32) []byte {
@@ -44,6 +47,13 @@ func toHeightHashKey(height uint32) []byte {
 	return bs
 }
 
+func toHeightSignaturesKey(height uint32) []byte {
+	bs := make([]byte, 6)
+	copy(bs, tagHeightSignatures)
+	binutil.BigEndian.PutUint32(bs[2:], height)
+	return bs
+}
+
 func toHashHeightKey(h hash.Hash256) []byte {
 	bs := make([]byte, 34)
 	copy(bs, tagHashHeight)
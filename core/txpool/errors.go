@@ -4,11 +4,13 @@ import "errors"
 
 // TransactionPool errors
 var (
-	ErrEmptyQueue                = errors.New("empty queue")
-	ErrNotAccountTransaction     = errors.New("not account transaction")
-	ErrExistTransaction          = errors.New("exist transaction")
-	ErrExistTransactionSeq       = errors.New("exist transaction seq")
-	ErrTransactionPoolOverflowed = errors.New("transaction pool overflowed")
-	ErrPastSeq                   = errors.New("past seq")
-	ErrTooFarSeq                 = errors.New("too far seq")
+	ErrEmptyQueue                 = errors.New("empty queue")
+	ErrNotAccountTransaction      = errors.New("not account transaction")
+	ErrExistTransaction           = errors.New("exist transaction")
+	ErrExistTransactionSeq        = errors.New("exist transaction seq")
+	ErrTransactionPoolOverflowed  = errors.New("transaction pool overflowed")
+	ErrPastSeq                    = errors.New("past seq")
+	ErrTooFarSeq                  = errors.New("too far seq")
+	ErrReplaceUnderpriced         = errors.New("replace underpriced")
+	ErrTooManyAddressTransactions = errors.New("too many address transactions")
 )
@@ -0,0 +1,146 @@
+package txpool
+
+// itemHeap is a binary heap of pool items that keeps the index of each item, so the item can be removed or fixed in the middle
+// The pool uses three heaps, the pop heap that has the most valuable item at the top, the evict heap that has the cheapest item at the top and the time heap that has the oldest item at the top
+type itemHeap struct {
+	items []*PoolItem
+	less  func(a *PoolItem, b *PoolItem) bool
+	index func(item *PoolItem) *int
+}
+
+func newPopHeap() *itemHeap {
+	return &itemHeap{
+		less: func(a *PoolItem, b *PoolItem) bool {
+			return isMoreValuable(a, b)
+		},
+		index: func(item *PoolItem) *int {
+			return &item.popIndex
+		},
+	}
+}
+
+func newEvictHeap() *itemHeap {
+	return &itemHeap{
+		less: func(a *PoolItem, b *PoolItem) bool {
+			return isMoreValuable(b, a)
+		},
+		index: func(item *PoolItem) *int {
+			return &item.evictIndex
+		},
+	}
+}
+
+func newTimeHeap() *itemHeap {
+	return &itemHeap{
+		less: func(a *PoolItem, b *PoolItem) bool {
+			if !a.PushedAt.Equal(b.PushedAt) {
+				return a.PushedAt.Before(b.PushedAt)
+			}
+			return a.order < b.order
+		},
+		index: func(item *PoolItem) *int {
+			return &item.timeIndex
+		},
+	}
+}
+
+// isMoreValuable returns a has the higher fee than b, the older one is more valuable when fees are same
+func isMoreValuable(a *PoolItem, b *PoolItem) bool {
+	if cmp := a.Fee.Cmp(b.Fee.Int); cmp != 0 {
+		return cmp > 0
+	}
+	return a.order < b.order
+}
+
+// Len returns the number of items
+func (h *itemHeap) Len() int {
+	return len(h.items)
+}
+
+// Peek returns the top item without removing it
+func (h *itemHeap) Peek() *PoolItem {
+	if len(h.items) == 0 {
+		return nil
+	}
+	return h.items[0]
+}
+
+// Push inserts the item
+func (h *itemHeap) Push(item *PoolItem) {
+	h.items = append(h.items, item)
+	*h.index(item) = len(h.items) - 1
+	h.up(len(h.items) - 1)
+}
+
+// Pop removes and returns the top item
+func (h *itemHeap) Pop() *PoolItem {
+	if len(h.items) == 0 {
+		return nil
+	}
+	item := h.items[0]
+	h.Remove(item)
+	return item
+}
+
+// Remove deletes the item if it is in the heap
+func (h *itemHeap) Remove(item *PoolItem) {
+	i := *h.index(item)
+	if i < 0 || i >= len(h.items) || h.items[i] != item {
+		return
+	}
+	last := len(h.items) - 1
+	if i != last {
+		h.swap(i, last)
+	}
+	h.items[last] = nil
+	h.items = h.items[:last]
+	*h.index(item) = -1
+	if i != last {
+		if !h.down(i) {
+			h.up(i)
+		}
+	}
+}
+
+// Has returns the item is in the heap or not
+func (h *itemHeap) Has(item *PoolItem) bool {
+	i := *h.index(item)
+	return i >= 0 && i < len(h.items) && h.items[i] == item
+}
+
+func (h *itemHeap) swap(i int, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	*h.index(h.items[i]) = i
+	*h.index(h.items[j]) = j
+}
+
+func (h *itemHeap) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !h.less(h.items[i], h.items[p]) {
+			break
+		}
+		h.swap(i, p)
+		i = p
+	}
+}
+
+func (h *itemHeap) down(i int) bool {
+	start := i
+	n := len(h.items)
+	for {
+		c := 2*i + 1
+		if c >= n {
+			break
+		}
+		if r := c + 1; r < n && h.less(h.items[r], h.items[c]) {
+			c = r
+		}
+		if !h.less(h.items[c], h.items[i]) {
+			break
+		}
+		h.swap(i, c)
+		i = c
+	}
+	return i > start
+}
@@ -0,0 +1,13 @@
+package txpool
+
+import (
+	"github.com/fletaio/fleta_v1/common/metrics"
+)
+
+// metrics of transaction pools
+var (
+	sizeGauge   = metrics.NewGauge("fleta_txpool_size", "The number of transactions in transaction pools")
+	pushedTxs   = metrics.NewCounter("fleta_txpool_pushed_total", "The number of transactions that are pushed to transaction pools")
+	rejectedTxs = metrics.NewCounter("fleta_txpool_rejected_total", "The number of transactions that are rejected by transaction pools")
+	evictedTxs  = metrics.NewCounter("fleta_txpool_evicted_total", "The number of transactions that are replaced, evicted or expired by transaction pools")
+)
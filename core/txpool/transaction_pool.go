@@ -4,42 +4,101 @@ import (
 	"bytes"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/common/binutil"
 	"github.com/fletaio/fleta_v1/common/hash"
-	"github.com/fletaio/fleta_v1/common/queue"
 	"github.com/fletaio/fleta_v1/core/chain"
 	"github.com/fletaio/fleta_v1/core/types"
 )
 
-// TransactionPool provides a transaction queue
+// pool settings
+const (
+	DefaultMaxSize       = 65535
+	DefaultMaxPerAddress = 100
+	DefaultTTL           = 3 * time.Hour
+	DefaultPriceBump     = 10
+)
+
+// Config is the config of the TransactionPool
+type Config struct {
+	MaxSize       int           // the maximum number of transactions, the cheapest one is evicted when it is full
+	MaxPerAddress int           // the maximum number of account transactions of an address
+	TTL           time.Duration // transactions are dropped after the ttl
+	PriceBump     int64         // the fee increase in percent that is required to replace the transaction of the same seq
+}
+
+// DefaultConfig returns the default config of the TransactionPool
+func DefaultConfig() Config {
+	return Config{
+		MaxSize:       DefaultMaxSize,
+		MaxPerAddress: DefaultMaxPerAddress,
+		TTL:           DefaultTTL,
+		PriceBump:     DefaultPriceBump,
+	}
+}
+
+// TransactionPool provides a transaction queue that is ordered by the fee
 // User can push transaction regardless of UTXO model based transactions or account model based transactions
-// If the sequence of the account model based transaction is not reached to the next of the last sequence, it doens't poped
+// Account model based transactions of an address are sorted by the sequence, only the next sequence of the address is a candidate to pop
+// Candidates are popped by the higher fee first, the older one is popped first when fees are same
 type TransactionPool struct {
 	sync.Mutex
-	turnQ        *queue.Queue
-	numberQ      *queue.Queue
-	utxoQ        *queue.LinkedQueue
-	txhashMap    map[hash.Hash256]*PoolItem
-	turnOutMap   map[bool]int
-	numberOutMap map[common.Address]int
-	bucketMap    map[common.Address]*queue.SortedQueue
+	config     Config
+	popQ       *itemHeap
+	evictQ     *itemHeap
+	timeQ      *itemHeap
+	txhashMap  map[hash.Hash256]*PoolItem
+	shortIDMap map[uint64][]*PoolItem
+	bucketMap  map[common.Address]*accountBucket
+	order      uint64
 }
 
 // NewTransactionPool returns a TransactionPool
 func NewTransactionPool() *TransactionPool {
 	tp := &TransactionPool{
-		turnQ:        queue.NewQueue(),
-		numberQ:      queue.NewQueue(),
-		utxoQ:        queue.NewLinkedQueue(),
-		txhashMap:    map[hash.Hash256]*PoolItem{},
-		turnOutMap:   map[bool]int{},
-		numberOutMap: map[common.Address]int{},
-		bucketMap:    map[common.Address]*queue.SortedQueue{},
+		config:     DefaultConfig(),
+		popQ:       newPopHeap(),
+		evictQ:     newEvictHeap(),
+		timeQ:      newTimeHeap(),
+		txhashMap:  map[hash.Hash256]*PoolItem{},
+		shortIDMap: map[uint64][]*PoolItem{},
+		bucketMap:  map[common.Address]*accountBucket{},
 	}
 	return tp
 }
 
+// SetConfig updates the config, zero values are replaced by default values
+// It should be called before transactions are pushed
+func (tp *TransactionPool) SetConfig(config Config) {
+	tp.Lock()
+	defer tp.Unlock()
+
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultMaxSize
+	}
+	if config.MaxPerAddress <= 0 {
+		config.MaxPerAddress = DefaultMaxPerAddress
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultTTL
+	}
+	if config.PriceBump <= 0 {
+		config.PriceBump = DefaultPriceBump
+	}
+	tp.config = config
+}
+
+// Config returns the config
+func (tp *TransactionPool) Config() Config {
+	tp.Lock()
+	defer tp.Unlock()
+
+	return tp.config
+}
+
 // IsExist checks that the transaction hash is inserted or not
 func (tp *TransactionPool) IsExist(TxHash hash.Hash256) bool {
 	tp.Lock()
@@ -54,53 +113,125 @@ func (tp *TransactionPool) Size() int {
 	tp.Lock()
 	defer tp.Unlock()
 
-	sum := 0
-	for _, v := range tp.turnOutMap {
-		sum += v
-	}
-	return tp.turnQ.Size() - sum
+	return len(tp.txhashMap)
 }
 
-// Push inserts the transaction and signatures of it by base model and sequence
-// An UTXO model based transaction will be handled by FIFO
-// An account model based transaction will be sorted by the sequence value
-func (tp *TransactionPool) Push(t uint16, TxHash hash.Hash256, tx types.Transaction, sigs []common.Signature, signers []common.PublicHash) error {
+// Push inserts the transaction and signatures of it with the fee of it
+// An account model based transaction replaces the one of the same sequence when the fee is higher by the price bump
+// When the pool is full, the cheapest transaction is evicted if the transaction is more valuable than it
+// It returns transactions that are replaced or evicted by the transaction, they should be forgotten by the caller
+func (tp *TransactionPool) Push(t uint16, TxHash hash.Hash256, tx types.Transaction, sigs []common.Signature, signers []common.PublicHash, Fee *amount.Amount) ([]*PoolItem, error) {
 	tp.Lock()
 	defer tp.Unlock()
+	defer tp.updateSize(len(tp.txhashMap))
+
+	removed, err := tp.push(t, TxHash, tx, sigs, signers, Fee)
+	evictedTxs.Add(uint64(len(removed)))
+	if err != nil {
+		rejectedTxs.Inc()
+		return removed, err
+	}
+	pushedTxs.Inc()
+	return removed, nil
+}
+
+func (tp *TransactionPool) push(t uint16, TxHash hash.Hash256, tx types.Transaction, sigs []common.Signature, signers []common.PublicHash, Fee *amount.Amount) ([]*PoolItem, error) {
+	now := time.Now()
+	tp.expire(now)
 
 	if _, has := tp.txhashMap[TxHash]; has {
-		return ErrExistTransaction
+		return nil, ErrExistTransaction
+	}
+	if Fee == nil {
+		Fee = amount.NewCoinAmount(0, 0)
 	}
 
+	tp.order++
 	item := &PoolItem{
 		TxType:      t,
 		TxHash:      TxHash,
 		Transaction: tx,
 		Signatures:  sigs,
 		Signers:     signers,
+		Fee:         Fee,
+		PushedAt:    now,
+		order:       tp.order,
+		popIndex:    -1,
+		evictIndex:  -1,
+		timeIndex:   -1,
 	}
 	atx, is := tx.(chain.AccountTransaction)
 	if !is {
-		if tp.utxoQ.Push(TxHash, item) {
-			tp.turnQ.Push(true)
-		} else {
-			return ErrExistTransaction
+		removed, err := tp.makeRoom(item)
+		if err != nil {
+			return removed, err
 		}
-	} else {
-		addr := atx.From()
-		q, has := tp.bucketMap[addr]
-		if !has {
-			q = queue.NewSortedQueue()
-			tp.bucketMap[addr] = q
+		tp.putItem(item)
+		tp.popQ.Push(item)
+		tp.evictQ.Push(item)
+		return removed, nil
+	}
+
+	item.isAccount = true
+	item.addr = atx.From()
+	item.seq = atx.Seq()
+	if b, has := tp.bucketMap[item.addr]; has {
+		if idx, found := b.search(item.seq); found {
+			old := b.items[idx]
+			required := old.Fee.MulC(100 + tp.config.PriceBump).DivC(100)
+			if !old.Fee.Less(item.Fee) || item.Fee.Less(required) {
+				return nil, ErrReplaceUnderpriced
+			}
+			oldHead, oldTail := b.head(), b.tail()
+			b.items[idx] = item
+			tp.deleteItem(old)
+			tp.putItem(item)
+			tp.updateBucket(b, oldHead, oldTail)
+			return []*PoolItem{old}, nil
+		}
+		if len(b.items) >= tp.config.MaxPerAddress {
+			return nil, ErrTooManyAddressTransactions
+		}
+	}
+	removed, err := tp.makeRoom(item)
+	if err != nil {
+		return removed, err
+	}
+	b, has := tp.bucketMap[item.addr]
+	if !has {
+		b = &accountBucket{
+			addr: item.addr,
 		}
-		if q.FindOrInsert(item, atx.Seq()) != nil {
-			return ErrExistTransactionSeq
+		tp.bucketMap[item.addr] = b
+	}
+	oldHead, oldTail := b.head(), b.tail()
+	b.insert(item)
+	tp.putItem(item)
+	tp.updateBucket(b, oldHead, oldTail)
+	return removed, nil
+}
+
+// makeRoom evicts the cheapest transactions until the item can be inserted
+// The last sequence of an address is evicted first, so remained transactions of the address keep their sequences continuous
+func (tp *TransactionPool) makeRoom(item *PoolItem) ([]*PoolItem, error) {
+	removed := []*PoolItem{}
+	for len(tp.txhashMap) >= tp.config.MaxSize {
+		victim := tp.evictQ.Peek()
+		if victim == nil || !isMoreValuable(item, victim) {
+			return removed, ErrTransactionPoolOverflowed
+		}
+		if item.isAccount && victim.isAccount && victim.addr == item.addr && victim.seq < item.seq {
+			return removed, ErrTransactionPoolOverflowed
+		}
+		if victim.isAccount {
+			b := tp.bucketMap[victim.addr]
+			removed = append(removed, tp.removeAccountFrom(b, len(b.items)-1)...)
+		} else {
+			tp.removeUTXO(victim)
+			removed = append(removed, victim)
 		}
-		tp.numberQ.Push(addr)
-		tp.turnQ.Push(false)
 	}
-	tp.txhashMap[TxHash] = item
-	return nil
+	return removed, nil
 }
 
 // Get returns the pool item of the hash
@@ -111,62 +242,63 @@ func (tp *TransactionPool) Get(TxHash hash.Hash256) *PoolItem {
 	return tp.txhashMap[TxHash]
 }
 
+// GetByShortID returns pool items that have the short id
+// Different transactions can have the same short id, so the caller should check the type or the hash of them
+func (tp *TransactionPool) GetByShortID(ShortID uint64) []*PoolItem {
+	tp.Lock()
+	defer tp.Unlock()
+
+	return append([]*PoolItem{}, tp.shortIDMap[ShortID]...)
+}
+
 // Remove deletes the target transaction from the queue
-// If it is an account model based transaction, it will be sorted by the sequence in the address
+// If it is an account model based transaction, transactions of the address that have the sequence until it are removed together
 func (tp *TransactionPool) Remove(TxHash hash.Hash256, t types.Transaction) {
 	tp.Lock()
 	defer tp.Unlock()
+	defer tp.updateSize(len(tp.txhashMap))
 
 	if tx, is := t.(chain.AccountTransaction); !is {
-		if tp.utxoQ.Remove(TxHash) != nil {
-			turn := tp.turnQ.Peek()
-			if turn != nil {
-				if turn == true {
-					tp.turnQ.Pop()
-				} else {
-					tp.turnOutMap[true]++
-				}
-			}
-			delete(tp.txhashMap, TxHash)
+		if item, has := tp.txhashMap[TxHash]; has && !item.isAccount {
+			tp.removeUTXO(item)
 		}
 	} else {
-		addr := tx.From()
-		if q, has := tp.bucketMap[addr]; has {
-			for {
-				if q.Size() == 0 {
-					break
-				}
-				v, _ := q.Peek()
-				item := v.(*PoolItem)
-				if item.Transaction.(chain.AccountTransaction).Seq() > tx.Seq() {
-					break
-				}
-				q.Pop()
-				delete(tp.txhashMap, item.TxHash)
-				turn := tp.turnQ.Peek()
-				if turn != nil {
-					if turn == false {
-						tp.turnQ.Pop()
-					} else {
-						tp.turnOutMap[false]++
-					}
-				}
-				number := tp.numberQ.Peek()
-				if number != nil {
-					if number.(common.Address) == addr {
-						tp.numberQ.Pop()
-					} else {
-						tp.numberOutMap[addr]++
-					}
-				}
-			}
-			if q.Size() == 0 {
-				delete(tp.bucketMap, addr)
+		if b, has := tp.bucketMap[tx.From()]; has {
+			idx, found := b.search(tx.Seq())
+			if found {
+				idx++
 			}
+			tp.removeAccountUntil(b, idx)
 		}
 	}
 }
 
+// Drop removes the transaction of the hash and returns removed items
+// If it is an account model based transaction, transactions of the address that have the sequence after it are removed together because they cannot be executed without it
+func (tp *TransactionPool) Drop(TxHash hash.Hash256) []*PoolItem {
+	tp.Lock()
+	defer tp.Unlock()
+	defer tp.updateSize(len(tp.txhashMap))
+
+	item, has := tp.txhashMap[TxHash]
+	if !has {
+		return nil
+	}
+	if !item.isAccount {
+		tp.removeUTXO(item)
+		return []*PoolItem{item}
+	}
+	b, has := tp.bucketMap[item.addr]
+	if !has {
+		return nil
+	}
+	idx, found := b.search(item.seq)
+	if !found {
+		return nil
+	}
+	return tp.removeAccountFrom(b, idx)
+}
+
 // Pop returns and removes the proper transaction
 func (tp *TransactionPool) Pop(SeqCache SeqCache) *PoolItem {
 	tp.Lock()
@@ -176,105 +308,164 @@ func (tp *TransactionPool) Pop(SeqCache SeqCache) *PoolItem {
 }
 
 // UnsafePop returns and removes the proper transaction without mutex locking
+// Account model based transactions that are not reached to the next of the last sequence are kept, past ones are dropped
 func (tp *TransactionPool) UnsafePop(SeqCache SeqCache) *PoolItem {
-	var turn bool
+	defer tp.updateSize(len(tp.txhashMap))
+
+	tp.expire(time.Now())
+
+	ignores := []*PoolItem{}
+	defer func() {
+		for _, item := range ignores {
+			if b, has := tp.bucketMap[item.addr]; has && b.head() == item && !tp.popQ.Has(item) {
+				tp.popQ.Push(item)
+			}
+		}
+	}()
 	for {
-		if tp.turnQ.Size() == 0 {
+		item := tp.popQ.Pop()
+		if item == nil {
 			return nil
 		}
-		turn = tp.turnQ.Pop().(bool)
-		tout := tp.turnOutMap[turn]
-		if tout > 0 {
-			tout--
-			if tout == 0 {
-				delete(tp.turnOutMap, turn)
+		if !item.isAccount {
+			tp.removeUTXO(item)
+			return item
+		}
+		b := tp.bucketMap[item.addr]
+		lastSeq := SeqCache.Seq(item.addr)
+		if item.seq > lastSeq+1 {
+			ignores = append(ignores, item)
+			continue
+		}
+		tp.removeAccountUntil(b, 1)
+		if item.seq == lastSeq+1 {
+			return item
+		}
+	}
+}
+
+// expire drops transactions that are pushed before the ttl
+// Following sequences of the expired account model based transaction are dropped together because they cannot be popped
+func (tp *TransactionPool) expire(now time.Time) {
+	for tp.timeQ.Len() > 0 {
+		item := tp.timeQ.Peek()
+		if now.Sub(item.PushedAt) < tp.config.TTL {
+			return
+		}
+		if item.isAccount {
+			b := tp.bucketMap[item.addr]
+			if idx, found := b.search(item.seq); found {
+				evictedTxs.Add(uint64(len(tp.removeAccountFrom(b, idx))))
 			} else {
-				tp.turnOutMap[turn] = tout
+				tp.timeQ.Remove(item)
 			}
 		} else {
+			tp.removeUTXO(item)
+			evictedTxs.Inc()
+		}
+	}
+}
+
+// updateSize applies the change of the size from the previous size to the size gauge that is shared by pools
+func (tp *TransactionPool) updateSize(prev int) {
+	if diff := len(tp.txhashMap) - prev; diff != 0 {
+		sizeGauge.Add(float64(diff))
+	}
+}
+
+func (tp *TransactionPool) removeUTXO(item *PoolItem) {
+	tp.popQ.Remove(item)
+	tp.evictQ.Remove(item)
+	tp.deleteItem(item)
+}
+
+func (tp *TransactionPool) putItem(item *PoolItem) {
+	tp.txhashMap[item.TxHash] = item
+	ShortID := ShortTxID(item.TxHash)
+	tp.shortIDMap[ShortID] = append(tp.shortIDMap[ShortID], item)
+	tp.timeQ.Push(item)
+}
+
+func (tp *TransactionPool) deleteItem(item *PoolItem) {
+	delete(tp.txhashMap, item.TxHash)
+	tp.timeQ.Remove(item)
+	ShortID := ShortTxID(item.TxHash)
+	list := tp.shortIDMap[ShortID]
+	for i, v := range list {
+		if v == item {
+			list = append(list[:i], list[i+1:]...)
 			break
 		}
 	}
-	if turn {
-		item := tp.utxoQ.Pop().(*PoolItem)
-		delete(tp.txhashMap, item.TxHash)
-		return item
+	if len(list) == 0 {
+		delete(tp.shortIDMap, ShortID)
 	} else {
-		remain := tp.numberQ.Size()
-		ignoreMap := map[common.Address]bool{}
-		for {
-			var addr common.Address
-			for {
-				if tp.numberQ.Size() == 0 {
-					return nil
-				}
-				addr = tp.numberQ.Pop().(common.Address)
-				remain--
-				nout := tp.numberOutMap[addr]
-				if nout > 0 {
-					nout--
-					if nout == 0 {
-						delete(tp.numberOutMap, addr)
-					} else {
-						tp.numberOutMap[addr] = nout
-					}
-				} else {
-					break
-				}
-			}
-			if ignoreMap[addr] {
-				tp.numberQ.Push(addr)
-				if remain > 0 {
-					continue
-				} else {
-					tp.turnQ.Push(false)
-					return nil
-				}
-			}
-			q := tp.bucketMap[addr]
-			v, _ := q.Peek()
-			item := v.(*PoolItem)
-			lastSeq := SeqCache.Seq(addr)
-			txSeq := item.Transaction.(chain.AccountTransaction).Seq()
-			if txSeq < lastSeq+1 {
-				q.Pop()
-				delete(tp.txhashMap, item.TxHash)
-				if q.Size() == 0 {
-					delete(tp.bucketMap, addr)
-				}
-				if remain > 0 {
-					turn := tp.turnQ.Peek()
-					if turn != nil {
-						if turn == false {
-							tp.turnQ.Pop()
-						} else {
-							tp.turnOutMap[false]++
-						}
-					}
-					continue
-				} else {
-					return nil
-				}
-			} else if txSeq > lastSeq+1 {
-				ignoreMap[addr] = true
-				tp.numberQ.Push(addr)
-				if remain > 0 {
-					continue
-				} else {
-					tp.turnQ.Push(false)
-					return nil
-				}
-			}
-			q.Pop()
-			delete(tp.txhashMap, item.TxHash)
-			if q.Size() == 0 {
-				delete(tp.bucketMap, addr)
-			}
-			return item
+		tp.shortIDMap[ShortID] = list
+	}
+}
+
+// removeAccountUntil removes transactions of the bucket before the index
+func (tp *TransactionPool) removeAccountUntil(b *accountBucket, idx int) []*PoolItem {
+	if idx <= 0 {
+		return nil
+	}
+	oldHead, oldTail := b.head(), b.tail()
+	removed := append([]*PoolItem{}, b.items[:idx]...)
+	for _, item := range removed {
+		tp.deleteItem(item)
+	}
+	b.items = append([]*PoolItem{}, b.items[idx:]...)
+	tp.updateBucket(b, oldHead, oldTail)
+	return removed
+}
+
+// removeAccountFrom removes transactions of the bucket from the index
+func (tp *TransactionPool) removeAccountFrom(b *accountBucket, idx int) []*PoolItem {
+	if idx >= len(b.items) {
+		return nil
+	}
+	oldHead, oldTail := b.head(), b.tail()
+	removed := append([]*PoolItem{}, b.items[idx:]...)
+	for _, item := range removed {
+		tp.deleteItem(item)
+	}
+	for i := idx; i < len(b.items); i++ {
+		b.items[i] = nil
+	}
+	b.items = b.items[:idx]
+	tp.updateBucket(b, oldHead, oldTail)
+	return removed
+}
+
+// updateBucket keeps the head of the bucket in the pop queue and the tail of the bucket in the evict queue
+func (tp *TransactionPool) updateBucket(b *accountBucket, oldHead *PoolItem, oldTail *PoolItem) {
+	head, tail := b.head(), b.tail()
+	if oldHead != head {
+		if oldHead != nil {
+			tp.popQ.Remove(oldHead)
 		}
+		if head != nil {
+			tp.popQ.Push(head)
+		}
+	}
+	if oldTail != tail {
+		if oldTail != nil {
+			tp.evictQ.Remove(oldTail)
+		}
+		if tail != nil {
+			tp.evictQ.Push(tail)
+		}
+	}
+	if len(b.items) == 0 {
+		delete(tp.bucketMap, b.addr)
 	}
 }
 
+// ShortTxID returns the short id of the transaction hash that is used to find transactions of the compact block
+func ShortTxID(TxHash hash.Hash256) uint64 {
+	return binutil.LittleEndian.Uint64(TxHash[:8])
+}
+
 // PoolItem represents the item of the queue
 type PoolItem struct {
 	TxType      uint16
@@ -282,6 +473,56 @@ type PoolItem struct {
 	Transaction types.Transaction
 	Signatures  []common.Signature
 	Signers     []common.PublicHash
+	Fee         *amount.Amount
+	PushedAt    time.Time
+	order       uint64
+	popIndex    int
+	evictIndex  int
+	timeIndex   int
+	isAccount   bool
+	addr        common.Address
+	seq         uint64
+}
+
+// accountBucket has account model based transactions of the address that are sorted by the sequence
+type accountBucket struct {
+	addr  common.Address
+	items []*PoolItem
+}
+
+// search returns the index of the sequence, or the index to insert it when it is not found
+func (b *accountBucket) search(seq uint64) (int, bool) {
+	lo, hi := 0, len(b.items)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if b.items[mid].seq < seq {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < len(b.items) && b.items[lo].seq == seq
+}
+
+func (b *accountBucket) insert(item *PoolItem) {
+	idx, _ := b.search(item.seq)
+	b.items = append(b.items, nil)
+	copy(b.items[idx+1:], b.items[idx:])
+	b.items[idx] = item
+}
+
+func (b *accountBucket) head() *PoolItem {
+	if len(b.items) == 0 {
+		return nil
+	}
+	return b.items[0]
+}
+
+func (b *accountBucket) tail() *PoolItem {
+	if len(b.items) == 0 {
+		return nil
+	}
+	return b.items[len(b.items)-1]
 }
 
 // List return txpool list
@@ -298,6 +539,8 @@ func (tp *TransactionPool) List() []*PoolItem {
 			Transaction: item.Transaction,
 			Signatures:  item.Signatures,
 			Signers:     item.Signers,
+			Fee:         item.Fee,
+			PushedAt:    item.PushedAt,
 		})
 	}
 	return pis
@@ -309,71 +552,48 @@ func (tp *TransactionPool) Dump() string {
 	defer tp.Unlock()
 
 	var buffer bytes.Buffer
-	if tp.turnQ.Size() > 0 {
-		buffer.WriteString("turnQ\n")
-		tp.turnQ.Iter(func(value interface{}) {
-			if value.(bool) {
-				buffer.WriteString("true")
-			} else {
-				buffer.WriteString("false")
-			}
-			buffer.WriteString("\n")
-		})
-		buffer.WriteString("\n")
-	}
-	if tp.numberQ.Size() > 0 {
-		buffer.WriteString("numberQ\n")
-		tp.numberQ.Iter(func(value interface{}) {
-			buffer.WriteString(value.(common.Address).String())
-			buffer.WriteString("\n")
-		})
-		buffer.WriteString("\n")
-	}
-	if len(tp.txhashMap) > 0 {
-		buffer.WriteString("txhashMap\n")
-		for k := range tp.txhashMap {
-			buffer.WriteString(k.String())
+	if tp.popQ.Len() > 0 {
+		buffer.WriteString("popQ\n")
+		for _, item := range tp.popQ.items {
+			buffer.WriteString(item.TxHash.String())
+			buffer.WriteString(":")
+			buffer.WriteString(item.Fee.String())
 			buffer.WriteString("\n")
 		}
 		buffer.WriteString("\n")
 	}
-	if len(tp.turnOutMap) > 0 {
-		buffer.WriteString("turnOutMap\n")
-		for k, v := range tp.turnOutMap {
-			if k {
-				buffer.WriteString("true")
-			} else {
-				buffer.WriteString("false")
-			}
+	if tp.evictQ.Len() > 0 {
+		buffer.WriteString("evictQ\n")
+		for _, item := range tp.evictQ.items {
+			buffer.WriteString(item.TxHash.String())
 			buffer.WriteString(":")
-			buffer.WriteString(strconv.Itoa(v))
+			buffer.WriteString(item.Fee.String())
 			buffer.WriteString("\n")
 		}
 		buffer.WriteString("\n")
 	}
-	if len(tp.numberOutMap) > 0 {
-		buffer.WriteString("numberOutMap\n")
-		for k, v := range tp.numberOutMap {
+	if len(tp.txhashMap) > 0 {
+		buffer.WriteString("txhashMap\n")
+		for k := range tp.txhashMap {
 			buffer.WriteString(k.String())
-			buffer.WriteString(":")
-			buffer.WriteString(strconv.Itoa(v))
 			buffer.WriteString("\n")
 		}
 		buffer.WriteString("\n")
 	}
 	if len(tp.bucketMap) > 0 {
 		buffer.WriteString("bucketMap\n")
-		for k, v := range tp.bucketMap {
+		for k, b := range tp.bucketMap {
 			buffer.WriteString(k.String())
 			buffer.WriteString(":")
 			buffer.WriteString("\n")
-			v.Iter(func(value interface{}, priority uint64) {
-				buffer.WriteString(strconv.FormatUint(priority, 10))
+			for _, item := range b.items {
+				buffer.WriteString(strconv.FormatUint(item.seq, 10))
 				buffer.WriteString(":")
-				k := value.(*PoolItem)
-				buffer.WriteString(k.TxHash.String())
+				buffer.WriteString(item.TxHash.String())
+				buffer.WriteString(":")
+				buffer.WriteString(item.Fee.String())
 				buffer.WriteString("\n")
-			})
+			}
 			buffer.WriteString("\n")
 		}
 		buffer.WriteString("\n")
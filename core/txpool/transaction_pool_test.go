@@ -0,0 +1,456 @@
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/types"
+)
+
+type testAccountTx struct {
+	types.Transaction
+	from common.Address
+	seq  uint64
+}
+
+func (tx *testAccountTx) From() common.Address {
+	return tx.from
+}
+
+func (tx *testAccountTx) Seq() uint64 {
+	return tx.seq
+}
+
+type testUTXOTx struct {
+	types.Transaction
+}
+
+type testSeqCache map[common.Address]uint64
+
+func (sc testSeqCache) Seq(addr common.Address) uint64 {
+	return sc[addr]
+}
+
+var (
+	testAddrA = common.NewAddress(0, 1, 0)
+	testAddrB = common.NewAddress(0, 2, 0)
+)
+
+// testTx describes the transaction that is pushed by the test, the name is hashed to the tx hash
+type testTx struct {
+	name string
+	addr common.Address
+	seq  uint64
+	fee  uint64
+}
+
+func (v testTx) hash() hash.Hash256 {
+	return hash.Hash([]byte(v.name))
+}
+
+func (v testTx) tx() types.Transaction {
+	if v.seq == 0 {
+		return &testUTXOTx{}
+	}
+	return &testAccountTx{from: v.addr, seq: v.seq}
+}
+
+func pushTestTx(tp *TransactionPool, v testTx) ([]*PoolItem, error) {
+	return tp.Push(0, v.hash(), v.tx(), nil, nil, amount.NewCoinAmount(v.fee, 0))
+}
+
+func newTestPool(MaxSize int) *TransactionPool {
+	tp := NewTransactionPool()
+	tp.SetConfig(Config{
+		MaxSize:       MaxSize,
+		MaxPerAddress: 10,
+		TTL:           time.Hour,
+		PriceBump:     10,
+	})
+	return tp
+}
+
+func itemNames(items []*PoolItem, txs []testTx) []string {
+	names := []string{}
+	for _, item := range items {
+		for _, v := range txs {
+			if v.hash() == item.TxHash {
+				names = append(names, v.name)
+			}
+		}
+	}
+	return names
+}
+
+func equalNames(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := map[string]int{}
+	for _, v := range a {
+		m[v]++
+	}
+	for _, v := range b {
+		m[v]--
+	}
+	for _, c := range m {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPoolInvariants checks indexes of heaps and that heaps have heads and tails of buckets and utxo items
+func checkPoolInvariants(t *testing.T, tp *TransactionPool) {
+	t.Helper()
+
+	for name, h := range map[string]*itemHeap{"pop": tp.popQ, "evict": tp.evictQ, "time": tp.timeQ} {
+		for i, item := range h.items {
+			if *h.index(item) != i {
+				t.Fatalf("%v heap: invalid index of %v: %v", name, i, *h.index(item))
+			}
+			if p := (i - 1) / 2; i > 0 && h.less(item, h.items[p]) {
+				t.Fatalf("%v heap: the item at %v is less than the parent", name, i)
+			}
+		}
+	}
+	popMap := map[*PoolItem]bool{}
+	evictMap := map[*PoolItem]bool{}
+	count := 0
+	for addr, b := range tp.bucketMap {
+		if len(b.items) == 0 {
+			t.Fatalf("the empty bucket of %v is kept", addr)
+		}
+		for i, item := range b.items {
+			if i > 0 && b.items[i-1].seq >= item.seq {
+				t.Fatalf("the bucket of %v is not sorted", addr)
+			}
+			if tp.txhashMap[item.TxHash] != item {
+				t.Fatalf("the item of the bucket is not in the hash map")
+			}
+		}
+		popMap[b.head()] = true
+		evictMap[b.tail()] = true
+		count += len(b.items)
+	}
+	timeMap := map[*PoolItem]bool{}
+	for _, item := range tp.txhashMap {
+		timeMap[item] = true
+		if !item.isAccount {
+			popMap[item] = true
+			evictMap[item] = true
+			count++
+		}
+	}
+	if count != len(tp.txhashMap) {
+		t.Fatalf("invalid hash map size: %v != %v", len(tp.txhashMap), count)
+	}
+	for name, v := range map[string]struct {
+		h *itemHeap
+		m map[*PoolItem]bool
+	}{"pop": {tp.popQ, popMap}, "evict": {tp.evictQ, evictMap}, "time": {tp.timeQ, timeMap}} {
+		if v.h.Len() != len(v.m) {
+			t.Fatalf("%v heap: invalid length: %v != %v", name, v.h.Len(), len(v.m))
+		}
+		for item := range v.m {
+			if !v.h.Has(item) {
+				t.Fatalf("%v heap: the item is not in the heap", name)
+			}
+		}
+	}
+	shortCount := 0
+	for ShortID, list := range tp.shortIDMap {
+		for _, item := range list {
+			if ShortTxID(item.TxHash) != ShortID || tp.txhashMap[item.TxHash] != item {
+				t.Fatal("invalid short id index")
+			}
+		}
+		shortCount += len(list)
+	}
+	if shortCount != len(tp.txhashMap) {
+		t.Fatalf("invalid short id index size: %v != %v", shortCount, len(tp.txhashMap))
+	}
+}
+
+func TestTransactionPoolReplace(t *testing.T) {
+	tests := []struct {
+		name     string
+		fee      uint64
+		replaced bool
+	}{
+		{"same fee", 100, false},
+		{"lower fee", 90, false},
+		{"under price bump", 109, false},
+		{"price bump", 110, true},
+		{"over price bump", 200, true},
+	}
+	for _, tt := range tests {
+		tp := newTestPool(10)
+		old := testTx{"old", testAddrA, 1, 100}
+		next := testTx{"next", testAddrA, 2, 1}
+		if _, err := pushTestTx(tp, old); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pushTestTx(tp, next); err != nil {
+			t.Fatal(err)
+		}
+		v := testTx{"new", testAddrA, 1, tt.fee}
+		removed, err := pushTestTx(tp, v)
+		if tt.replaced {
+			if err != nil {
+				t.Fatalf("%v: %v", tt.name, err)
+			}
+			if !equalNames(itemNames(removed, []testTx{old}), []string{"old"}) {
+				t.Fatalf("%v: the replaced item is not returned", tt.name)
+			}
+			if tp.IsExist(old.hash()) || !tp.IsExist(v.hash()) {
+				t.Fatalf("%v: the item is not replaced", tt.name)
+			}
+		} else {
+			if err != ErrReplaceUnderpriced {
+				t.Fatalf("%v: expected %v but %v", tt.name, ErrReplaceUnderpriced, err)
+			}
+			if !tp.IsExist(old.hash()) || tp.IsExist(v.hash()) {
+				t.Fatalf("%v: the underpriced item replaced", tt.name)
+			}
+		}
+		if !tp.IsExist(next.hash()) || tp.Size() != 2 {
+			t.Fatalf("%v: the following sequence is changed", tt.name)
+		}
+		checkPoolInvariants(t, tp)
+	}
+}
+
+func TestTransactionPoolEviction(t *testing.T) {
+	txs := []testTx{
+		{"a1", testAddrA, 1, 50},
+		{"a2", testAddrA, 2, 5},
+		{"a3", testAddrA, 3, 10},
+		{"u1", testAddrB, 0, 20},
+		{"u2", testAddrB, 0, 30},
+		{"u3", testAddrB, 0, 1},
+		{"a4", testAddrA, 4, 100},
+		{"b1", testAddrB, 1, 40},
+		{"b2", testAddrB, 2, 60},
+	}
+	byName := map[string]testTx{}
+	for _, v := range txs {
+		byName[v.name] = v
+	}
+	steps := []struct {
+		push    string
+		err     error
+		removed []string
+	}{
+		{"a1", nil, nil},
+		{"a2", nil, nil},
+		{"a3", nil, nil},
+		{"u1", nil, nil},
+		// the tail of the bucket is evicted even if the middle of it is cheaper
+		{"u2", nil, []string{"a3"}},
+		{"u3", ErrTransactionPoolOverflowed, nil},
+		// the later sequence of the same address cannot evict the earlier one
+		{"a4", ErrTransactionPoolOverflowed, nil},
+		{"b1", nil, []string{"a2"}},
+		{"b2", nil, []string{"u1"}},
+	}
+	tp := newTestPool(4)
+	for _, step := range steps {
+		removed, err := pushTestTx(tp, byName[step.push])
+		if err != step.err {
+			t.Fatalf("push %v: expected %v but %v", step.push, step.err, err)
+		}
+		if names := itemNames(removed, txs); !equalNames(names, step.removed) {
+			t.Fatalf("push %v: expected removed %v but %v", step.push, step.removed, names)
+		}
+		for _, name := range step.removed {
+			if tp.IsExist(byName[name].hash()) {
+				t.Fatalf("push %v: %v is not removed", step.push, name)
+			}
+		}
+		if tp.Size() > 4 {
+			t.Fatalf("push %v: the pool is overflowed: %v", step.push, tp.Size())
+		}
+		checkPoolInvariants(t, tp)
+	}
+	for _, name := range []string{"a1", "u2", "b1", "b2"} {
+		if !tp.IsExist(byName[name].hash()) {
+			t.Fatalf("%v is evicted", name)
+		}
+	}
+}
+
+func TestTransactionPoolExpire(t *testing.T) {
+	tp := newTestPool(10)
+	txs := []testTx{
+		{"a1", testAddrA, 1, 10},
+		{"a2", testAddrA, 2, 10},
+		{"a3", testAddrA, 3, 10},
+		{"u1", testAddrB, 0, 10},
+	}
+	for _, v := range txs {
+		if _, err := pushTestTx(tp, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	replaced := testTx{"a1'", testAddrA, 1, 20}
+	if _, err := pushTestTx(tp, replaced); err != nil {
+		t.Fatal(err)
+	}
+
+	// the replaced first sequence is not expired, the second one is expired with the following sequences
+	tp.Lock()
+	tp.expire(tp.txhashMap[txs[1].hash()].PushedAt.Add(time.Hour))
+	tp.Unlock()
+	for _, v := range txs[:3] {
+		if tp.IsExist(v.hash()) {
+			t.Fatalf("%v is not expired", v.name)
+		}
+	}
+	if !tp.IsExist(replaced.hash()) {
+		t.Fatal("the replaced item is expired")
+	}
+	if !tp.IsExist(txs[3].hash()) || tp.Size() != 2 {
+		t.Fatal("the item that is pushed after the expired one is expired")
+	}
+	checkPoolInvariants(t, tp)
+
+	tp.Lock()
+	tp.expire(time.Now().Add(time.Hour))
+	tp.Unlock()
+	if tp.Size() != 0 || tp.timeQ.Len() != 0 {
+		t.Fatalf("items are not expired: %v %v", tp.Size(), tp.timeQ.Len())
+	}
+	checkPoolInvariants(t, tp)
+}
+
+func TestTransactionPoolUnsafePop(t *testing.T) {
+	tp := newTestPool(10)
+	sc := testSeqCache{testAddrA: 0, testAddrB: 5}
+	txs := []testTx{
+		{"a2", testAddrA, 2, 100},
+		{"a3", testAddrA, 3, 90},
+		{"b5", testAddrB, 5, 80},
+		{"b6", testAddrB, 6, 70},
+		{"u1", testAddrB, 0, 10},
+	}
+	for _, v := range txs {
+		if _, err := pushTestTx(tp, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the head of the gap is ignored and the past sequence is dropped
+	item := tp.Pop(sc)
+	if names := itemNames([]*PoolItem{item}, txs); !equalNames(names, []string{"b6"}) {
+		t.Fatalf("invalid popped item: %v", names)
+	}
+	if tp.IsExist(txs[2].hash()) {
+		t.Fatal("the past sequence is not dropped")
+	}
+	sc[testAddrB] = 6
+	checkPoolInvariants(t, tp)
+	if !tp.popQ.Has(tp.Get(txs[0].hash())) {
+		t.Fatal("the ignored head is not pushed again")
+	}
+
+	item = tp.Pop(sc)
+	if names := itemNames([]*PoolItem{item}, txs); !equalNames(names, []string{"u1"}) {
+		t.Fatalf("invalid popped item: %v", names)
+	}
+	if item := tp.Pop(sc); item != nil {
+		t.Fatal("popped the item of the gap")
+	}
+	checkPoolInvariants(t, tp)
+
+	// the gap is filled
+	a1 := testTx{"a1", testAddrA, 1, 1}
+	if _, err := pushTestTx(tp, a1); err != nil {
+		t.Fatal(err)
+	}
+	all := append(txs, a1)
+	for _, name := range []string{"a1", "a2", "a3"} {
+		item := tp.Pop(sc)
+		if names := itemNames([]*PoolItem{item}, all); !equalNames(names, []string{name}) {
+			t.Fatalf("expected %v but %v", name, names)
+		}
+		sc[testAddrA]++
+		checkPoolInvariants(t, tp)
+	}
+	if tp.Size() != 0 {
+		t.Fatalf("invalid size: %v", tp.Size())
+	}
+}
+
+func TestTransactionPoolRemoveDrop(t *testing.T) {
+	txs := []testTx{
+		{"a1", testAddrA, 1, 10},
+		{"a2", testAddrA, 2, 30},
+		{"a3", testAddrA, 3, 20},
+		{"a4", testAddrA, 4, 40},
+		{"b1", testAddrB, 1, 15},
+		{"b2", testAddrB, 2, 25},
+		{"u1", testAddrB, 0, 35},
+		{"u2", testAddrB, 0, 5},
+	}
+	tests := []struct {
+		name   string
+		op     func(tp *TransactionPool) []*PoolItem
+		remain []string
+	}{
+		{"remove until the sequence", func(tp *TransactionPool) []*PoolItem {
+			tp.Remove(txs[1].hash(), txs[1].tx())
+			return nil
+		}, []string{"a3", "a4", "b1", "b2", "u1", "u2"}},
+		{"remove the sequence that is not in the pool", func(tp *TransactionPool) []*PoolItem {
+			v := testTx{"a5", testAddrA, 5, 1}
+			tp.Remove(v.hash(), v.tx())
+			return nil
+		}, []string{"b1", "b2", "u1", "u2"}},
+		{"remove the utxo", func(tp *TransactionPool) []*PoolItem {
+			tp.Remove(txs[6].hash(), txs[6].tx())
+			return nil
+		}, []string{"a1", "a2", "a3", "a4", "b1", "b2", "u2"}},
+		{"drop from the sequence", func(tp *TransactionPool) []*PoolItem {
+			return tp.Drop(txs[2].hash())
+		}, []string{"a1", "a2", "b1", "b2", "u1", "u2"}},
+		{"drop the head", func(tp *TransactionPool) []*PoolItem {
+			return tp.Drop(txs[4].hash())
+		}, []string{"a1", "a2", "a3", "a4", "u1", "u2"}},
+		{"drop the utxo", func(tp *TransactionPool) []*PoolItem {
+			return tp.Drop(txs[7].hash())
+		}, []string{"a1", "a2", "a3", "a4", "b1", "b2", "u1"}},
+	}
+	for _, tt := range tests {
+		tp := newTestPool(10)
+		for _, v := range txs {
+			if _, err := pushTestTx(tp, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		removed := tt.op(tp)
+		checkPoolInvariants(t, tp)
+		remain := []string{}
+		for _, v := range txs {
+			if tp.IsExist(v.hash()) {
+				remain = append(remain, v.name)
+			}
+		}
+		if !equalNames(remain, tt.remain) {
+			t.Fatalf("%v: expected %v but %v", tt.name, tt.remain, remain)
+		}
+		if removed != nil && len(removed)+len(remain) != len(txs) {
+			t.Fatalf("%v: invalid removed count: %v", tt.name, len(removed))
+		}
+		for _, item := range removed {
+			if tp.popQ.Has(item) || tp.evictQ.Has(item) {
+				t.Fatalf("%v: the removed item is in the heap", tt.name)
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
 	"reflect"
 
 	"github.com/fletaio/fleta_v1/common"
@@ -139,3 +141,66 @@ type Block struct {
 	TransactionResults    []uint8              //MAXLEN : 65535
 	Signatures            []common.Signature   //MAXLEN : 255
 }
+
+// MarshalJSON is a marshaler function
+func (b *Block) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"header":`)
+	if bs, err := b.Header.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"transaction_types":`)
+	if bs, err := json.Marshal(b.TransactionTypes); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"transactions":`)
+	buffer.WriteString(`[`)
+	for i, tx := range b.Transactions {
+		if i > 0 {
+			buffer.WriteString(`,`)
+		}
+		if bs, err := tx.MarshalJSON(); err != nil {
+			return nil, err
+		} else {
+			buffer.Write(bs)
+		}
+	}
+	buffer.WriteString(`]`)
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"transaction_signatures":`)
+	if bs, err := json.Marshal(b.TransactionSignatures); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"transaction_results":`)
+	buffer.WriteString(`[`)
+	for i, r := range b.TransactionResults {
+		if i > 0 {
+			buffer.WriteString(`,`)
+		}
+		if bs, err := json.Marshal(r); err != nil {
+			return nil, err
+		} else {
+			buffer.Write(bs)
+		}
+	}
+	buffer.WriteString(`]`)
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"signatures":`)
+	if bs, err := json.Marshal(b.Signatures); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
@@ -1,6 +1,10 @@
 package types
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
 	"github.com/fletaio/fleta_v1/common"
 	"github.com/fletaio/fleta_v1/common/hash"
 )
@@ -17,3 +21,73 @@ type Header struct {
 	Generator     common.Address
 	ConsensusData []byte
 }
+
+// MarshalJSON is a marshaler function
+func (bh *Header) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"chain_id":`)
+	if bs, err := json.Marshal(bh.ChainID); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"version":`)
+	if bs, err := json.Marshal(bh.Version); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"height":`)
+	if bs, err := json.Marshal(bh.Height); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"prev_hash":`)
+	if bs, err := bh.PrevHash.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"level_root_hash":`)
+	if bs, err := bh.LevelRootHash.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"context_hash":`)
+	if bs, err := bh.ContextHash.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"timestamp":`)
+	if bs, err := json.Marshal(bh.Timestamp); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"generator":`)
+	if bs, err := bh.Generator.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"consensus_data":`)
+	if bs, err := json.Marshal(hex.EncodeToString(bh.ConsensusData)); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
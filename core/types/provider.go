@@ -16,7 +16,9 @@ type Provider interface {
 	LastHash() hash.Hash256
 	LastTimestamp() uint64
 	Hash(height uint32) (hash.Hash256, error)
+	HeightByHash(h hash.Hash256) (uint32, error)
 	Header(height uint32) (*Header, error)
+	Signatures(height uint32) ([]common.Signature, error)
 	Block(height uint32) (*Block, error)
 	Seq(addr common.Address) uint64
 	Events(From uint32, To uint32) ([]Event, error)
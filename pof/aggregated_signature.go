@@ -0,0 +1,151 @@
+package pof
+
+import (
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/schnorr"
+)
+
+// aggregatedKeys returns the ordered observer public keys when the aggregated signature is activated
+func (cs *Consensus) aggregatedKeys() []common.PublicKey {
+	return cs.aggregatedKeysOf(cs.currentPolicy())
+}
+
+// aggregatedKeysOf returns nil when the policy doesn't use it or the public keys don't match to the observer keys
+func (cs *Consensus) aggregatedKeysOf(policy *ConsensusPolicy) []common.PublicKey {
+	if !policy.UseAggregatedSignature {
+		return nil
+	}
+	keys := policy.ObserverPublicKeys
+	if len(keys) != cs.observerKeyMap.Len() {
+		return nil
+	}
+	KeyMap := map[common.PublicHash]bool{}
+	for _, pubkey := range keys {
+		pubhash := common.NewPublicHash(pubkey)
+		if !cs.observerKeyMap.Has(pubhash) {
+			return nil
+		}
+		KeyMap[pubhash] = true
+	}
+	if len(KeyMap) != len(keys) {
+		return nil
+	}
+	return keys
+}
+
+func (cs *Consensus) validateAggregatedSignature(keys []common.PublicKey, h hash.Hash256, sigs []common.Signature, Quorum int) error {
+	Bitmap, as, err := unpackAggregatedSignature(sigs, len(keys))
+	if err != nil {
+		return err
+	}
+	signers := []common.PublicKey{}
+	for i, pubkey := range keys {
+		if Bitmap[i/8]&(1<<uint(i%8)) != 0 {
+			signers = append(signers, pubkey)
+		}
+	}
+	if len(signers) != Quorum {
+		return ErrInvalidSignatureCount
+	}
+	if err := schnorr.VerifyAggregate(signers, h, as); err != nil {
+		return err
+	}
+	return nil
+}
+
+// buildAggregatedSignature aggregates the schnorr signatures of the signers in the order of the keys
+func buildAggregatedSignature(keys []common.PublicKey, h hash.Hash256, SigMap map[common.PublicHash]common.Signature, Quorum int) ([]common.Signature, error) {
+	Bitmap := make([]byte, (len(keys)+7)/8)
+	signers := []common.PublicKey{}
+	sigs := []schnorr.Signature{}
+	for i, pubkey := range keys {
+		if len(signers) >= Quorum {
+			break
+		}
+		if sig, has := SigMap[common.NewPublicHash(pubkey)]; has {
+			Bitmap[i/8] |= 1 << uint(i%8)
+			signers = append(signers, pubkey)
+			var ssig schnorr.Signature
+			copy(ssig[:], sig[:])
+			sigs = append(sigs, ssig)
+		}
+	}
+	if len(signers) != Quorum {
+		return nil, ErrInvalidSignatureCount
+	}
+	as, err := schnorr.Aggregate(signers, h, sigs)
+	if err != nil {
+		return nil, err
+	}
+	return packAggregatedSignature(Bitmap, as), nil
+}
+
+// packAggregatedSignature packs the signer bitmap and the aggregated signature to the signature slots of the block
+// [bitmap length(1)][bitmap][S(32)][R(32) of each signer] padded by zeros
+func packAggregatedSignature(Bitmap []byte, as *schnorr.AggregateSignature) []common.Signature {
+	data := make([]byte, 0, 1+len(Bitmap)+32+32*len(as.Rs))
+	data = append(data, byte(len(Bitmap)))
+	data = append(data, Bitmap...)
+	data = append(data, as.S[:]...)
+	for _, R := range as.Rs {
+		data = append(data, R[:]...)
+	}
+	sigs := make([]common.Signature, (len(data)+common.SignatureSize-1)/common.SignatureSize)
+	for i := range sigs {
+		copy(sigs[i][:], data[i*common.SignatureSize:])
+	}
+	return sigs
+}
+
+func unpackAggregatedSignature(sigs []common.Signature, KeyCount int) ([]byte, *schnorr.AggregateSignature, error) {
+	data := make([]byte, 0, len(sigs)*common.SignatureSize)
+	for _, sig := range sigs {
+		data = append(data, sig[:]...)
+	}
+	BitmapLen := (KeyCount + 7) / 8
+	if len(data) < 1+BitmapLen+32 || int(data[0]) != BitmapLen {
+		return nil, nil, schnorr.ErrInvalidAggregateData
+	}
+	Bitmap := data[1 : 1+BitmapLen]
+	SignerCount := 0
+	for i := 0; i < BitmapLen*8; i++ {
+		if Bitmap[i/8]&(1<<uint(i%8)) != 0 {
+			if i >= KeyCount {
+				return nil, nil, schnorr.ErrInvalidAggregateData
+			}
+			SignerCount++
+		}
+	}
+	Size := 1 + BitmapLen + 32 + 32*SignerCount
+	if len(sigs) != (Size+common.SignatureSize-1)/common.SignatureSize {
+		return nil, nil, schnorr.ErrInvalidAggregateData
+	}
+	for _, v := range data[Size:] {
+		if v != 0 {
+			return nil, nil, schnorr.ErrInvalidAggregateData
+		}
+	}
+	as := &schnorr.AggregateSignature{
+		Rs: make([][32]byte, SignerCount),
+	}
+	pos := 1 + BitmapLen
+	copy(as.S[:], data[pos:])
+	pos += 32
+	for i := range as.Rs {
+		copy(as.Rs[i][:], data[pos:])
+		pos += 32
+	}
+	return Bitmap, as, nil
+}
+
+func verifySchnorrVote(keys []common.PublicKey, SenderPublicHash common.PublicHash, h hash.Hash256, sig common.Signature) error {
+	for _, pubkey := range keys {
+		if common.NewPublicHash(pubkey) == SenderPublicHash {
+			var ssig schnorr.Signature
+			copy(ssig[:], sig[:])
+			return schnorr.Verify(pubkey, h, ssig)
+		}
+	}
+	return ErrInvalidVote
+}
@@ -2,6 +2,7 @@ package pof
 
 import (
 	"bytes"
+	"net/http"
 	"sync"
 
 	"github.com/fletaio/fleta_v1/common"
@@ -21,6 +22,16 @@ type Consensus struct {
 	blocksBySameFormulator uint32
 	observerKeyMap         *types.PublicHashBoolMap
 	rt                     *RankTable
+	policy                 *ConsensusPolicy
+	isPolicyActivated      bool
+	pp                     PolicyProcess
+	missedHeight           uint32
+	missedRanks            []*Rank
+	savedHeight            uint32
+	headerPolicyLock       sync.Mutex
+	headerPolicyBase       uint32
+	headerPolicyScanned    uint32
+	headerPolicies         map[uint32]*ConsensusPolicy
 }
 
 // NewConsensus returns a Consensus
@@ -33,6 +44,8 @@ func NewConsensus(MaxBlocksPerFormulator uint32, ObserverKeys []common.PublicHas
 		maxBlocksPerFormulator: MaxBlocksPerFormulator,
 		observerKeyMap:         ObserverKeyMap,
 		rt:                     NewRankTable(),
+		policy:                 DefaultConsensusPolicy(MaxBlocksPerFormulator),
+		headerPolicies:         map[uint32]*ConsensusPolicy{},
 	}
 	return cs
 }
@@ -42,6 +55,15 @@ func (cs *Consensus) Init(cn *chain.Chain, ct chain.Committer) error {
 	cs.cn = cn
 	cs.ct = ct
 
+	if p, err := cn.ProcessByName("fleta.consensus"); err != nil {
+		//ignore when not loaded
+	} else if pp, is := p.(PolicyProcess); !is {
+		return types.ErrInvalidProcess
+	} else {
+		pp.SetObserverCount(cs.observerKeyMap.Len())
+		cs.pp = pp
+	}
+
 	if vs, err := cn.ServiceByName("fleta.apiserver"); err != nil {
 		//ignore when not loaded
 	} else if v, is := vs.(*apiserver.APIServer); !is {
@@ -55,6 +77,38 @@ func (cs *Consensus) Init(cn *chain.Chain, ct chain.Committer) error {
 			list := cs.rt.Candidates()
 			return list, nil
 		})
+		s.Set("policy", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return cs.Policy(), nil
+		})
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodGet,
+			Path:    "/v1/formulators",
+			RPC:     "consensus.getRanks",
+			Summary: "returns formulators in the order of the rank",
+			Result: apiserver.ArraySchema(apiserver.ObjectSchema(map[string]*apiserver.Schema{
+				"Address":    apiserver.StringSchema(""),
+				"PublicHash": apiserver.StringSchema(""),
+			})),
+		}); err != nil {
+			return err
+		}
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodGet,
+			Path:    "/v1/consensus/policy",
+			RPC:     "consensus.policy",
+			Summary: "returns the consensus policy",
+			Result: apiserver.ObjectSchema(map[string]*apiserver.Schema{
+				"MaxBlocksPerFormulator": apiserver.IntegerSchema("int32", ""),
+				"BlockIntervalMs":        apiserver.IntegerSchema("int32", ""),
+				"TxCollectionTimeoutMs":  apiserver.IntegerSchema("int32", ""),
+				"RoundTimeoutMs":         apiserver.IntegerSchema("int32", ""),
+				"ObserverQuorum":         apiserver.IntegerSchema("int32", "0 means the simple majority of observers"),
+				"UseAggregatedSignature": apiserver.BooleanSchema(""),
+				"ObserverPublicKeys":     apiserver.ArraySchema(apiserver.StringSchema("")),
+			}),
+		}); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -81,13 +135,11 @@ func (cs *Consensus) OnLoadChain(loader types.LoaderWrapper) error {
 	cs.Lock()
 	defer cs.Unlock()
 
-	dec := encoding.NewDecoder(bytes.NewReader(loader.ProcessData(tagState)))
-	if v, err := dec.DecodeUint32(); err != nil {
+	r := bytes.NewReader(loader.ProcessData(tagState))
+	dec := encoding.NewDecoder(r)
+	MaxBlocksPerFormulator, err := dec.DecodeUint32()
+	if err != nil {
 		return err
-	} else {
-		if cs.maxBlocksPerFormulator != v {
-			return ErrInvalidMaxBlocksPerFormulator
-		}
 	}
 	ObserverKeyMap := types.NewPublicHashBoolMap()
 	if err := dec.Decode(&ObserverKeyMap); err != nil {
@@ -116,6 +168,24 @@ func (cs *Consensus) OnLoadChain(loader types.LoaderWrapper) error {
 	if err := dec.Decode(&cs.rt); err != nil {
 		return err
 	}
+	if r.Len() > 0 {
+		policy := &ConsensusPolicy{}
+		if err := dec.Decode(&policy); err != nil {
+			return err
+		}
+		if policy.MaxBlocksPerFormulator != MaxBlocksPerFormulator {
+			return ErrInvalidMaxBlocksPerFormulator
+		}
+		if !policy.IsValid(cs.observerKeyMap.Len()) {
+			return ErrInvalidPolicy
+		}
+		cs.policy = policy
+		cs.isPolicyActivated = true
+		cs.maxBlocksPerFormulator = MaxBlocksPerFormulator
+	} else if cs.maxBlocksPerFormulator != MaxBlocksPerFormulator {
+		return ErrInvalidMaxBlocksPerFormulator
+	}
+	cs.savedHeight = loader.TargetHeight() - 1
 	return nil
 }
 
@@ -134,6 +204,9 @@ func (cs *Consensus) ValidateSignature(bh *types.Header, sigs []common.Signature
 		return ErrInvalidTopAddress
 	}
 
+	if len(sigs) == 0 {
+		return ErrInvalidSignatureCount
+	}
 	GeneratorSignature := sigs[0]
 	pubkey, err := common.RecoverPubkey(encoding.Hash(bh), GeneratorSignature)
 	if err != nil {
@@ -143,8 +216,90 @@ func (cs *Consensus) ValidateSignature(bh *types.Header, sigs []common.Signature
 	if Top.PublicHash != pubhash {
 		return ErrInvalidTopSignature
 	}
+	return cs.validateObserverSignatures(cs.currentPolicy(), bh, sigs)
+}
+
+// ValidateHeaderSignature validates the signatures of the header that is not connected yet
+// It cannot check the rank of the generator because the rank table is not advanced to the height,
+// so it only checks the generator signature is recoverable and the observer signatures of the generator signature
+func (cs *Consensus) ValidateHeaderSignature(bh *types.Header, sigs []common.Signature) error {
+	if len(sigs) == 0 {
+		return ErrInvalidSignatureCount
+	}
+	if _, err := common.RecoverPubkey(encoding.Hash(bh), sigs[0]); err != nil {
+		return err
+	}
+	policy, err := cs.headerPolicy(cs.cn.NewContext(), bh.Height)
+	if err != nil {
+		return err
+	}
+	return cs.validateObserverSignatures(policy, bh, sigs)
+}
+
+// HeaderHorizon returns the last height that the policy of the header is known
+// Policies are scheduled at least PolicyActivationDelay blocks ahead, so the headers until the horizon are validated by the policy of the height
+func (cs *Consensus) HeaderHorizon() uint32 {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return cs.savedHeight + PolicyActivationDelay
+}
+
+// headerPolicy returns the policy that is activated at the height of the header that is not connected yet
+func (cs *Consensus) headerPolicy(loader types.Loader, Height uint32) (*ConsensusPolicy, error) {
+	cs.Lock()
+	policy := cs.policy
+	Base := cs.savedHeight
+	cs.Unlock()
 
-	if len(sigs) != cs.observerKeyMap.Len()/2+2 {
+	if Height <= Base+1 || cs.pp == nil {
+		return policy, nil
+	}
+	if Height > Base+PolicyActivationDelay {
+		return nil, ErrUnknownHeaderPolicy
+	}
+
+	cs.headerPolicyLock.Lock()
+	defer cs.headerPolicyLock.Unlock()
+
+	if cs.headerPolicyBase != Base {
+		cs.headerPolicyBase = Base
+		cs.headerPolicyScanned = Base + 1
+		cs.headerPolicies = map[uint32]*ConsensusPolicy{}
+	}
+	for h := cs.headerPolicyScanned + 1; h <= Height; h++ {
+		p, err := cs.pp.ScheduledPolicy(loader, h)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			if !p.IsValid(cs.observerKeyMap.Len()) {
+				return nil, ErrInvalidPolicy
+			}
+			cs.headerPolicies[h] = p
+		}
+		cs.headerPolicyScanned = h
+	}
+	var Activated uint32
+	for h, p := range cs.headerPolicies {
+		if h <= Height && h > Activated {
+			Activated = h
+			policy = p
+		}
+	}
+	return policy, nil
+}
+
+func (cs *Consensus) validateObserverSignatures(policy *ConsensusPolicy, bh *types.Header, sigs []common.Signature) error {
+	Quorum := cs.observerQuorum(policy)
+	if keys := cs.aggregatedKeysOf(policy); keys != nil {
+		bs := types.BlockSign{
+			HeaderHash:         encoding.Hash(bh),
+			GeneratorSignature: sigs[0],
+		}
+		return cs.validateAggregatedSignature(keys, encoding.Hash(bs), sigs[1:], Quorum)
+	}
+	if len(sigs) != Quorum+1 {
 		return ErrInvalidSignatureCount
 	}
 	KeyMap := map[common.PublicHash]bool{}
@@ -157,7 +312,7 @@ func (cs *Consensus) ValidateSignature(bh *types.Header, sigs []common.Signature
 		GeneratorSignature: sigs[0],
 	}
 	ObserverSignatures := sigs[1:]
-	if err := common.ValidateSignaturesMajority(encoding.Hash(bs), ObserverSignatures, KeyMap); err != nil {
+	if err := common.ValidateSignaturesQuorum(encoding.Hash(bs), ObserverSignatures, KeyMap, Quorum); err != nil {
 		return err
 	}
 	return nil
@@ -174,6 +329,9 @@ func (cs *Consensus) OnSaveData(b *types.Block, ctw *types.ContextWrapper) error
 	if err != nil {
 		return err
 	}
+	cs.missedHeight = b.Header.Height
+	cs.savedHeight = b.Header.Height
+	cs.missedRanks = cs.rt.topRanks(int(TimeoutCount))
 	if TimeoutCount > 0 {
 		if err := cs.rt.forwardCandidates(int(TimeoutCount)); err != nil {
 			return err
@@ -185,6 +343,24 @@ func (cs *Consensus) OnSaveData(b *types.Block, ctw *types.ContextWrapper) error
 		cs.rt.forwardTop(HeaderHash)
 		cs.blocksBySameFormulator = 0
 	}
+	if cs.pp != nil {
+		policy, err := cs.pp.ScheduledPolicy(ctw, b.Header.Height+1)
+		if err != nil {
+			return err
+		}
+		if policy != nil {
+			if !policy.IsValid(cs.observerKeyMap.Len()) {
+				return ErrInvalidPolicy
+			}
+			cs.policy = policy
+			cs.isPolicyActivated = true
+			cs.maxBlocksPerFormulator = policy.MaxBlocksPerFormulator
+			if cs.blocksBySameFormulator >= cs.maxBlocksPerFormulator {
+				cs.rt.forwardTop(HeaderHash)
+				cs.blocksBySameFormulator = 0
+			}
+		}
+	}
 
 	if err := cs.updateFormulatorList(ctw); err != nil {
 		return err
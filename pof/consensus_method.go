@@ -9,6 +9,66 @@ import (
 	"github.com/fletaio/fleta_v1/encoding"
 )
 
+// Policy returns the current consensus policy
+func (cs *Consensus) Policy() *ConsensusPolicy {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return cs.policy.Clone()
+}
+
+// ObserverQuorum returns the number of observer signatures that are required to confirm a block
+func (cs *Consensus) ObserverQuorum() int {
+	return cs.observerQuorum(cs.currentPolicy())
+}
+
+// RoundVoteQuorum returns the number of round votes that are required to start the round
+// It keeps the quorum of the majority plus one before the policy specifies the quorum
+func (cs *Consensus) RoundVoteQuorum() int {
+	policy := cs.currentPolicy()
+	if policy.ObserverQuorum == 0 {
+		return cs.observerKeyMap.Len()/2 + 2
+	}
+	return int(policy.ObserverQuorum)
+}
+
+// MaxBlocksPerFormulator returns the maximum number of blocks that a formulator generates in a row
+func (cs *Consensus) MaxBlocksPerFormulator() uint32 {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return cs.maxBlocksPerFormulator
+}
+
+// RemainBlocks returns the number of blocks that the top formulator of the timeout count can generate in a row
+func (cs *Consensus) RemainBlocks(TimeoutCount uint32) uint32 {
+	cs.Lock()
+	defer cs.Unlock()
+
+	if TimeoutCount == 0 {
+		return cs.maxBlocksPerFormulator - cs.blocksBySameFormulator
+	}
+	return cs.maxBlocksPerFormulator
+}
+
+// currentPolicy returns the activated policy
+// The policy is replaced when a scheduled policy is activated and never modified, so it can be used as a snapshot
+func (cs *Consensus) currentPolicy() *ConsensusPolicy {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return cs.policy
+}
+
+// observerQuorum returns the simple majority when the policy doesn't specify the quorum
+// The quorum of the policy is validated by the observer count when it is scheduled, so it is not clamped here
+func (cs *Consensus) observerQuorum(policy *ConsensusPolicy) int {
+	if policy.ObserverQuorum == 0 {
+		return cs.observerKeyMap.Len()/2 + 1
+	}
+	return int(policy.ObserverQuorum)
+}
+
 // Candidates returns a candidates
 func (cs *Consensus) Candidates() []*Rank {
 	cs.Lock()
@@ -17,6 +77,22 @@ func (cs *Consensus) Candidates() []*Rank {
 	return cs.rt.Candidates()
 }
 
+// MissedRanks returns the ranks that missed their turn in the block of the height
+// They are snapshotted before the candidates are forwarded by the block, so it returns false when the height is not the last saved block
+func (cs *Consensus) MissedRanks(Height uint32) ([]*Rank, bool) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	if cs.missedHeight != Height {
+		return nil, false
+	}
+	list := make([]*Rank, 0, len(cs.missedRanks))
+	for _, r := range cs.missedRanks {
+		list = append(list, r.Clone())
+	}
+	return list, true
+}
+
 func (cs *Consensus) updateFormulatorList(ctw *types.ContextWrapper) error {
 	var inErr error
 	phase := cs.rt.smallestPhase() + 2
@@ -104,5 +180,11 @@ func (cs *Consensus) buildSaveData() ([]byte, error) {
 	if err := enc.Encode(cs.rt); err != nil {
 		return nil, err
 	}
+	// the policy is appended after the first activation to keep the state of the previous blocks
+	if cs.isPolicyActivated {
+		if err := enc.Encode(cs.policy); err != nil {
+			return nil, err
+		}
+	}
 	return buffer.Bytes(), nil
 }
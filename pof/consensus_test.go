@@ -0,0 +1,297 @@
+package pof
+
+import (
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/types"
+)
+
+type testPolicyProcess struct {
+	types.ProcessBase
+	policies map[uint32]*ConsensusPolicy
+}
+
+func (p *testPolicyProcess) ID() uint8 {
+	return 6
+}
+
+func (p *testPolicyProcess) Name() string {
+	return "fleta.consensus"
+}
+
+func (p *testPolicyProcess) Version() string {
+	return "0.0.1"
+}
+
+func (p *testPolicyProcess) Init(reg *types.Register, pm types.ProcessManager, cn types.Provider) error {
+	return nil
+}
+
+func (p *testPolicyProcess) SetObserverCount(Count int) {
+}
+
+func (p *testPolicyProcess) ScheduledPolicy(loader types.Loader, Height uint32) (*ConsensusPolicy, error) {
+	return p.policies[Height], nil
+}
+
+func testObserverKeys(Count int) []common.PublicHash {
+	keys := []common.PublicHash{}
+	for i := 0; i < Count; i++ {
+		var pubhash common.PublicHash
+		pubhash[0] = byte(i + 1)
+		keys = append(keys, pubhash)
+	}
+	return keys
+}
+
+func newTestConsensus(t *testing.T, MaxBlocksPerFormulator uint32, pp PolicyProcess) *Consensus {
+	cs := NewConsensus(MaxBlocksPerFormulator, testObserverKeys(5))
+	cs.pp = pp
+	for i := 0; i < 3; i++ {
+		addr := common.NewAddress(0, uint16(i), 0)
+		var pubhash common.PublicHash
+		pubhash[0] = byte(i + 10)
+		if err := cs.rt.addRank(NewRank(addr, pubhash, 0, hash.DoubleHash(addr[:]))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cs
+}
+
+func saveTestBlock(t *testing.T, cs *Consensus, ctw *types.ContextWrapper, Height uint32, TimeoutCount uint32) {
+	data, err := cs.encodeConsensusData(TimeoutCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &types.Block{
+		Header: types.Header{
+			Height:        Height,
+			ConsensusData: data,
+		},
+	}
+	if err := cs.OnSaveData(b, ctw); err != nil {
+		t.Fatal(Height, err)
+	}
+}
+
+func TestConsensusScheduledPolicy(t *testing.T) {
+	policy := DefaultConsensusPolicy(4)
+	policy.ObserverQuorum = 4
+	pp := &testPolicyProcess{
+		policies: map[uint32]*ConsensusPolicy{3: policy},
+	}
+	cs := newTestConsensus(t, 2, pp)
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+
+	saveTestBlock(t, cs, ctw, 1, 0)
+	if cs.MaxBlocksPerFormulator() != 2 || cs.ObserverQuorum() != 3 {
+		t.Fatalf("activated before the height: %v %v", cs.MaxBlocksPerFormulator(), cs.ObserverQuorum())
+	}
+	saveTestBlock(t, cs, ctw, 2, 0)
+	if cs.MaxBlocksPerFormulator() != 4 {
+		t.Fatalf("max blocks per formulator is not activated: %v", cs.MaxBlocksPerFormulator())
+	}
+	if cs.ObserverQuorum() != 4 {
+		t.Fatalf("observer quorum is not activated: %v", cs.ObserverQuorum())
+	}
+	if cs.RemainBlocks(1) != 4 {
+		t.Fatalf("invalid remain blocks: %v", cs.RemainBlocks(1))
+	}
+	if p := cs.Policy(); p.MaxBlocksPerFormulator != 4 || p.ObserverQuorum != 4 {
+		t.Fatalf("invalid policy: %+v", p)
+	}
+}
+
+func TestConsensusRoundVoteQuorum(t *testing.T) {
+	policy := DefaultConsensusPolicy(2)
+	policy.ObserverQuorum = 3
+	pp := &testPolicyProcess{
+		policies: map[uint32]*ConsensusPolicy{3: policy},
+	}
+	cs := newTestConsensus(t, 2, pp)
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+
+	saveTestBlock(t, cs, ctw, 1, 0)
+	if cs.RoundVoteQuorum() != 4 {
+		t.Fatalf("invalid round vote quorum without the policy quorum: %v", cs.RoundVoteQuorum())
+	}
+	saveTestBlock(t, cs, ctw, 2, 0)
+	if cs.RoundVoteQuorum() != 3 {
+		t.Fatalf("round vote quorum is not activated: %v", cs.RoundVoteQuorum())
+	}
+}
+
+func TestConsensusPolicyLoadChain(t *testing.T) {
+	policy := DefaultConsensusPolicy(4)
+	policy.ObserverQuorum = 4
+	pp := &testPolicyProcess{
+		policies: map[uint32]*ConsensusPolicy{2: policy},
+	}
+	cs := newTestConsensus(t, 2, pp)
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+	saveTestBlock(t, cs, ctw, 1, 0)
+	saveTestBlock(t, cs, ctw, 2, 1)
+
+	loaded := NewConsensus(2, testObserverKeys(5))
+	if err := loaded.OnLoadChain(ctw); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.isPolicyActivated {
+		t.Fatal("policy is not loaded")
+	}
+	if loaded.MaxBlocksPerFormulator() != 4 || loaded.ObserverQuorum() != 4 {
+		t.Fatalf("invalid loaded policy: %+v", loaded.Policy())
+	}
+	if loaded.RemainBlocks(0) != cs.RemainBlocks(0) {
+		t.Fatalf("invalid remain blocks: %v != %v", loaded.RemainBlocks(0), cs.RemainBlocks(0))
+	}
+	a, b := cs.Candidates(), loaded.Candidates()
+	if len(a) != len(b) {
+		t.Fatalf("invalid candidate count: %v != %v", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].Address != b[i].Address || a[i].Phase() != b[i].Phase() {
+			t.Fatalf("invalid candidate at %v", i)
+		}
+	}
+
+	if err := NewConsensus(2, testObserverKeys(4)).OnLoadChain(ctw); err != ErrInvalidObserverKey {
+		t.Fatalf("loaded by the different observers: %v", err)
+	}
+}
+
+func TestConsensusLoadChainWithoutPolicy(t *testing.T) {
+	cs := newTestConsensus(t, 2, &testPolicyProcess{})
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+	saveTestBlock(t, cs, ctw, 1, 0)
+
+	loaded := NewConsensus(2, testObserverKeys(5))
+	if err := loaded.OnLoadChain(ctw); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.isPolicyActivated {
+		t.Fatal("policy is activated without the schedule")
+	}
+	if err := NewConsensus(3, testObserverKeys(5)).OnLoadChain(ctw); err != ErrInvalidMaxBlocksPerFormulator {
+		t.Fatalf("loaded by the different max blocks per formulator: %v", err)
+	}
+}
+
+func TestConsensusPolicyConcurrentRead(t *testing.T) {
+	policy := DefaultConsensusPolicy(4)
+	pp := &testPolicyProcess{
+		policies: map[uint32]*ConsensusPolicy{},
+	}
+	for i := uint32(2); i < 100; i += 2 {
+		pp.policies[i] = policy
+	}
+	cs := newTestConsensus(t, 2, pp)
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			cs.ObserverQuorum()
+			cs.MaxBlocksPerFormulator()
+			cs.RemainBlocks(0)
+			cs.aggregatedKeys()
+		}
+	}()
+	for i := uint32(1); i < 100; i++ {
+		saveTestBlock(t, cs, ctw, i, 0)
+	}
+	<-done
+}
+
+func TestConsensusPolicyObserverQuorum(t *testing.T) {
+	tests := []struct {
+		quorum uint32
+		valid  bool
+	}{
+		{0, true},
+		{2, false},
+		{3, true},
+		{5, true},
+		{6, false},
+	}
+	for _, tt := range tests {
+		policy := DefaultConsensusPolicy(2)
+		policy.ObserverQuorum = tt.quorum
+		if policy.IsValid(5) != tt.valid {
+			t.Errorf("quorum %v of 5 observers: expected valid %v", tt.quorum, tt.valid)
+		}
+	}
+
+	policy := DefaultConsensusPolicy(2)
+	policy.ObserverQuorum = 6
+	pp := &testPolicyProcess{
+		policies: map[uint32]*ConsensusPolicy{2: policy},
+	}
+	cs := newTestConsensus(t, 2, pp)
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+	data, err := cs.encodeConsensusData(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &types.Block{
+		Header: types.Header{
+			Height:        1,
+			ConsensusData: data,
+		},
+	}
+	if err := cs.OnSaveData(b, ctw); err != ErrInvalidPolicy {
+		t.Fatalf("activated the invalid policy: %v", err)
+	}
+}
+
+func TestConsensusHeaderPolicy(t *testing.T) {
+	policy := DefaultConsensusPolicy(2)
+	policy.ObserverQuorum = 4
+	next := DefaultConsensusPolicy(2)
+	next.ObserverQuorum = 5
+	pp := &testPolicyProcess{
+		policies: map[uint32]*ConsensusPolicy{10: policy, 20: next},
+	}
+	cs := newTestConsensus(t, 2, pp)
+	ctw := types.NewContextWrapper(0, types.NewEmptyContext())
+	saveTestBlock(t, cs, ctw, 1, 0)
+
+	tests := []struct {
+		height uint32
+		quorum int
+	}{
+		{2, 3},
+		{9, 3},
+		{10, 4},
+		{19, 4},
+		{20, 5},
+		{5, 3},
+		{PolicyActivationDelay + 1, 5},
+	}
+	for _, tt := range tests {
+		p, err := cs.headerPolicy(ctw, tt.height)
+		if err != nil {
+			t.Fatal(tt.height, err)
+		}
+		if q := cs.observerQuorum(p); q != tt.quorum {
+			t.Errorf("header at %v: expected quorum %v but %v", tt.height, tt.quorum, q)
+		}
+	}
+	if _, err := cs.headerPolicy(ctw, PolicyActivationDelay+2); err != ErrUnknownHeaderPolicy {
+		t.Fatalf("validated the header beyond the horizon: %v", err)
+	}
+	if cs.HeaderHorizon() != PolicyActivationDelay+1 {
+		t.Fatalf("invalid header horizon: %v", cs.HeaderHorizon())
+	}
+	for h := uint32(2); h < 10; h++ {
+		saveTestBlock(t, cs, ctw, h, 0)
+	}
+	if p, err := cs.headerPolicy(ctw, 12); err != nil {
+		t.Fatal(err)
+	} else if cs.observerQuorum(p) != 4 {
+		t.Fatalf("invalid quorum after the activation: %v", cs.observerQuorum(p))
+	}
+}
@@ -20,4 +20,14 @@ var (
 	ErrAlreadyVoted                  = errors.New("already voted")
 	ErrNotExistObserverPeer          = errors.New("not exist observer peer")
 	ErrNotExistFormulatorPeer        = errors.New("not exist formulator peer")
+	ErrInvalidSignGuardFile          = errors.New("invalid sign guard file")
+	ErrSignGuardPastHeight           = errors.New("sign guard past height")
+	ErrSignGuardPastRound            = errors.New("sign guard past round")
+	ErrSignGuardConflict             = errors.New("sign guard conflict")
+	ErrInvalidPolicy                 = errors.New("invalid policy")
+	ErrNotLeader                     = errors.New("not leader")
+	ErrReservedHeight                = errors.New("reserved height")
+	ErrInvalidLeaseFile              = errors.New("invalid lease file")
+	ErrLeaseLockTimeout              = errors.New("lease lock timeout")
+	ErrUnknownHeaderPolicy           = errors.New("unknown header policy")
 )
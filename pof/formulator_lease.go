@@ -0,0 +1,278 @@
+package pof
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/binutil"
+	"github.com/fletaio/fleta_v1/common/rlog"
+)
+
+// LeaderElector decides which one of the formulator processes that share the generator key is active
+type LeaderElector interface {
+	IsLeader() bool
+	ReserveHeight(Height uint32) error
+}
+
+const leaseOwnerSize = 16
+const leaseFileSize = leaseOwnerSize + 8 + 4 + leaseOwnerSize
+
+type leaseState struct {
+	owner          [leaseOwnerSize]byte
+	expiry         int64
+	reservedHeight uint32
+	reservedOwner  [leaseOwnerSize]byte
+}
+
+// FileLease elects the leader of the formulator processes through the lease file on the shared path
+// The leader renews the lease before it expires and the standby takes over the expired lease
+// Every generated height is reserved in the lease file, so the processes never generate blocks at the same height
+// Each state is written as a new version of the file by the hard link that fails when the version exists,
+// so only one of the processes that read the same version can update it and the others retry with the new one
+type FileLease struct {
+	sync.Mutex
+	path      string
+	ttl       time.Duration
+	owner     [leaseOwnerSize]byte
+	expiry    int64
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// NewFileLease returns a FileLease
+func NewFileLease(path string, TTL time.Duration) (*FileLease, error) {
+	if TTL == 0 {
+		TTL = 3 * time.Second
+	}
+	fl := &FileLease{
+		path:      path,
+		ttl:       TTL,
+		closeChan: make(chan struct{}),
+	}
+	if _, err := crand.Read(fl.owner[:]); err != nil {
+		return nil, err
+	}
+	return fl, nil
+}
+
+// Run renews or acquires the lease periodically
+func (fl *FileLease) Run() {
+	ticker := time.NewTicker(fl.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := fl.renew(); err != nil {
+			rlog.Println("[lease]", err)
+		}
+		select {
+		case <-fl.closeChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close stops the renewal and releases the lease if it is the leader
+func (fl *FileLease) Close() {
+	fl.closeOnce.Do(func() {
+		close(fl.closeChan)
+		fl.update(func(st *leaseState) (bool, error) {
+			if st.owner != fl.owner {
+				return false, nil
+			}
+			st.expiry = 0
+			return true, nil
+		})
+		fl.Lock()
+		fl.expiry = 0
+		fl.Unlock()
+	})
+}
+
+// IsLeader returns the process holds the valid lease or not
+func (fl *FileLease) IsLeader() bool {
+	fl.Lock()
+	defer fl.Unlock()
+
+	return time.Now().UnixNano() < fl.expiry
+}
+
+// ReserveHeight reserves the height to generate a block
+// It fails when the lease is not held or the other process has already reserved the height
+func (fl *FileLease) ReserveHeight(Height uint32) error {
+	return fl.update(func(st *leaseState) (bool, error) {
+		if st.owner != fl.owner || time.Now().UnixNano() >= st.expiry {
+			return false, ErrNotLeader
+		}
+		if Height <= st.reservedHeight {
+			if st.reservedOwner != fl.owner {
+				return false, ErrReservedHeight
+			}
+			return false, nil
+		}
+		st.reservedHeight = Height
+		st.reservedOwner = fl.owner
+		return true, nil
+	})
+}
+
+func (fl *FileLease) renew() error {
+	var expiry int64
+	if err := fl.update(func(st *leaseState) (bool, error) {
+		now := time.Now().UnixNano()
+		if st.owner != fl.owner && now < st.expiry {
+			expiry = 0
+			return false, nil
+		}
+		if st.owner != fl.owner {
+			rlog.Println("[lease]", "acquired", fl.path)
+		}
+		st.owner = fl.owner
+		st.expiry = now + int64(fl.ttl)
+		expiry = st.expiry
+		return true, nil
+	}); err != nil {
+		fl.Lock()
+		fl.expiry = 0
+		fl.Unlock()
+		return err
+	}
+
+	fl.Lock()
+	// the local expiry is shorter than the file's one to give up generating before the standby takes over
+	if expiry > 0 {
+		fl.expiry = expiry - int64(fl.ttl/3)
+	} else {
+		fl.expiry = 0
+	}
+	fl.Unlock()
+	return nil
+}
+
+func (fl *FileLease) update(fn func(st *leaseState) (bool, error)) error {
+	deadline := time.Now().Add(fl.ttl / 3)
+	for {
+		st, Version, err := fl.load()
+		if err != nil {
+			return err
+		}
+		if changed, err := fn(st); err != nil {
+			return err
+		} else if !changed {
+			return nil
+		}
+		if err := fl.save(st, Version+1); err == nil {
+			fl.removeVersionsBefore(Version)
+			return nil
+		} else if !os.IsExist(err) {
+			return err
+		}
+		// the other process has updated the version that is read, so it retries with the new one
+		if time.Now().After(deadline) {
+			return ErrLeaseLockTimeout
+		}
+	}
+}
+
+func (fl *FileLease) versionPath(Version uint64) string {
+	return fl.path + "." + strconv.FormatUint(Version, 10)
+}
+
+func (fl *FileLease) versions() ([]uint64, error) {
+	names, err := filepath.Glob(fl.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	list := []uint64{}
+	for _, name := range names {
+		v, err := strconv.ParseUint(strings.TrimPrefix(name, fl.path+"."), 10, 64)
+		if err != nil {
+			continue
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (fl *FileLease) removeVersionsBefore(Version uint64) {
+	list, err := fl.versions()
+	if err != nil {
+		return
+	}
+	for _, v := range list {
+		if v < Version {
+			os.Remove(fl.versionPath(v))
+		}
+	}
+}
+
+func (fl *FileLease) load() (*leaseState, uint64, error) {
+	for {
+		list, err := fl.versions()
+		if err != nil {
+			return nil, 0, err
+		}
+		var Version uint64
+		for _, v := range list {
+			if v > Version {
+				Version = v
+			}
+		}
+		st := &leaseState{}
+		if Version == 0 {
+			return st, 0, nil
+		}
+		bs, err := ioutil.ReadFile(fl.versionPath(Version))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// the version is removed by the other process after the newer one is written
+				continue
+			}
+			return nil, 0, err
+		}
+		if len(bs) != leaseFileSize {
+			return nil, 0, ErrInvalidLeaseFile
+		}
+		copy(st.owner[:], bs)
+		st.expiry = int64(binutil.BigEndian.Uint64(bs[leaseOwnerSize:]))
+		st.reservedHeight = binutil.BigEndian.Uint32(bs[leaseOwnerSize+8:])
+		copy(st.reservedOwner[:], bs[leaseOwnerSize+12:])
+		return st, Version, nil
+	}
+}
+
+func (fl *FileLease) save(st *leaseState, Version uint64) error {
+	bs := make([]byte, leaseFileSize)
+	copy(bs, st.owner[:])
+	binutil.BigEndian.PutUint64(bs[leaseOwnerSize:], uint64(st.expiry))
+	binutil.BigEndian.PutUint32(bs[leaseOwnerSize+8:], st.reservedHeight)
+	copy(bs[leaseOwnerSize+12:], st.reservedOwner[:])
+
+	tmpPath := fl.path + ".tmp" + hex.EncodeToString(fl.owner[:])
+	defer os.Remove(tmpPath)
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(bs); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// the link fails when the version exists, so it works as a compare and swap of the version
+	return os.Link(tmpPath, fl.versionPath(Version))
+}
@@ -0,0 +1,134 @@
+package pof
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLease(t *testing.T, path string, TTL time.Duration) *FileLease {
+	fl, err := NewFileLease(path, TTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fl
+}
+
+func TestFileLeaseFailover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lease")
+	TTL := 300 * time.Millisecond
+
+	active := newTestLease(t, path, TTL)
+	standby := newTestLease(t, path, TTL)
+	if err := active.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if err := standby.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if !active.IsLeader() || standby.IsLeader() {
+		t.Fatal("the first process is not the only leader")
+	}
+	if err := active.ReserveHeight(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := standby.ReserveHeight(6); err != ErrNotLeader {
+		t.Fatalf("the standby reserved the height: %v", err)
+	}
+
+	// the active process stops renewing
+	time.Sleep(TTL)
+	if active.IsLeader() {
+		t.Fatal("the active process keeps the expired lease")
+	}
+	if err := standby.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if !standby.IsLeader() {
+		t.Fatal("the standby doesn't take over the expired lease")
+	}
+	if err := standby.ReserveHeight(5); err != ErrReservedHeight {
+		t.Fatalf("the standby reserved the height of the previous leader: %v", err)
+	}
+	if err := standby.ReserveHeight(6); err != nil {
+		t.Fatal(err)
+	}
+	if err := active.ReserveHeight(7); err != ErrNotLeader {
+		t.Fatalf("the previous leader reserved the height: %v", err)
+	}
+	if err := active.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if active.IsLeader() {
+		t.Fatal("the previous leader takes the lease back")
+	}
+
+	// the lease is released when the leader is closed
+	standby.Close()
+	if err := active.renew(); err != nil {
+		t.Fatal(err)
+	}
+	if !active.IsLeader() {
+		t.Fatal("the released lease is not acquired")
+	}
+	if err := active.ReserveHeight(6); err != ErrReservedHeight {
+		t.Fatalf("the reserved height is not kept: %v", err)
+	}
+}
+
+func TestFileLeaseSingleLeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lease")
+	TTL := 3 * time.Second
+
+	for round := 0; round < 20; round++ {
+		list := []*FileLease{}
+		for i := 0; i < 8; i++ {
+			list = append(list, newTestLease(t, path, TTL))
+		}
+		var wg sync.WaitGroup
+		for _, fl := range list {
+			wg.Add(1)
+			go func(fl *FileLease) {
+				defer wg.Done()
+				if err := fl.renew(); err != nil && err != ErrLeaseLockTimeout {
+					t.Error(err)
+				}
+			}(fl)
+		}
+		wg.Wait()
+
+		Count := 0
+		var leader *FileLease
+		for _, fl := range list {
+			if fl.IsLeader() {
+				Count++
+				leader = fl
+			}
+		}
+		if Count != 1 {
+			t.Fatalf("round %v: %v leaders", round, Count)
+		}
+		leader.Close()
+	}
+
+	names, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) > 2 {
+		t.Fatalf("old versions are not removed: %v", names)
+	}
+}
@@ -41,8 +41,8 @@ func (ms *FormulatorNodeMesh) Run() {
 	for PubHash, v := range ms.netAddressMap {
 		go func(pubhash common.PublicHash, NetAddr string) {
 			time.Sleep(1 * time.Second)
-			for {
-				if ms.fr.cs.rt.IsFormulator(ms.fr.Config.Formulator, myPubHash) {
+			for !ms.fr.isClose {
+				if ms.fr.isLeader() && ms.fr.cs.rt.IsFormulator(ms.fr.Config.Formulator, myPubHash) {
 					ms.Lock()
 					_, has := ms.peerMap[string(pubhash[:])]
 					ms.Unlock()
@@ -56,6 +56,19 @@ func (ms *FormulatorNodeMesh) Run() {
 			}
 		}(PubHash, v)
 	}
+	if ms.fr.le != nil {
+		go func() {
+			for !ms.fr.isClose {
+				// the standby should not keep the connection because the observer accepts only one connection per formulator
+				if !ms.fr.isLeader() {
+					for _, p := range ms.Peers() {
+						ms.RemovePeer(p.ID())
+					}
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		}()
+	}
 }
 
 // Peers returns peers of the formulator mesh
@@ -118,11 +131,15 @@ func (ms *FormulatorNodeMesh) client(Address string, TargetPubHash common.Public
 	}
 	defer conn.Close()
 
-	if err := ms.recvHandshake(conn); err != nil {
+	hs, err := p2p.NewSecureHandshake()
+	if err != nil {
+		return err
+	}
+	if err := ms.recvHandshake(conn, hs); err != nil {
 		rlog.Println("[recvHandshake]", err)
 		return err
 	}
-	pubhash, err := ms.sendHandshake(conn)
+	pubhash, err := ms.sendHandshake(conn, hs)
 	if err != nil {
 		rlog.Println("[sendHandshake]", err)
 		return err
@@ -135,7 +152,16 @@ func (ms *FormulatorNodeMesh) client(Address string, TargetPubHash common.Public
 	}
 
 	ID := string(pubhash[:])
-	p := p2p.NewWebsocketPeer(conn, ID, pubhash.String(), time.Now().UnixNano())
+	var p *p2p.WebsocketPeer
+	if hs.IsSecure() {
+		codec, err := hs.NewSecureCodec(true)
+		if err != nil {
+			return err
+		}
+		p = p2p.NewSecureWebsocketPeer(conn, ID, pubhash.String(), time.Now().UnixNano(), codec)
+	} else {
+		p = p2p.NewWebsocketPeer(conn, ID, pubhash.String(), time.Now().UnixNano())
+	}
 	ms.RemovePeer(ID)
 	ms.Lock()
 	ms.peerMap[ID] = p
@@ -167,7 +193,7 @@ func (ms *FormulatorNodeMesh) handleConnection(p peer.Peer) error {
 	}
 }
 
-func (ms *FormulatorNodeMesh) recvHandshake(conn *websocket.Conn) error {
+func (ms *FormulatorNodeMesh) recvHandshake(conn *websocket.Conn, hs *p2p.SecureHandshake) error {
 	//rlog.Println("recvHandshake")
 	_, req, err := conn.ReadMessage()
 	if err != nil {
@@ -189,21 +215,29 @@ func (ms *FormulatorNodeMesh) recvHandshake(conn *websocket.Conn) error {
 		return p2p.ErrInvalidHandshake
 	}
 	//rlog.Println("sendHandshakeAck")
-	if sig, err := ms.key.Sign(hash.Hash(req)); err != nil {
-		return err
-	} else if err := conn.WriteMessage(websocket.BinaryMessage, sig[:]); err != nil {
+	if sig, err := ms.key.Sign(hs.ResponseHash(req)); err != nil {
 		return err
+	} else {
+		bs := sig[:]
+		if p2p.IsMarkedChallenge(req) {
+			ephPubKey := hs.EphemeralPublicKey()
+			bs = append(bs, ephPubKey[:]...)
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, bs); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (ms *FormulatorNodeMesh) sendHandshake(conn *websocket.Conn) (common.PublicHash, error) {
+func (ms *FormulatorNodeMesh) sendHandshake(conn *websocket.Conn, hs *p2p.SecureHandshake) (common.PublicHash, error) {
 	//rlog.Println("sendHandshake")
 	req := make([]byte, 40+common.AddressSize)
 	if _, err := crand.Read(req[:32]); err != nil {
 		return common.PublicHash{}, err
 	}
 	req[0] = ms.fr.cs.cn.Provider().ChainID()
+	hs.MarkChallenge(req)
 	binutil.LittleEndian.PutUint64(req[32:], uint64(time.Now().UnixNano()))
 	copy(req[40:], ms.fr.Config.Formulator[:])
 	if err := conn.WriteMessage(websocket.BinaryMessage, req); err != nil {
@@ -214,12 +248,18 @@ func (ms *FormulatorNodeMesh) sendHandshake(conn *websocket.Conn) (common.Public
 	if err != nil {
 		return common.PublicHash{}, err
 	}
-	if len(bs) != common.SignatureSize {
+	h := hash.Hash(req)
+	if len(bs) == common.SignatureSize+common.PublicKeySize {
+		var ephPubKey common.PublicKey
+		copy(ephPubKey[:], bs[common.SignatureSize:])
+		h = hs.PeerResponseHash(req, ephPubKey)
+		hs.SetPeerEphemeralPublicKey(ephPubKey)
+	} else if len(bs) != common.SignatureSize {
 		return common.PublicHash{}, p2p.ErrInvalidHandshake
 	}
 	var sig common.Signature
 	copy(sig[:], bs)
-	pubkey, err := common.RecoverPubkey(hash.Hash(req), sig)
+	pubkey, err := common.RecoverPubkey(h, sig)
 	if err != nil {
 		return common.PublicHash{}, err
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/bluele/gcache"
 
 	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
 	"github.com/fletaio/fleta_v1/common/hash"
 	"github.com/fletaio/fleta_v1/common/key"
 	"github.com/fletaio/fleta_v1/common/queue"
@@ -18,6 +19,7 @@ import (
 	"github.com/fletaio/fleta_v1/core/txpool"
 	"github.com/fletaio/fleta_v1/core/types"
 	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/process/vault"
 	"github.com/fletaio/fleta_v1/service/p2p"
 )
 
@@ -41,6 +43,7 @@ type FormulatorNode struct {
 	cs             *Consensus
 	ms             *FormulatorNodeMesh
 	nm             *p2p.NodeMesh
+	le             LeaderElector
 	key            key.Key
 	ndkey          key.Key
 	myPublicHash   common.PublicHash
@@ -98,6 +101,12 @@ func NewFormulatorNode(Config *FormulatorConfig, key key.Key, ndkey key.Key, Net
 	}
 	fr.ms = NewFormulatorNodeMesh(key, NetAddressMap, fr)
 	fr.nm = p2p.NewNodeMesh(fr.cs.cn.Provider().ChainID(), ndkey, SeedNodeMap, fr, peerStorePath)
+	// the formulator serves headers and missing transactions of compact blocks, but it receives full transactions and does not join the discovery
+	fr.nm.SetCapabilities(p2p.CapHeaderSync | p2p.CapCompactBlock)
+	fr.nm.SetUserAgent("fleta-formulator")
+	fr.nm.SetHeightProvider(func() uint32 {
+		return fr.cs.cn.Provider().Height()
+	})
 	fr.txQ.AddGroup(60 * time.Second)
 	fr.txQ.AddGroup(600 * time.Second)
 	fr.txQ.AddGroup(3600 * time.Second)
@@ -106,6 +115,38 @@ func NewFormulatorNode(Config *FormulatorConfig, key key.Key, ndkey key.Key, Net
 	return fr
 }
 
+// SetLeaderElector sets the leader elector for the active/standby mode
+// The formulator always works as the leader when it is not set
+func (fr *FormulatorNode) SetLeaderElector(le LeaderElector) {
+	fr.le = le
+}
+
+func (fr *FormulatorNode) isLeader() bool {
+	if fr.le == nil {
+		return true
+	}
+	return fr.le.IsLeader()
+}
+
+// PeerCount returns the number of connected observers and nodes
+func (fr *FormulatorNode) PeerCount() int {
+	return len(fr.ms.Peers()) + len(fr.nm.Peers())
+}
+
+// BestPeerHeight returns the highest height that is reported by connected observers and nodes
+func (fr *FormulatorNode) BestPeerHeight() uint32 {
+	fr.statusLock.Lock()
+	defer fr.statusLock.Unlock()
+
+	var best uint32
+	for _, status := range fr.statusMap {
+		if best < status.Height {
+			best = status.Height
+		}
+	}
+	return best
+}
+
 // Close terminates the formulator
 func (fr *FormulatorNode) Close() {
 	fr.closeLock.Lock()
@@ -130,6 +171,12 @@ func (fr *FormulatorNode) Init() error {
 	fc.Register(types.DefineHashedType("p2p.TransactionMessage"), &p2p.TransactionMessage{})
 	fc.Register(types.DefineHashedType("p2p.PeerListMessage"), &p2p.PeerListMessage{})
 	fc.Register(types.DefineHashedType("p2p.RequestPeerListMessage"), &p2p.RequestPeerListMessage{})
+	fc.Register(types.DefineHashedType("p2p.HeaderRequestMessage"), &p2p.HeaderRequestMessage{})
+	fc.Register(types.DefineHashedType("p2p.BatchRequestMessage"), &p2p.BatchRequestMessage{})
+	fc.Register(types.DefineHashedType("p2p.CompactBlockMessage"), &p2p.CompactBlockMessage{})
+	fc.Register(types.DefineHashedType("p2p.CompactTxRequestMessage"), &p2p.CompactTxRequestMessage{})
+	fc.Register(types.DefineHashedType("p2p.TxInvMessage"), &p2p.TxInvMessage{})
+	fc.Register(types.DefineHashedType("p2p.FindNodeMessage"), &p2p.FindNodeMessage{})
 	return nil
 }
 
@@ -185,10 +232,10 @@ func (fr *FormulatorNode) Run(BindAddress string) {
 					}
 					item := v.(*p2p.TxMsgItem)
 					if err := fr.addTx(ctw, item.TxHash, item.Type, item.Tx, item.Sigs); err != nil {
-						if err != p2p.ErrInvalidUTXO && err != txpool.ErrExistTransaction && err != txpool.ErrExistTransactionSeq && err != txpool.ErrTooFarSeq && err != txpool.ErrPastSeq {
+						if !p2p.IsLocalTxError(err) {
 							rlog.Println("TransactionError", item.TxHash.String(), err.Error())
 							if len(item.PeerID) > 0 {
-								fr.nm.AddBadPoint(item.PeerID, 1)
+								fr.nm.Penalize(item.PeerID, p2p.MisbehaviorInvalidTx)
 							}
 						}
 						continue
@@ -322,7 +369,7 @@ func (fr *FormulatorNode) Run(BindAddress string) {
 
 			fr.lastReqLock.Lock()
 			if fr.lastReqMessage != nil {
-				if b.Header.Height <= fr.lastReqMessage.TargetHeight+fr.cs.maxBlocksPerFormulator {
+				if b.Header.Height <= fr.lastReqMessage.TargetHeight+fr.cs.MaxBlocksPerFormulator() {
 					if b.Header.Generator != fr.Config.Formulator {
 						fr.lastReqMessage = nil
 					}
@@ -395,11 +442,6 @@ func (fr *FormulatorNode) PushTx(tx types.Transaction, sigs []common.Signature)
 }
 
 func (fr *FormulatorNode) addTx(ctw types.LoaderWrapper, TxHash hash.Hash256, t uint16, tx types.Transaction, sigs []common.Signature) error {
-	/*
-		if fr.txpool.Size() > 65535 {
-			return txpool.ErrTransactionPoolOverflowed
-		}
-	*/
 	cp := fr.cs.cn.Provider()
 	if fr.txpool.IsExist(TxHash) {
 		return txpool.ErrExistTransaction
@@ -429,7 +471,15 @@ func (fr *FormulatorNode) addTx(ctw types.LoaderWrapper, TxHash hash.Hash256, t
 	if err := tx.Validate(p, ctw, signers); err != nil {
 		return err
 	}
-	if err := fr.txpool.Push(t, TxHash, tx, sigs, signers); err != nil {
+	var Fee *amount.Amount
+	if ftx, is := tx.(vault.FeeTransaction); is {
+		Fee = ftx.Fee(p, ctw)
+	}
+	removed, err := fr.txpool.Push(t, TxHash, tx, sigs, signers, Fee)
+	for _, item := range removed {
+		fr.txQ.Remove(string(item.TxHash[:]))
+	}
+	if err != nil {
 		return err
 	}
 	fr.txQ.Push(string(TxHash[:]), &p2p.TxMsgItem{
@@ -502,3 +552,12 @@ func (fr *FormulatorNode) TxPoolList() []*txpool.PoolItem {
 func (fr *FormulatorNode) GetTxFromTXPool(TxHash hash.Hash256) *txpool.PoolItem {
 	return fr.txpool.Get(TxHash)
 }
+
+// RemoveTxFromTXPool removes the tx and following txs of the same address from txpool
+func (fr *FormulatorNode) RemoveTxFromTXPool(TxHash hash.Hash256) []*txpool.PoolItem {
+	removed := fr.txpool.Drop(TxHash)
+	for _, item := range removed {
+		fr.txQ.Remove(string(item.TxHash[:]))
+	}
+	return removed
+}
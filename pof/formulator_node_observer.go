@@ -60,6 +60,10 @@ func (fr *FormulatorNode) handleObserverMessage(p peer.Peer, m interface{}, Retr
 	case *BlockReqMessage:
 		rlog.Println("Formulator", fr.Config.Formulator.String(), "BlockReqMessage", msg.TargetHeight)
 
+		if !fr.isLeader() {
+			return nil
+		}
+
 		TargetHeight := fr.cs.cn.Provider().Height() + 1
 		if msg.TargetHeight < TargetHeight {
 			return nil
@@ -404,6 +408,7 @@ func (fr *FormulatorNode) updateByGenItem() {
 				return
 			}
 		}
+		fr.relayCompactBlock(b)
 		fr.broadcastStatus()
 		fr.cleanPool(b)
 		rlog.Println("Formulator", fr.Config.Formulator.String(), "BlockConnected", b.Header.Generator.String(), b.Header.Height, len(b.Transactions))
@@ -416,16 +421,14 @@ func (fr *FormulatorNode) updateByGenItem() {
 
 func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 	cp := fr.cs.cn.Provider()
+	policy := fr.cs.Policy()
 
-	RemainBlocks := fr.cs.maxBlocksPerFormulator
-	if msg.TimeoutCount == 0 {
-		RemainBlocks = fr.cs.maxBlocksPerFormulator - fr.cs.blocksBySameFormulator
-	}
+	RemainBlocks := fr.cs.RemainBlocks(msg.TimeoutCount)
 
 	start := time.Now().UnixNano()
 	Now := uint64(time.Now().UnixNano())
 	StartBlockTime := Now
-	EndBlockTime := StartBlockTime + uint64(500*time.Millisecond)*uint64(RemainBlocks)
+	EndBlockTime := StartBlockTime + uint64(policy.BlockInterval())*uint64(RemainBlocks)
 
 	LastTimestamp := cp.LastTimestamp()
 	if StartBlockTime < LastTimestamp {
@@ -437,6 +440,7 @@ func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 	var lastHeader *types.Header
 	ctx := fr.cs.cn.NewContext()
 	for i := uint32(0); i < RemainBlocks; i++ {
+		genBegin := time.Now()
 		var TimeoutCount uint32
 		if i == 0 {
 			TimeoutCount = msg.TimeoutCount
@@ -444,7 +448,7 @@ func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 			ctx = ctx.NextContext(encoding.Hash(lastHeader), lastHeader.Timestamp)
 		}
 
-		Timestamp := StartBlockTime + uint64(i)*uint64(500*time.Millisecond)
+		Timestamp := StartBlockTime + uint64(i)*uint64(policy.BlockInterval())
 		if Timestamp > EndBlockTime {
 			Timestamp = EndBlockTime
 		}
@@ -462,7 +466,7 @@ func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 			return err
 		}
 
-		timer := time.NewTimer(200 * time.Millisecond)
+		timer := time.NewTimer(policy.TxCollectionTimeout())
 
 		fr.txpool.Lock() // Prevent delaying from TxPool.Push
 		Count := 0
@@ -494,6 +498,11 @@ func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 		if err != nil {
 			return err
 		}
+		if fr.le != nil {
+			if err := fr.le.ReserveHeight(b.Header.Height); err != nil {
+				return err
+			}
+		}
 
 		sm := &BlockGenMessage{
 			Block: b,
@@ -506,6 +515,8 @@ func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 			sm.GeneratorSignature = sig
 		}
 		fr.ms.SendTo(ID, sm)
+		blockGenLatency.ObserveSince(genBegin)
+		generatedBlocks.Inc()
 
 		rlog.Println("Formulator", fr.Config.Formulator.String(), "Send.BlockGenMessage", sm.Block.Header.Height, len(sm.Block.Transactions))
 
@@ -516,11 +527,12 @@ func (fr *FormulatorNode) genBlock(ID string, msg *BlockReqMessage) error {
 		fr.lastGenHeight = ctx.TargetHeight()
 		fr.lastGenTime = time.Now().UnixNano()
 
-		ExpectedTime := 200*time.Millisecond + time.Duration(i)*500*time.Millisecond
+		LastIndex := policy.MaxBlocksPerFormulator - 1
+		ExpectedTime := policy.TxCollectionTimeout() + time.Duration(i)*policy.BlockInterval()
 		if i == 0 {
-			ExpectedTime = 200 * time.Millisecond
-		} else if i >= 9 {
-			ExpectedTime = 4200*time.Millisecond + time.Duration(i-9+1)*200*time.Millisecond
+			ExpectedTime = policy.TxCollectionTimeout()
+		} else if i >= LastIndex {
+			ExpectedTime = policy.TxCollectionTimeout() + time.Duration(LastIndex-1)*policy.BlockInterval() + time.Duration(i-LastIndex+1)*policy.TxCollectionTimeout()
 		}
 		PastTime := time.Duration(time.Now().UnixNano() - start)
 		if ExpectedTime > PastTime {
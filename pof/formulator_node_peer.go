@@ -80,6 +80,42 @@ func (fr *FormulatorNode) handlePeerMessage(ID string, m interface{}) error {
 		}
 		fr.sendMessagePacket(0, SenderPublicHash, bs)
 		//log.Println("Send.BlockMessage", SenderPublicHash.String(), msg.Height)
+	case *p2p.HeaderRequestMessage:
+		bs, err := p2p.HeaderPacket(msg, fr.cs.cn.Provider())
+		if err != nil {
+			return err
+		}
+		if bs != nil {
+			fr.sendMessagePacket(0, SenderPublicHash, bs)
+		}
+	case *p2p.BatchRequestMessage:
+		bs, err := p2p.BatchBlockPacket(msg, fr.cs.cn.Provider())
+		if err != nil {
+			return err
+		}
+		if bs != nil {
+			fr.sendMessagePacket(0, SenderPublicHash, bs)
+		}
+	case *p2p.CompactTxRequestMessage:
+		bs, err := p2p.CompactTxPacket(msg, fr.cs.cn.Provider())
+		if err != nil {
+			return err
+		}
+		if bs != nil {
+			fr.sendMessagePacket(0, SenderPublicHash, bs)
+		}
+	case *p2p.TxInvMessage:
+		// the formulator receives full transactions, nodes fall back to them for peers that do not announce inventories
+	case *p2p.FindNodeMessage:
+		// the formulator is not a member of the node discovery table
+	case *p2p.CompactBlockMessage:
+		fr.statusLock.Lock()
+		if status, has := fr.statusMap[ID]; has {
+			if status.Height < msg.Header.Height {
+				status.Height = msg.Header.Height
+			}
+		}
+		fr.statusLock.Unlock()
 	case *p2p.StatusMessage:
 		//log.Println("Recv.StatusMessage", SenderPublicHash.String(), msg.Height)
 		fr.statusLock.Lock()
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
 	"github.com/fletaio/fleta_v1/service/p2p"
 )
 
@@ -83,6 +84,41 @@ func (fr *FormulatorNode) broadcastStatus() error {
 	return nil
 }
 
+// relayCompactBlock sends the compact block to node peers that are waiting the block as the next one
+// Peers that don't support compact blocks receive the full block because they cannot decode the compact block message
+func (fr *FormulatorNode) relayCompactBlock(b *types.Block) {
+	compactTargets := []common.PublicHash{}
+	fullTargets := []common.PublicHash{}
+	fr.statusLock.Lock()
+	for ID, status := range fr.statusMap {
+		if status.Height+1 == b.Header.Height {
+			var pubhash common.PublicHash
+			copy(pubhash[:], []byte(ID))
+			if fr.nm.PeerCapabilities(ID).Has(p2p.CapCompactBlock) {
+				compactTargets = append(compactTargets, pubhash)
+			} else {
+				fullTargets = append(fullTargets, pubhash)
+			}
+		}
+	}
+	fr.statusLock.Unlock()
+
+	if len(compactTargets) > 0 {
+		bs := p2p.MessageToPacket(p2p.NewCompactBlockMessage(fr.cs.cn.Provider().ChainID(), b))
+		for _, pubhash := range compactTargets {
+			fr.sendMessagePacket(0, pubhash, bs)
+		}
+	}
+	if len(fullTargets) > 0 {
+		bs := p2p.MessageToPacket(&p2p.BlockMessage{
+			Blocks: []*types.Block{b},
+		})
+		for _, pubhash := range fullTargets {
+			fr.sendMessagePacket(0, pubhash, bs)
+		}
+	}
+}
+
 func (fr *FormulatorNode) sendRequestBlockTo(TargetID string, Height uint32, Count uint8) error {
 	//log.Println("sendRequestBlockTo", Height, Count)
 
@@ -0,0 +1,15 @@
+package pof
+
+import (
+	"github.com/fletaio/fleta_v1/common/metrics"
+)
+
+// metrics of observer and formulator nodes
+var (
+	roundVoteLatency = metrics.NewHistogram("fleta_pof_round_vote_seconds", "The time from the start of the round to the agreement of the round vote", nil)
+	completedRounds  = metrics.NewHistogram("fleta_pof_round_seconds", "The time of rounds from the start to the end", []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}, "result", "completed")
+	failedRounds     = metrics.NewHistogram("fleta_pof_round_seconds", "The time of rounds from the start to the end", []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}, "result", "failed")
+	committedBlocks  = metrics.NewCounter("fleta_pof_committed_blocks_total", "The number of blocks that are committed by observer votes")
+	blockGenLatency  = metrics.NewHistogram("fleta_pof_block_gen_seconds", "The time to collect transactions and generate the block by the formulator", nil)
+	generatedBlocks  = metrics.NewCounter("fleta_pof_generated_blocks_total", "The number of blocks that are generated by the formulator")
+)
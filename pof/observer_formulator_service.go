@@ -106,12 +106,16 @@ func (ms *FormulatorService) server(BindAddress string) error {
 		}
 		defer conn.Close()
 
-		pubhash, err := ms.sendHandshake(conn)
+		hs, err := p2p.NewSecureHandshake()
+		if err != nil {
+			return err
+		}
+		pubhash, err := ms.sendHandshake(conn, hs)
 		if err != nil {
 			rlog.Println("[sendHandshake]", err)
 			return err
 		}
-		Formulator, err := ms.recvHandshake(conn)
+		Formulator, err := ms.recvHandshake(conn, hs)
 		if err != nil {
 			rlog.Println("[recvHandshakeAck]", err)
 			return err
@@ -122,7 +126,16 @@ func (ms *FormulatorService) server(BindAddress string) error {
 		}
 
 		ID := string(Formulator[:])
-		p := p2p.NewWebsocketPeer(conn, ID, Formulator.String(), time.Now().UnixNano())
+		var p *p2p.WebsocketPeer
+		if hs.IsSecure() {
+			codec, err := hs.NewSecureCodec(false)
+			if err != nil {
+				return err
+			}
+			p = p2p.NewSecureWebsocketPeer(conn, ID, Formulator.String(), time.Now().UnixNano(), codec)
+		} else {
+			p = p2p.NewWebsocketPeer(conn, ID, Formulator.String(), time.Now().UnixNano())
+		}
 		ms.RemovePeer(ID)
 		ms.Lock()
 		ms.peerMap[ID] = p
@@ -157,7 +170,7 @@ func (ms *FormulatorService) handleConnection(p peer.Peer) error {
 	}
 }
 
-func (ms *FormulatorService) recvHandshake(conn *websocket.Conn) (common.Address, error) {
+func (ms *FormulatorService) recvHandshake(conn *websocket.Conn, hs *p2p.SecureHandshake) (common.Address, error) {
 	//rlog.Println("recvHandshake")
 	_, req, err := conn.ReadMessage()
 	if err != nil {
@@ -181,21 +194,29 @@ func (ms *FormulatorService) recvHandshake(conn *websocket.Conn) (common.Address
 		return common.Address{}, p2p.ErrInvalidHandshake
 	}
 	//rlog.Println("sendHandshakeAck")
-	if sig, err := ms.key.Sign(hash.Hash(req)); err != nil {
-		return common.Address{}, err
-	} else if err := conn.WriteMessage(websocket.BinaryMessage, sig[:]); err != nil {
+	if sig, err := ms.key.Sign(hs.ResponseHash(req)); err != nil {
 		return common.Address{}, err
+	} else {
+		bs := sig[:]
+		if p2p.IsMarkedChallenge(req) {
+			ephPubKey := hs.EphemeralPublicKey()
+			bs = append(bs, ephPubKey[:]...)
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, bs); err != nil {
+			return common.Address{}, err
+		}
 	}
 	return Formulator, nil
 }
 
-func (ms *FormulatorService) sendHandshake(conn *websocket.Conn) (common.PublicHash, error) {
+func (ms *FormulatorService) sendHandshake(conn *websocket.Conn, hs *p2p.SecureHandshake) (common.PublicHash, error) {
 	//rlog.Println("sendHandshake")
 	req := make([]byte, 40)
 	if _, err := crand.Read(req[:32]); err != nil {
 		return common.PublicHash{}, err
 	}
 	req[0] = ms.ob.cs.cn.Provider().ChainID()
+	hs.MarkChallenge(req)
 	binutil.LittleEndian.PutUint64(req[32:], uint64(time.Now().UnixNano()))
 	if err := conn.WriteMessage(websocket.BinaryMessage, req); err != nil {
 		return common.PublicHash{}, err
@@ -205,12 +226,18 @@ func (ms *FormulatorService) sendHandshake(conn *websocket.Conn) (common.PublicH
 	if err != nil {
 		return common.PublicHash{}, err
 	}
-	if len(bs) != common.SignatureSize {
+	h := hash.Hash(req)
+	if len(bs) == common.SignatureSize+common.PublicKeySize {
+		var ephPubKey common.PublicKey
+		copy(ephPubKey[:], bs[common.SignatureSize:])
+		h = hs.PeerResponseHash(req, ephPubKey)
+		hs.SetPeerEphemeralPublicKey(ephPubKey)
+	} else if len(bs) != common.SignatureSize {
 		return common.PublicHash{}, p2p.ErrInvalidHandshake
 	}
 	var sig common.Signature
 	copy(sig[:], bs)
-	pubkey, err := common.RecoverPubkey(hash.Hash(req), sig)
+	pubkey, err := common.RecoverPubkey(h, sig)
 	if err != nil {
 		return common.PublicHash{}, err
 	}
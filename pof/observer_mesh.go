@@ -185,12 +185,16 @@ func (ms *ObserverNodeMesh) client(Address string, TargetPubHash common.PublicHa
 	}
 	defer conn.Close()
 
+	hs, err := p2p.NewSecureHandshake()
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	if err := ms.recvHandshake(conn); err != nil {
+	if err := ms.recvHandshake(conn, hs); err != nil {
 		rlog.Println("[recvHandshake]", err)
 		return err
 	}
-	pubhash, err := ms.sendHandshake(conn)
+	pubhash, err := ms.sendHandshake(conn, hs)
 	if err != nil {
 		rlog.Println("[sendHandshake]", err)
 		return err
@@ -203,7 +207,15 @@ func (ms *ObserverNodeMesh) client(Address string, TargetPubHash common.PublicHa
 	}
 
 	ID := string(pubhash[:])
-	p := p2p.NewTCPAsyncPeer(conn, ID, pubhash.String(), start.UnixNano())
+	var pconn net.Conn = conn
+	if hs.IsSecure() {
+		codec, err := hs.NewSecureCodec(true)
+		if err != nil {
+			return err
+		}
+		pconn = p2p.NewSecureConn(conn, codec)
+	}
+	p := p2p.NewTCPAsyncPeer(pconn, ID, pubhash.String(), start.UnixNano())
 	ms.removePeerInMap(ID, ms.clientPeerMap)
 	ms.Lock()
 	ms.clientPeerMap[ID] = p
@@ -230,8 +242,13 @@ func (ms *ObserverNodeMesh) server(BindAddress string) error {
 		go func() {
 			defer conn.Close()
 
+			hs, err := p2p.NewSecureHandshake()
+			if err != nil {
+				rlog.Println("[NewSecureHandshake]", err)
+				return
+			}
 			start := time.Now()
-			pubhash, err := ms.sendHandshake(conn)
+			pubhash, err := ms.sendHandshake(conn, hs)
 			if err != nil {
 				rlog.Println("[sendHandshake]", err)
 				return
@@ -240,13 +257,22 @@ func (ms *ObserverNodeMesh) server(BindAddress string) error {
 				rlog.Println("ErrInvalidPublicHash")
 				return
 			}
-			if err := ms.recvHandshake(conn); err != nil {
+			if err := ms.recvHandshake(conn, hs); err != nil {
 				rlog.Println("[recvHandshakeAck]", err)
 				return
 			}
 
 			ID := string(pubhash[:])
-			p := p2p.NewTCPAsyncPeer(conn, ID, pubhash.String(), start.UnixNano())
+			var pconn net.Conn = conn
+			if hs.IsSecure() {
+				codec, err := hs.NewSecureCodec(false)
+				if err != nil {
+					rlog.Println("[NewSecureCodec]", err)
+					return
+				}
+				pconn = p2p.NewSecureConn(conn, codec)
+			}
+			p := p2p.NewTCPAsyncPeer(pconn, ID, pubhash.String(), start.UnixNano())
 			ms.removePeerInMap(ID, ms.serverPeerMap)
 			ms.Lock()
 			ms.serverPeerMap[ID] = p
@@ -276,7 +302,7 @@ func (ms *ObserverNodeMesh) handleConnection(p peer.Peer) error {
 	}
 }
 
-func (ms *ObserverNodeMesh) recvHandshake(conn net.Conn) error {
+func (ms *ObserverNodeMesh) recvHandshake(conn net.Conn, hs *p2p.SecureHandshake) error {
 	//rlog.Println("recvHandshake")
 	req := make([]byte, 40)
 	if _, err := p2p.FillBytes(conn, req); err != nil {
@@ -295,21 +321,30 @@ func (ms *ObserverNodeMesh) recvHandshake(conn net.Conn) error {
 		return p2p.ErrInvalidHandshake
 	}
 	//rlog.Println("sendHandshakeAck")
-	if sig, err := ms.key.Sign(hash.Hash(req)); err != nil {
+	sig, err := ms.key.Sign(hs.ResponseHash(req))
+	if err != nil {
 		return err
-	} else if _, err := conn.Write(sig[:]); err != nil {
+	}
+	bs := sig[:]
+	if p2p.IsMarkedChallenge(req) {
+		p2p.MarkResponseSignature(&sig)
+		ephPubKey := hs.EphemeralPublicKey()
+		bs = append(sig[:], ephPubKey[:]...)
+	}
+	if _, err := conn.Write(bs); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (ms *ObserverNodeMesh) sendHandshake(conn net.Conn) (common.PublicHash, error) {
+func (ms *ObserverNodeMesh) sendHandshake(conn net.Conn, hs *p2p.SecureHandshake) (common.PublicHash, error) {
 	//rlog.Println("sendHandshake")
 	req := make([]byte, 40)
 	if _, err := crand.Read(req[:32]); err != nil {
 		return common.PublicHash{}, err
 	}
 	req[0] = ms.ob.cs.cn.Provider().ChainID()
+	hs.MarkChallenge(req)
 	binutil.LittleEndian.PutUint64(req[32:], uint64(time.Now().UnixNano()))
 	if _, err := conn.Write(req); err != nil {
 		return common.PublicHash{}, err
@@ -319,7 +354,16 @@ func (ms *ObserverNodeMesh) sendHandshake(conn net.Conn) (common.PublicHash, err
 	if _, err := p2p.FillBytes(conn, sig[:]); err != nil {
 		return common.PublicHash{}, err
 	}
-	pubkey, err := common.RecoverPubkey(hash.Hash(req), sig)
+	h := hash.Hash(req)
+	if p2p.UnmarkResponseSignature(&sig) {
+		var ephPubKey common.PublicKey
+		if _, err := p2p.FillBytes(conn, ephPubKey[:]); err != nil {
+			return common.PublicHash{}, err
+		}
+		h = hs.PeerResponseHash(req, ephPubKey)
+		hs.SetPeerEphemeralPublicKey(ephPubKey)
+	}
+	pubkey, err := common.RecoverPubkey(h, sig)
 	if err != nil {
 		return common.PublicHash{}, err
 	}
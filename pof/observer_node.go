@@ -32,6 +32,7 @@ type ObserverNode struct {
 	ms               *ObserverNodeMesh
 	fs               *FormulatorService
 	cs               *Consensus
+	sg               *SignGuard
 	round            *VoteRound
 	roundFirstTime   uint64
 	roundFirstHeight uint32
@@ -50,15 +51,16 @@ type ObserverNode struct {
 	closeLock        sync.RWMutex
 	isClose          bool
 
-	prevRoundEndTime int64 // FOR DEBUG
+	prevRoundEndTime int64 // the start time of the current round
 }
 
 // NewObserverNode returns a ObserverNode
-func NewObserverNode(key key.Key, NetAddressMap map[common.PublicHash]string, cs *Consensus) *ObserverNode {
+func NewObserverNode(key key.Key, NetAddressMap map[common.PublicHash]string, cs *Consensus, sg *SignGuard) *ObserverNode {
 	ob := &ObserverNode{
 		key:          key,
 		cs:           cs,
-		round:        NewVoteRound(cs.cn.Provider().Height()+1, cs.maxBlocksPerFormulator),
+		sg:           sg,
+		round:        NewVoteRound(cs.cn.Provider().Height()+1, cs.MaxBlocksPerFormulator()),
 		ignoreMap:    map[common.Address]int64{},
 		myPublicHash: common.NewPublicHash(key.PublicKey()),
 		statusMap:    map[string]*p2p.Status{},
@@ -120,6 +122,25 @@ func (ob *ObserverNode) Init() error {
 	return nil
 }
 
+// PeerCount returns the number of connected observers and formulators
+func (ob *ObserverNode) PeerCount() int {
+	return len(ob.ms.Peers()) + ob.fs.PeerCount()
+}
+
+// BestPeerHeight returns the highest height that is reported by connected formulators
+func (ob *ObserverNode) BestPeerHeight() uint32 {
+	ob.statusLock.Lock()
+	defer ob.statusLock.Unlock()
+
+	var best uint32
+	for _, status := range ob.statusMap {
+		if best < status.Height {
+			best = status.Height
+		}
+	}
+	return best
+}
+
 // Close terminates the observer
 func (ob *ObserverNode) Close() {
 	ob.closeLock.Lock()
@@ -259,16 +280,19 @@ func (ob *ObserverNode) Run(BindObserver string, BindFormulator string) {
 				} else if ob.round.RoundState == BlockVoteState {
 					br, has := ob.round.BlockRoundMap[ob.round.TargetHeight]
 					if has {
-						ob.sendBlockVote(br.BlockGenMessage)
-						if debug.DEBUG {
-							rlog.Println(cp.Height(), "sendBlockVote", ob.round.MinRoundVoteAck.Formulator.String(), encoding.Hash(br.BlockGenMessage.Block.Header), ob.round.RoundState, len(ob.adjustFormulatorMap()), ob.fs.PeerCount(), (time.Now().UnixNano()-ob.prevRoundEndTime)/int64(time.Millisecond))
+						if err := ob.sendBlockVote(br.BlockGenMessage); err != nil {
+							rlog.Println(cp.Height(), "sendBlockVote", err)
+						} else {
+							if debug.DEBUG {
+								rlog.Println(cp.Height(), "sendBlockVote", ob.round.MinRoundVoteAck.Formulator.String(), encoding.Hash(br.BlockGenMessage.Block.Header), ob.round.RoundState, len(ob.adjustFormulatorMap()), ob.fs.PeerCount(), (time.Now().UnixNano()-ob.prevRoundEndTime)/int64(time.Millisecond))
+							}
+							IsFailable = false
 						}
-						IsFailable = false
 					}
 				}
 				if IsFailable {
 					ob.round.VoteFailCount++
-					if ob.round.VoteFailCount > 30 {
+					if ob.round.VoteFailCount > int(ob.cs.Policy().RoundTimeout()/(100*time.Millisecond)) {
 						if ob.round.MinRoundVoteAck != nil {
 							addr := ob.round.MinRoundVoteAck.Formulator
 							if _, has := ob.ignoreMap[addr]; has {
@@ -382,8 +406,16 @@ func (ob *ObserverNode) syncVoteRound() {
 }
 
 func (ob *ObserverNode) resetVoteRound(resetStat bool) {
-	ob.round = NewVoteRound(ob.cs.cn.Provider().Height()+1, ob.cs.maxBlocksPerFormulator)
-	ob.prevRoundEndTime = time.Now().UnixNano()
+	now := time.Now().UnixNano()
+	if ob.prevRoundEndTime > 0 {
+		if resetStat {
+			failedRounds.ObserveDuration(time.Duration(now - ob.prevRoundEndTime))
+		} else {
+			completedRounds.ObserveDuration(time.Duration(now - ob.prevRoundEndTime))
+		}
+	}
+	ob.round = NewVoteRound(ob.cs.cn.Provider().Height()+1, ob.cs.MaxBlocksPerFormulator())
+	ob.prevRoundEndTime = now
 	if resetStat {
 		ob.roundFirstTime = 0
 		ob.roundFirstHeight = 0
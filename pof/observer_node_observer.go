@@ -109,7 +109,7 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 		if !msg.RoundVote.IsReply && SenderPublicHash != ob.myPublicHash {
 			ob.sendRoundVoteTo(SenderPublicHash)
 		}
-		if len(ob.round.RoundVoteMessageMap) >= ob.cs.observerKeyMap.Len()/2+2 {
+		if len(ob.round.RoundVoteMessageMap) >= ob.cs.RoundVoteQuorum() {
 			ob.round.RoundState = RoundVoteAckState
 			if ob.roundFirstTime == 0 {
 				ob.roundFirstTime = uint64(time.Now().UnixNano())
@@ -201,7 +201,8 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 			ob.sendRoundVoteAckTo(SenderPublicHash)
 		}
 
-		if len(ob.round.RoundVoteAckMessageMap) >= ob.cs.observerKeyMap.Len()/2+1 {
+		Quorum := ob.cs.ObserverQuorum()
+		if len(ob.round.RoundVoteAckMessageMap) >= Quorum {
 			var MinRoundVoteAck *RoundVoteAck
 			PublicHashCountMap := map[common.PublicHash]int{}
 			TimeoutCountMap := map[uint32]int{}
@@ -213,20 +214,20 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 				PublicHashCount := PublicHashCountMap[vt.PublicHash]
 				PublicHashCount++
 				PublicHashCountMap[vt.PublicHash] = PublicHashCount
-				if TimeoutCount >= ob.cs.observerKeyMap.Len()/2+1 && PublicHashCount >= ob.cs.observerKeyMap.Len()/2+1 {
+				if TimeoutCount >= Quorum && PublicHashCount >= Quorum {
 					MinRoundVoteAck = vt
 					break
 				}
 			}
 
 			if MinRoundVoteAck != nil {
+				if ob.prevRoundEndTime > 0 {
+					roundVoteLatency.ObserveDuration(time.Duration(time.Now().UnixNano() - ob.prevRoundEndTime))
+				}
 				ob.round.RoundState = BlockWaitState
 				ob.round.MinRoundVoteAck = MinRoundVoteAck
 				ob.round.VoteFailCount = 0
-				RemainBlocks := ob.cs.maxBlocksPerFormulator
-				if MinRoundVoteAck.TimeoutCount == 0 {
-					RemainBlocks = ob.cs.maxBlocksPerFormulator - ob.cs.blocksBySameFormulator
-				}
+				RemainBlocks := ob.cs.RemainBlocks(MinRoundVoteAck.TimeoutCount)
 				for TargetHeight, br := range ob.round.BlockRoundMap {
 					if TargetHeight >= ob.round.TargetHeight+RemainBlocks {
 						delete(ob.round.BlockRoundMap, TargetHeight)
@@ -405,7 +406,9 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 		br.BlockGenMessage = msg
 		br.Context = ctx
 
-		ob.sendBlockVote(br.BlockGenMessage)
+		if err := ob.sendBlockVote(br.BlockGenMessage); err != nil {
+			rlog.Println(cp.Height(), "sendBlockVote", err)
+		}
 
 		for pubhash, msg := range br.BlockVoteMessageWaitMap {
 			ob.messageQueue.Push(&messageItem{
@@ -553,7 +556,11 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 			HeaderHash:         bh,
 			GeneratorSignature: msg.BlockVote.GeneratorSignature,
 		}
-		if pubkey, err := common.RecoverPubkey(encoding.Hash(s), msg.BlockVote.ObserverSignature); err != nil {
+		if keys := ob.cs.aggregatedKeys(); keys != nil {
+			if err := verifySchnorrVote(keys, SenderPublicHash, encoding.Hash(s), msg.BlockVote.ObserverSignature); err != nil {
+				return err
+			}
+		} else if pubkey, err := common.RecoverPubkey(encoding.Hash(s), msg.BlockVote.ObserverSignature); err != nil {
 			return err
 		} else if SenderPublicHash != common.NewPublicHash(pubkey) {
 			return ErrInvalidVote
@@ -575,18 +582,40 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 		}
 
 		//[apply vote]
-		if len(br.BlockVoteMap) >= ob.cs.observerKeyMap.Len()/2+1 {
+		policy := ob.cs.currentPolicy()
+		Quorum := ob.cs.observerQuorum(policy)
+		if len(br.BlockVoteMap) >= Quorum {
 			sigs := []common.Signature{}
-			for _, vt := range br.BlockVoteMap {
-				sigs = append(sigs, vt.ObserverSignature)
+			if keys := ob.cs.aggregatedKeysOf(policy); keys != nil {
+				SigMap := map[common.PublicHash]common.Signature{}
+				for pubhash, vt := range br.BlockVoteMap {
+					SigMap[pubhash] = vt.ObserverSignature
+				}
+				bs := &types.BlockSign{
+					HeaderHash:         bh,
+					GeneratorSignature: msg.BlockVote.GeneratorSignature,
+				}
+				if v, err := buildAggregatedSignature(keys, encoding.Hash(bs), SigMap, Quorum); err != nil {
+					return err
+				} else {
+					sigs = v
+				}
+			} else {
+				for _, vt := range br.BlockVoteMap {
+					sigs = append(sigs, vt.ObserverSignature)
+					if len(sigs) >= Quorum {
+						break
+					}
+				}
 			}
 
+			BlockInterval := ob.cs.Policy().BlockInterval()
 			PastTime := uint64(time.Now().UnixNano()) - ob.roundFirstTime
-			ExpectedTime := uint64(msg.BlockVote.Header.Height-ob.roundFirstHeight) * uint64(500*time.Millisecond)
+			ExpectedTime := uint64(msg.BlockVote.Header.Height-ob.roundFirstHeight) * uint64(BlockInterval)
 			if PastTime < ExpectedTime {
 				diff := time.Duration(ExpectedTime - PastTime)
-				if diff > 500*time.Millisecond {
-					diff = 500 * time.Millisecond
+				if diff > BlockInterval {
+					diff = BlockInterval
 				}
 				time.Sleep(diff)
 			}
@@ -602,6 +631,7 @@ func (ob *ObserverNode) handleObserverMessage(SenderPublicHash common.PublicHash
 			if err := ob.cs.ct.ConnectBlockWithContext(b, br.Context); err != nil {
 				return err
 			} else {
+				committedBlocks.Inc()
 				ob.broadcastStatus()
 			}
 			delete(ob.ignoreMap, ob.round.MinRoundVoteAck.Formulator)
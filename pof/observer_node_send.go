@@ -267,11 +267,19 @@ func (ob *ObserverNode) sendBlockVote(gen *BlockGenMessage) error {
 		},
 	}
 
+	TimeoutCount, err := ob.cs.DecodeConsensusData(gen.Block.Header.ConsensusData)
+	if err != nil {
+		return err
+	}
+	HeaderHash := encoding.Hash(gen.Block.Header)
+	if err := ob.sg.Guard(gen.Block.Header.Height, TimeoutCount, HeaderHash); err != nil {
+		return err
+	}
 	s := &types.BlockSign{
-		HeaderHash:         encoding.Hash(gen.Block.Header),
+		HeaderHash:         HeaderHash,
 		GeneratorSignature: gen.GeneratorSignature,
 	}
-	if sig, err := ob.key.Sign(encoding.Hash(s)); err != nil {
+	if sig, err := ob.signBlockSign(s); err != nil {
 		return err
 	} else {
 		nm.BlockVote.ObserverSignature = sig
@@ -291,6 +299,20 @@ func (ob *ObserverNode) sendBlockVote(gen *BlockGenMessage) error {
 	return nil
 }
 
+// signBlockSign signs the block sign by the schnorr signature when the aggregated signature is activated
+func (ob *ObserverNode) signBlockSign(s *types.BlockSign) (common.Signature, error) {
+	if keys := ob.cs.aggregatedKeys(); keys != nil {
+		ssig, err := ob.key.SchnorrSign(encoding.Hash(s))
+		if err != nil {
+			return common.Signature{}, err
+		}
+		var sig common.Signature
+		copy(sig[:], ssig[:])
+		return sig, nil
+	}
+	return ob.key.Sign(encoding.Hash(s))
+}
+
 func (ob *ObserverNode) sendBlockGenTo(gen *BlockGenMessage, TargetPubHash common.PublicHash) error {
 	if TargetPubHash == ob.myPublicHash {
 		return nil
@@ -313,11 +335,19 @@ func (ob *ObserverNode) sendBlockVoteTo(gen *BlockGenMessage, TargetPubHash comm
 		},
 	}
 
+	TimeoutCount, err := ob.cs.DecodeConsensusData(gen.Block.Header.ConsensusData)
+	if err != nil {
+		return err
+	}
+	HeaderHash := encoding.Hash(gen.Block.Header)
+	if err := ob.sg.Guard(gen.Block.Header.Height, TimeoutCount, HeaderHash); err != nil {
+		return err
+	}
 	s := &types.BlockSign{
-		HeaderHash:         encoding.Hash(gen.Block.Header),
+		HeaderHash:         HeaderHash,
 		GeneratorSignature: gen.GeneratorSignature,
 	}
-	if sig, err := ob.key.Sign(encoding.Hash(s)); err != nil {
+	if sig, err := ob.signBlockSign(s); err != nil {
 		return err
 	} else {
 		nm.BlockVote.ObserverSignature = sig
@@ -0,0 +1,158 @@
+package pof
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
+)
+
+// PolicyActivationDelay is the minimum number of blocks between the scheduling and the activation of the policy
+// Headers are validated ahead of the chain until the delay because the policies of them are already scheduled
+const PolicyActivationDelay = 2000
+
+// ConsensusPolicy defines the parameters of the consensus
+type ConsensusPolicy struct {
+	MaxBlocksPerFormulator uint32
+	BlockIntervalMs        uint32
+	TxCollectionTimeoutMs  uint32
+	RoundTimeoutMs         uint32
+	ObserverQuorum         uint32 // 0 means the simple majority of observers, otherwise between the simple majority and the number of observers
+	UseAggregatedSignature bool
+	ObserverPublicKeys     []common.PublicKey // the order of the signer bitmap of the aggregated signature
+}
+
+// DefaultConsensusPolicy returns the policy that is used before any policy is activated
+func DefaultConsensusPolicy(MaxBlocksPerFormulator uint32) *ConsensusPolicy {
+	return &ConsensusPolicy{
+		MaxBlocksPerFormulator: MaxBlocksPerFormulator,
+		BlockIntervalMs:        500,
+		TxCollectionTimeoutMs:  200,
+		RoundTimeoutMs:         3000,
+	}
+}
+
+// IsValid returns the policy is applicable to the observers or not
+func (pc *ConsensusPolicy) IsValid(ObserverCount int) bool {
+	if pc.MaxBlocksPerFormulator == 0 {
+		return false
+	}
+	if pc.BlockIntervalMs == 0 {
+		return false
+	}
+	if pc.TxCollectionTimeoutMs == 0 || pc.TxCollectionTimeoutMs > pc.BlockIntervalMs {
+		return false
+	}
+	if pc.RoundTimeoutMs < pc.BlockIntervalMs {
+		return false
+	}
+	if pc.ObserverQuorum != 0 {
+		if int(pc.ObserverQuorum) < ObserverCount/2+1 || int(pc.ObserverQuorum) > ObserverCount {
+			return false
+		}
+	}
+	if pc.UseAggregatedSignature && len(pc.ObserverPublicKeys) != ObserverCount {
+		return false
+	}
+	return true
+}
+
+// BlockInterval returns the block interval as a duration
+func (pc *ConsensusPolicy) BlockInterval() time.Duration {
+	return time.Duration(pc.BlockIntervalMs) * time.Millisecond
+}
+
+// TxCollectionTimeout returns the tx collection timeout as a duration
+func (pc *ConsensusPolicy) TxCollectionTimeout() time.Duration {
+	return time.Duration(pc.TxCollectionTimeoutMs) * time.Millisecond
+}
+
+// RoundTimeout returns the round timeout as a duration
+func (pc *ConsensusPolicy) RoundTimeout() time.Duration {
+	return time.Duration(pc.RoundTimeoutMs) * time.Millisecond
+}
+
+// Clone returns the clonend value of it
+func (pc *ConsensusPolicy) Clone() *ConsensusPolicy {
+	return &ConsensusPolicy{
+		MaxBlocksPerFormulator: pc.MaxBlocksPerFormulator,
+		BlockIntervalMs:        pc.BlockIntervalMs,
+		TxCollectionTimeoutMs:  pc.TxCollectionTimeoutMs,
+		RoundTimeoutMs:         pc.RoundTimeoutMs,
+		ObserverQuorum:         pc.ObserverQuorum,
+		UseAggregatedSignature: pc.UseAggregatedSignature,
+		ObserverPublicKeys:     append([]common.PublicKey{}, pc.ObserverPublicKeys...),
+	}
+}
+
+// MarshalJSON is a marshaler function
+func (pc *ConsensusPolicy) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"max_blocks_per_formulator":`)
+	if bs, err := json.Marshal(pc.MaxBlocksPerFormulator); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"block_interval_ms":`)
+	if bs, err := json.Marshal(pc.BlockIntervalMs); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"tx_collection_timeout_ms":`)
+	if bs, err := json.Marshal(pc.TxCollectionTimeoutMs); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"round_timeout_ms":`)
+	if bs, err := json.Marshal(pc.RoundTimeoutMs); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"observer_quorum":`)
+	if bs, err := json.Marshal(pc.ObserverQuorum); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"use_aggregated_signature":`)
+	if bs, err := json.Marshal(pc.UseAggregatedSignature); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"observer_public_keys":`)
+	buffer.WriteString(`[`)
+	for i, pubkey := range pc.ObserverPublicKeys {
+		if i > 0 {
+			buffer.WriteString(`,`)
+		}
+		if bs, err := pubkey.MarshalJSON(); err != nil {
+			return nil, err
+		} else {
+			buffer.Write(bs)
+		}
+	}
+	buffer.WriteString(`]`)
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
+
+// PolicyProcess provides the consensus policy that is scheduled by the governance
+type PolicyProcess interface {
+	types.Process
+	ScheduledPolicy(loader types.Loader, Height uint32) (*ConsensusPolicy, error)
+	SetObserverCount(Count int)
+}
@@ -194,6 +194,17 @@ func (rt *RankTable) removeRank(addr common.Address) {
 	}
 }
 
+func (rt *RankTable) topRanks(Count int) []*Rank {
+	if Count > len(rt.candidates) {
+		Count = len(rt.candidates)
+	}
+	list := make([]*Rank, 0, Count)
+	for _, c := range rt.candidates[:Count] {
+		list = append(list, c.Clone())
+	}
+	return list
+}
+
 func (rt *RankTable) forwardCandidates(TimeoutCount int) error {
 	if TimeoutCount >= len(rt.candidates) {
 		return ErrExceedCandidateCount
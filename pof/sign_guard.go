@@ -0,0 +1,115 @@
+package pof
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/fletaio/fleta_v1/common/binutil"
+	"github.com/fletaio/fleta_v1/common/hash"
+)
+
+// SignGuard prevents the observer from signing conflicting blocks in the same round
+// A round is identified by the height and the timeout count, so a block of the next formulator can be signed after the round timeout
+// It persists the last signed round and hash to the file, so it works across restarts
+type SignGuard struct {
+	sync.Mutex
+	path         string
+	height       uint32
+	timeoutCount uint32
+	hash         hash.Hash256
+}
+
+// NewSignGuard returns a SignGuard that is loaded from the file
+func NewSignGuard(path string) (*SignGuard, error) {
+	sg := &SignGuard{
+		path: path,
+	}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sg, nil
+		}
+		return nil, err
+	}
+	if len(bs) != 8+hash.Hash256Size {
+		return nil, ErrInvalidSignGuardFile
+	}
+	sg.height = binutil.BigEndian.Uint32(bs)
+	sg.timeoutCount = binutil.BigEndian.Uint32(bs[4:])
+	copy(sg.hash[:], bs[8:])
+	return sg, nil
+}
+
+// LastSigned returns the last signed height, timeout count and hash
+func (sg *SignGuard) LastSigned() (uint32, uint32, hash.Hash256) {
+	sg.Lock()
+	defer sg.Unlock()
+
+	return sg.height, sg.timeoutCount, sg.hash
+}
+
+// Check returns an error when the signing of the hash at the round conflicts with the last signed one
+func (sg *SignGuard) Check(Height uint32, TimeoutCount uint32, h hash.Hash256) error {
+	sg.Lock()
+	defer sg.Unlock()
+
+	return sg.check(Height, TimeoutCount, h)
+}
+
+func (sg *SignGuard) check(Height uint32, TimeoutCount uint32, h hash.Hash256) error {
+	if Height < sg.height {
+		return ErrSignGuardPastHeight
+	}
+	if Height == sg.height {
+		if TimeoutCount < sg.timeoutCount {
+			return ErrSignGuardPastRound
+		}
+		if TimeoutCount == sg.timeoutCount && h != sg.hash {
+			return ErrSignGuardConflict
+		}
+	}
+	return nil
+}
+
+// Guard checks the hash at the round and persists it as the last signed one before signing
+func (sg *SignGuard) Guard(Height uint32, TimeoutCount uint32, h hash.Hash256) error {
+	sg.Lock()
+	defer sg.Unlock()
+
+	if err := sg.check(Height, TimeoutCount, h); err != nil {
+		return err
+	}
+	if Height == sg.height && TimeoutCount == sg.timeoutCount {
+		return nil
+	}
+
+	bs := make([]byte, 8+hash.Hash256Size)
+	binutil.BigEndian.PutUint32(bs, Height)
+	binutil.BigEndian.PutUint32(bs[4:], TimeoutCount)
+	copy(bs[8:], h[:])
+
+	tmpPath := sg.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(bs); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, sg.path); err != nil {
+		return err
+	}
+	sg.height = Height
+	sg.timeoutCount = TimeoutCount
+	sg.hash = h
+	return nil
+}
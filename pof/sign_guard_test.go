@@ -0,0 +1,67 @@
+package pof
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common/hash"
+)
+
+func TestSignGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sign_guard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sign_guard")
+
+	sg, err := NewSignGuard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	A := hash.Hash([]byte("A"))
+	B := hash.Hash([]byte("B"))
+	C := hash.Hash([]byte("C"))
+
+	if err := sg.Guard(10, 0, A); err != nil {
+		t.Fatal(err)
+	}
+	if err := sg.Guard(10, 0, A); err != nil {
+		t.Fatalf("the same block is not signed again: %v", err)
+	}
+	if err := sg.Guard(10, 0, B); err != ErrSignGuardConflict {
+		t.Fatalf("signed the conflicting block in the same round: %v", err)
+	}
+	if err := sg.Guard(10, 1, B); err != nil {
+		t.Fatalf("the block of the next round is not signed: %v", err)
+	}
+	if err := sg.Guard(10, 0, A); err != ErrSignGuardPastRound {
+		t.Fatalf("signed the block of the past round: %v", err)
+	}
+	if err := sg.Guard(9, 5, C); err != ErrSignGuardPastHeight {
+		t.Fatalf("signed the block of the past height: %v", err)
+	}
+
+	loaded, err := NewSignGuard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Height, TimeoutCount, h := loaded.LastSigned(); Height != 10 || TimeoutCount != 1 || h != B {
+		t.Fatalf("invalid loaded guard: %v %v %v", Height, TimeoutCount, h)
+	}
+	if err := loaded.Check(10, 1, C); err != ErrSignGuardConflict {
+		t.Fatalf("signed the conflicting block after the restart: %v", err)
+	}
+	if err := loaded.Check(11, 0, C); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte{1, 2, 3}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSignGuard(path); err != ErrInvalidSignGuardFile {
+		t.Fatalf("loaded the invalid file: %v", err)
+	}
+}
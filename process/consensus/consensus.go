@@ -0,0 +1,119 @@
+package consensus
+
+import (
+	"sync"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/pof"
+	"github.com/fletaio/fleta_v1/process/admin"
+)
+
+// Consensus manages the consensus policy that is governed by the admin
+// The admin of the formulator process governs it because the policy changes how formulators generate blocks
+type Consensus struct {
+	sync.Mutex
+	*types.ProcessBase
+	pid           uint8
+	pm            types.ProcessManager
+	cn            types.Provider
+	admin         *admin.Admin
+	observerCount int
+}
+
+// NewConsensus returns a Consensus
+func NewConsensus(pid uint8) *Consensus {
+	p := &Consensus{
+		pid: pid,
+	}
+	return p
+}
+
+// ID returns the id of the process
+func (p *Consensus) ID() uint8 {
+	return p.pid
+}
+
+// Name returns the name of the process
+func (p *Consensus) Name() string {
+	return "fleta.consensus"
+}
+
+// Version returns the version of the process
+func (p *Consensus) Version() string {
+	return "0.0.1"
+}
+
+// Init initializes the process
+func (p *Consensus) Init(reg *types.Register, pm types.ProcessManager, cn types.Provider) error {
+	p.pm = pm
+	p.cn = cn
+
+	if vp, err := pm.ProcessByName("fleta.admin"); err != nil {
+		return err
+	} else if v, is := vp.(*admin.Admin); !is {
+		return types.ErrInvalidProcess
+	} else {
+		p.admin = v
+	}
+
+	reg.RegisterTransaction(1, &UpdateConsensusPolicy{})
+	return nil
+}
+
+// OnLoadChain called when the chain loaded
+func (p *Consensus) OnLoadChain(loader types.LoaderWrapper) error {
+	return nil
+}
+
+// BeforeExecuteTransactions called before processes transactions of the block
+func (p *Consensus) BeforeExecuteTransactions(ctw *types.ContextWrapper) error {
+	return nil
+}
+
+// AfterExecuteTransactions called after processes transactions of the block
+func (p *Consensus) AfterExecuteTransactions(b *types.Block, ctw *types.ContextWrapper) error {
+	return nil
+}
+
+// OnSaveData called when the context of the block saved
+func (p *Consensus) OnSaveData(b *types.Block, ctw *types.ContextWrapper) error {
+	return nil
+}
+
+// ScheduledPolicy returns the policy that is activated at the height
+// It returns nil when there is no policy that is scheduled at the height
+func (p *Consensus) ScheduledPolicy(loader types.Loader, Height uint32) (*pof.ConsensusPolicy, error) {
+	lw := types.NewLoaderWrapper(p.pid, loader)
+
+	bs := lw.ProcessData(toScheduledPolicyKey(Height))
+	if len(bs) == 0 {
+		return nil, nil
+	}
+	policy := &pof.ConsensusPolicy{}
+	if err := encoding.Unmarshal(bs, &policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// SetObserverCount sets the number of observers that is used to validate the policy
+func (p *Consensus) SetObserverCount(Count int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.observerCount = Count
+}
+
+// ObserverCount returns the number of observers that is used to validate the policy
+func (p *Consensus) ObserverCount() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.observerCount
+}
+
+func (p *Consensus) adminAddress(loader types.Loader) common.Address {
+	return p.admin.AdminAddress(loader, "fleta.formulator")
+}
@@ -0,0 +1,9 @@
+package consensus
+
+import "errors"
+
+// errors
+var (
+	ErrInvalidPolicy           = errors.New("invalid policy")
+	ErrInvalidActivationHeight = errors.New("invalid activation height")
+)
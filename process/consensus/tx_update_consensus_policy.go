@@ -0,0 +1,116 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/pof"
+	"github.com/fletaio/fleta_v1/process/admin"
+)
+
+// UpdateConsensusPolicy is used to schedule the consensus policy at the activation height
+type UpdateConsensusPolicy struct {
+	Timestamp_       uint64
+	Seq_             uint64
+	From_            common.Address
+	ActivationHeight uint32
+	Policy           *pof.ConsensusPolicy
+}
+
+// Timestamp returns the timestamp of the transaction
+func (tx *UpdateConsensusPolicy) Timestamp() uint64 {
+	return tx.Timestamp_
+}
+
+// Seq returns the sequence of the transaction
+func (tx *UpdateConsensusPolicy) Seq() uint64 {
+	return tx.Seq_
+}
+
+// From returns the from address of the transaction
+func (tx *UpdateConsensusPolicy) From() common.Address {
+	return tx.From_
+}
+
+// Validate validates signatures of the transaction
+func (tx *UpdateConsensusPolicy) Validate(p types.Process, loader types.LoaderWrapper, signers []common.PublicHash) error {
+	sp := p.(*Consensus)
+
+	if tx.From() != sp.adminAddress(loader) {
+		return admin.ErrUnauthorizedTransaction
+	}
+	if tx.Policy == nil || !tx.Policy.IsValid(sp.ObserverCount()) {
+		return ErrInvalidPolicy
+	}
+	if tx.ActivationHeight < loader.TargetHeight()+pof.PolicyActivationDelay {
+		return ErrInvalidActivationHeight
+	}
+
+	if tx.Seq() <= loader.Seq(tx.From()) {
+		return types.ErrInvalidSequence
+	}
+
+	fromAcc, err := loader.Account(tx.From())
+	if err != nil {
+		return err
+	}
+	if err := fromAcc.Validate(loader, signers); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Execute updates the context by the transaction
+func (tx *UpdateConsensusPolicy) Execute(p types.Process, ctw *types.ContextWrapper, index uint16) error {
+	if bs, err := encoding.Marshal(tx.Policy); err != nil {
+		return err
+	} else {
+		ctw.SetProcessData(toScheduledPolicyKey(tx.ActivationHeight), bs)
+	}
+	return nil
+}
+
+// MarshalJSON is a marshaler function
+func (tx *UpdateConsensusPolicy) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"timestamp":`)
+	if bs, err := json.Marshal(tx.Timestamp_); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"seq":`)
+	if bs, err := json.Marshal(tx.Seq_); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"from":`)
+	if bs, err := tx.From_.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"activation_height":`)
+	if bs, err := json.Marshal(tx.ActivationHeight); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"policy":`)
+	if bs, err := tx.Policy.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
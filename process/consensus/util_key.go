@@ -0,0 +1,17 @@
+package consensus
+
+import (
+	"github.com/fletaio/fleta_v1/common/binutil"
+)
+
+// tags
+var (
+	tagScheduledPolicy = []byte{1, 0}
+)
+
+func toScheduledPolicyKey(Height uint32) []byte {
+	bs := make([]byte, 6)
+	copy(bs, tagScheduledPolicy)
+	binutil.BigEndian.PutUint32(bs[2:], Height)
+	return bs
+}
@@ -1,6 +1,8 @@
 package vault
 
 import (
+	"net/http"
+
 	"github.com/fletaio/fleta_v1/common"
 	"github.com/fletaio/fleta_v1/common/amount"
 	"github.com/fletaio/fleta_v1/core/types"
@@ -92,6 +94,30 @@ func (p *Vault) Init(reg *types.Register, pm types.ProcessManager, cn types.Prov
 			loader := cn.NewLoaderWrapper(p.ID())
 			return p.CollectedFee(loader), nil
 		})
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodGet,
+			Path:    "/v1/accounts/:address/balance",
+			RPC:     "vault.balance",
+			Summary: "returns the balance of the address",
+			Params: []*apiserver.RESTParam{
+				{
+					Name: "address",
+					In:   apiserver.RESTParamPath,
+				},
+			},
+			Result: apiserver.StringSchema("the amount"),
+		}); err != nil {
+			return err
+		}
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodGet,
+			Path:    "/v1/fees/collected",
+			RPC:     "vault.collectedFee",
+			Summary: "returns the collected fee that is not distributed yet",
+			Result:  apiserver.StringSchema("the amount"),
+		}); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,123 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/key"
+)
+
+// BankClient calls methods of the bank namespace of the wallet
+type BankClient struct {
+	c *Client
+}
+
+// Height returns the height of the chain of the wallet
+func (s *BankClient) Height(ctx context.Context) (uint32, error) {
+	var height uint32
+	if err := s.c.Call(ctx, "bank.height", &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// KeyNames returns names of keys in the wallet
+func (s *BankClient) KeyNames(ctx context.Context) ([]string, error) {
+	names := []string{}
+	if err := s.c.Call(ctx, "bank.keyNames", &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Accounts returns addresses of accounts of the key
+func (s *BankClient) Accounts(ctx context.Context, Name string) ([]common.Address, error) {
+	addrs := []common.Address{}
+	if err := s.c.Call(ctx, "bank.accounts", &addrs, Name); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// CreateKey creates the key of the name that is encrypted by the password
+func (s *BankClient) CreateKey(ctx context.Context, Name string, Password string) error {
+	return s.c.Call(ctx, "bank.createKey", nil, Name, Password)
+}
+
+// ImportKey imports the private key as the key of the name that is encrypted by the password
+func (s *BankClient) ImportKey(ctx context.Context, Name string, k *key.MemoryKey, Password string) error {
+	return s.c.Call(ctx, "bank.importKey", nil, Name, hex.EncodeToString(k.Bytes()), Password)
+}
+
+// CheckPassword checks the password of the key
+func (s *BankClient) CheckPassword(ctx context.Context, Name string, Password string) error {
+	return s.c.Call(ctx, "bank.checkPassword", nil, Name, Password)
+}
+
+// ChangePassword changes the password of the key
+func (s *BankClient) ChangePassword(ctx context.Context, Name string, oldPassword string, Password string) error {
+	return s.c.Call(ctx, "bank.changePassword", nil, Name, oldPassword, Password)
+}
+
+// DeleteKey deletes the key
+func (s *BankClient) DeleteKey(ctx context.Context, Name string, Password string) error {
+	return s.c.Call(ctx, "bank.deleteKey", nil, Name, Password)
+}
+
+// AccountDetail returns the json object of the account, use ChainClient.Account to decode the account to its type
+func (s *BankClient) AccountDetail(ctx context.Context, addr common.Address) (map[string]interface{}, error) {
+	mp := map[string]interface{}{}
+	if err := s.c.Call(ctx, "bank.accountDetail", &mp, addr.String()); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// Send transfers the amount by the key of the sender, it returns the hash of the transaction
+func (s *BankClient) Send(ctx context.Context, From common.Address, To common.Address, am *amount.Amount, Password string) (hash.Hash256, error) {
+	var TxHash hash.Hash256
+	if err := s.c.Call(ctx, "bank.send", &TxHash, From.String(), To.String(), am.String(), Password); err != nil {
+		return hash.Hash256{}, err
+	}
+	return TxHash, nil
+}
+
+// Transaction returns the json object of the transaction of the txid with the type name and the result
+func (s *BankClient) Transaction(ctx context.Context, TXID string) (map[string]interface{}, error) {
+	mp := map[string]interface{}{}
+	if err := s.c.Call(ctx, "bank.transaction", &mp, TXID); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// Transactions returns json objects of transactions of the address from the newest
+func (s *BankClient) Transactions(ctx context.Context, addr common.Address, offset int, count int) ([]map[string]interface{}, error) {
+	return s.list(ctx, "bank.transactions", addr.String(), offset, count)
+}
+
+// TransferSends returns json objects of transfers that are sent from the address
+func (s *BankClient) TransferSends(ctx context.Context, addr common.Address, offset int, count int) ([]map[string]interface{}, error) {
+	return s.list(ctx, "bank.transferSends", addr.String(), offset, count)
+}
+
+// TransferRecvs returns json objects of transfers that are received by the address
+func (s *BankClient) TransferRecvs(ctx context.Context, addr common.Address, offset int, count int) ([]map[string]interface{}, error) {
+	return s.list(ctx, "bank.transferRecvs", addr.String(), offset, count)
+}
+
+// Pendings returns json objects of transactions of the address that are not included in blocks yet
+func (s *BankClient) Pendings(ctx context.Context, addr common.Address) ([]map[string]interface{}, error) {
+	return s.list(ctx, "bank.pendings", addr.String())
+}
+
+func (s *BankClient) list(ctx context.Context, Method string, Params ...interface{}) ([]map[string]interface{}, error) {
+	list := []map[string]interface{}{}
+	if err := s.c.Call(ctx, Method, &list, Params...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,207 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/hex"
+	"reflect"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/service/chainapi"
+)
+
+// ChainClient calls methods of the chain namespace
+type ChainClient struct {
+	c *Client
+}
+
+// Height returns the height of the chain
+func (s *ChainClient) Height(ctx context.Context) (uint32, error) {
+	var height uint32
+	if err := s.c.Call(ctx, "chain.height", &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// Status returns the status of the chain
+func (s *ChainClient) Status(ctx context.Context) (*chainapi.Status, error) {
+	var st chainapi.Status
+	if err := s.c.Call(ctx, "chain.status", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Hash returns the block hash of the height
+func (s *ChainClient) Hash(ctx context.Context, height uint32) (hash.Hash256, error) {
+	var h hash.Hash256
+	if err := s.c.Call(ctx, "chain.hash", &h, height); err != nil {
+		return hash.Hash256{}, err
+	}
+	return h, nil
+}
+
+// Header returns the header of the height
+func (s *ChainClient) Header(ctx context.Context, height uint32) (*types.Header, error) {
+	return s.header(ctx, height)
+}
+
+// HeaderByHash returns the header of the block hash
+func (s *ChainClient) HeaderByHash(ctx context.Context, h hash.Hash256) (*types.Header, error) {
+	return s.header(ctx, h.String())
+}
+
+func (s *ChainClient) header(ctx context.Context, key interface{}) (*types.Header, error) {
+	bs, _, err := s.raw(ctx, "chain.rawHeader", key)
+	if err != nil {
+		return nil, err
+	}
+	bh := &types.Header{}
+	if err := encoding.Unmarshal(bs, bh); err != nil {
+		return nil, err
+	}
+	return bh, nil
+}
+
+// Block returns the block of the height
+// Transactions of the block are decoded by the encoding factory, so types of processes should be registered by the same process ids of the node
+func (s *ChainClient) Block(ctx context.Context, height uint32) (*types.Block, error) {
+	return s.block(ctx, height)
+}
+
+// BlockByHash returns the block of the block hash
+func (s *ChainClient) BlockByHash(ctx context.Context, h hash.Hash256) (*types.Block, error) {
+	return s.block(ctx, h.String())
+}
+
+func (s *ChainClient) block(ctx context.Context, key interface{}) (*types.Block, error) {
+	bs, _, err := s.raw(ctx, "chain.rawBlock", key)
+	if err != nil {
+		return nil, err
+	}
+	b := &types.Block{}
+	if err := encoding.Unmarshal(bs, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Headers returns json objects of headers from the height, the next height is zero at the end of the chain
+func (s *ChainClient) Headers(ctx context.Context, From uint32, Count uint32) (*chainapi.BlockPage, error) {
+	var page chainapi.BlockPage
+	if err := s.c.Call(ctx, "chain.headers", &page, From, Count); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Blocks returns json objects of blocks from the height, the next height is zero at the end of the chain
+func (s *ChainClient) Blocks(ctx context.Context, From uint32, Count uint32) (*chainapi.BlockPage, error) {
+	var page chainapi.BlockPage
+	if err := s.c.Call(ctx, "chain.blocks", &page, From, Count); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Transaction returns the json object of the transaction of the txid with the type name and the result
+func (s *ChainClient) Transaction(ctx context.Context, TXID string) (map[string]interface{}, error) {
+	mp := map[string]interface{}{}
+	if err := s.c.Call(ctx, "chain.transaction", &mp, TXID); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// Account returns the account of the address, the type is created by the type name from the encoding factory
+// Use AccountInto when types of the process are not registered
+func (s *ChainClient) Account(ctx context.Context, addr common.Address) (types.Account, error) {
+	return s.account(ctx, addr.String())
+}
+
+// AccountByName returns the account of the name
+func (s *ChainClient) AccountByName(ctx context.Context, Name string) (types.Account, error) {
+	return s.account(ctx, Name)
+}
+
+func (s *ChainClient) account(ctx context.Context, key string) (types.Account, error) {
+	bs, Type, err := s.raw(ctx, "chain.rawAccount", key)
+	if err != nil {
+		return nil, err
+	}
+	fc := encoding.Factory("account")
+	t, err := fc.TypeByName(Type)
+	if err != nil {
+		return nil, err
+	}
+	v, err := fc.Create(t)
+	if err != nil {
+		return nil, err
+	}
+	acc, is := v.(types.Account)
+	if !is {
+		return nil, ErrNotAccount
+	}
+	if err := encoding.Unmarshal(bs, acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// AccountInto decodes the account of the address to acc like *formulator.FormulatorAccount, it returns ErrMismatchedType when the account is another type
+func (s *ChainClient) AccountInto(ctx context.Context, addr common.Address, acc types.Account) error {
+	bs, Type, err := s.raw(ctx, "chain.rawAccount", addr.String())
+	if err != nil {
+		return err
+	}
+	if Type != typeName(acc) {
+		return ErrMismatchedType
+	}
+	return encoding.Unmarshal(bs, acc)
+}
+
+// AccountJSON returns the json object of the account of the address with the type name and the seq
+func (s *ChainClient) AccountJSON(ctx context.Context, addr common.Address) (map[string]interface{}, error) {
+	mp := map[string]interface{}{}
+	if err := s.c.Call(ctx, "chain.account", &mp, addr.String()); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// Events returns json objects of events in the height range with type names
+func (s *ChainClient) Events(ctx context.Context, From uint32, To uint32) ([]map[string]interface{}, error) {
+	list := []map[string]interface{}{}
+	if err := s.c.Call(ctx, "chain.events", &list, From, To); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// raw returns the binary encoding and the type name of the raw method
+func (s *ChainClient) raw(ctx context.Context, Method string, key interface{}) ([]byte, string, error) {
+	var rd chainapi.RawData
+	if err := s.c.Call(ctx, Method, &rd, key); err != nil {
+		return nil, "", err
+	}
+	bs, err := hex.DecodeString(rd.Data)
+	if err != nil {
+		return nil, "", err
+	}
+	return bs, rd.Type, nil
+}
+
+// typeName returns the name of the type that is same with the name of the encoding factory
+func typeName(v interface{}) string {
+	rt := reflect.TypeOf(v)
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if len(rt.PkgPath()) > 0 {
+		return rt.PkgPath() + "." + rt.Name()
+	}
+	return rt.Name()
+}
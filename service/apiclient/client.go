@@ -0,0 +1,138 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MaxResponseSize is the maximum size of the response that is read from the node
+const MaxResponseSize = 64 * 1024 * 1024
+
+// DefaultTimeout is the timeout of a call when the context has no deadline
+const DefaultTimeout = 30 * time.Second
+
+// Client calls jrpc methods of the node through the transport
+// Namespace clients decode results to types of the chain, processes and services
+type Client struct {
+	sync.Mutex
+	tr      Transport
+	timeout time.Duration
+	retry   *RetryPolicy
+
+	Chain           *ChainClient
+	Vault           *VaultClient
+	Bank            *BankClient
+	Consensus       *ConsensusClient
+	Observer        *ObserverClient
+	FormulatorStats *FormulatorStatsClient
+	Tx              *TxClient
+	TxPool          *TxPoolClient
+	P2P             *P2PClient
+	Fork            *ForkClient
+}
+
+// NewClient returns a Client
+func NewClient(tr Transport) *Client {
+	c := &Client{
+		tr:      tr,
+		timeout: DefaultTimeout,
+		retry:   DefaultRetryPolicy(),
+	}
+	c.Chain = &ChainClient{c: c}
+	c.Vault = &VaultClient{c: c}
+	c.Bank = &BankClient{c: c}
+	c.Consensus = &ConsensusClient{c: c}
+	c.Observer = &ObserverClient{c: c}
+	c.FormulatorStats = &FormulatorStatsClient{c: c}
+	c.Tx = &TxClient{c: c}
+	c.TxPool = &TxPoolClient{c: c}
+	c.P2P = &P2PClient{c: c}
+	c.Fork = &ForkClient{c: c}
+	return c
+}
+
+// NewHTTPClient returns a Client that uses the HTTPTransport
+func NewHTTPClient(hostURL string) *Client {
+	return NewClient(NewHTTPTransport(hostURL))
+}
+
+// NewWebsocketClient returns a Client that uses the WebsocketTransport
+func NewWebsocketClient(hostURL string) (*Client, error) {
+	tr, err := NewWebsocketTransport(hostURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(tr), nil
+}
+
+// SetTimeout sets the timeout of each attempt when the context has no deadline, zero means no timeout
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.timeout = timeout
+}
+
+// SetRetryPolicy sets the retry policy, nil disables retries
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.retry = p
+}
+
+// Transport returns the transport of the client
+func (c *Client) Transport() Transport {
+	return c.tr
+}
+
+// Close closes the transport
+func (c *Client) Close() error {
+	return c.tr.Close()
+}
+
+// Call calls the method(bank.send) and decodes the result to the result when it is not nil
+func (c *Client) Call(ctx context.Context, Method string, result interface{}, Params ...interface{}) error {
+	data, err := c.CallRaw(ctx, Method, Params...)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// CallRaw calls the method and returns the result as raw json, it retries by the retry policy
+func (c *Client) CallRaw(ctx context.Context, Method string, Params ...interface{}) (json.RawMessage, error) {
+	c.Lock()
+	timeout := c.timeout
+	retry := c.retry
+	c.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		data, err := c.call(ctx, timeout, Method, Params)
+		if err == nil {
+			return data, nil
+		}
+		if retry == nil || attempt >= retry.MaxAttempts || !retry.IsRetryable(err) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retry.Delay(attempt)):
+		}
+	}
+}
+
+func (c *Client) call(ctx context.Context, timeout time.Duration, Method string, Params []interface{}) (json.RawMessage, error) {
+	if _, has := ctx.Deadline(); !has && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return c.tr.Call(ctx, Method, Params)
+}
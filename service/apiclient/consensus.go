@@ -0,0 +1,72 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/pof"
+)
+
+// ConsensusClient calls methods of the consensus namespace
+type ConsensusClient struct {
+	c *Client
+}
+
+// Ranks returns formulator candidates in the rank order
+func (s *ConsensusClient) Ranks(ctx context.Context) ([]*pof.Rank, error) {
+	list := []*pof.Rank{}
+	if err := s.c.Call(ctx, "consensus.getRanks", &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Policy returns the consensus policy that is activated
+func (s *ConsensusClient) Policy(ctx context.Context) (*pof.ConsensusPolicy, error) {
+	var policy pof.ConsensusPolicy
+	if err := s.c.Call(ctx, "consensus.policy", &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ObserverClient calls methods of the observer namespace
+type ObserverClient struct {
+	c *Client
+}
+
+// FormulatorMap returns formulators that are connected to the observer
+func (s *ObserverClient) FormulatorMap(ctx context.Context) (map[common.Address]bool, error) {
+	data, err := s.c.CallRaw(ctx, "observer.formulatorMap")
+	if err != nil {
+		return nil, err
+	}
+	return addressMap(data)
+}
+
+// AdjustFormulatorMap returns connected formulators except ignored ones
+func (s *ObserverClient) AdjustFormulatorMap(ctx context.Context) (map[common.Address]bool, error) {
+	data, err := s.c.CallRaw(ctx, "observer.adjustFormulatorMap")
+	if err != nil {
+		return nil, err
+	}
+	return addressMap(data)
+}
+
+// addressMap converts keys of the json object to addresses
+func addressMap(data json.RawMessage) (map[common.Address]bool, error) {
+	mp := map[string]bool{}
+	if err := json.Unmarshal(data, &mp); err != nil {
+		return nil, err
+	}
+	result := map[common.Address]bool{}
+	for k, v := range mp {
+		addr, err := common.ParseAddress(k)
+		if err != nil {
+			return nil, err
+		}
+		result[addr] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,16 @@
+package apiclient
+
+import (
+	"errors"
+)
+
+// errors
+var (
+	ErrClosedTransport     = errors.New("closed transport")
+	ErrConnectionClosed    = errors.New("connection closed")
+	ErrInvalidResponse     = errors.New("invalid response")
+	ErrInvalidURL          = errors.New("invalid url")
+	ErrMismatchedType      = errors.New("mismatched type")
+	ErrNotAccount          = errors.New("not account")
+	ErrUnsignedTransaction = errors.New("unsigned transaction")
+)
@@ -0,0 +1,40 @@
+package apiclient
+
+import (
+	"context"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/service/formulatorstats"
+)
+
+// FormulatorStatsClient calls methods of the formulatorstats namespace
+type FormulatorStatsClient struct {
+	c *Client
+}
+
+// RangeSize returns the number of blocks of a stat range
+func (s *FormulatorStatsClient) RangeSize(ctx context.Context) (uint32, error) {
+	var size uint32
+	if err := s.c.Call(ctx, "formulatorstats.rangeSize", &size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// Stat returns the stat of the formulator from the genesis
+func (s *FormulatorStatsClient) Stat(ctx context.Context, addr common.Address) (*formulatorstats.StatResult, error) {
+	var st formulatorstats.StatResult
+	if err := s.c.Call(ctx, "formulatorstats.stat", &st, addr.String()); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// StatsByRange returns stats of the formulator of ranges in the height range
+func (s *FormulatorStatsClient) StatsByRange(ctx context.Context, addr common.Address, From uint32, To uint32) ([]*formulatorstats.StatResult, error) {
+	list := []*formulatorstats.StatResult{}
+	if err := s.c.Call(ctx, "formulatorstats.statsByRange", &list, addr.String(), From, To); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
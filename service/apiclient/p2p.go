@@ -0,0 +1,96 @@
+package apiclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/service/p2p"
+)
+
+// P2PClient calls methods of the p2p namespace
+type P2PClient struct {
+	c *Client
+}
+
+// Peers returns connected peers
+func (s *P2PClient) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	list := []*p2p.PeerInfo{}
+	if err := s.c.Call(ctx, "p2p.peers", &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Bans returns banned peers with expired times
+func (s *P2PClient) Bans(ctx context.Context) ([]*p2p.BanResult, error) {
+	list := []*p2p.BanResult{}
+	if err := s.c.Call(ctx, "p2p.bans", &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Ban bans the peer for the duration, the ban never expires when the duration is zero
+func (s *P2PClient) Ban(ctx context.Context, pubhash common.PublicHash, d time.Duration) error {
+	if d <= 0 {
+		return s.c.Call(ctx, "p2p.ban", nil, pubhash.String())
+	}
+	return s.c.Call(ctx, "p2p.ban", nil, pubhash.String(), uint32(d/time.Second))
+}
+
+// Unban removes the ban of the peer
+func (s *P2PClient) Unban(ctx context.Context, pubhash common.PublicHash) error {
+	return s.c.Call(ctx, "p2p.unban", nil, pubhash.String())
+}
+
+// Whitelist returns whitelisted peers
+func (s *P2PClient) Whitelist(ctx context.Context) ([]common.PublicHash, error) {
+	list := []common.PublicHash{}
+	if err := s.c.Call(ctx, "p2p.whitelist", &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// AddWhitelist adds the peer to the whitelist
+func (s *P2PClient) AddWhitelist(ctx context.Context, pubhash common.PublicHash) error {
+	return s.c.Call(ctx, "p2p.whitelist", nil, pubhash.String())
+}
+
+// RemoveWhitelist removes the peer from the whitelist
+func (s *P2PClient) RemoveWhitelist(ctx context.Context, pubhash common.PublicHash) error {
+	return s.c.Call(ctx, "p2p.unwhitelist", nil, pubhash.String())
+}
+
+// Bandwidth returns the bandwidth config and traffic counters of the node and its peers
+func (s *P2PClient) Bandwidth(ctx context.Context) (*p2p.BandwidthInfo, error) {
+	var info p2p.BandwidthInfo
+	if err := s.c.Call(ctx, "p2p.bandwidth", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ForkClient calls methods of the fork namespace
+type ForkClient struct {
+	c *Client
+}
+
+// Evidences returns evidences of forks that are detected
+func (s *ForkClient) Evidences(ctx context.Context) ([]*p2p.ForkEvidence, error) {
+	list := []*p2p.ForkEvidence{}
+	if err := s.c.Call(ctx, "fork.evidences", &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Bans returns peers that are banned by fork evidences
+func (s *ForkClient) Bans(ctx context.Context) ([]*p2p.BanResult, error) {
+	list := []*p2p.BanResult{}
+	if err := s.c.Call(ctx, "fork.bans", &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,57 @@
+package apiclient
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// RetryPolicy decides retries of failed calls
+// Only failures that the node surely did not process are retried, so sending transactions is not duplicated
+type RetryPolicy struct {
+	MaxAttempts int           // the number of attempts including the first call
+	Backoff     time.Duration // the delay after the first failure, it is doubled at each failure
+	MaxBackoff  time.Duration // the maximum delay, zero means unlimited
+}
+
+// DefaultRetryPolicy returns the policy that tries three times from 200ms to 2s
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     200 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+	}
+}
+
+// Delay returns the delay after the failed attempt
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// IsRetryable returns true when the request is rejected by the rate limiter or is not sent because of the connection failure
+func (p *RetryPolicy) IsRetryable(err error) bool {
+	switch e := err.(type) {
+	case *apiserver.JRPCError:
+		return e.Code == apiserver.CodeRateLimited
+	case *HTTPStatusError:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusBadGateway || e.StatusCode == http.StatusServiceUnavailable
+	case *url.Error:
+		return p.IsRetryable(e.Err)
+	case *net.OpError:
+		return e.Op == "dial"
+	}
+	return false
+}
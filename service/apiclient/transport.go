@@ -0,0 +1,152 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// Transport sends the jrpc request to the node and returns the result of the response
+// The error of the response is returned as *apiserver.JRPCError
+type Transport interface {
+	Call(ctx context.Context, Method string, Params []interface{}) (json.RawMessage, error)
+	Close() error
+}
+
+// response is the jrpc response that keeps the result as raw json
+type response struct {
+	JSONRPC string               `json:"jsonrpc"`
+	ID      json.RawMessage      `json:"id"`
+	Result  json.RawMessage      `json:"result"`
+	Error   *apiserver.JRPCError `json:"error"`
+}
+
+// HTTPStatusError is returned when the node responds the http error without the jrpc response
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error returns the status and the body
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("http status %d: %s", e.StatusCode, e.Body)
+}
+
+// HTTPTransport sends requests to the http endpoint of the node
+type HTTPTransport struct {
+	sync.Mutex
+	url    string
+	client *http.Client
+	header http.Header
+	id     uint64
+}
+
+// NewHTTPTransport returns a HTTPTransport, hostURL is like http://localhost:48000
+func NewHTTPTransport(hostURL string) *HTTPTransport {
+	t := &HTTPTransport{
+		url:    strings.TrimRight(hostURL, "/") + "/api/endpoints/http",
+		client: http.DefaultClient,
+		header: http.Header{},
+	}
+	return t
+}
+
+// SetHTTPClient sets the http client that sends requests
+func (t *HTTPTransport) SetHTTPClient(client *http.Client) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.client = client
+}
+
+// SetHeader sets the header that is added to every request
+func (t *HTTPTransport) SetHeader(key string, value string) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.header.Set(key, value)
+}
+
+// SetAPIKey sets the api key of requests
+func (t *HTTPTransport) SetAPIKey(key string) {
+	t.SetHeader("X-API-Key", key)
+}
+
+// SetToken sets the bearer token of requests
+func (t *HTTPTransport) SetToken(token string) {
+	t.SetHeader("Authorization", "Bearer "+token)
+}
+
+// Call sends the request by the http post
+func (t *HTTPTransport) Call(ctx context.Context, Method string, Params []interface{}) (json.RawMessage, error) {
+	bs, err := marshalRequest(atomic.AddUint64(&t.id, 1), Method, Params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	t.Lock()
+	client := t.client
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	t.Unlock()
+	req.Header.Set("Content-Type", "application/json")
+
+	r, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	var res response
+	if err := json.Unmarshal(data, &res); err != nil || (res.Result == nil && res.Error == nil) {
+		if r.StatusCode != http.StatusOK {
+			return nil, &HTTPStatusError{
+				StatusCode: r.StatusCode,
+				Body:       string(data),
+			}
+		}
+		return nil, ErrInvalidResponse
+	}
+	return res.result()
+}
+
+// Close does nothing, http connections are managed by the http client
+func (t *HTTPTransport) Close() error {
+	return nil
+}
+
+func marshalRequest(id uint64, Method string, Params []interface{}) ([]byte, error) {
+	if Params == nil {
+		Params = []interface{}{}
+	}
+	return json.Marshal(&apiserver.JRPCRequest{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  Method,
+		Params:  Params,
+	})
+}
+
+func (res *response) result() (json.RawMessage, error) {
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return res.Result, nil
+}
@@ -0,0 +1,60 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/service/txapi"
+)
+
+// TxClient calls methods of the tx namespace
+type TxClient struct {
+	c *Client
+}
+
+// Send encodes the signed transaction of the type and submits it, it returns the hash of the transaction
+func (s *TxClient) Send(ctx context.Context, t uint16, tx types.Transaction, sigs []common.Signature) (hash.Hash256, error) {
+	if len(sigs) == 0 {
+		return hash.Hash256{}, ErrUnsignedTransaction
+	}
+	bs, err := txapi.EncodeRawTransaction(t, tx, sigs)
+	if err != nil {
+		return hash.Hash256{}, err
+	}
+	return s.SendRaw(ctx, bs)
+}
+
+// SendRaw submits the signed raw transaction, it returns the hash of the transaction
+func (s *TxClient) SendRaw(ctx context.Context, raw []byte) (hash.Hash256, error) {
+	var TxHash hash.Hash256
+	if err := s.c.Call(ctx, "tx.send", &TxHash, hex.EncodeToString(raw)); err != nil {
+		return hash.Hash256{}, err
+	}
+	return TxHash, nil
+}
+
+// Decode returns the json object of the raw transaction with the type name, the hash and signatures
+func (s *TxClient) Decode(ctx context.Context, raw []byte) (map[string]interface{}, error) {
+	mp := map[string]interface{}{}
+	if err := s.c.Call(ctx, "tx.decode", &mp, hex.EncodeToString(raw)); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// Encode returns the unsigned raw transaction of the type name(vault.Transfer) from fields that are marshaled to the json object
+func (s *TxClient) Encode(ctx context.Context, TypeName string, fields interface{}) (*txapi.EncodeResult, error) {
+	bs, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var result txapi.EncodeResult
+	if err := s.c.Call(ctx, "tx.encode", &result, TypeName, string(bs)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,52 @@
+package apiclient
+
+import (
+	"context"
+
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/service/txpoolapi"
+)
+
+// TxPoolClient calls methods of the txpool namespace
+type TxPoolClient struct {
+	c *Client
+}
+
+// Status returns the number of pending transactions
+func (s *TxPoolClient) Status(ctx context.Context) (*txpoolapi.Status, error) {
+	var st txpoolapi.Status
+	if err := s.c.Call(ctx, "txpool.status", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Content returns pending transactions that are matched to the filter in the order of the arrival, nil filter matches all transactions
+func (s *TxPoolClient) Content(ctx context.Context, filter *txpoolapi.Filter) (*txpoolapi.Content, error) {
+	if filter == nil {
+		filter = &txpoolapi.Filter{}
+	}
+	var content txpoolapi.Content
+	if err := s.c.Call(ctx, "txpool.content", &content, filter); err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// Get returns the pending transaction of the hash
+func (s *TxPoolClient) Get(ctx context.Context, TxHash hash.Hash256) (*txpoolapi.Transaction, error) {
+	var tx txpoolapi.Transaction
+	if err := s.c.Call(ctx, "txpool.get", &tx, TxHash.String()); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Remove removes the pending transaction and following transactions of the same sender, it returns hashes of removed transactions
+func (s *TxPoolClient) Remove(ctx context.Context, TxHash hash.Hash256) ([]hash.Hash256, error) {
+	list := []hash.Hash256{}
+	if err := s.c.Call(ctx, "txpool.remove", &list, TxHash.String()); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,31 @@
+package apiclient
+
+import (
+	"context"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+)
+
+// VaultClient calls methods of the vault namespace
+type VaultClient struct {
+	c *Client
+}
+
+// Balance returns the balance of the address
+func (s *VaultClient) Balance(ctx context.Context, addr common.Address) (*amount.Amount, error) {
+	am := amount.NewCoinAmount(0, 0)
+	if err := s.c.Call(ctx, "vault.balance", am, addr.String()); err != nil {
+		return nil, err
+	}
+	return am, nil
+}
+
+// CollectedFee returns the collected fee that is not distributed yet
+func (s *VaultClient) CollectedFee(ctx context.Context) (*amount.Amount, error) {
+	am := amount.NewCoinAmount(0, 0)
+	if err := s.c.Call(ctx, "vault.collectedFee", am); err != nil {
+		return nil, err
+	}
+	return am, nil
+}
@@ -0,0 +1,266 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WriteTimeout is the timeout of writing a message to the websocket
+const WriteTimeout = 10 * time.Second
+
+// WebsocketTransport sends requests through a websocket connection and matches responses by the id
+// The connection is dialed at the first request and dialed again after it is closed
+type WebsocketTransport struct {
+	sync.Mutex
+	url       string
+	header    http.Header
+	conn      *websocket.Conn
+	pendings  map[uint64]chan *response
+	id        uint64
+	writeLock sync.Mutex
+	isClose   bool
+}
+
+// NewWebsocketTransport returns a WebsocketTransport, hostURL is like http://localhost:48000 or ws://localhost:48000
+func NewWebsocketTransport(hostURL string) (*WebsocketTransport, error) {
+	u, err := websocketURL(hostURL, "/api/endpoints/websocket")
+	if err != nil {
+		return nil, err
+	}
+	t := &WebsocketTransport{
+		url:      u,
+		header:   http.Header{},
+		pendings: map[uint64]chan *response{},
+	}
+	return t, nil
+}
+
+// SetHeader sets the header of the handshake, it is applied from the next connection
+func (t *WebsocketTransport) SetHeader(key string, value string) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.header.Set(key, value)
+}
+
+// SetAPIKey sets the api key of the handshake
+func (t *WebsocketTransport) SetAPIKey(key string) {
+	t.SetHeader("X-API-Key", key)
+}
+
+// SetToken sets the bearer token of the handshake
+func (t *WebsocketTransport) SetToken(token string) {
+	t.SetHeader("Authorization", "Bearer "+token)
+}
+
+// Call sends the request and waits the response of the same id
+func (t *WebsocketTransport) Call(ctx context.Context, Method string, Params []interface{}) (json.RawMessage, error) {
+	conn, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Lock()
+	t.id++
+	id := t.id
+	ch := make(chan *response, 1)
+	t.pendings[id] = ch
+	t.Unlock()
+
+	defer func() {
+		t.Lock()
+		delete(t.pendings, id)
+		t.Unlock()
+	}()
+
+	bs, err := marshalRequest(id, Method, Params)
+	if err != nil {
+		return nil, err
+	}
+	t.writeLock.Lock()
+	if err := conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		t.writeLock.Unlock()
+		return nil, err
+	}
+	err = conn.WriteMessage(websocket.TextMessage, bs)
+	t.writeLock.Unlock()
+	if err != nil {
+		t.closeConn(conn)
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res == nil {
+			return nil, ErrConnectionClosed
+		}
+		return res.result()
+	}
+}
+
+// Close closes the connection, requests are failed after it is closed
+func (t *WebsocketTransport) Close() error {
+	t.Lock()
+	t.isClose = true
+	conn := t.conn
+	t.Unlock()
+
+	if conn != nil {
+		t.closeConn(conn)
+	}
+	return nil
+}
+
+func (t *WebsocketTransport) connect(ctx context.Context) (*websocket.Conn, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.isClose {
+		return nil, ErrClosedTransport
+	}
+	if t.conn != nil {
+		return t.conn, nil
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, t.url, t.header)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(MaxResponseSize)
+	t.conn = conn
+	go t.readLoop(conn)
+	return conn, nil
+}
+
+func (t *WebsocketTransport) readLoop(conn *websocket.Conn) {
+	defer t.closeConn(conn)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var res response
+		if err := json.Unmarshal(data, &res); err != nil {
+			continue
+		}
+		id, err := strconv.ParseUint(string(res.ID), 10, 64)
+		if err != nil {
+			continue
+		}
+		t.Lock()
+		ch, has := t.pendings[id]
+		delete(t.pendings, id)
+		t.Unlock()
+		if has {
+			ch <- &res
+		}
+	}
+}
+
+// closeConn closes the connection and fails requests that wait responses from it
+func (t *WebsocketTransport) closeConn(conn *websocket.Conn) {
+	conn.Close()
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.conn != conn {
+		return
+	}
+	t.conn = nil
+	for id, ch := range t.pendings {
+		close(ch)
+		delete(t.pendings, id)
+	}
+}
+
+// EventMessage is the event that is pushed by the node, Data is decoded by the subscriber
+type EventMessage struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Subscription receives events of the topic from the node until it is closed
+type Subscription struct {
+	conn *websocket.Conn
+	ch   chan *EventMessage
+	err  error
+}
+
+// Subscribe connects to the event stream of the topic, the empty topic receives all events
+func Subscribe(ctx context.Context, hostURL string, Topic string, header http.Header) (*Subscription, error) {
+	u, err := websocketURL(hostURL, "/api/endpoints/websocket")
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Set("type", "event")
+	q.Set("topic", Topic)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u+"?"+q.Encode(), header)
+	if err != nil {
+		return nil, err
+	}
+	sub := &Subscription{
+		conn: conn,
+		ch:   make(chan *EventMessage, 100),
+	}
+	go sub.readLoop()
+	return sub, nil
+}
+
+// Events returns the channel of events, it is closed when the subscription is closed
+func (sub *Subscription) Events() <-chan *EventMessage {
+	return sub.ch
+}
+
+// Err returns the error that closes the subscription, it is valid after the channel is closed
+func (sub *Subscription) Err() error {
+	return sub.err
+}
+
+// Close closes the subscription
+func (sub *Subscription) Close() error {
+	return sub.conn.Close()
+}
+
+func (sub *Subscription) readLoop() {
+	defer close(sub.ch)
+	defer sub.conn.Close()
+
+	for {
+		var m EventMessage
+		if err := sub.conn.ReadJSON(&m); err != nil {
+			sub.err = err
+			return
+		}
+		sub.ch <- &m
+	}
+}
+
+// websocketURL returns the websocket url of the path, http and https schemes are changed to ws and wss
+func websocketURL(hostURL string, path string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(hostURL, "/"))
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", ErrInvalidURL
+	}
+	u.Path += path
+	return u.String(), nil
+}
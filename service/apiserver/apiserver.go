@@ -11,15 +11,28 @@ import (
 type APIServer struct {
 	types.ServiceBase
 	sync.Mutex
-	e      *echo.Echo
-	subMap map[string]*JRPCSub
+	e             *echo.Echo
+	subMap        map[string]*JRPCSub
+	routes        []*RESTRoute
+	handlers      []*HTTPRoute
+	events        *eventHub
+	cfg           *Config
+	limiter       *rateLimiter
+	reqCh         chan *ReqData
+	workerOnce    sync.Once
+	requiredRoles map[string][]string
 }
 
 // NewAPIServer returns a APIServer
 func NewAPIServer() *APIServer {
 	s := &APIServer{
-		e:      echo.New(),
-		subMap: map[string]*JRPCSub{},
+		e:             echo.New(),
+		subMap:        map[string]*JRPCSub{},
+		events:        newEventHub(),
+		cfg:           DefaultConfig(),
+		limiter:       newRateLimiter(0, 0),
+		reqCh:         make(chan *ReqData),
+		requiredRoles: map[string][]string{},
 	}
 	return s
 }
@@ -3,8 +3,11 @@ package apiserver
 import (
 	"bytes"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,97 +15,134 @@ import (
 	"github.com/labstack/echo/middleware"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
 type ReqData struct {
 	req   *jRPCRequest
 	resCh *chan *JRPCResponse
+	ctx   *requestContext
 }
 
 // Run starts web service of the apiserver
 func (s *APIServer) Run(BindAddress string) error {
-	reqCh := make(chan *ReqData)
+	return s.serve(s.e, BindAddress, false)
+}
 
-	s.e.Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
-	s.e.POST("/api/endpoints/http", func(c echo.Context) error {
-		defer c.Request().Body.Close()
-		dec := json.NewDecoder(c.Request().Body)
-		dec.UseNumber()
+// RunPrivate starts web service of the apiserver that also serves private namespaces
+// It should be bound to the local address like 127.0.0.1:48001
+func (s *APIServer) RunPrivate(BindAddress string) error {
+	return s.serve(echo.New(), BindAddress, true)
+}
 
-		var req jRPCRequest
-		if err := dec.Decode(&req); err != nil {
+func (s *APIServer) serve(e *echo.Echo, BindAddress string, private bool) error {
+	cfg := s.Config()
+	reqCh := s.requestChannel()
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return cfg.isAllowedOrigin(r.Header.Get("Origin"))
+		},
+	}
+	if len(cfg.AllowOrigins) > 0 {
+		corsConfig := middleware.DefaultCORSConfig
+		corsConfig.AllowOrigins = cfg.AllowOrigins
+		e.Use(middleware.CORSWithConfig(corsConfig))
+	}
+	e.Use(s.authMiddleware(private))
+	e.POST("/api/endpoints/http", func(c echo.Context) error {
+		defer c.Request().Body.Close()
+		data, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, MaxMessageSize+1))
+		if err != nil {
 			return err
 		}
-		resCh := make(chan *JRPCResponse)
-		reqCh <- &ReqData{
-			req:   &req,
-			resCh: &resCh,
-		}
-		/*
-			res := s.handleJRPC(&req)
-		*/
-		res := <-resCh
-		if res == nil {
+		ret := s.handleMessage(data, reqCh, c.Get(requestContextKey).(*requestContext), true)
+		if ret == nil {
 			return c.NoContent(http.StatusOK)
 		} else {
-			return c.JSON(http.StatusOK, res)
+			return c.JSON(http.StatusOK, ret)
 		}
 	})
-	s.e.GET("/api/endpoints/websocket", func(c echo.Context) error {
+	e.GET("/api/endpoints/websocket", func(c echo.Context) error {
+		ctx := c.Get(requestContextKey).(*requestContext)
+		Type := strings.ToLower(c.QueryParam("type"))
+		if Type == "event" {
+			if err := s.checkAccess(ctx, "events", "subscribe"); err != nil {
+				return authError(c, err)
+			}
+		}
 		conn, err := upgrader.Upgrade(c.Response().Writer, c.Request(), nil)
 		if err != nil {
 			return err
 		}
 		defer conn.Close()
 
-		Type := strings.ToLower(c.QueryParam("type"))
 		switch Type {
+		case "event":
+			ch := s.events.subscribe(c.QueryParam("topic"))
+			defer s.events.unsubscribe(ch)
+
+			closeChan := make(chan struct{})
+			go func() {
+				defer close(closeChan)
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+			for {
+				select {
+				case <-closeChan:
+					return nil
+				case m := <-ch:
+					if err := conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+						return err
+					}
+					if err := conn.WriteJSON(m); err != nil {
+						return err
+					}
+				}
+			}
 		default:
 			for {
 				_, data, err := conn.ReadMessage()
 				if err != nil {
 					return err
 				}
-				dec := json.NewDecoder(bytes.NewReader(data))
-				dec.UseNumber()
-
-				var req jRPCRequest
-				if err := dec.Decode(&req); err != nil {
-					return err
-				}
-				resCh := make(chan *JRPCResponse)
-				reqCh <- &ReqData{
-					req:   &req,
-					resCh: &resCh,
-				}
-				/*
-					res := s.handleJRPC(&req)
-				*/
-				res := <-resCh
-				if res != nil {
+				ret := s.handleMessage(data, reqCh, ctx, false)
+				if ret != nil {
 					if err := conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
 						return err
 					}
-					if err := conn.WriteJSON(res); err != nil {
+					if err := conn.WriteJSON(ret); err != nil {
 						return err
 					}
 				}
 			}
 		}
 	})
-	for i := 0; i < 50; i++ {
-		go func() {
-			for r := range reqCh {
-				res := s.handleJRPC(r.req)
-				(*r.resCh) <- res
-			}
-		}()
+	for _, route := range s.Routes() {
+		e.Add(route.Method, route.Path, s.restHandler(route, reqCh))
+	}
+	for _, route := range s.HTTPRoutes() {
+		e.Add(route.Method, route.Path, s.httpHandler(route))
 	}
-	return s.e.Start(BindAddress)
+	e.GET("/v1/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, s.OpenAPI(private))
+	})
+	return e.Start(BindAddress)
+}
+
+// requestChannel returns the channel of json rpc workers, workers are started at the first call
+func (s *APIServer) requestChannel() chan<- *ReqData {
+	s.workerOnce.Do(func() {
+		for i := 0; i < 50; i++ {
+			go func() {
+				for r := range s.reqCh {
+					res := s.handleJRPC(r.req, r.ctx)
+					(*r.resCh) <- res
+				}
+			}()
+		}
+	})
+	return s.reqCh
 }
 
 // JRPC provides the json rpc feature as a SubName.FunctionName methods
@@ -118,66 +158,137 @@ func (s *APIServer) JRPC(SubName string) (*JRPCSub, error) {
 	return js, nil //TEMP
 }
 
-func (s *APIServer) handleJRPC(req *jRPCRequest) *JRPCResponse {
-	ls := strings.SplitN(req.Method, ".", 2)
-	if len(ls) != 2 {
-		res := &JRPCResponse{
-			JSONRPC: req.JSONRPC,
-			ID:      req.ID,
-			Error:   ErrInvalidMethod.Error(),
+// handleMessage handles the request or the batch of requests, it returns nil when there is nothing to respond
+// The first request is not limited when it is already charged by the middleware
+func (s *APIServer) handleMessage(data []byte, reqCh chan<- *ReqData, ctx *requestContext, charged bool) interface{} {
+	data = bytes.TrimSpace(data)
+	if len(data) > MaxMessageSize {
+		return newErrorResponse(nil, ErrTooLargeMessage)
+	}
+	if len(data) == 0 || data[0] != '[' {
+		if res := s.handleRequest(data, reqCh, ctx, !charged); res != nil {
+			return res
+		}
+		return nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return newErrorResponse(nil, &JRPCError{Code: CodeParseError, Message: err.Error()})
+	}
+	if len(list) == 0 {
+		return newErrorResponse(nil, ErrInvalidRequest)
+	}
+	if len(list) > MaxBatchSize {
+		return newErrorResponse(nil, ErrTooLargeBatch)
+	}
+	results := make([]*JRPCResponse, len(list))
+	var wg sync.WaitGroup
+	for i, item := range list {
+		wg.Add(1)
+		go func(i int, item json.RawMessage) {
+			defer wg.Done()
+			results[i] = s.handleRequest(item, reqCh, ctx, i > 0 || !charged)
+		}(i, item)
+	}
+	wg.Wait()
+	responses := []*JRPCResponse{}
+	for _, res := range results {
+		if res != nil {
+			responses = append(responses, res)
 		}
-		return res
 	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
 
-	args := []*string{}
-	for _, v := range req.Params {
-		if v == nil {
-			args = append(args, nil)
-		} else {
-			args = append(args, (*string)(v))
+// handleRequest parses the request and sends it to json rpc workers
+func (s *APIServer) handleRequest(data []byte, reqCh chan<- *ReqData, ctx *requestContext, limit bool) *JRPCResponse {
+	var req jRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		if !json.Valid(data) {
+			return newErrorResponse(nil, &JRPCError{Code: CodeParseError, Message: err.Error()})
+		}
+		return newErrorResponse(nil, ErrInvalidRequest)
+	}
+	if len(req.Method) == 0 || (len(req.JSONRPC) > 0 && req.JSONRPC != "2.0") {
+		return newErrorResponse(req.ID, ErrInvalidRequest)
+	}
+	if limit && !s.allow(ctx) {
+		if req.isNotification() {
+			return nil
 		}
+		return newErrorResponse(req.ID, ErrRateLimited)
+	}
+	resCh := make(chan *JRPCResponse)
+	reqCh <- &ReqData{
+		req:   &req,
+		resCh: &resCh,
+		ctx:   ctx,
 	}
+	return <-resCh
+}
+
+func (s *APIServer) handleJRPC(req *jRPCRequest, ctx *requestContext) *JRPCResponse {
+	res, err := s.callJRPC(req, ctx)
+	if req.isNotification() {
+		return nil
+	}
+	if err != nil {
+		return newErrorResponse(req.ID, err)
+	}
+	return res
+}
+
+func (s *APIServer) callJRPC(req *jRPCRequest, ctx *requestContext) (*JRPCResponse, error) {
+	ls := strings.SplitN(req.Method, ".", 2)
+	if len(ls) != 2 {
+		return nil, ErrInvalidMethod
+	}
+	if err := s.checkAccess(ctx, ls[0], ls[1]); err != nil {
+		return nil, err
+	}
+	params, err := req.params()
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
 	s.Lock()
 	sub, has := s.subMap[ls[0]]
 	s.Unlock()
 	if !has {
-		res := &JRPCResponse{
-			JSONRPC: req.JSONRPC,
-			ID:      req.ID,
-			Error:   ErrInvalidMethod.Error(),
-		}
-		return res
+		return nil, ErrInvalidMethod
 	}
 
 	sub.Lock()
 	fn, has := sub.funcMap[ls[1]]
 	sub.Unlock()
 	if !has {
-		if req.ID == nil {
-			return nil
-		} else {
-			res := &JRPCResponse{
-				JSONRPC: req.JSONRPC,
-				ID:      req.ID,
-				Error:   ErrInvalidMethod.Error(),
-			}
-			return res
-		}
+		return nil, ErrInvalidMethod
 	}
 
-	ret, err := fn(req.ID, NewArgument(args))
-	if req.ID == nil {
-		return nil
-	} else {
-		res := &JRPCResponse{
-			JSONRPC: req.JSONRPC,
-			ID:      req.ID,
-		}
-		if err != nil {
-			res.Error = err.Error()
-		} else {
-			res.Result = ret
-		}
-		return res
+	ret, err := fn(req.ID, newJSONArgument(params))
+	if err != nil {
+		return nil, err
+	}
+	res := &JRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.responseID(),
+		Result:  ret,
+	}
+	return res, nil
+}
+
+// newErrorResponse returns the response of the error, the id is null when it is unknown
+func newErrorResponse(ID json.RawMessage, err error) *JRPCResponse {
+	if len(ID) == 0 {
+		ID = json.RawMessage("null")
+	}
+	res := &JRPCResponse{
+		JSONRPC: "2.0",
+		ID:      ID,
+		Error:   NewJRPCError(err),
 	}
+	return res
 }
@@ -1,12 +1,18 @@
 package apiserver
 
 import (
+	"encoding/json"
 	"strconv"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/common/hash"
 )
 
 // Argument parses rpc arguments
 type Argument struct {
 	args []*string
+	raws []json.RawMessage
 }
 
 // NewArgument returns a Argument
@@ -17,6 +23,19 @@ func NewArgument(args []*string) *Argument {
 	return arg
 }
 
+// newJSONArgument returns a Argument of json params, strings are unquoted and others are kept as the json text
+func newJSONArgument(params []json.RawMessage) *Argument {
+	args := make([]*string, 0, len(params))
+	for _, v := range params {
+		args = append(args, paramString(v))
+	}
+	arg := &Argument{
+		args: args,
+		raws: params,
+	}
+	return arg
+}
+
 // Len returns length of arguments
 func (arg *Argument) Len() int {
 	return len(arg.args)
@@ -145,3 +164,98 @@ func (arg *Argument) String(index int) (string, error) {
 	}
 	return (*a), nil
 }
+
+// Bool returns a bool value of the index
+func (arg *Argument) Bool(index int) (bool, error) {
+	if index < 0 || index >= len(arg.args) {
+		return false, ErrInvalidArgumentIndex
+	}
+	a := arg.args[index]
+	if a == nil {
+		return false, ErrInvalidArgumentType
+	}
+	return strconv.ParseBool((*a))
+}
+
+// Address returns a address value of the index
+func (arg *Argument) Address(index int) (common.Address, error) {
+	str, err := arg.String(index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.ParseAddress(str)
+}
+
+// Amount returns a amount value of the index, the amount can be a string or a number of the coin unit
+func (arg *Argument) Amount(index int) (*amount.Amount, error) {
+	str, err := arg.String(index)
+	if err != nil {
+		return nil, err
+	}
+	return amount.ParseAmount(str)
+}
+
+// Hash returns a hash value of the index
+func (arg *Argument) Hash(index int) (hash.Hash256, error) {
+	str, err := arg.String(index)
+	if err != nil {
+		return hash.Hash256{}, err
+	}
+	return hash.ParseHash(str)
+}
+
+// Raw returns the json text of the index
+func (arg *Argument) Raw(index int) (json.RawMessage, error) {
+	if index < 0 || index >= len(arg.args) {
+		return nil, ErrInvalidArgumentIndex
+	}
+	if index < len(arg.raws) {
+		return arg.raws[index], nil
+	}
+	a := arg.args[index]
+	if a == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal((*a))
+}
+
+// Decode unmarshals the json param of the index to v
+func (arg *Argument) Decode(index int, v interface{}) error {
+	bs, err := arg.Raw(index)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, v)
+}
+
+// Object returns a object value of the index
+func (arg *Argument) Object(index int) (map[string]interface{}, error) {
+	bs, err := arg.Raw(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) == 0 || bs[0] != '{' {
+		return nil, ErrInvalidArgumentType
+	}
+	var mp map[string]interface{}
+	if err := json.Unmarshal(bs, &mp); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// Array returns a array value of the index
+func (arg *Argument) Array(index int) ([]interface{}, error) {
+	bs, err := arg.Raw(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) == 0 || bs[0] != '[' {
+		return nil, ErrInvalidArgumentType
+	}
+	var list []interface{}
+	if err := json.Unmarshal(bs, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,132 @@
+package apiserver
+
+import (
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+const requestContextKey = "fleta.apiserver.request"
+
+// requestContext is the authenticated client of the request
+type requestContext struct {
+	role    string
+	client  string
+	private bool
+}
+
+// authenticate returns the context of the request from the api key or the bearer token
+// The token can be given by the token query param for websocket clients that cannot set headers
+func (s *APIServer) authenticate(c echo.Context, cfg *Config, private bool) (*requestContext, error) {
+	token := c.Request().Header.Get("X-API-Key")
+	if len(token) == 0 {
+		auth := c.Request().Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimSpace(auth[len("Bearer "):])
+		} else if len(auth) > 0 {
+			return nil, ErrInvalidToken
+		}
+	}
+	if len(token) == 0 {
+		token = c.QueryParam("token")
+	}
+	if len(token) == 0 {
+		return &requestContext{
+			role:    RoleAnonymous,
+			client:  "ip:" + cfg.clientIP(c.Request()),
+			private: private,
+		}, nil
+	}
+	if role, has := cfg.APIKeys[token]; has {
+		return &requestContext{
+			role:    role,
+			client:  "key:" + token,
+			private: private,
+		}, nil
+	}
+	if len(cfg.JWTSecret) == 0 {
+		return nil, ErrInvalidToken
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrInvalidToken
+		}
+		return []byte(cfg.JWTSecret), nil
+	}); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if _, has := claims["exp"]; !has {
+		return nil, ErrInvalidToken
+	}
+	role, _ := claims["role"].(string)
+	if len(role) == 0 {
+		return nil, ErrInvalidToken
+	}
+	client := "jwt:" + token
+	if sub, is := claims["sub"].(string); is && len(sub) > 0 {
+		client = "jwt:" + sub
+	}
+	return &requestContext{
+		role:    role,
+		client:  client,
+		private: private,
+	}, nil
+}
+
+// authMiddleware authenticates the request and limits the rate of the client
+func (s *APIServer) authMiddleware(private bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cfg := s.Config()
+			ctx, err := s.authenticate(c, cfg, private)
+			if err != nil {
+				// the failed request is charged to the ip of the client, so invalid tokens cannot be tried without the limit
+				if !s.allow(&requestContext{client: "ip:" + cfg.clientIP(c.Request())}) {
+					return authError(c, ErrRateLimited)
+				}
+				return authError(c, err)
+			}
+			if !s.allow(ctx) {
+				return authError(c, ErrRateLimited)
+			}
+			c.Set(requestContextKey, ctx)
+			return next(c)
+		}
+	}
+}
+
+// authError responds the error as the jrpc response to jrpc endpoints and as the rest error to others
+func authError(c echo.Context, err error) error {
+	res := newErrorResponse(nil, err)
+	if strings.HasPrefix(c.Path(), "/api/endpoints/") {
+		return c.JSON(httpStatus(res.Error.Code), res)
+	}
+	return restError(c, res.Error)
+}
+
+// allow returns the client can send a request or not
+func (s *APIServer) allow(ctx *requestContext) bool {
+	s.Lock()
+	l := s.limiter
+	s.Unlock()
+
+	return l.Allow(ctx.client)
+}
+
+// checkAccess returns the error when the method is not allowed to the request
+func (s *APIServer) checkAccess(ctx *requestContext, Namespace string, Method string) error {
+	s.Lock()
+	cfg := s.cfg
+	allowed := cfg.isAllowed(Namespace, Method, ctx.role, s.requiredRoles)
+	s.Unlock()
+
+	if !ctx.private && cfg.isPrivate(Namespace) {
+		return ErrInvalidMethod
+	}
+	if !allowed {
+		return ErrPermissionDenied
+	}
+	return nil
+}
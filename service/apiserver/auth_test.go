@@ -0,0 +1,65 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+func TestAuthJWTExpiration(t *testing.T) {
+	s := NewAPIServer()
+	s.SetConfig(&Config{JWTSecret: "secret"})
+	cfg := s.Config()
+	authenticate := func(claims jwt.MapClaims) error {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/endpoints/jrpc", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, err = s.authenticate(echo.New().NewContext(req, httptest.NewRecorder()), cfg, false)
+		return err
+	}
+	if err := authenticate(jwt.MapClaims{"role": RoleAdmin}); err != ErrInvalidToken {
+		t.Fatalf("the token without the expiration is accepted: %v", err)
+	}
+	if err := authenticate(jwt.MapClaims{"role": RoleAdmin, "exp": time.Now().Add(-time.Minute).Unix()}); err != ErrInvalidToken {
+		t.Fatalf("the expired token is accepted: %v", err)
+	}
+	if err := authenticate(jwt.MapClaims{"role": RoleAdmin, "exp": time.Now().Add(time.Minute).Unix()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthRateLimitInvalidToken(t *testing.T) {
+	s := NewAPIServer()
+	s.SetConfig(&Config{RateLimit: 0.001, RateBurst: 2})
+	e := echo.New()
+	handler := s.authMiddleware(false)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	request := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/endpoints/jrpc", nil)
+		req.RemoteAddr = "1.2.3.4:5000"
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/api/endpoints/jrpc")
+		if err := handler(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code
+	}
+	for i := 0; i < 2; i++ {
+		if code := request("invalid"); code != http.StatusUnauthorized {
+			t.Fatalf("invalid status of the invalid key: %v", code)
+		}
+	}
+	if code := request("other"); code != http.StatusTooManyRequests {
+		t.Fatalf("invalid keys are not rate limited: %v", code)
+	}
+}
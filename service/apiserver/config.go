@@ -0,0 +1,179 @@
+package apiserver
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// roles of the acl
+const (
+	RoleAnonymous = "anonymous"
+	RoleAdmin     = "admin"
+	RoleAny       = "*"
+)
+
+// Config is the access control configuration of the apiserver
+// ACL maps a namespace(bank) or a method(bank.send) to roles that are allowed to call it, the method rule is used prior to the namespace rule and "*" is the rule of all methods
+// A method that has no rule is allowed to all clients except methods of DefaultACL that require the admin role unless the ACL has their rules
+type Config struct {
+	APIKeys           map[string]string   // api key to the role
+	JWTSecret         string              // HS256 secret of bearer tokens, the role claim of the token is used as the role and the exp claim is required
+	ACL               map[string][]string // namespace or method to roles, RoleAny allows all clients
+	AllowOrigins      []string            // allowed origins of CORS and websocket, browsers of other origins are not allowed when it is empty
+	TrustedProxies    []string            // ips or cidrs of reverse proxies whose forwarded headers are used as the address of the client
+	PrivateNamespaces []string            // namespaces that are served only by the private listener
+	RateLimit         float64             // requests per second of a client, zero means unlimited
+	RateBurst         int                 // the bucket size of a client, RateLimit is used when it is zero
+}
+
+// DefaultACL returns rules of methods that manage keys of the node or change peers, they require the admin role
+func DefaultACL() map[string][]string {
+	return map[string][]string{
+		"bank":            {RoleAdmin},
+		"p2p.ban":         {RoleAdmin},
+		"p2p.unban":       {RoleAdmin},
+		"p2p.whitelist":   {RoleAdmin},
+		"p2p.unwhitelist": {RoleAdmin},
+	}
+}
+
+// DefaultConfig returns the config that serves methods except methods of DefaultACL without authentication and does not allow other origins
+func DefaultConfig() *Config {
+	return &Config{
+		APIKeys:      map[string]string{},
+		ACL:          DefaultACL(),
+		AllowOrigins: []string{},
+	}
+}
+
+// SetConfig sets the access control configuration, it should be called before Run
+func (s *APIServer) SetConfig(cfg *Config) {
+	s.Lock()
+	defer s.Unlock()
+
+	c := *cfg
+	if c.APIKeys == nil {
+		c.APIKeys = map[string]string{}
+	}
+	ACL := DefaultACL()
+	for k, v := range c.ACL {
+		ACL[k] = v
+	}
+	c.ACL = ACL
+	if c.AllowOrigins == nil {
+		c.AllowOrigins = []string{}
+	}
+	s.cfg = &c
+	s.limiter = newRateLimiter(c.RateLimit, c.RateBurst)
+}
+
+// Config returns the access control configuration
+func (s *APIServer) Config() *Config {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.cfg
+}
+
+// RequireRoles sets roles that are allowed to call the method(txpool.remove) when the config has no rule of the method
+func (s *APIServer) RequireRoles(Method string, roles ...string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.requiredRoles[Method] = roles
+}
+
+// isAllowed returns the role can call the method or not, required roles are used when the acl has no rule of the method
+func (cfg *Config) isAllowed(Namespace string, Method string, role string, required map[string][]string) bool {
+	roles, has := cfg.ACL[Namespace+"."+Method]
+	if !has {
+		roles, has = required[Namespace+"."+Method]
+	}
+	if !has {
+		roles, has = cfg.ACL[Namespace]
+	}
+	if !has {
+		roles, has = cfg.ACL[RoleAny]
+	}
+	if !has {
+		return true
+	}
+	for _, r := range roles {
+		if r == RoleAny || r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivate returns the namespace is served only by the private listener or not
+func (cfg *Config) isPrivate(Namespace string) bool {
+	for _, ns := range cfg.PrivateNamespaces {
+		if ns == Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the ip of the client, forwarded headers are used only when the request is from the trusted proxy
+// The nearest address of X-Forwarded-For that is not the trusted proxy is used because addresses before it can be given by the client
+func (cfg *Config) clientIP(r *http.Request) string {
+	IP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		IP = r.RemoteAddr
+	}
+	if !cfg.isTrustedProxy(IP) {
+		return IP
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); len(xff) > 0 {
+		ls := strings.Split(xff, ",")
+		for i := len(ls) - 1; i >= 0; i-- {
+			v := strings.TrimSpace(ls[i])
+			if net.ParseIP(v) == nil {
+				break
+			}
+			IP = v
+			if !cfg.isTrustedProxy(v) {
+				break
+			}
+		}
+		return IP
+	}
+	if v := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(v) != nil {
+		return v
+	}
+	return IP
+}
+
+// isTrustedProxy returns the ip is the trusted proxy or not
+func (cfg *Config) isTrustedProxy(IP string) bool {
+	ip := net.ParseIP(IP)
+	if ip == nil {
+		return false
+	}
+	for _, v := range cfg.TrustedProxies {
+		if _, ipnet, err := net.ParseCIDR(v); err == nil {
+			if ipnet.Contains(ip) {
+				return true
+			}
+		} else if p := net.ParseIP(v); p != nil && p.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowedOrigin returns the origin is allowed or not, requests without the origin are not from browsers so they are allowed
+func (cfg *Config) isAllowedOrigin(origin string) bool {
+	if len(origin) == 0 {
+		return true
+	}
+	for _, o := range cfg.AllowOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
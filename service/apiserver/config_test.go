@@ -0,0 +1,103 @@
+package apiserver
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConfigDefaultACL(t *testing.T) {
+	s := NewAPIServer()
+	anonymous := &requestContext{role: RoleAnonymous, client: "ip:1.2.3.4"}
+	admin := &requestContext{role: RoleAdmin, client: "key:admin"}
+	check := func(ctx *requestContext, Namespace string, Method string, expected error) {
+		if err := s.checkAccess(ctx, Namespace, Method); err != expected {
+			t.Fatalf("invalid access of %v to %v.%v: %v", ctx.role, Namespace, Method, err)
+		}
+	}
+	for _, cfg := range []*Config{DefaultConfig(), {}} {
+		s.SetConfig(cfg)
+		check(anonymous, "bank", "send", ErrPermissionDenied)
+		check(anonymous, "p2p", "ban", ErrPermissionDenied)
+		check(anonymous, "p2p", "unwhitelist", ErrPermissionDenied)
+		check(anonymous, "p2p", "peers", nil)
+		check(anonymous, "chain", "height", nil)
+		check(anonymous, "events", "subscribe", nil)
+		check(admin, "bank", "send", nil)
+		check(admin, "p2p", "ban", nil)
+	}
+
+	// the rule of the config is used prior to the default rule
+	s.SetConfig(&Config{
+		ACL: map[string][]string{
+			"*":                {RoleAdmin},
+			"p2p":              {RoleAny},
+			"p2p.ban":          {RoleAny},
+			"events.subscribe": {"reader"},
+		},
+	})
+	check(anonymous, "p2p", "ban", nil)
+	check(anonymous, "p2p", "unban", ErrPermissionDenied)
+	check(anonymous, "p2p", "peers", nil)
+	check(anonymous, "chain", "height", ErrPermissionDenied)
+	check(anonymous, "events", "subscribe", ErrPermissionDenied)
+	check(&requestContext{role: "reader"}, "events", "subscribe", nil)
+}
+
+func TestConfigAllowOrigins(t *testing.T) {
+	s := NewAPIServer()
+	s.SetConfig(&Config{})
+	cfg := s.Config()
+	if !cfg.isAllowedOrigin("") {
+		t.Fatal("the request without the origin is not allowed")
+	}
+	if cfg.isAllowedOrigin("http://example.com") {
+		t.Fatal("the origin is allowed by the empty list")
+	}
+
+	s.SetConfig(&Config{AllowOrigins: []string{"http://example.com"}})
+	cfg = s.Config()
+	if !cfg.isAllowedOrigin("http://example.com") {
+		t.Fatal("the allowed origin is not allowed")
+	}
+	if cfg.isAllowedOrigin("http://other.com") {
+		t.Fatal("the other origin is allowed")
+	}
+}
+
+func TestConfigClientIP(t *testing.T) {
+	request := func(RemoteAddr string, Forwarded string, RealIP string) *http.Request {
+		r := &http.Request{
+			RemoteAddr: RemoteAddr,
+			Header:     http.Header{},
+		}
+		if len(Forwarded) > 0 {
+			r.Header.Set("X-Forwarded-For", Forwarded)
+		}
+		if len(RealIP) > 0 {
+			r.Header.Set("X-Real-IP", RealIP)
+		}
+		return r
+	}
+
+	cfg := &Config{}
+	if IP := cfg.clientIP(request("1.2.3.4:5000", "5.6.7.8", "5.6.7.8")); IP != "1.2.3.4" {
+		t.Fatalf("forwarded headers are used without trusted proxies: %v", IP)
+	}
+
+	cfg = &Config{TrustedProxies: []string{"10.0.0.0/8", "192.168.0.1"}}
+	if IP := cfg.clientIP(request("1.2.3.4:5000", "5.6.7.8", "")); IP != "1.2.3.4" {
+		t.Fatalf("forwarded headers are used from the untrusted address: %v", IP)
+	}
+	if IP := cfg.clientIP(request("10.1.2.3:5000", "5.6.7.8", "")); IP != "5.6.7.8" {
+		t.Fatalf("invalid forwarded ip: %v", IP)
+	}
+	if IP := cfg.clientIP(request("192.168.0.1:5000", "9.9.9.9, 5.6.7.8, 10.0.0.2", "")); IP != "5.6.7.8" {
+		t.Fatalf("the spoofed forwarded ip is used: %v", IP)
+	}
+	if IP := cfg.clientIP(request("192.168.0.1:5000", "", "5.6.7.8")); IP != "5.6.7.8" {
+		t.Fatalf("invalid real ip: %v", IP)
+	}
+	if IP := cfg.clientIP(request("10.1.2.3:5000", "", "")); IP != "10.1.2.3" {
+		t.Fatalf("invalid ip without forwarded headers: %v", IP)
+	}
+}
@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// error codes of the jrpc error object, -32768 to -32000 are reserved by the specification
+const (
+	CodeParseError       = -32700
+	CodeInvalidRequest   = -32600
+	CodeMethodNotFound   = -32601
+	CodeInvalidParams    = -32602
+	CodeInternalError    = -32603
+	CodeServerError      = -32000
+	CodeUnauthorized     = -32001
+	CodePermissionDenied = -32002
+	CodeRateLimited      = -32003
+	CodeNotFound         = -32004
+	CodeRejected         = -32005
+)
+
+var errorCodeLock sync.Mutex
+var errorCodeMap = map[error]int{}
+
+func init() {
+	RegisterErrorCode(ErrInvalidRequest, CodeInvalidRequest)
+	RegisterErrorCode(ErrInvalidParams, CodeInvalidParams)
+	RegisterErrorCode(ErrInvalidArgument, CodeInvalidParams)
+	RegisterErrorCode(ErrInvalidArgumentIndex, CodeInvalidParams)
+	RegisterErrorCode(ErrInvalidArgumentType, CodeInvalidParams)
+	RegisterErrorCode(ErrInvalidMethod, CodeMethodNotFound)
+	RegisterErrorCode(ErrInvalidRESTParam, CodeInvalidParams)
+	RegisterErrorCode(ErrTooLargeRESTBody, CodeInvalidRequest)
+	RegisterErrorCode(ErrTooLargeMessage, CodeInvalidRequest)
+	RegisterErrorCode(ErrTooLargeBatch, CodeInvalidRequest)
+	RegisterErrorCode(ErrInvalidToken, CodeUnauthorized)
+	RegisterErrorCode(ErrPermissionDenied, CodePermissionDenied)
+	RegisterErrorCode(ErrRateLimited, CodeRateLimited)
+}
+
+// RegisterErrorCode maps the error variable to the code of the jrpc error object
+func RegisterErrorCode(err error, code int) {
+	errorCodeLock.Lock()
+	defer errorCodeLock.Unlock()
+
+	errorCodeMap[err] = code
+}
+
+// ErrorCode returns the code of the error, errors of parsing arguments are CodeInvalidParams and unregistered errors are CodeServerError
+func ErrorCode(err error) int {
+	switch e := err.(type) {
+	case *JRPCError:
+		return e.Code
+	case *strconv.NumError, *json.SyntaxError, *json.UnmarshalTypeError:
+		return CodeInvalidParams
+	}
+
+	errorCodeLock.Lock()
+	defer errorCodeLock.Unlock()
+
+	if code, has := errorCodeMap[err]; has {
+		return code
+	}
+	return CodeServerError
+}
+
+// NewJRPCError returns the jrpc error object of the error
+func NewJRPCError(err error) *JRPCError {
+	if e, is := err.(*JRPCError); is {
+		return e
+	}
+	return &JRPCError{
+		Code:    ErrorCode(err),
+		Message: err.Error(),
+	}
+}
+
+// httpStatus returns the http status of the rest response from the code
+func httpStatus(code int) int {
+	switch code {
+	case CodeInternalError:
+		return http.StatusInternalServerError
+	case CodeMethodNotFound, CodeNotFound:
+		return http.StatusNotFound
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodePermissionDenied:
+		return http.StatusForbidden
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
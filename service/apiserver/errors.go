@@ -11,4 +11,16 @@ var (
 	ErrInvalidArgumentType  = errors.New("invalid argument type")
 	ErrInvalidMethod        = errors.New("invalid method")
 	ErrExistSubName         = errors.New("exist sub name")
+	ErrExistRESTRoute       = errors.New("exist rest route")
+	ErrExistHTTPRoute       = errors.New("exist http route")
+	ErrInvalidRESTMethod    = errors.New("invalid rest method")
+	ErrInvalidRESTParam     = errors.New("invalid rest param")
+	ErrTooLargeRESTBody     = errors.New("too large rest body")
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrPermissionDenied     = errors.New("permission denied")
+	ErrRateLimited          = errors.New("rate limited")
+	ErrInvalidRequest       = errors.New("invalid request")
+	ErrInvalidParams        = errors.New("invalid params")
+	ErrTooLargeMessage      = errors.New("too large message")
+	ErrTooLargeBatch        = errors.New("too large batch")
 )
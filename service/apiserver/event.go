@@ -0,0 +1,63 @@
+package apiserver
+
+import (
+	"sync"
+)
+
+// EventMessage is a message that is pushed to the websocket subscribers
+type EventMessage struct {
+	Topic string      `json:"topic"`
+	Data  interface{} `json:"data"`
+}
+
+type eventHub struct {
+	sync.Mutex
+	subMap map[chan *EventMessage]string
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subMap: map[chan *EventMessage]string{},
+	}
+}
+
+// subscribe registers the channel for the topic, the empty topic receives all events
+func (eh *eventHub) subscribe(Topic string) chan *EventMessage {
+	eh.Lock()
+	defer eh.Unlock()
+
+	ch := make(chan *EventMessage, 100)
+	eh.subMap[ch] = Topic
+	return ch
+}
+
+func (eh *eventHub) unsubscribe(ch chan *EventMessage) {
+	eh.Lock()
+	defer eh.Unlock()
+
+	delete(eh.subMap, ch)
+}
+
+func (eh *eventHub) publish(m *EventMessage) {
+	eh.Lock()
+	defer eh.Unlock()
+
+	for ch, Topic := range eh.subMap {
+		if len(Topic) > 0 && Topic != m.Topic {
+			continue
+		}
+		select {
+		case ch <- m:
+		default:
+			// drop the event for the slow subscriber
+		}
+	}
+}
+
+// Publish pushes the event to the websocket subscribers of the topic
+func (s *APIServer) Publish(Topic string, Data interface{}) {
+	s.events.publish(&EventMessage{
+		Topic: Topic,
+		Data:  Data,
+	})
+}
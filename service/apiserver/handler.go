@@ -0,0 +1,62 @@
+package apiserver
+
+import (
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// HTTPRoute is the http handler that is served beside json rpc endpoints like the graphql endpoint
+// The request is authenticated and limited by the middleware, then the handler is called when the acl allows the RPC(graphql.query) to the client
+type HTTPRoute struct {
+	Method  string
+	Path    string
+	RPC     string
+	Handler echo.HandlerFunc
+}
+
+// Handle adds the http route that is served when the apiserver runs
+func (s *APIServer) Handle(route *HTTPRoute) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if !openAPIMethods[route.Method] {
+		return ErrInvalidRESTMethod
+	}
+	if len(strings.SplitN(route.RPC, ".", 2)) != 2 {
+		return ErrInvalidMethod
+	}
+	for _, r := range s.routes {
+		if r.Method == route.Method && r.Path == route.Path {
+			return ErrExistHTTPRoute
+		}
+	}
+	for _, r := range s.handlers {
+		if r.Method == route.Method && r.Path == route.Path {
+			return ErrExistHTTPRoute
+		}
+	}
+	s.handlers = append(s.handlers, route)
+	return nil
+}
+
+// HTTPRoutes returns registered http routes
+func (s *APIServer) HTTPRoutes() []*HTTPRoute {
+	s.Lock()
+	defer s.Unlock()
+
+	routes := make([]*HTTPRoute, len(s.handlers))
+	copy(routes, s.handlers)
+	return routes
+}
+
+// httpHandler returns the echo handler of the route that checks the access of the client
+func (s *APIServer) httpHandler(route *HTTPRoute) echo.HandlerFunc {
+	ls := strings.SplitN(route.RPC, ".", 2)
+	return func(c echo.Context) error {
+		if err := s.checkAccess(c.Get(requestContextKey).(*requestContext), ls[0], ls[1]); err != nil {
+			return restError(c, NewJRPCError(err))
+		}
+		return route.Handler(c)
+	}
+}
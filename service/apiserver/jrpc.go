@@ -1,10 +1,17 @@
 package apiserver
 
 import (
+	"bytes"
 	"encoding/json"
 	"sync"
 )
 
+// jrpc limits
+const (
+	MaxMessageSize = 4 * 1024 * 1024
+	MaxBatchSize   = 100
+)
+
 // Handler handles a rpc method
 type Handler func(ID interface{}, arg *Argument) (interface{}, error)
 
@@ -35,18 +42,114 @@ type JRPCRequest struct {
 	Params  []interface{} `json:"params"`
 }
 
-// jRPCRequest is a jrpc request
+// jRPCRequest is a jrpc request, the request that has no id is a notification
 type jRPCRequest struct {
-	JSONRPC string         `json:"jsonrpc"`
-	ID      interface{}    `json:"id"`
-	Method  string         `json:"method"`
-	Params  []*json.Number `json:"params"`
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// isNotification returns the request has no id or not
+func (req *jRPCRequest) isNotification() bool {
+	return len(req.ID) == 0
+}
+
+// responseID returns the id of the response, it is null when the id of the request is unknown
+func (req *jRPCRequest) responseID() json.RawMessage {
+	if len(req.ID) == 0 {
+		return json.RawMessage("null")
+	}
+	return req.ID
+}
+
+// params returns params as arguments, by-position params are arguments in the order and by-name params are the first argument
+func (req *jRPCRequest) params() ([]json.RawMessage, error) {
+	ps := bytes.TrimSpace(req.Params)
+	if len(ps) == 0 || string(ps) == "null" {
+		return []json.RawMessage{}, nil
+	}
+	switch ps[0] {
+	case '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(ps, &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	case '{':
+		return []json.RawMessage{ps}, nil
+	default:
+		return nil, ErrInvalidParams
+	}
+}
+
+// paramString returns the string of the param, a string param is unquoted and other params are kept as the json text
+func paramString(v json.RawMessage) *string {
+	if len(v) == 0 || string(v) == "null" {
+		return nil
+	}
+	str := string(v)
+	if v[0] == '"' {
+		if err := json.Unmarshal(v, &str); err != nil {
+			return nil
+		}
+	}
+	return &str
 }
 
-// JRPCResponse is a jrpc response
+// JRPCResponse is a jrpc response, it has the result or the error
 type JRPCResponse struct {
 	JSONRPC string      `json:"jsonrpc"`
 	ID      interface{} `json:"id"`
-	Result  interface{} `json:"result"`
-	Error   interface{} `json:"error"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *JRPCError  `json:"error,omitempty"`
+}
+
+// MarshalJSON is a marshaler function, the result is written even if it is null when there is no error
+func (res *JRPCResponse) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"jsonrpc":`)
+	if bs, err := json.Marshal(res.JSONRPC); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"id":`)
+	if bs, err := json.Marshal(res.ID); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	if res.Error != nil {
+		buffer.WriteString(`"error":`)
+		if bs, err := json.Marshal(res.Error); err != nil {
+			return nil, err
+		} else {
+			buffer.Write(bs)
+		}
+	} else {
+		buffer.WriteString(`"result":`)
+		if bs, err := json.Marshal(res.Result); err != nil {
+			return nil, err
+		} else {
+			buffer.Write(bs)
+		}
+	}
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
+
+// JRPCError is the error object of the jrpc response
+type JRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error returns the message of the error
+func (e *JRPCError) Error() string {
+	return e.Message
 }
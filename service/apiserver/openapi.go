@@ -0,0 +1,197 @@
+package apiserver
+
+import (
+	"net/http"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of the document
+const OpenAPIVersion = "3.0.3"
+
+// Schema is the json schema of the value in the OpenAPI document
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// StringSchema returns the schema of the string
+func StringSchema(Description string) *Schema {
+	return &Schema{Type: "string", Description: Description}
+}
+
+// IntegerSchema returns the schema of the integer, the format is int32 or int64
+func IntegerSchema(Format string, Description string) *Schema {
+	return &Schema{Type: "integer", Format: Format, Description: Description}
+}
+
+// BooleanSchema returns the schema of the boolean
+func BooleanSchema(Description string) *Schema {
+	return &Schema{Type: "boolean", Description: Description}
+}
+
+// ArraySchema returns the schema of the array of items
+func ArraySchema(Items *Schema) *Schema {
+	return &Schema{Type: "array", Items: Items}
+}
+
+// ObjectSchema returns the schema of the object that has properties
+func ObjectSchema(Properties map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: Properties}
+}
+
+// MapSchema returns the schema of the object that has values of the schema by any key
+func MapSchema(Values *Schema) *Schema {
+	return &Schema{Type: "object", AdditionalProperties: Values}
+}
+
+// OpenAPI returns the OpenAPI document that describes registered rest routes
+// Routes of private namespaces are described only in the document of the private listener
+func (s *APIServer) OpenAPI(private bool) map[string]interface{} {
+	cfg := s.Config()
+	errorContent := map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": ObjectSchema(map[string]*Schema{
+				"code":  IntegerSchema("int32", "the code of the jrpc error object"),
+				"error": StringSchema("the reason of the failure"),
+			}),
+		},
+	}
+	paths := map[string]map[string]interface{}{}
+	for _, route := range s.Routes() {
+		if !private && cfg.isPrivate(strings.SplitN(route.RPC, ".", 2)[0]) {
+			continue
+		}
+		path := openAPIPath(route.Path)
+		item, has := paths[path]
+		if !has {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		params := []map[string]interface{}{}
+		var body map[string]interface{}
+		for _, p := range route.Params {
+			schema := p.Schema
+			if schema == nil {
+				schema = StringSchema("")
+			}
+			if p.In == RESTParamBody {
+				body = map[string]interface{}{
+					"description": p.Description,
+					"required":    true,
+					"content": map[string]interface{}{
+						"text/plain": map[string]interface{}{
+							"schema": schema,
+						},
+					},
+				}
+				continue
+			}
+			param := map[string]interface{}{
+				"name":     p.Name,
+				"in":       p.In,
+				"required": p.Required || p.In == RESTParamPath,
+				"schema":   schema,
+			}
+			if len(p.Default) > 0 {
+				param["description"] = strings.TrimSpace(p.Description + " (default " + p.Default + ")")
+			} else if len(p.Description) > 0 {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+		result := route.Result
+		if result == nil {
+			result = &Schema{}
+		}
+		op := map[string]interface{}{
+			"operationId": route.RPC,
+			"summary":     route.Summary,
+			"parameters":  params,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "the result of " + route.RPC,
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": result,
+						},
+					},
+				},
+				"400": map[string]interface{}{
+					"description": "invalid request or the failure of the method",
+					"content":     errorContent,
+				},
+				"401": map[string]interface{}{
+					"description": "invalid api key or token",
+					"content":     errorContent,
+				},
+				"403": map[string]interface{}{
+					"description": "the role is not allowed to call the method",
+					"content":     errorContent,
+				},
+				"404": map[string]interface{}{
+					"description": "the method is not served",
+					"content":     errorContent,
+				},
+				"429": map[string]interface{}{
+					"description": "too many requests of the client",
+					"content":     errorContent,
+				},
+			},
+		}
+		if body != nil {
+			op["requestBody"] = body
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "FLETA REST API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "X-API-Key",
+				},
+				"bearer": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+		"security": []map[string][]string{
+			{},
+			{"apiKey": {}},
+			{"bearer": {}},
+		},
+	}
+}
+
+// openAPIPath converts the echo path to the OpenAPI path, /v1/blocks/:height to /v1/blocks/{height}
+func openAPIPath(path string) string {
+	ls := strings.Split(path, "/")
+	for i, v := range ls {
+		if strings.HasPrefix(v, ":") {
+			ls[i] = "{" + v[1:] + "}"
+		}
+	}
+	return strings.Join(ls, "/")
+}
+
+// openAPIMethods lists http methods that can be described in the OpenAPI document
+var openAPIMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+	http.MethodPatch:  true,
+}
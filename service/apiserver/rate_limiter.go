@@ -0,0 +1,80 @@
+package apiserver
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter limits requests of each client by the token bucket
+type rateLimiter struct {
+	sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastClean time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	l := &rateLimiter{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   map[string]*tokenBucket{},
+		lastClean: time.Now(),
+	}
+	if l.burst < 1 {
+		l.burst = rate
+	}
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	return l
+}
+
+// Allow consumes a token of the client, it returns false when the bucket is empty
+func (l *rateLimiter) Allow(client string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastClean) > time.Minute {
+		l.clean(now)
+	}
+	b, has := l.buckets[client]
+	if !has {
+		b = &tokenBucket{
+			tokens: l.burst,
+			last:   now,
+		}
+		l.buckets[client] = b
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * l.rate
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// clean removes buckets that are filled up, they are same with new buckets
+func (l *rateLimiter) clean(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastClean = now
+}
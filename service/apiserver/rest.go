@@ -0,0 +1,187 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// MaxRESTBodySize is the maximum size of the body param of the rest request
+const MaxRESTBodySize = 2 * 1024 * 1024
+
+// locations of the rest param
+const (
+	RESTParamPath  = "path"
+	RESTParamQuery = "query"
+	RESTParamBody  = "body"
+)
+
+// RESTRoute maps the rest route to the json rpc method, so the rest request is served by the same handler of the JRPCSub
+// Params are passed to the method as arguments in the order of the slice
+type RESTRoute struct {
+	Method  string
+	Path    string
+	RPC     string
+	Summary string
+	Params  []*RESTParam
+	Result  *Schema
+}
+
+// RESTParam is a param of the rest route
+// The query param that is not given is replaced by the default, it is passed as null when the default is empty and trailing nulls are omitted
+type RESTParam struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Default     string
+	Schema      *Schema
+}
+
+// RESTError is the response of the failed rest request, the code is same with the code of the jrpc error object
+type RESTError struct {
+	Code  int    `json:"code,omitempty"`
+	Error string `json:"error"`
+}
+
+// REST adds the rest route that is served when the apiserver runs
+func (s *APIServer) REST(route *RESTRoute) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if !openAPIMethods[route.Method] {
+		return ErrInvalidRESTMethod
+	}
+	if len(strings.SplitN(route.RPC, ".", 2)) != 2 {
+		return ErrInvalidMethod
+	}
+	hasBody := false
+	for _, p := range route.Params {
+		switch p.In {
+		case RESTParamPath:
+			if !strings.Contains(route.Path+"/", "/:"+p.Name+"/") {
+				return ErrInvalidRESTParam
+			}
+		case RESTParamQuery:
+		case RESTParamBody:
+			if hasBody {
+				return ErrInvalidRESTParam
+			}
+			hasBody = true
+		default:
+			return ErrInvalidRESTParam
+		}
+	}
+	for _, r := range s.routes {
+		if r.Method == route.Method && r.Path == route.Path {
+			return ErrExistRESTRoute
+		}
+	}
+	for _, r := range s.handlers {
+		if r.Method == route.Method && r.Path == route.Path {
+			return ErrExistRESTRoute
+		}
+	}
+	s.routes = append(s.routes, route)
+	return nil
+}
+
+// Routes returns registered rest routes
+func (s *APIServer) Routes() []*RESTRoute {
+	s.Lock()
+	defer s.Unlock()
+
+	routes := make([]*RESTRoute, len(s.routes))
+	copy(routes, s.routes)
+	return routes
+}
+
+// restHandler returns the echo handler of the route that sends the request to the json rpc workers
+func (s *APIServer) restHandler(route *RESTRoute, reqCh chan<- *ReqData) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params, err := restParams(c, route)
+		if err != nil {
+			return restError(c, NewJRPCError(err))
+		}
+		ps, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		ID, err := json.Marshal(c.Path())
+		if err != nil {
+			return err
+		}
+		req := &jRPCRequest{
+			JSONRPC: "2.0",
+			ID:      ID,
+			Method:  route.RPC,
+			Params:  ps,
+		}
+		resCh := make(chan *JRPCResponse)
+		reqCh <- &ReqData{
+			req:   req,
+			resCh: &resCh,
+			ctx:   c.Get(requestContextKey).(*requestContext),
+		}
+		res := <-resCh
+		if res.Error != nil {
+			return restError(c, res.Error)
+		}
+		return c.JSON(http.StatusOK, res.Result)
+	}
+}
+
+// restError responds the error with the http status of the error code
+func restError(c echo.Context, e *JRPCError) error {
+	return c.JSON(httpStatus(e.Code), &RESTError{
+		Code:  e.Code,
+		Error: e.Message,
+	})
+}
+
+// restParams returns json rpc params from the path, the query and the body of the request
+func restParams(c echo.Context, route *RESTRoute) ([]json.RawMessage, error) {
+	params := []json.RawMessage{}
+	for _, p := range route.Params {
+		var v string
+		switch p.In {
+		case RESTParamPath:
+			v = c.Param(p.Name)
+		case RESTParamQuery:
+			v = c.QueryParam(p.Name)
+		case RESTParamBody:
+			defer c.Request().Body.Close()
+			bs, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, MaxRESTBodySize+1))
+			if err != nil {
+				return nil, err
+			}
+			if len(bs) > MaxRESTBodySize {
+				return nil, ErrTooLargeRESTBody
+			}
+			v = strings.TrimSpace(string(bs))
+		}
+		if len(v) == 0 {
+			v = p.Default
+		}
+		if len(v) == 0 {
+			if p.Required || p.In != RESTParamQuery {
+				return nil, ErrInvalidArgument
+			}
+			params = append(params, json.RawMessage("null"))
+			continue
+		}
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, bs)
+	}
+	for len(params) > 0 && string(params[len(params)-1]) == "null" {
+		params = params[:len(params)-1]
+	}
+	return params, nil
+}
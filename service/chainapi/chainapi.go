@@ -0,0 +1,430 @@
+package chainapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/factory"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// api limits
+const (
+	MaxBlocksPerPage = 100
+	MaxEventRange    = 1000
+)
+
+// ChainAPI serves the api to read blocks, transactions, accounts and events of the chain
+type ChainAPI struct {
+	types.ServiceBase
+	cn types.Provider
+}
+
+// NewChainAPI returns a ChainAPI
+func NewChainAPI() *ChainAPI {
+	s := &ChainAPI{}
+	return s
+}
+
+// Name returns the name of the service
+func (s *ChainAPI) Name() string {
+	return "fleta.chainapi"
+}
+
+// BlockPage is the result of the block range, Next is zero when there is no more block
+type BlockPage struct {
+	Blocks []json.RawMessage `json:"blocks"`
+	Next   uint32            `json:"next"`
+}
+
+// Status is the result of chain.status
+type Status struct {
+	ChainID   uint8        `json:"chain_id"`
+	Symbol    string       `json:"symbol"`
+	Usage     string       `json:"usage"`
+	Version   uint16       `json:"version"`
+	Height    uint32       `json:"height"`
+	Hash      hash.Hash256 `json:"hash"`
+	Timestamp uint64       `json:"timestamp"`
+}
+
+// RawData is the hex of the binary encoding, Type is the type name of the account
+type RawData struct {
+	Type string `json:"type,omitempty"`
+	Data string `json:"data"`
+}
+
+// Init called when initialize service
+func (s *ChainAPI) Init(pm types.ProcessManager, cn types.Provider) error {
+	s.cn = cn
+
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		as, err := v.JRPC("chain")
+		if err != nil {
+			return err
+		}
+		as.Set("height", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return s.cn.Height(), nil
+		})
+		as.Set("status", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			height, h := s.cn.LastStatus()
+			return &Status{
+				ChainID:   s.cn.ChainID(),
+				Symbol:    s.cn.Symbol(),
+				Usage:     s.cn.Usage(),
+				Version:   s.cn.Version(),
+				Height:    height,
+				Hash:      h,
+				Timestamp: s.cn.LastTimestamp(),
+			}, nil
+		})
+		as.Set("hash", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			height, err := arg.Uint32(0)
+			if err != nil {
+				return nil, err
+			}
+			return s.cn.Hash(height)
+		})
+		as.Set("header", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			height, err := s.heightArgument(arg, 0)
+			if err != nil {
+				return nil, err
+			}
+			return s.headerJSON(height)
+		})
+		as.Set("headers", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return s.page(arg, s.headerJSON)
+		})
+		as.Set("block", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			height, err := s.heightArgument(arg, 0)
+			if err != nil {
+				return nil, err
+			}
+			return s.blockJSON(height)
+		})
+		as.Set("blocks", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return s.page(arg, s.blockJSON)
+		})
+		as.Set("transaction", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			txid, err := arg.String(0)
+			if err != nil {
+				return nil, err
+			}
+			height, index, err := types.ParseTransactionID(txid)
+			if err != nil {
+				return nil, err
+			}
+			b, err := s.cn.Block(height)
+			if err != nil {
+				return nil, err
+			}
+			if len(b.Transactions) <= int(index) {
+				return nil, ErrInvalidTXID
+			}
+			mp, err := typedJSON(encoding.Factory("transaction"), b.TransactionTypes[index], b.Transactions[index])
+			if err != nil {
+				return nil, err
+			}
+			mp["txid"] = txid
+			mp["sigs"] = b.TransactionSignatures[index]
+			mp["result"] = b.TransactionResults[index]
+			return mp, nil
+		})
+		as.Set("account", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			addrStr, err := arg.String(0)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := common.ParseAddress(addrStr)
+			if err != nil {
+				return nil, err
+			}
+			return s.accountJSON(addr)
+		})
+		as.Set("accountByName", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			name, err := arg.String(0)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := s.cn.NewLoaderWrapper(1).AddressByName(name)
+			if err != nil {
+				return nil, err
+			}
+			return s.accountJSON(addr)
+		})
+		as.Set("events", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 2 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			From, err := arg.Uint32(0)
+			if err != nil {
+				return nil, err
+			}
+			To, err := arg.Uint32(1)
+			if err != nil {
+				return nil, err
+			}
+			if To < From {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			if To-From >= MaxEventRange {
+				return nil, ErrTooLargeRange
+			}
+			evs, err := s.cn.Events(From, To)
+			if err != nil {
+				return nil, err
+			}
+			fc := encoding.Factory("event")
+			list := []map[string]interface{}{}
+			for _, ev := range evs {
+				t, err := fc.TypeOf(ev)
+				if err != nil {
+					return nil, err
+				}
+				mp, err := typedJSON(fc, t, ev)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, mp)
+			}
+			return list, nil
+		})
+		as.Set("rawHeader", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			height, err := s.heightArgument(arg, 0)
+			if err != nil {
+				return nil, err
+			}
+			bh, err := s.cn.Header(height)
+			if err != nil {
+				return nil, err
+			}
+			return rawData("", bh)
+		})
+		as.Set("rawBlock", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			height, err := s.heightArgument(arg, 0)
+			if err != nil {
+				return nil, err
+			}
+			b, err := s.cn.Block(height)
+			if err != nil {
+				return nil, err
+			}
+			return rawData("", b)
+		})
+		as.Set("rawAccount", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			str, err := arg.String(0)
+			if err != nil {
+				return nil, err
+			}
+			loader := s.cn.NewLoaderWrapper(1)
+			addr, err := common.ParseAddress(str)
+			if err != nil {
+				if addr, err = loader.AddressByName(str); err != nil {
+					return nil, err
+				}
+			}
+			acc, err := loader.Account(addr)
+			if err != nil {
+				return nil, err
+			}
+			fc := encoding.Factory("account")
+			t, err := fc.TypeOf(acc)
+			if err != nil {
+				return nil, err
+			}
+			name, err := fc.TypeName(t)
+			if err != nil {
+				return nil, err
+			}
+			return rawData(name, acc)
+		})
+		for _, route := range restRoutes() {
+			if err := v.REST(route); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// heightArgument returns the height of the argument, the argument can be the height or the block hash
+func (s *ChainAPI) heightArgument(arg *apiserver.Argument, index int) (uint32, error) {
+	if height, err := arg.Uint32(index); err == nil {
+		return height, nil
+	}
+	str, err := arg.String(index)
+	if err != nil {
+		return 0, err
+	}
+	h, err := hash.ParseHash(str)
+	if err != nil {
+		return 0, err
+	}
+	height, err := s.cn.HeightByHash(h)
+	if err != nil {
+		return 0, ErrNotExistBlock
+	}
+	return height, nil
+}
+
+// page returns items of the range from the height, at most MaxBlocksPerPage items are returned
+func (s *ChainAPI) page(arg *apiserver.Argument, fn func(height uint32) (json.RawMessage, error)) (interface{}, error) {
+	if arg.Len() != 2 {
+		return nil, apiserver.ErrInvalidArgument
+	}
+	From, err := arg.Uint32(0)
+	if err != nil {
+		return nil, err
+	}
+	Count, err := arg.Uint32(1)
+	if err != nil {
+		return nil, err
+	}
+	if From == 0 {
+		From = 1
+	}
+	if Count == 0 || Count > MaxBlocksPerPage {
+		Count = MaxBlocksPerPage
+	}
+	Height := s.cn.Height()
+	result := &BlockPage{
+		Blocks: []json.RawMessage{},
+	}
+	if From > Height {
+		return result, nil
+	}
+	for i := From; i < From+Count && i <= Height; i++ {
+		bs, err := fn(i)
+		if err != nil {
+			return nil, err
+		}
+		result.Blocks = append(result.Blocks, bs)
+	}
+	if From+Count <= Height {
+		result.Next = From + Count
+	}
+	return result, nil
+}
+
+func (s *ChainAPI) headerJSON(height uint32) (json.RawMessage, error) {
+	bh, err := s.cn.Header(height)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := bh.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return withHash(bs, encoding.Hash(*bh))
+}
+
+func (s *ChainAPI) blockJSON(height uint32) (json.RawMessage, error) {
+	b, err := s.cn.Block(height)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := b.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return withHash(bs, encoding.Hash(b.Header))
+}
+
+func (s *ChainAPI) accountJSON(addr common.Address) (interface{}, error) {
+	acc, err := s.cn.NewLoaderWrapper(1).Account(addr)
+	if err != nil {
+		return nil, err
+	}
+	fc := encoding.Factory("account")
+	t, err := fc.TypeOf(acc)
+	if err != nil {
+		return nil, err
+	}
+	mp, err := typedJSON(fc, t, acc)
+	if err != nil {
+		return nil, err
+	}
+	mp["seq"] = s.cn.Seq(addr)
+	return mp, nil
+}
+
+// rawData returns the hex of the binary encoding of the value
+func rawData(Type string, v interface{}) (*RawData, error) {
+	bs, err := encoding.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &RawData{
+		Type: Type,
+		Data: hex.EncodeToString(bs),
+	}, nil
+}
+
+// withHash adds the hash field to the json object
+func withHash(bs []byte, h hash.Hash256) (json.RawMessage, error) {
+	hs, err := h.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, len(bs)+len(hs)+9)
+	data = append(data, `{"hash":`...)
+	data = append(data, hs...)
+	if len(bs) > 2 {
+		data = append(data, ',')
+	}
+	data = append(data, bs[1:]...)
+	return data, nil
+}
+
+// typedJSON returns the json object of the value with the type name
+func typedJSON(fc *factory.Factory, t uint16, v json.Marshaler) (map[string]interface{}, error) {
+	bs, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	mp := map[string]interface{}{}
+	if err := json.Unmarshal(bs, &mp); err != nil {
+		return nil, err
+	}
+	name, err := fc.TypeName(t)
+	if err != nil {
+		return nil, err
+	}
+	mp["type"] = name
+	return mp, nil
+}
@@ -0,0 +1,23 @@
+package chainapi
+
+import (
+	"errors"
+
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// errors
+var (
+	ErrInvalidTXID   = errors.New("invalid txid")
+	ErrNotExistBlock = errors.New("not exist block")
+	ErrTooLargeRange = errors.New("too large range")
+)
+
+func init() {
+	apiserver.RegisterErrorCode(ErrInvalidTXID, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrNotExistBlock, apiserver.CodeNotFound)
+	apiserver.RegisterErrorCode(ErrTooLargeRange, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(types.ErrInvalidTransactionIDFormat, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(types.ErrNotExistAccount, apiserver.CodeNotFound)
+}
@@ -0,0 +1,187 @@
+package chainapi
+
+import (
+	"net/http"
+
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// schemas of results
+var (
+	headerSchema = apiserver.ObjectSchema(map[string]*apiserver.Schema{
+		"hash":            apiserver.StringSchema("the hash of the header"),
+		"chain_id":        apiserver.IntegerSchema("int32", ""),
+		"version":         apiserver.IntegerSchema("int32", ""),
+		"height":          apiserver.IntegerSchema("int32", ""),
+		"prev_hash":       apiserver.StringSchema(""),
+		"level_root_hash": apiserver.StringSchema(""),
+		"context_hash":    apiserver.StringSchema(""),
+		"timestamp":       apiserver.IntegerSchema("int64", "unix nano"),
+		"generator":       apiserver.StringSchema("the address of the formulator"),
+		"consensus_data":  apiserver.StringSchema("hex"),
+	})
+	blockSchema = apiserver.ObjectSchema(map[string]*apiserver.Schema{
+		"hash":                   apiserver.StringSchema("the hash of the header"),
+		"header":                 headerSchema,
+		"transaction_types":      apiserver.ArraySchema(apiserver.IntegerSchema("int32", "")),
+		"transactions":           apiserver.ArraySchema(apiserver.MapSchema(&apiserver.Schema{})),
+		"transaction_signatures": apiserver.ArraySchema(apiserver.ArraySchema(apiserver.StringSchema(""))),
+		"transaction_results":    apiserver.ArraySchema(apiserver.IntegerSchema("int32", "")),
+		"signatures":             apiserver.ArraySchema(apiserver.StringSchema("")),
+	})
+	typedSchema = &apiserver.Schema{
+		Type: "object",
+		Properties: map[string]*apiserver.Schema{
+			"type": apiserver.StringSchema("the type name"),
+		},
+		AdditionalProperties: &apiserver.Schema{},
+	}
+)
+
+// restRoutes returns rest routes of the chain namespace
+func restRoutes() []*apiserver.RESTRoute {
+	heightParam := &apiserver.RESTParam{
+		Name:        "height",
+		In:          apiserver.RESTParamPath,
+		Description: "the height or the hash of the block",
+	}
+	pageParams := []*apiserver.RESTParam{
+		{
+			Name:        "from",
+			In:          apiserver.RESTParamQuery,
+			Description: "the first height of the page",
+			Default:     "1",
+			Schema:      apiserver.IntegerSchema("int32", ""),
+		},
+		{
+			Name:        "count",
+			In:          apiserver.RESTParamQuery,
+			Description: "the number of items, at most 100",
+			Default:     "0",
+			Schema:      apiserver.IntegerSchema("int32", ""),
+		},
+	}
+	pageSchema := func(item *apiserver.Schema) *apiserver.Schema {
+		return apiserver.ObjectSchema(map[string]*apiserver.Schema{
+			"blocks": apiserver.ArraySchema(item),
+			"next":   apiserver.IntegerSchema("int32", "the height of the next page, zero when there is no more"),
+		})
+	}
+	return []*apiserver.RESTRoute{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/status",
+			RPC:     "chain.status",
+			Summary: "returns the status of the chain",
+			Result: apiserver.ObjectSchema(map[string]*apiserver.Schema{
+				"chain_id":  apiserver.IntegerSchema("int32", ""),
+				"symbol":    apiserver.StringSchema(""),
+				"usage":     apiserver.StringSchema(""),
+				"version":   apiserver.IntegerSchema("int32", ""),
+				"height":    apiserver.IntegerSchema("int32", ""),
+				"hash":      apiserver.StringSchema(""),
+				"timestamp": apiserver.IntegerSchema("int64", "unix nano"),
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/height",
+			RPC:     "chain.height",
+			Summary: "returns the height of the chain",
+			Result:  apiserver.IntegerSchema("int32", ""),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/headers",
+			RPC:     "chain.headers",
+			Summary: "returns headers of the range",
+			Params:  pageParams,
+			Result:  pageSchema(headerSchema),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/headers/:height",
+			RPC:     "chain.header",
+			Summary: "returns the header of the height or the hash",
+			Params:  []*apiserver.RESTParam{heightParam},
+			Result:  headerSchema,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/blocks",
+			RPC:     "chain.blocks",
+			Summary: "returns blocks of the range",
+			Params:  pageParams,
+			Result:  pageSchema(blockSchema),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/blocks/:height",
+			RPC:     "chain.block",
+			Summary: "returns the block of the height or the hash",
+			Params:  []*apiserver.RESTParam{heightParam},
+			Result:  blockSchema,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/txs/:id",
+			RPC:     "chain.transaction",
+			Summary: "returns the transaction of the txid",
+			Params: []*apiserver.RESTParam{
+				{
+					Name:        "id",
+					In:          apiserver.RESTParamPath,
+					Description: "the txid, height:index",
+				},
+			},
+			Result: typedSchema,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/accounts/:address",
+			RPC:     "chain.account",
+			Summary: "returns the account of the address",
+			Params: []*apiserver.RESTParam{
+				{
+					Name: "address",
+					In:   apiserver.RESTParamPath,
+				},
+			},
+			Result: typedSchema,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/names/:name",
+			RPC:     "chain.accountByName",
+			Summary: "returns the account of the name",
+			Params: []*apiserver.RESTParam{
+				{
+					Name: "name",
+					In:   apiserver.RESTParamPath,
+				},
+			},
+			Result: typedSchema,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/events",
+			RPC:     "chain.events",
+			Summary: "returns events of the range, at most 1000 heights",
+			Params: []*apiserver.RESTParam{
+				{
+					Name:     "from",
+					In:       apiserver.RESTParamQuery,
+					Required: true,
+					Schema:   apiserver.IntegerSchema("int32", ""),
+				},
+				{
+					Name:     "to",
+					In:       apiserver.RESTParamQuery,
+					Required: true,
+					Schema:   apiserver.IntegerSchema("int32", ""),
+				},
+			},
+			Result: apiserver.ArraySchema(typedSchema),
+		},
+	}
+}
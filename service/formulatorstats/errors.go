@@ -0,0 +1,10 @@
+package formulatorstats
+
+import "errors"
+
+// errors
+var (
+	ErrInvalidHeightRange  = errors.New("invalid height range")
+	ErrTooManyRanges       = errors.New("too many ranges")
+	ErrMissedRanksNotFound = errors.New("missed ranks not found")
+)
@@ -0,0 +1,327 @@
+package formulatorstats
+
+import (
+	"net/http"
+	"sync"
+
+	lediscfg "github.com/siddontang/ledisdb/config"
+	"github.com/siddontang/ledisdb/ledis"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+	"github.com/fletaio/fleta_v1/common/rlog"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/pof"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// MaxRangesPerRequest is the maximum number of ranges that returned by one request
+const MaxRangesPerRequest = 1000
+
+// FormulatorStats records the liveness statistics of formulators
+// It compares the ranks before the block with the generator and the timeout count of the block,
+// so the formulators that caused the NextRoundVote are recorded as missed
+type FormulatorStats struct {
+	sync.Mutex
+	cs            *pof.Consensus
+	cn            types.Provider
+	l             *ledis.Ledis
+	db            *ledis.DB
+	rangeSize     uint32
+	lastHeight    uint32
+	lastTimestamp uint64
+}
+
+// NewFormulatorStats returns a FormulatorStats
+func NewFormulatorStats(cs *pof.Consensus, dbpath string, RangeSize uint32) *FormulatorStats {
+	if RangeSize == 0 {
+		RangeSize = 172800 // 1 day
+	}
+	cfg := lediscfg.NewConfigDefault()
+	cfg.DataDir = dbpath
+	l, err := ledis.Open(cfg)
+	if err != nil {
+		panic(err)
+	}
+	db, err := l.Select(0)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &FormulatorStats{
+		cs:        cs,
+		l:         l,
+		db:        db,
+		rangeSize: RangeSize,
+	}
+	return s
+}
+
+// Name returns the name of the service
+func (s *FormulatorStats) Name() string {
+	return "fleta.formulatorstats"
+}
+
+// Init called when initialize service
+func (s *FormulatorStats) Init(pm types.ProcessManager, cn types.Provider) error {
+	s.cn = cn
+
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		as, err := v.JRPC("formulatorstats")
+		if err != nil {
+			return err
+		}
+		as.Set("rangeSize", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return s.rangeSize, nil
+		})
+		as.Set("stat", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			addrStr, err := arg.String(0)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := common.ParseAddress(addrStr)
+			if err != nil {
+				return nil, err
+			}
+			st, err := s.TotalStat(addr)
+			if err != nil {
+				return nil, err
+			}
+			return newStatResult(addr, 1, s.LastHeight(), st), nil
+		})
+		as.Set("statsByRange", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 3 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			addrStr, err := arg.String(0)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := common.ParseAddress(addrStr)
+			if err != nil {
+				return nil, err
+			}
+			From, err := arg.Uint32(1)
+			if err != nil {
+				return nil, err
+			}
+			To, err := arg.Uint32(2)
+			if err != nil {
+				return nil, err
+			}
+			return s.RangeStats(addr, From, To)
+		})
+		statSchema := apiserver.ObjectSchema(map[string]*apiserver.Schema{
+			"address":              apiserver.StringSchema(""),
+			"from":                 apiserver.IntegerSchema("int32", ""),
+			"to":                   apiserver.IntegerSchema("int32", ""),
+			"produced":             apiserver.IntegerSchema("int32", ""),
+			"missed":               apiserver.IntegerSchema("int32", ""),
+			"opportunities":        apiserver.IntegerSchema("int32", ""),
+			"average_latency_ms":   apiserver.IntegerSchema("int64", ""),
+			"last_produced_height": apiserver.IntegerSchema("int32", ""),
+			"last_missed_height":   apiserver.IntegerSchema("int32", ""),
+		})
+		addressParam := &apiserver.RESTParam{
+			Name: "address",
+			In:   apiserver.RESTParamPath,
+		}
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodGet,
+			Path:    "/v1/formulators/:address/stat",
+			RPC:     "formulatorstats.stat",
+			Summary: "returns the total liveness stat of the formulator",
+			Params:  []*apiserver.RESTParam{addressParam},
+			Result:  statSchema,
+		}); err != nil {
+			return err
+		}
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodGet,
+			Path:    "/v1/formulators/:address/stats",
+			RPC:     "formulatorstats.statsByRange",
+			Summary: "returns liveness stats of the formulator by the range",
+			Params: []*apiserver.RESTParam{
+				addressParam,
+				{
+					Name:     "from",
+					In:       apiserver.RESTParamQuery,
+					Required: true,
+					Schema:   apiserver.IntegerSchema("int32", ""),
+				},
+				{
+					Name:     "to",
+					In:       apiserver.RESTParamQuery,
+					Required: true,
+					Schema:   apiserver.IntegerSchema("int32", ""),
+				},
+			},
+			Result: apiserver.ArraySchema(statSchema),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnLoadChain called when the chain loaded
+func (s *FormulatorStats) OnLoadChain(loader types.Loader) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if bs, err := s.db.Get(tagLastHeight); err != nil {
+		return err
+	} else if len(bs) == 4 {
+		s.lastHeight = binutil.BigEndian.Uint32(bs)
+	}
+	s.lastTimestamp = s.cn.LastTimestamp()
+	return nil
+}
+
+// Close closes the statistics db
+func (s *FormulatorStats) Close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.l != nil {
+		s.l.Close()
+		s.l = nil
+	}
+}
+
+// OnBlockConnected called when a block is connected to the chain
+func (s *FormulatorStats) OnBlockConnected(b *types.Block, events []types.Event, loader types.Loader) {
+	s.Lock()
+	defer s.Unlock()
+
+	lastTimestamp := s.lastTimestamp
+	s.lastTimestamp = b.Header.Timestamp
+
+	if s.l == nil || b.Header.Height <= s.lastHeight {
+		return
+	}
+	// the consensus snapshots the ranks before the block forwards the candidates
+	missed, has := s.cs.MissedRanks(b.Header.Height)
+	if !has {
+		rlog.Println("[formulatorstats]", b.Header.Height, ErrMissedRanksNotFound)
+	}
+	if err := s.recordBlock(b, missed, lastTimestamp); err != nil {
+		rlog.Println("[formulatorstats]", b.Header.Height, err)
+	}
+}
+
+func (s *FormulatorStats) recordBlock(b *types.Block, missed []*pof.Rank, lastTimestamp uint64) error {
+	Height := b.Header.Height
+	for _, r := range missed {
+		if err := s.updateStat(r.Address, Height, func(st *Stat) {
+			st.Missed++
+			st.LastMissedHeight = Height
+		}); err != nil {
+			return err
+		}
+	}
+	var Latency uint64
+	if b.Header.Timestamp > lastTimestamp {
+		Latency = b.Header.Timestamp - lastTimestamp
+	}
+	if err := s.updateStat(b.Header.Generator, Height, func(st *Stat) {
+		st.Produced++
+		st.LatencySum += Latency
+		st.LastProducedHeight = Height
+	}); err != nil {
+		return err
+	}
+
+	bs := make([]byte, 4)
+	binutil.BigEndian.PutUint32(bs, Height)
+	if err := s.db.Set(tagLastHeight, bs); err != nil {
+		return err
+	}
+	s.lastHeight = Height
+	return nil
+}
+
+func (s *FormulatorStats) updateStat(addr common.Address, Height uint32, fn func(st *Stat)) error {
+	keys := [][]byte{
+		toRangeStatKey(addr, s.rangeIndex(Height)),
+		toTotalStatKey(addr),
+	}
+	for _, key := range keys {
+		st, err := s.loadStat(key)
+		if err != nil {
+			return err
+		}
+		fn(st)
+		bs, err := encoding.Marshal(st)
+		if err != nil {
+			return err
+		}
+		if err := s.db.Set(key, bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FormulatorStats) loadStat(key []byte) (*Stat, error) {
+	st := &Stat{}
+	bs, err := s.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) > 0 {
+		if err := encoding.Unmarshal(bs, &st); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+func (s *FormulatorStats) rangeIndex(Height uint32) uint32 {
+	if Height == 0 {
+		return 0
+	}
+	return (Height - 1) / s.rangeSize
+}
+
+// LastHeight returns the last recorded height
+func (s *FormulatorStats) LastHeight() uint32 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.lastHeight
+}
+
+// TotalStat returns the accumulated statistics of the formulator
+func (s *FormulatorStats) TotalStat(addr common.Address) (*Stat, error) {
+	return s.loadStat(toTotalStatKey(addr))
+}
+
+// RangeStats returns the statistics of the formulator for each height range between From and To
+func (s *FormulatorStats) RangeStats(addr common.Address, From uint32, To uint32) ([]*StatResult, error) {
+	if From == 0 || From > To {
+		return nil, ErrInvalidHeightRange
+	}
+	Start := s.rangeIndex(From)
+	End := s.rangeIndex(To)
+	if End-Start >= MaxRangesPerRequest {
+		return nil, ErrTooManyRanges
+	}
+	list := []*StatResult{}
+	for i := Start; i <= End; i++ {
+		st, err := s.loadStat(toRangeStatKey(addr, i))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, newStatResult(addr, i*s.rangeSize+1, (i+1)*s.rangeSize, st))
+	}
+	return list, nil
+}
@@ -0,0 +1,56 @@
+package formulatorstats
+
+import (
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+)
+
+// Stat is the liveness statistics of a formulator
+type Stat struct {
+	Produced           uint32
+	Missed             uint32
+	LatencySum         uint64
+	LastProducedHeight uint32
+	LastMissedHeight   uint32
+}
+
+// Opportunities returns the number of blocks that the formulator should have generated
+func (st *Stat) Opportunities() uint32 {
+	return st.Produced + st.Missed
+}
+
+// AverageLatency returns the average generation latency of the produced blocks
+func (st *Stat) AverageLatency() time.Duration {
+	if st.Produced == 0 {
+		return 0
+	}
+	return time.Duration(st.LatencySum / uint64(st.Produced))
+}
+
+// StatResult is the json result of the statistics
+type StatResult struct {
+	Address              common.Address `json:"address"`
+	From                 uint32         `json:"from"`
+	To                   uint32         `json:"to"`
+	Produced             uint32         `json:"produced"`
+	Missed               uint32         `json:"missed"`
+	Opportunities        uint32         `json:"opportunities"`
+	AverageLatencyMillis int64          `json:"average_latency_ms"`
+	LastProducedHeight   uint32         `json:"last_produced_height"`
+	LastMissedHeight     uint32         `json:"last_missed_height"`
+}
+
+func newStatResult(addr common.Address, From uint32, To uint32, st *Stat) *StatResult {
+	return &StatResult{
+		Address:              addr,
+		From:                 From,
+		To:                   To,
+		Produced:             st.Produced,
+		Missed:               st.Missed,
+		Opportunities:        st.Opportunities(),
+		AverageLatencyMillis: int64(st.AverageLatency() / time.Millisecond),
+		LastProducedHeight:   st.LastProducedHeight,
+		LastMissedHeight:     st.LastMissedHeight,
+	}
+}
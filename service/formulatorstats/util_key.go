@@ -0,0 +1,27 @@
+package formulatorstats
+
+import (
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+)
+
+var (
+	tagLastHeight = []byte{1, 0}
+	tagRangeStat  = []byte{2, 0}
+	tagTotalStat  = []byte{2, 1}
+)
+
+func toRangeStatKey(addr common.Address, RangeIndex uint32) []byte {
+	bs := make([]byte, 2+common.AddressSize+4)
+	copy(bs, tagRangeStat)
+	copy(bs[2:], addr[:])
+	binutil.BigEndian.PutUint32(bs[2+common.AddressSize:], RangeIndex)
+	return bs
+}
+
+func toTotalStatKey(addr common.Address) []byte {
+	bs := make([]byte, 2+common.AddressSize)
+	copy(bs, tagTotalStat)
+	copy(bs[2:], addr[:])
+	return bs
+}
@@ -0,0 +1,14 @@
+package graphqlapi
+
+import (
+	"errors"
+)
+
+// errors
+var (
+	ErrNotFormulatorAccount = errors.New("not formulator account")
+	ErrNotLoadedFormulator  = errors.New("not loaded formulator")
+	ErrNotLoadedVault       = errors.New("not loaded vault")
+	ErrTooLargeRange        = errors.New("too large range")
+	ErrEmptyQuery           = errors.New("empty query")
+)
@@ -0,0 +1,200 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/process/formulator"
+	"github.com/fletaio/fleta_v1/process/vault"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/labstack/echo"
+)
+
+// query limits
+const (
+	MaxBlockCount  = 100
+	MaxEventRange  = 1000
+	MaxDepth       = 10
+	MaxParallelism = 10
+)
+
+// GraphQLAPI serves the graphql query endpoint over blocks, transactions, accounts, formulators and events
+// Formulator and vault fields are resolved when those processes are loaded
+type GraphQLAPI struct {
+	types.ServiceBase
+	cn     types.Provider
+	fr     *formulator.Formulator
+	vt     *vault.Vault
+	schema *graphql.Schema
+}
+
+// NewGraphQLAPI returns a GraphQLAPI
+func NewGraphQLAPI() *GraphQLAPI {
+	s := &GraphQLAPI{}
+	return s
+}
+
+// Name returns the name of the service
+func (s *GraphQLAPI) Name() string {
+	return "fleta.graphqlapi"
+}
+
+// Request is the body of the graphql query
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Init called when initialize service
+func (s *GraphQLAPI) Init(pm types.ProcessManager, cn types.Provider) error {
+	s.cn = cn
+
+	if vp, err := pm.ProcessByName("fleta.formulator"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vp.(*formulator.Formulator); is {
+		s.fr = v
+	}
+	if vp, err := pm.ProcessByName("fleta.vault"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vp.(*vault.Vault); is {
+		s.vt = v
+	}
+
+	schema, err := graphql.ParseSchema(schema, &queryResolver{s: s}, graphql.MaxDepth(MaxDepth), graphql.MaxParallelism(MaxParallelism))
+	if err != nil {
+		return err
+	}
+	s.schema = schema
+
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		for _, Method := range []string{http.MethodGet, http.MethodPost} {
+			if err := v.Handle(&apiserver.HTTPRoute{
+				Method:  Method,
+				Path:    "/graphql",
+				RPC:     "graphql.query",
+				Handler: s.handle,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handle executes the query of the body or the query parameters
+func (s *GraphQLAPI) handle(c echo.Context) error {
+	var req Request
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("query")
+		req.OperationName = c.QueryParam("operationName")
+		if vars := c.QueryParam("variables"); len(vars) > 0 {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				return queryError(c, http.StatusBadRequest, err)
+			}
+		}
+	} else {
+		defer c.Request().Body.Close()
+		data, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, apiserver.MaxMessageSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > apiserver.MaxMessageSize {
+			return queryError(c, http.StatusRequestEntityTooLarge, apiserver.ErrTooLargeMessage)
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return queryError(c, http.StatusBadRequest, err)
+		}
+	}
+	if len(req.Query) == 0 {
+		return queryError(c, http.StatusBadRequest, ErrEmptyQuery)
+	}
+	return c.JSON(http.StatusOK, s.schema.Exec(c.Request().Context(), req.Query, req.OperationName, req.Variables))
+}
+
+// queryError writes the error in the form of the graphql response
+func queryError(c echo.Context, status int, err error) error {
+	return c.JSON(status, map[string]interface{}{
+		"errors": []map[string]string{
+			{"message": err.Error()},
+		},
+	})
+}
+
+// block returns the resolver of the block, it returns nil when the block is not exist
+func (s *GraphQLAPI) block(height uint32) (*blockResolver, error) {
+	if height < 1 || height > s.cn.Height() {
+		return nil, nil
+	}
+	b, err := s.cn.Block(height)
+	if err != nil {
+		return nil, err
+	}
+	return &blockResolver{
+		s: s,
+		b: b,
+	}, nil
+}
+
+// events returns events in the height range
+func (s *GraphQLAPI) events(From int32, To int32) ([]types.Event, error) {
+	if From < 0 || To < From {
+		return nil, apiserver.ErrInvalidArgument
+	}
+	if To-From >= MaxEventRange {
+		return nil, ErrTooLargeRange
+	}
+	return s.cn.Events(uint32(From), uint32(To))
+}
+
+// balance returns the balance of the address from the vault
+func (s *GraphQLAPI) balance(addr common.Address) (string, error) {
+	if s.vt == nil {
+		return "", ErrNotLoadedVault
+	}
+	return amountString(s.vt.Balance(s.cn.NewLoaderWrapper(1), addr)), nil
+}
+
+func (s *GraphQLAPI) newAccountResolver(acc types.Account) (*accountResolver, error) {
+	fc := encoding.Factory("account")
+	t, err := fc.TypeOf(acc)
+	if err != nil {
+		return nil, err
+	}
+	name, err := fc.TypeName(t)
+	if err != nil {
+		return nil, err
+	}
+	return &accountResolver{
+		s:        s,
+		acc:      acc,
+		typeName: name,
+	}, nil
+}
+
+func (s *GraphQLAPI) newEventResolver(ev types.Event) (*eventResolver, error) {
+	fc := encoding.Factory("event")
+	t, err := fc.TypeOf(ev)
+	if err != nil {
+		return nil, err
+	}
+	name, err := fc.TypeName(t)
+	if err != nil {
+		return nil, err
+	}
+	return &eventResolver{
+		s:        s,
+		ev:       ev,
+		typeName: name,
+	}, nil
+}
@@ -0,0 +1,882 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/process/formulator"
+	"github.com/fletaio/fleta_v1/process/vault"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+type queryResolver struct {
+	s *GraphQLAPI
+}
+
+func (r *queryResolver) Status() *statusResolver {
+	height, h := r.s.cn.LastStatus()
+	return &statusResolver{
+		cn:     r.s.cn,
+		height: height,
+		hash:   h,
+	}
+}
+
+func (r *queryResolver) Block(args struct {
+	Height *int32
+	Hash   *string
+}) (*blockResolver, error) {
+	var height uint32
+	if args.Hash != nil {
+		h, err := hash.ParseHash(*args.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if height, err = r.s.cn.HeightByHash(h); err != nil {
+			return nil, nil
+		}
+	} else if args.Height != nil {
+		height = uint32(*args.Height)
+	} else {
+		height = r.s.cn.Height()
+	}
+	return r.s.block(height)
+}
+
+func (r *queryResolver) Blocks(args struct {
+	From  int32
+	Count *int32
+}) ([]*blockResolver, error) {
+	From := uint32(args.From)
+	if From == 0 {
+		From = 1
+	}
+	Count := uint32(MaxBlockCount)
+	if args.Count != nil && *args.Count > 0 && *args.Count < MaxBlockCount {
+		Count = uint32(*args.Count)
+	}
+	Height := r.s.cn.Height()
+	list := []*blockResolver{}
+	for i := From; i < From+Count && i <= Height; i++ {
+		br, err := r.s.block(i)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, br)
+	}
+	return list, nil
+}
+
+func (r *queryResolver) Transaction(args struct {
+	Txid string
+}) (*transactionResolver, error) {
+	height, index, err := types.ParseTransactionID(args.Txid)
+	if err != nil {
+		return nil, err
+	}
+	br, err := r.s.block(height)
+	if err != nil || br == nil {
+		return nil, err
+	}
+	if int(index) >= len(br.b.Transactions) {
+		return nil, nil
+	}
+	return &transactionResolver{
+		s:     r.s,
+		b:     br.b,
+		index: index,
+	}, nil
+}
+
+func (r *queryResolver) Account(args struct {
+	Address *string
+	Name    *string
+}) (*accountResolver, error) {
+	loader := r.s.cn.NewLoaderWrapper(1)
+	var addr common.Address
+	if args.Address != nil {
+		a, err := common.ParseAddress(*args.Address)
+		if err != nil {
+			return nil, err
+		}
+		addr = a
+	} else if args.Name != nil {
+		a, err := loader.AddressByName(*args.Name)
+		if err != nil {
+			if isNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		addr = a
+	} else {
+		return nil, apiserver.ErrInvalidArgument
+	}
+	acc, err := loader.Account(addr)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r.s.newAccountResolver(acc)
+}
+
+func (r *queryResolver) Formulator(args struct {
+	Address string
+}) (*formulatorResolver, error) {
+	addr, err := common.ParseAddress(args.Address)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := r.s.cn.NewLoaderWrapper(1).Account(addr)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	frAcc, is := acc.(*formulator.FormulatorAccount)
+	if !is {
+		return nil, ErrNotFormulatorAccount
+	}
+	return &formulatorResolver{
+		s:   r.s,
+		acc: frAcc,
+	}, nil
+}
+
+func (r *queryResolver) Unstakings(args struct {
+	Address      string
+	UnlockHeight int32
+}) ([]*stakingResolver, error) {
+	if r.s.fr == nil {
+		return nil, ErrNotLoadedFormulator
+	}
+	addr, err := common.ParseAddress(args.Address)
+	if err != nil {
+		return nil, err
+	}
+	loader := r.s.cn.NewLoaderWrapper(1)
+	mp, err := r.s.fr.GetUnstakingAmountMap(loader, addr, uint32(args.UnlockHeight))
+	if err != nil {
+		if err == formulator.ErrNotExistUnstakingAmount {
+			return []*stakingResolver{}, nil
+		}
+		return nil, err
+	}
+	list := []*stakingResolver{}
+	mp.EachAll(func(HyperAddr common.Address, am *amount.Amount) bool {
+		list = append(list, &stakingResolver{
+			hyper:       HyperAddr,
+			addr:        addr,
+			amount:      am,
+			autoStaking: r.s.fr.GetUserAutoStaking(loader, HyperAddr, addr),
+		})
+		return true
+	})
+	return list, nil
+}
+
+func (r *queryResolver) Events(args struct {
+	From int32
+	To   int32
+	Type *string
+}) ([]*eventResolver, error) {
+	evs, err := r.s.events(args.From, args.To)
+	if err != nil {
+		return nil, err
+	}
+	fc := encoding.Factory("event")
+	var Type uint16
+	if args.Type != nil {
+		if Type, err = fc.TypeByName(*args.Type); err != nil {
+			return nil, err
+		}
+	}
+	list := []*eventResolver{}
+	for _, ev := range evs {
+		t, err := fc.TypeOf(ev)
+		if err != nil {
+			return nil, err
+		}
+		if args.Type != nil && t != Type {
+			continue
+		}
+		er, err := r.s.newEventResolver(ev)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, er)
+	}
+	return list, nil
+}
+
+type statusResolver struct {
+	cn     types.Provider
+	height uint32
+	hash   hash.Hash256
+}
+
+func (r *statusResolver) ChainID() int32 {
+	return int32(r.cn.ChainID())
+}
+
+func (r *statusResolver) Symbol() string {
+	return r.cn.Symbol()
+}
+
+func (r *statusResolver) Usage() string {
+	return r.cn.Usage()
+}
+
+func (r *statusResolver) Version() int32 {
+	return int32(r.cn.Version())
+}
+
+func (r *statusResolver) Height() int32 {
+	return int32(r.height)
+}
+
+func (r *statusResolver) Hash() string {
+	return r.hash.String()
+}
+
+func (r *statusResolver) Timestamp() string {
+	return strconv.FormatUint(r.cn.LastTimestamp(), 10)
+}
+
+type blockResolver struct {
+	s *GraphQLAPI
+	b *types.Block
+}
+
+func (r *blockResolver) Hash() string {
+	return encoding.Hash(r.b.Header).String()
+}
+
+func (r *blockResolver) Height() int32 {
+	return int32(r.b.Header.Height)
+}
+
+func (r *blockResolver) Version() int32 {
+	return int32(r.b.Header.Version)
+}
+
+func (r *blockResolver) PrevHash() string {
+	return r.b.Header.PrevHash.String()
+}
+
+func (r *blockResolver) LevelRootHash() string {
+	return r.b.Header.LevelRootHash.String()
+}
+
+func (r *blockResolver) ContextHash() string {
+	return r.b.Header.ContextHash.String()
+}
+
+func (r *blockResolver) Timestamp() string {
+	return strconv.FormatUint(r.b.Header.Timestamp, 10)
+}
+
+func (r *blockResolver) Generator() string {
+	return r.b.Header.Generator.String()
+}
+
+func (r *blockResolver) TransactionCount() int32 {
+	return int32(len(r.b.Transactions))
+}
+
+func (r *blockResolver) Transactions() []*transactionResolver {
+	list := make([]*transactionResolver, 0, len(r.b.Transactions))
+	for i := range r.b.Transactions {
+		list = append(list, &transactionResolver{
+			s:     r.s,
+			b:     r.b,
+			index: uint16(i),
+		})
+	}
+	return list
+}
+
+func (r *blockResolver) Events() ([]*eventResolver, error) {
+	evs, err := r.s.cn.Events(r.b.Header.Height, r.b.Header.Height)
+	if err != nil {
+		return nil, err
+	}
+	list := []*eventResolver{}
+	for _, ev := range evs {
+		er, err := r.s.newEventResolver(ev)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, er)
+	}
+	return list, nil
+}
+
+type transactionResolver struct {
+	s     *GraphQLAPI
+	b     *types.Block
+	index uint16
+}
+
+func (r *transactionResolver) Txid() string {
+	return types.TransactionID(r.b.Header.Height, r.index)
+}
+
+func (r *transactionResolver) Hash() string {
+	return chain.HashTransactionByType(r.s.cn.ChainID(), r.b.TransactionTypes[r.index], r.b.Transactions[r.index]).String()
+}
+
+func (r *transactionResolver) Height() int32 {
+	return int32(r.b.Header.Height)
+}
+
+func (r *transactionResolver) Index() int32 {
+	return int32(r.index)
+}
+
+func (r *transactionResolver) Type() (string, error) {
+	return encoding.Factory("transaction").TypeName(r.b.TransactionTypes[r.index])
+}
+
+func (r *transactionResolver) Result() int32 {
+	return int32(r.b.TransactionResults[r.index])
+}
+
+func (r *transactionResolver) From() *string {
+	if tx, is := r.b.Transactions[r.index].(chain.AccountTransaction); is {
+		str := tx.From().String()
+		return &str
+	}
+	return nil
+}
+
+func (r *transactionResolver) Seq() *string {
+	if tx, is := r.b.Transactions[r.index].(chain.AccountTransaction); is {
+		str := strconv.FormatUint(tx.Seq(), 10)
+		return &str
+	}
+	return nil
+}
+
+func (r *transactionResolver) Signatures() []string {
+	list := []string{}
+	for _, sig := range r.b.TransactionSignatures[r.index] {
+		list = append(list, sig.String())
+	}
+	return list
+}
+
+func (r *transactionResolver) JSON() (string, error) {
+	bs, err := r.b.Transactions[r.index].MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// accountResolver resolves the Account interface, the concrete type is decided by the go type of the account
+type accountResolver struct {
+	s        *GraphQLAPI
+	acc      types.Account
+	typeName string
+}
+
+func (r *accountResolver) Address() string {
+	return r.acc.Address().String()
+}
+
+func (r *accountResolver) Name() string {
+	return r.acc.Name()
+}
+
+func (r *accountResolver) Type() string {
+	return r.typeName
+}
+
+func (r *accountResolver) Seq() string {
+	return strconv.FormatUint(r.s.cn.Seq(r.acc.Address()), 10)
+}
+
+func (r *accountResolver) Balance() (string, error) {
+	return r.s.balance(r.acc.Address())
+}
+
+func (r *accountResolver) JSON() (string, error) {
+	bs, err := r.acc.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+func (r *accountResolver) ToSingleAccount() (*singleAccountResolver, bool) {
+	acc, is := r.acc.(*vault.SingleAccount)
+	if !is {
+		return nil, false
+	}
+	return &singleAccountResolver{accountResolver: r, acc: acc}, true
+}
+
+func (r *accountResolver) ToMultiAccount() (*multiAccountResolver, bool) {
+	acc, is := r.acc.(*vault.MultiAccount)
+	if !is {
+		return nil, false
+	}
+	return &multiAccountResolver{accountResolver: r, acc: acc}, true
+}
+
+func (r *accountResolver) ToFormulatorAccount() (*formulatorAccountResolver, bool) {
+	acc, is := r.acc.(*formulator.FormulatorAccount)
+	if !is {
+		return nil, false
+	}
+	return &formulatorAccountResolver{accountResolver: r, acc: acc}, true
+}
+
+func (r *accountResolver) ToUnknownAccount() (*accountResolver, bool) {
+	switch r.acc.(type) {
+	case *vault.SingleAccount, *vault.MultiAccount, *formulator.FormulatorAccount:
+		return nil, false
+	}
+	return r, true
+}
+
+type singleAccountResolver struct {
+	*accountResolver
+	acc *vault.SingleAccount
+}
+
+func (r *singleAccountResolver) KeyHash() string {
+	return r.acc.KeyHash.String()
+}
+
+type multiAccountResolver struct {
+	*accountResolver
+	acc *vault.MultiAccount
+}
+
+func (r *multiAccountResolver) Required() int32 {
+	return int32(r.acc.Required)
+}
+
+func (r *multiAccountResolver) KeyHashes() []string {
+	list := []string{}
+	for _, pubhash := range r.acc.KeyHashes {
+		list = append(list, pubhash.String())
+	}
+	return list
+}
+
+type formulatorAccountResolver struct {
+	*accountResolver
+	acc *formulator.FormulatorAccount
+}
+
+func (r *formulatorAccountResolver) Formulator() *formulatorResolver {
+	return &formulatorResolver{
+		s:   r.s,
+		acc: r.acc,
+	}
+}
+
+type formulatorResolver struct {
+	s   *GraphQLAPI
+	acc *formulator.FormulatorAccount
+}
+
+func (r *formulatorResolver) Address() string {
+	return r.acc.Address_.String()
+}
+
+func (r *formulatorResolver) Name() string {
+	return r.acc.Name_
+}
+
+func (r *formulatorResolver) FormulatorType() string {
+	return formulatorTypeName(r.acc.FormulatorType)
+}
+
+func (r *formulatorResolver) KeyHash() string {
+	return r.acc.KeyHash.String()
+}
+
+func (r *formulatorResolver) GenHash() string {
+	return r.acc.GenHash.String()
+}
+
+func (r *formulatorResolver) Amount() string {
+	return amountString(r.acc.Amount)
+}
+
+func (r *formulatorResolver) StakingAmount() string {
+	return amountString(r.acc.StakingAmount)
+}
+
+func (r *formulatorResolver) Balance() (string, error) {
+	return r.s.balance(r.acc.Address_)
+}
+
+func (r *formulatorResolver) IsRevoked() bool {
+	return r.acc.IsRevoked
+}
+
+func (r *formulatorResolver) RevokedHeight() (*int32, error) {
+	if r.s.fr == nil {
+		return nil, ErrNotLoadedFormulator
+	}
+	height, err := r.s.fr.GetRevokedFormulatorHeight(r.s.cn.NewLoaderWrapper(1), r.acc.Address_)
+	if err != nil {
+		if err == formulator.ErrNotRevoked {
+			return nil, nil
+		}
+		return nil, err
+	}
+	h := int32(height)
+	return &h, nil
+}
+
+func (r *formulatorResolver) PreHeight() int32 {
+	return int32(r.acc.PreHeight)
+}
+
+func (r *formulatorResolver) UpdatedHeight() int32 {
+	return int32(r.acc.UpdatedHeight)
+}
+
+func (r *formulatorResolver) RewardCount() int32 {
+	return int32(r.acc.RewardCount)
+}
+
+func (r *formulatorResolver) Policy() *validatorPolicyResolver {
+	if r.acc.Policy == nil {
+		return nil
+	}
+	return &validatorPolicyResolver{policy: r.acc.Policy}
+}
+
+// Stakings returns staking amounts of the hyper formulator in the order of addresses
+func (r *formulatorResolver) Stakings() ([]*stakingResolver, error) {
+	list := []*stakingResolver{}
+	if r.acc.FormulatorType != formulator.HyperFormulatorType {
+		return list, nil
+	}
+	if r.s.fr == nil {
+		return nil, ErrNotLoadedFormulator
+	}
+	loader := r.s.cn.NewLoaderWrapper(1)
+	mp, err := r.s.fr.GetStakingAmountMap(loader, r.acc.Address_)
+	if err != nil {
+		return nil, err
+	}
+	for StakingAddress, am := range mp {
+		list = append(list, &stakingResolver{
+			hyper:       r.acc.Address_,
+			addr:        StakingAddress,
+			amount:      am,
+			autoStaking: r.s.fr.GetUserAutoStaking(loader, r.acc.Address_, StakingAddress),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].addr[:], list[j].addr[:]) < 0
+	})
+	return list, nil
+}
+
+// Rewards returns rewards of the formulator from reward events in the height range
+func (r *formulatorResolver) Rewards(args struct {
+	From int32
+	To   int32
+}) ([]*rewardResolver, error) {
+	evs, err := r.s.events(args.From, args.To)
+	if err != nil {
+		return nil, err
+	}
+	list := []*rewardResolver{}
+	for _, ev := range evs {
+		if rev, is := ev.(*formulator.RewardEvent); is {
+			rr := &rewardResolver{
+				ev:   rev,
+				addr: r.acc.Address_,
+			}
+			if rr.has() {
+				list = append(list, rr)
+			}
+		}
+	}
+	return list, nil
+}
+
+type validatorPolicyResolver struct {
+	policy *formulator.ValidatorPolicy
+}
+
+func (r *validatorPolicyResolver) CommissionRatio1000() int32 {
+	return int32(r.policy.CommissionRatio1000)
+}
+
+func (r *validatorPolicyResolver) MinimumStaking() string {
+	return amountString(r.policy.MinimumStaking)
+}
+
+func (r *validatorPolicyResolver) PayOutInterval() int32 {
+	return int32(r.policy.PayOutInterval)
+}
+
+type stakingResolver struct {
+	hyper       common.Address
+	addr        common.Address
+	amount      *amount.Amount
+	autoStaking bool
+}
+
+func (r *stakingResolver) HyperFormulator() string {
+	return r.hyper.String()
+}
+
+func (r *stakingResolver) Address() string {
+	return r.addr.String()
+}
+
+func (r *stakingResolver) Amount() string {
+	return amountString(r.amount)
+}
+
+func (r *stakingResolver) AutoStaking() bool {
+	return r.autoStaking
+}
+
+// rewardResolver resolves the reward of the formulator in the reward event
+type rewardResolver struct {
+	ev   *formulator.RewardEvent
+	addr common.Address
+}
+
+func (r *rewardResolver) has() bool {
+	return r.ev.GenBlockMap.Has(r.addr) ||
+		r.ev.RewardMap.Has(r.addr) ||
+		r.ev.StackedMap.Has(r.addr) ||
+		r.ev.CommissionMap.Has(r.addr) ||
+		r.ev.StakedMap.Has(r.addr) ||
+		r.ev.StakeRewardMap.Has(r.addr)
+}
+
+func (r *rewardResolver) Height() int32 {
+	return int32(r.ev.Height())
+}
+
+func (r *rewardResolver) Formulator() string {
+	return r.addr.String()
+}
+
+func (r *rewardResolver) GenBlocks() int32 {
+	v, _ := r.ev.GenBlockMap.Get(r.addr)
+	return int32(v)
+}
+
+func (r *rewardResolver) Reward() string {
+	am, _ := r.ev.RewardMap.Get(r.addr)
+	return amountString(am)
+}
+
+func (r *rewardResolver) Stacked() string {
+	am, _ := r.ev.StackedMap.Get(r.addr)
+	return amountString(am)
+}
+
+func (r *rewardResolver) Commission() string {
+	am, _ := r.ev.CommissionMap.Get(r.addr)
+	return amountString(am)
+}
+
+func (r *rewardResolver) Staked() []*stakeRewardResolver {
+	return stakeRewards(r.ev.StakedMap, r.addr)
+}
+
+func (r *rewardResolver) StakeRewards() []*stakeRewardResolver {
+	return stakeRewards(r.ev.StakeRewardMap, r.addr)
+}
+
+type stakeRewardResolver struct {
+	addr   common.Address
+	amount *amount.Amount
+}
+
+func (r *stakeRewardResolver) Address() string {
+	return r.addr.String()
+}
+
+func (r *stakeRewardResolver) Amount() string {
+	return amountString(r.amount)
+}
+
+// eventResolver resolves the Event interface, the concrete type is decided by the go type of the event
+type eventResolver struct {
+	s        *GraphQLAPI
+	ev       types.Event
+	typeName string
+}
+
+func (r *eventResolver) Height() int32 {
+	return int32(r.ev.Height())
+}
+
+func (r *eventResolver) Index() int32 {
+	return int32(r.ev.Index())
+}
+
+func (r *eventResolver) N() int32 {
+	return int32(r.ev.N())
+}
+
+func (r *eventResolver) Type() string {
+	return r.typeName
+}
+
+func (r *eventResolver) JSON() (string, error) {
+	bs, err := r.ev.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+func (r *eventResolver) ToRewardEvent() (*rewardEventResolver, bool) {
+	ev, is := r.ev.(*formulator.RewardEvent)
+	if !is {
+		return nil, false
+	}
+	return &rewardEventResolver{eventResolver: r, ev: ev}, true
+}
+
+func (r *eventResolver) ToRevokedEvent() (*revokedEventResolver, bool) {
+	ev, is := r.ev.(*formulator.RevokedEvent)
+	if !is {
+		return nil, false
+	}
+	return &revokedEventResolver{eventResolver: r, ev: ev}, true
+}
+
+func (r *eventResolver) ToUnstakedEvent() (*unstakedEventResolver, bool) {
+	ev, is := r.ev.(*formulator.UnstakedEvent)
+	if !is {
+		return nil, false
+	}
+	return &unstakedEventResolver{eventResolver: r, ev: ev}, true
+}
+
+func (r *eventResolver) ToUnknownEvent() (*eventResolver, bool) {
+	switch r.ev.(type) {
+	case *formulator.RewardEvent, *formulator.RevokedEvent, *formulator.UnstakedEvent:
+		return nil, false
+	}
+	return r, true
+}
+
+type rewardEventResolver struct {
+	*eventResolver
+	ev *formulator.RewardEvent
+}
+
+// Rewards returns rewards of formulators in the event in the order of addresses
+func (r *rewardEventResolver) Rewards() []*rewardResolver {
+	addrMap := map[common.Address]bool{}
+	add := func(addr common.Address) bool {
+		addrMap[addr] = true
+		return true
+	}
+	r.ev.GenBlockMap.EachAll(func(addr common.Address, _ uint32) bool { return add(addr) })
+	r.ev.RewardMap.EachAll(func(addr common.Address, _ *amount.Amount) bool { return add(addr) })
+	r.ev.StackedMap.EachAll(func(addr common.Address, _ *amount.Amount) bool { return add(addr) })
+	r.ev.CommissionMap.EachAll(func(addr common.Address, _ *amount.Amount) bool { return add(addr) })
+	r.ev.StakedMap.EachAll(func(addr common.Address, _ *types.AddressAmountMap) bool { return add(addr) })
+	r.ev.StakeRewardMap.EachAll(func(addr common.Address, _ *types.AddressAmountMap) bool { return add(addr) })
+
+	list := []*rewardResolver{}
+	for addr := range addrMap {
+		list = append(list, &rewardResolver{
+			ev:   r.ev,
+			addr: addr,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].addr[:], list[j].addr[:]) < 0
+	})
+	return list
+}
+
+type revokedEventResolver struct {
+	*eventResolver
+	ev *formulator.RevokedEvent
+}
+
+func (r *revokedEventResolver) Formulator() string {
+	return r.ev.Formulator.String()
+}
+
+type unstakedEventResolver struct {
+	*eventResolver
+	ev *formulator.UnstakedEvent
+}
+
+func (r *unstakedEventResolver) HyperFormulator() string {
+	return r.ev.HyperFormulator.String()
+}
+
+func (r *unstakedEventResolver) Address() string {
+	return r.ev.Address.String()
+}
+
+func (r *unstakedEventResolver) Amount() string {
+	return amountString(r.ev.Amount)
+}
+
+// stakeRewards returns amounts of stakers of the hyper formulator in the map
+func stakeRewards(mp *types.AddressAddressAmountMap, HyperAddr common.Address) []*stakeRewardResolver {
+	list := []*stakeRewardResolver{}
+	if sm, has := mp.Get(HyperAddr); has {
+		sm.EachAll(func(addr common.Address, am *amount.Amount) bool {
+			list = append(list, &stakeRewardResolver{
+				addr:   addr,
+				amount: am,
+			})
+			return true
+		})
+	}
+	return list
+}
+
+func formulatorTypeName(t formulator.FormulatorType) string {
+	switch t {
+	case formulator.AlphaFormulatorType:
+		return "alpha"
+	case formulator.SigmaFormulatorType:
+		return "sigma"
+	case formulator.OmegaFormulatorType:
+		return "omega"
+	case formulator.HyperFormulatorType:
+		return "hyper"
+	default:
+		return strconv.Itoa(int(t))
+	}
+}
+
+func amountString(am *amount.Amount) string {
+	if am == nil {
+		return "0"
+	}
+	return am.String()
+}
+
+func isNotExist(err error) bool {
+	return err == types.ErrNotExistAccount || err == types.ErrDeletedAccount
+}
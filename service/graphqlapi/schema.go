@@ -0,0 +1,199 @@
+package graphqlapi
+
+// schema is the graphql schema of the chain state
+// Amounts and uint64 values are strings, hashes and addresses are strings of their text forms
+const schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	status: Status!
+	block(height: Int, hash: String): Block
+	blocks(from: Int!, count: Int): [Block!]!
+	transaction(txid: String!): Transaction
+	account(address: String, name: String): Account
+	formulator(address: String!): Formulator
+	unstakings(address: String!, unlockHeight: Int!): [Staking!]!
+	events(from: Int!, to: Int!, type: String): [Event!]!
+}
+
+type Status {
+	chainID: Int!
+	symbol: String!
+	usage: String!
+	version: Int!
+	height: Int!
+	hash: String!
+	timestamp: String!
+}
+
+type Block {
+	hash: String!
+	height: Int!
+	version: Int!
+	prevHash: String!
+	levelRootHash: String!
+	contextHash: String!
+	timestamp: String!
+	generator: String!
+	transactionCount: Int!
+	transactions: [Transaction!]!
+	events: [Event!]!
+}
+
+type Transaction {
+	txid: String!
+	hash: String!
+	height: Int!
+	index: Int!
+	type: String!
+	result: Int!
+	from: String
+	seq: String
+	signatures: [String!]!
+	json: String!
+}
+
+interface Account {
+	address: String!
+	name: String!
+	type: String!
+	seq: String!
+	balance: String!
+	json: String!
+}
+
+type SingleAccount implements Account {
+	address: String!
+	name: String!
+	type: String!
+	seq: String!
+	balance: String!
+	json: String!
+	keyHash: String!
+}
+
+type MultiAccount implements Account {
+	address: String!
+	name: String!
+	type: String!
+	seq: String!
+	balance: String!
+	json: String!
+	required: Int!
+	keyHashes: [String!]!
+}
+
+type FormulatorAccount implements Account {
+	address: String!
+	name: String!
+	type: String!
+	seq: String!
+	balance: String!
+	json: String!
+	formulator: Formulator!
+}
+
+type UnknownAccount implements Account {
+	address: String!
+	name: String!
+	type: String!
+	seq: String!
+	balance: String!
+	json: String!
+}
+
+type Formulator {
+	address: String!
+	name: String!
+	formulatorType: String!
+	keyHash: String!
+	genHash: String!
+	amount: String!
+	stakingAmount: String!
+	balance: String!
+	isRevoked: Boolean!
+	revokedHeight: Int
+	preHeight: Int!
+	updatedHeight: Int!
+	rewardCount: Int!
+	policy: ValidatorPolicy
+	stakings: [Staking!]!
+	rewards(from: Int!, to: Int!): [Reward!]!
+}
+
+type ValidatorPolicy {
+	commissionRatio1000: Int!
+	minimumStaking: String!
+	payOutInterval: Int!
+}
+
+type Staking {
+	hyperFormulator: String!
+	address: String!
+	amount: String!
+	autoStaking: Boolean!
+}
+
+type Reward {
+	height: Int!
+	formulator: String!
+	genBlocks: Int!
+	reward: String!
+	stacked: String!
+	commission: String!
+	staked: [StakeReward!]!
+	stakeRewards: [StakeReward!]!
+}
+
+type StakeReward {
+	address: String!
+	amount: String!
+}
+
+interface Event {
+	height: Int!
+	index: Int!
+	n: Int!
+	type: String!
+	json: String!
+}
+
+type RewardEvent implements Event {
+	height: Int!
+	index: Int!
+	n: Int!
+	type: String!
+	json: String!
+	rewards: [Reward!]!
+}
+
+type RevokedEvent implements Event {
+	height: Int!
+	index: Int!
+	n: Int!
+	type: String!
+	json: String!
+	formulator: String!
+}
+
+type UnstakedEvent implements Event {
+	height: Int!
+	index: Int!
+	n: Int!
+	type: String!
+	json: String!
+	hyperFormulator: String!
+	address: String!
+	amount: String!
+}
+
+type UnknownEvent implements Event {
+	height: Int!
+	index: Int!
+	n: Int!
+	type: String!
+	json: String!
+}
+`
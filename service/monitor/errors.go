@@ -0,0 +1,12 @@
+package monitor
+
+import (
+	"errors"
+)
+
+// errors
+var (
+	ErrNodeNotReady  = errors.New("node not ready")
+	ErrNotSynced     = errors.New("not synced")
+	ErrNotEnoughPeer = errors.New("not enough peer")
+)
@@ -0,0 +1,249 @@
+package monitor
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/metrics"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+	"github.com/labstack/echo"
+)
+
+// readiness settings
+const (
+	DefaultMaxHeightLag   = 10
+	DefaultMinPeers       = 1
+	WritableCheckInterval = time.Second
+)
+
+// Node defines functions of the node that decide the readiness
+type Node interface {
+	PeerCount() int
+	BestPeerHeight() uint32
+}
+
+// WritableChecker is the store that can check that it accepts updates
+type WritableChecker interface {
+	CheckWritable() error
+}
+
+// Config is the config of the readiness
+type Config struct {
+	MaxHeightLag uint32 // the node is synced when the height is behind the best peer height within the lag
+	MinPeers     int    // the minimum number of connected peers
+}
+
+// Health is the result of /healthz
+type Health struct {
+	Status string `json:"status"`
+	Height uint32 `json:"height"`
+	Uptime uint64 `json:"uptime"`
+}
+
+// Readiness is the result of /readyz, Reasons explain why the node is not ready
+type Readiness struct {
+	Ready          bool     `json:"ready"`
+	Height         uint32   `json:"height"`
+	BestPeerHeight uint32   `json:"best_peer_height"`
+	PeerCount      int      `json:"peer_count"`
+	Synced         bool     `json:"synced"`
+	StoreWritable  bool     `json:"store_writable"`
+	Reasons        []string `json:"reasons,omitempty"`
+}
+
+// Monitor serves the liveness, the readiness and prometheus metrics of the node
+type Monitor struct {
+	types.ServiceBase
+	sync.Mutex
+	cn          types.Provider
+	nd          Node
+	config      Config
+	startedAt   time.Time
+	checkLock   sync.Mutex
+	checkedAt   time.Time
+	writableErr error
+}
+
+// NewMonitor returns a Monitor
+func NewMonitor() *Monitor {
+	s := &Monitor{
+		config: Config{
+			MaxHeightLag: DefaultMaxHeightLag,
+			MinPeers:     DefaultMinPeers,
+		},
+		startedAt: time.Now(),
+	}
+	return s
+}
+
+// Name returns the name of the service
+func (s *Monitor) Name() string {
+	return "fleta.monitor"
+}
+
+// SetConfig updates the config, zero values are replaced by default values
+func (s *Monitor) SetConfig(config Config) {
+	s.Lock()
+	defer s.Unlock()
+
+	if config.MaxHeightLag == 0 {
+		config.MaxHeightLag = DefaultMaxHeightLag
+	}
+	if config.MinPeers <= 0 {
+		config.MinPeers = DefaultMinPeers
+	}
+	s.config = config
+}
+
+// SetNode sets the node that provides peer status
+func (s *Monitor) SetNode(nd Node) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.nd = nd
+}
+
+func (s *Monitor) node() Node {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.nd
+}
+
+// Init called when initialize service
+func (s *Monitor) Init(pm types.ProcessManager, cn types.Provider) error {
+	s.cn = cn
+
+	metrics.NewGaugeFunc("fleta_node_best_peer_height", "The highest height that is reported by connected peers", func() float64 {
+		if nd := s.node(); nd != nil {
+			return float64(nd.BestPeerHeight())
+		}
+		return 0
+	})
+	metrics.NewGaugeFunc("fleta_node_peers", "The number of connected peers of the node", func() float64 {
+		if nd := s.node(); nd != nil {
+			return float64(nd.PeerCount())
+		}
+		return 0
+	})
+	metrics.NewGaugeFunc("fleta_node_uptime_seconds", "The time since the node is started", func() float64 {
+		return time.Since(s.startedAt).Seconds()
+	})
+	metrics.NewGaugeFunc("fleta_go_goroutines", "The number of goroutines", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	metrics.NewGaugeFunc("fleta_go_heap_alloc_bytes", "The number of bytes of allocated heap objects", func() float64 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		return float64(ms.HeapAlloc)
+	})
+
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		routes := []*apiserver.HTTPRoute{
+			{
+				Method: http.MethodGet,
+				Path:   "/healthz",
+				RPC:    "monitor.healthz",
+				Handler: func(c echo.Context) error {
+					return c.JSON(http.StatusOK, s.Health())
+				},
+			},
+			{
+				Method: http.MethodGet,
+				Path:   "/readyz",
+				RPC:    "monitor.readyz",
+				Handler: func(c echo.Context) error {
+					rd := s.Readiness()
+					if !rd.Ready {
+						return c.JSON(http.StatusServiceUnavailable, rd)
+					}
+					return c.JSON(http.StatusOK, rd)
+				},
+			},
+			{
+				Method: http.MethodGet,
+				Path:   "/metrics",
+				RPC:    "monitor.metrics",
+				Handler: func(c echo.Context) error {
+					var buffer bytes.Buffer
+					if err := metrics.DefaultRegistry.WritePrometheus(&buffer); err != nil {
+						return err
+					}
+					return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buffer.Bytes())
+				},
+			},
+		}
+		for _, route := range routes {
+			if err := v.Handle(route); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Health returns the liveness of the node
+func (s *Monitor) Health() *Health {
+	return &Health{
+		Status: "ok",
+		Height: s.cn.Height(),
+		Uptime: uint64(time.Since(s.startedAt) / time.Second),
+	}
+}
+
+// Readiness returns whether the node is synced with peers, has enough peers and can write to the store
+func (s *Monitor) Readiness() *Readiness {
+	s.Lock()
+	config := s.config
+	nd := s.nd
+	s.Unlock()
+
+	rd := &Readiness{
+		Height: s.cn.Height(),
+	}
+	if nd == nil {
+		rd.Reasons = append(rd.Reasons, ErrNodeNotReady.Error())
+	} else {
+		rd.BestPeerHeight = nd.BestPeerHeight()
+		rd.PeerCount = nd.PeerCount()
+		rd.Synced = rd.Height+config.MaxHeightLag >= rd.BestPeerHeight
+		if !rd.Synced {
+			rd.Reasons = append(rd.Reasons, ErrNotSynced.Error())
+		}
+		if rd.PeerCount < config.MinPeers {
+			rd.Reasons = append(rd.Reasons, ErrNotEnoughPeer.Error())
+		}
+	}
+	if err := s.checkWritable(); err != nil {
+		rd.Reasons = append(rd.Reasons, err.Error())
+	} else {
+		rd.StoreWritable = true
+	}
+	rd.Ready = len(rd.Reasons) == 0
+	return rd
+}
+
+// checkWritable checks the store at most once in the interval, the last result is returned in the interval
+func (s *Monitor) checkWritable() error {
+	wc, is := s.cn.(WritableChecker)
+	if !is {
+		return nil
+	}
+
+	s.checkLock.Lock()
+	defer s.checkLock.Unlock()
+
+	if time.Since(s.checkedAt) >= WritableCheckInterval {
+		s.writableErr = wc.CheckWritable()
+		s.checkedAt = time.Now()
+	}
+	return s.writableErr
+}
@@ -0,0 +1,187 @@
+package p2p
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/rlog"
+	"github.com/fletaio/fleta_v1/service/p2p/nat"
+)
+
+// address discovery settings
+const (
+	MinAddressVotes     = 4
+	MaxAddressVotes     = 64
+	PortMappingLifetime = 20 * time.Minute
+	PortMappingRefresh  = 15 * time.Minute
+)
+
+type addressVote struct {
+	IP    string
+	Group string
+}
+
+// addressVoter keeps the external ip of the node that is observed by peers that the node dialed
+// Peers of the same network group have a vote together, so identities that are created on a few hosts cannot rewrite the address
+type addressVoter struct {
+	sync.Mutex
+	voteMap map[string]*addressVote
+}
+
+func newAddressVoter() *addressVoter {
+	return &addressVoter{
+		voteMap: map[string]*addressVote{},
+	}
+}
+
+// addressGroup returns the network group of the address, /16 for IPv4 and /32 for IPv6
+func addressGroup(addr net.Addr) string {
+	tcpAddr, is := addr.(*net.TCPAddr)
+	if !is {
+		return ""
+	}
+	if ip := tcpAddr.IP.To4(); ip != nil {
+		return ip.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return tcpAddr.IP.Mask(net.CIDRMask(32, 128)).String()
+}
+
+// Vote records the ip that is observed by the outbound peer of the remote address
+func (av *addressVoter) Vote(ID string, remote net.Addr, IP string) {
+	if ip := net.ParseIP(IP); ip == nil || ip.IsUnspecified() {
+		return
+	}
+	Group := addressGroup(remote)
+	if len(Group) == 0 {
+		return
+	}
+
+	av.Lock()
+	defer av.Unlock()
+
+	if _, has := av.voteMap[ID]; !has && len(av.voteMap) >= MaxAddressVotes {
+		for k := range av.voteMap {
+			delete(av.voteMap, k)
+			break
+		}
+	}
+	av.voteMap[ID] = &addressVote{
+		IP:    IP,
+		Group: Group,
+	}
+}
+
+// Best returns the ip that has the most votes and the count of them
+// The count is the number of network groups that voted the ip
+func (av *addressVoter) Best() (string, int) {
+	av.Lock()
+	defer av.Unlock()
+
+	groupMap := map[string]map[string]bool{}
+	for _, v := range av.voteMap {
+		groups, has := groupMap[v.IP]
+		if !has {
+			groups = map[string]bool{}
+			groupMap[v.IP] = groups
+		}
+		groups[v.Group] = true
+	}
+	countMap := map[string]int{}
+	for IP, groups := range groupMap {
+		countMap[IP] = len(groups)
+	}
+	var best string
+	var bestCount int
+	for IP, Count := range countMap {
+		if Count > bestCount || (Count == bestCount && IP < best) {
+			best = IP
+			bestCount = Count
+		}
+	}
+	return best, bestCount
+}
+
+// SetAdvertiseAddress sets the address that is advertised to peers instead of the discovered one
+func (ms *NodeMesh) SetAdvertiseAddress(Address string) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.advertiseAddress = Address
+}
+
+// SetNAT sets the NAT that maps the port of the bind address when the mesh runs
+func (ms *NodeMesh) SetNAT(n nat.NAT) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.nat = n
+}
+
+// AdvertiseAddress returns the address that peers can dial to the node
+// The explicit address is used first, the mapped address of the NAT and the ip that is voted by the most network groups with the bind port after
+func (ms *NodeMesh) AdvertiseAddress() string {
+	ms.Lock()
+	advertiseAddress := ms.advertiseAddress
+	natAddress := ms.natAddress
+	ms.Unlock()
+
+	if len(advertiseAddress) > 0 {
+		return advertiseAddress
+	}
+	if len(natAddress) > 0 {
+		return natAddress
+	}
+	if _, port, err := net.SplitHostPort(ms.BindAddress); err == nil {
+		if IP, Count := ms.voter.Best(); Count >= MinAddressVotes {
+			return net.JoinHostPort(IP, port)
+		}
+	}
+	return ms.BindAddress
+}
+
+// runPortMapping maps the bind port by the NAT and refreshes it before the lifetime is expired
+func (ms *NodeMesh) runPortMapping(n nat.NAT) {
+	_, portStr, err := net.SplitHostPort(ms.BindAddress)
+	if err != nil {
+		rlog.Println("[nat]", err)
+		return
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		rlog.Println("[nat]", err)
+		return
+	}
+	for {
+		if ExternalPort, err := n.AddPortMapping("tcp", port, port, "fleta", PortMappingLifetime); err != nil {
+			rlog.Println("[nat]", n.String(), err)
+		} else if ip, err := n.ExternalIP(); err != nil {
+			rlog.Println("[nat]", n.String(), err)
+		} else {
+			Address := net.JoinHostPort(ip.String(), strconv.Itoa(ExternalPort))
+			ms.Lock()
+			ms.natAddress = Address
+			ms.Unlock()
+			rlog.Println("[nat]", n.String(), "Mapped", Address)
+		}
+		time.Sleep(PortMappingRefresh)
+	}
+}
+
+// resolvePeerAddress returns the dialable address of the peer by the advertised address
+// The host of the remote address is used when the advertised address does not have it
+func resolvePeerAddress(remote net.Addr, Advertised string) string {
+	host, port, err := net.SplitHostPort(Advertised)
+	if err != nil {
+		return ""
+	}
+	if ip := net.ParseIP(host); len(host) > 0 && (ip == nil || !ip.IsUnspecified()) {
+		return Advertised
+	}
+	addr, is := remote.(*net.TCPAddr)
+	if !is {
+		return ""
+	}
+	return net.JoinHostPort(addr.IP.String(), port)
+}
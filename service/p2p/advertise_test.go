@@ -0,0 +1,41 @@
+package p2p
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestAddressVoterGroups(t *testing.T) {
+	av := newAddressVoter()
+
+	// identities of the same /16 have a vote together
+	for i := 0; i < 10; i++ {
+		remote := &net.TCPAddr{IP: net.IPv4(10, 1, byte(i), 1), Port: 7000}
+		av.Vote("sybil"+strconv.Itoa(i), remote, "6.6.6.6")
+	}
+	if IP, Count := av.Best(); IP != "6.6.6.6" || Count != 1 {
+		t.Fatalf("invalid best of the same group: %v %v", IP, Count)
+	}
+
+	for i := 0; i < MinAddressVotes; i++ {
+		remote := &net.TCPAddr{IP: net.IPv4(20, byte(i), 0, 1), Port: 7000}
+		av.Vote("honest"+strconv.Itoa(i), remote, "1.2.3.4")
+	}
+	if IP, Count := av.Best(); IP != "1.2.3.4" || Count != MinAddressVotes {
+		t.Fatalf("invalid best of distinct groups: %v %v", IP, Count)
+	}
+
+	v6 := &net.TCPAddr{IP: net.ParseIP("2001:db8:1:2::1"), Port: 7000}
+	other := &net.TCPAddr{IP: net.ParseIP("2001:db8:ffff::1"), Port: 7000}
+	if addressGroup(v6) != addressGroup(other) {
+		t.Fatal("addresses of the same /32 are in the different groups")
+	}
+	if addressGroup(&net.UDPAddr{IP: net.IPv4(1, 1, 1, 1)}) != "" {
+		t.Fatal("the address that is not tcp has a group")
+	}
+	av.Vote("invalid", v6, "0.0.0.0")
+	if _, has := av.voteMap["invalid"]; has {
+		t.Fatal("the unspecified ip is voted")
+	}
+}
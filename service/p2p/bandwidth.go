@@ -0,0 +1,348 @@
+package p2p
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+)
+
+// Priority is the send priority of the message, the lower value is sent first
+type Priority int
+
+// priorities of messages
+const (
+	PriorityConsensus   = Priority(0)
+	PriorityBlock       = Priority(1)
+	PriorityTransaction = Priority(2)
+	PriorityPeerList    = Priority(3)
+	PriorityCount       = 4
+)
+
+func (pr Priority) String() string {
+	switch pr {
+	case PriorityConsensus:
+		return "consensus"
+	case PriorityBlock:
+		return "block"
+	case PriorityTransaction:
+		return "transaction"
+	case PriorityPeerList:
+		return "peerlist"
+	default:
+		return "unknown"
+	}
+}
+
+// bandwidth settings
+const (
+	MaxPeerSendQueueSize = 64 * 1024 * 1024
+	MaxSendQueueLen      = 1000
+	SendQueueTimeout     = 10 * time.Second
+	NodeSendQueueTimeout = 2 * time.Second
+)
+
+var messagePriorityMap = map[uint16]Priority{}
+
+func init() {
+	for _, t := range []uint16{
+		StatusMessageType,
+		RequestMessageType,
+		BlockMessageType,
+		HeaderRequestMessageType,
+		HeaderMessageType,
+		BatchRequestMessageType,
+		CompactBlockMessageType,
+		CompactTxRequestMessageType,
+		CompactTxMessageType,
+	} {
+		messagePriorityMap[t] = PriorityBlock
+	}
+	for _, t := range []uint16{
+		TransactionMessageType,
+		TxInvMessageType,
+		TxGetDataMessageType,
+	} {
+		messagePriorityMap[t] = PriorityTransaction
+	}
+	for _, t := range []uint16{
+		PeerListMessageType,
+		RequestPeerListMessageType,
+		FindNodeMessageType,
+		NodesMessageType,
+	} {
+		messagePriorityMap[t] = PriorityPeerList
+	}
+}
+
+// PacketPriority returns the priority of the packet by its message type
+// Messages that are not defined in p2p are consensus messages of the formulation, so they have the highest priority
+func PacketPriority(bs []byte) Priority {
+	if len(bs) < 2 {
+		return PriorityConsensus
+	}
+	if pr, has := messagePriorityMap[binutil.LittleEndian.Uint16(bs)]; has {
+		return pr
+	}
+	return PriorityConsensus
+}
+
+// BandwidthConfig is the config of bandwidth caps in bytes per second, zero means unlimited
+type BandwidthConfig struct {
+	GlobalUpload   int64 `json:"global_upload"`
+	GlobalDownload int64 `json:"global_download"`
+	PeerUpload     int64 `json:"peer_upload"`
+	PeerDownload   int64 `json:"peer_download"`
+}
+
+// TrafficInfo represents counters of the traffic
+type TrafficInfo struct {
+	SentBytes   uint64 `json:"sent_bytes"`
+	RecvBytes   uint64 `json:"recv_bytes"`
+	SentPackets uint64 `json:"sent_packets"`
+	RecvPackets uint64 `json:"recv_packets"`
+	Dropped     uint64 `json:"dropped"`
+	QueuedBytes int    `json:"queued_bytes"`
+}
+
+// BandwidthInfo represents the bandwidth config and traffic counters of the node and its peers
+type BandwidthInfo struct {
+	Config      BandwidthConfig        `json:"config"`
+	Total       TrafficInfo            `json:"total"`
+	SendQueue   int                    `json:"send_queue"`
+	SendDropped uint64                 `json:"send_dropped"`
+	Peers       map[string]TrafficInfo `json:"peers"`
+}
+
+type trafficStats struct {
+	sentBytes   uint64
+	recvBytes   uint64
+	sentPackets uint64
+	recvPackets uint64
+	dropped     uint64
+}
+
+func (st *trafficStats) info() TrafficInfo {
+	return TrafficInfo{
+		SentBytes:   atomic.LoadUint64(&st.sentBytes),
+		RecvBytes:   atomic.LoadUint64(&st.recvBytes),
+		SentPackets: atomic.LoadUint64(&st.sentPackets),
+		RecvPackets: atomic.LoadUint64(&st.recvPackets),
+		Dropped:     atomic.LoadUint64(&st.dropped),
+	}
+}
+
+// byteLimiter is a token bucket of bytes that sleeps the caller until the used bytes are refilled
+type byteLimiter struct {
+	sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+func (l *byteLimiter) setRate(rate int64) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.rate = rate
+	l.tokens = float64(rate)
+	l.last = time.Now()
+}
+
+// wait takes n bytes from the bucket, the bucket goes into debt and the caller sleeps until it is paid off
+func (l *byteLimiter) wait(n int) {
+	l.Lock()
+	if l.rate <= 0 {
+		l.Unlock()
+		return
+	}
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var d time.Duration
+	if l.tokens < 0 {
+		d = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.Unlock()
+
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// Bandwidth limits and counts the traffic of the node and its peers
+type Bandwidth struct {
+	sync.Mutex
+	config   BandwidthConfig
+	upload   *byteLimiter
+	download *byteLimiter
+	stats    trafficStats
+	peerMap  map[string]*PeerBandwidth
+}
+
+// NewBandwidth returns a Bandwidth without caps
+func NewBandwidth() *Bandwidth {
+	return &Bandwidth{
+		upload:   &byteLimiter{},
+		download: &byteLimiter{},
+		peerMap:  map[string]*PeerBandwidth{},
+	}
+}
+
+// SetConfig updates caps of the node and connected peers
+func (bw *Bandwidth) SetConfig(config BandwidthConfig) {
+	bw.Lock()
+	defer bw.Unlock()
+
+	bw.config = config
+	bw.upload.setRate(config.GlobalUpload)
+	bw.download.setRate(config.GlobalDownload)
+	for _, pb := range bw.peerMap {
+		pb.upload.setRate(config.PeerUpload)
+		pb.download.setRate(config.PeerDownload)
+	}
+}
+
+// Config returns the current config
+func (bw *Bandwidth) Config() BandwidthConfig {
+	bw.Lock()
+	defer bw.Unlock()
+
+	return bw.config
+}
+
+// Peer returns the bandwidth of the peer, connections of the same peer share it until all of them are released
+func (bw *Bandwidth) Peer(ID string) *PeerBandwidth {
+	bw.Lock()
+	defer bw.Unlock()
+
+	pb, has := bw.peerMap[ID]
+	if !has {
+		pb = &PeerBandwidth{
+			bw:       bw,
+			id:       ID,
+			upload:   &byteLimiter{},
+			download: &byteLimiter{},
+		}
+		pb.upload.setRate(bw.config.PeerUpload)
+		pb.download.setRate(bw.config.PeerDownload)
+		bw.peerMap[ID] = pb
+	}
+	pb.refs++
+	return pb
+}
+
+func (bw *Bandwidth) release(pb *PeerBandwidth) {
+	bw.Lock()
+	defer bw.Unlock()
+
+	pb.refs--
+	if pb.refs <= 0 && bw.peerMap[pb.id] == pb {
+		delete(bw.peerMap, pb.id)
+	}
+}
+
+// Total returns counters of the node
+func (bw *Bandwidth) Total() TrafficInfo {
+	return bw.stats.info()
+}
+
+// Peers returns counters of connected peers
+func (bw *Bandwidth) Peers() map[string]*PeerBandwidth {
+	bw.Lock()
+	defer bw.Unlock()
+
+	peerMap := map[string]*PeerBandwidth{}
+	for ID, pb := range bw.peerMap {
+		peerMap[ID] = pb
+	}
+	return peerMap
+}
+
+// PeerBandwidth limits and counts the traffic of the peer
+type PeerBandwidth struct {
+	bw       *Bandwidth
+	id       string
+	upload   *byteLimiter
+	download *byteLimiter
+	stats    trafficStats
+	refs     int
+}
+
+// WaitUpload waits until the bytes can be sent under caps of the peer and the node
+func (pb *PeerBandwidth) WaitUpload(n int) {
+	pb.upload.wait(n)
+	pb.bw.upload.wait(n)
+	atomic.AddUint64(&pb.stats.sentBytes, uint64(n))
+	atomic.AddUint64(&pb.stats.sentPackets, 1)
+	atomic.AddUint64(&pb.bw.stats.sentBytes, uint64(n))
+	atomic.AddUint64(&pb.bw.stats.sentPackets, 1)
+	sentBytes.Add(uint64(n))
+	sentPackets.Inc()
+}
+
+// WaitDownload waits until the bytes can be received under caps of the peer and the node
+func (pb *PeerBandwidth) WaitDownload(n int) {
+	atomic.AddUint64(&pb.stats.recvBytes, uint64(n))
+	atomic.AddUint64(&pb.stats.recvPackets, 1)
+	atomic.AddUint64(&pb.bw.stats.recvBytes, uint64(n))
+	atomic.AddUint64(&pb.bw.stats.recvPackets, 1)
+	recvBytes.Add(uint64(n))
+	recvPackets.Inc()
+	pb.download.wait(n)
+	pb.bw.download.wait(n)
+}
+
+// Drop counts the packet that is not sent because the send queue of the peer is full
+func (pb *PeerBandwidth) Drop() {
+	atomic.AddUint64(&pb.stats.dropped, 1)
+	atomic.AddUint64(&pb.bw.stats.dropped, 1)
+	droppedPacket.Inc()
+}
+
+// Release returns the bandwidth when the connection is closed
+func (pb *PeerBandwidth) Release() {
+	pb.bw.release(pb)
+}
+
+// Info returns counters of the peer
+func (pb *PeerBandwidth) Info() TrafficInfo {
+	return pb.stats.info()
+}
+
+// SetBandwidthConfig updates bandwidth caps of the mesh and its peers
+func (ms *NodeMesh) SetBandwidthConfig(config BandwidthConfig) {
+	ms.bw.SetConfig(config)
+}
+
+// BandwidthInfo returns the bandwidth config and traffic counters of the mesh and its peers
+func (ms *NodeMesh) BandwidthInfo() *BandwidthInfo {
+	queuedMap := map[string]int{}
+	total := 0
+	for _, p := range ms.Peers() {
+		if qp, is := p.(interface{ QueuedBytes() int }); is {
+			queuedMap[p.ID()] += qp.QueuedBytes()
+			total += qp.QueuedBytes()
+		}
+	}
+	info := &BandwidthInfo{
+		Config: ms.bw.Config(),
+		Total:  ms.bw.Total(),
+		Peers:  map[string]TrafficInfo{},
+	}
+	info.Total.QueuedBytes = total
+	for ID, pb := range ms.bw.Peers() {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(ID))
+		ti := pb.Info()
+		ti.QueuedBytes = queuedMap[ID]
+		info.Peers[pubhash.String()] = ti
+	}
+	return info
+}
@@ -0,0 +1,252 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/core/txpool"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+)
+
+// ShortTxID returns the short id of the transaction that is used by the compact block
+func ShortTxID(TxHash hash.Hash256) uint64 {
+	return txpool.ShortTxID(TxHash)
+}
+
+// NewCompactBlockMessage returns a CompactBlockMessage of the block
+func NewCompactBlockMessage(ChainID uint8, b *types.Block) *CompactBlockMessage {
+	msg := &CompactBlockMessage{
+		Header:             b.Header,
+		TransactionTypes:   b.TransactionTypes,
+		ShortIDs:           make([]uint64, 0, len(b.Transactions)),
+		TransactionResults: b.TransactionResults,
+		Signatures:         b.Signatures,
+	}
+	for i, tx := range b.Transactions {
+		TxHash := chain.HashTransactionByType(ChainID, b.TransactionTypes[i], tx)
+		msg.ShortIDs = append(msg.ShortIDs, ShortTxID(TxHash))
+	}
+	return msg
+}
+
+// CompactTxPacket returns a packet of the transactions that are requested by the compact block receiver
+func CompactTxPacket(msg *CompactTxRequestMessage, provider types.Provider) ([]byte, error) {
+	if msg.Height == 0 || msg.Height > provider.Height() {
+		return nil, nil
+	}
+	b, err := provider.Block(msg.Height)
+	if err != nil {
+		return nil, err
+	}
+	if encoding.Hash(b.Header) != msg.BlockHash {
+		return nil, nil
+	}
+	sm := &CompactTxMessage{
+		Height:  msg.Height,
+		Indexes: make([]uint16, 0, len(msg.Indexes)),
+		Transactions: TransactionMessage{
+			Types:      make([]uint16, 0, len(msg.Indexes)),
+			Txs:        make([]types.Transaction, 0, len(msg.Indexes)),
+			Signatures: make([][]common.Signature, 0, len(msg.Indexes)),
+		},
+	}
+	for _, idx := range msg.Indexes {
+		if int(idx) >= len(b.Transactions) {
+			return nil, ErrInvalidCompactBlock
+		}
+		sm.Indexes = append(sm.Indexes, idx)
+		sm.Transactions.Types = append(sm.Transactions.Types, b.TransactionTypes[idx])
+		sm.Transactions.Txs = append(sm.Transactions.Txs, b.Transactions[idx])
+		sm.Transactions.Signatures = append(sm.Transactions.Signatures, b.TransactionSignatures[idx])
+	}
+	return MessageToPacket(sm), nil
+}
+
+type pendingCompact struct {
+	ID       string
+	block    *types.Block
+	shortIDs []uint64
+	missing  map[uint16]bool
+}
+
+type compactSync struct {
+	sync.Mutex
+	pendingMap map[uint32]*pendingCompact
+}
+
+func newCompactSync() *compactSync {
+	return &compactSync{
+		pendingMap: map[uint32]*pendingCompact{},
+	}
+}
+
+// relayCompactBlock sends the compact block to peers that are waiting the block as the next one
+func (nd *Node) relayCompactBlock(b *types.Block) {
+	targets := []common.PublicHash{}
+	nd.statusLock.Lock()
+	for ID, status := range nd.statusMap {
+		if status.Height+1 == b.Header.Height && nd.ms.PeerCapabilities(ID).Has(CapCompactBlock) {
+			var pubhash common.PublicHash
+			copy(pubhash[:], []byte(ID))
+			targets = append(targets, pubhash)
+		}
+	}
+	nd.statusLock.Unlock()
+
+	if len(targets) == 0 {
+		return
+	}
+	bs := MessageToPacket(NewCompactBlockMessage(nd.cn.Provider().ChainID(), b))
+	for _, pubhash := range targets {
+		nd.ms.SendTo(pubhash, bs)
+	}
+}
+
+func (nd *Node) handleCompactBlockMessage(ID string, msg *CompactBlockMessage) error {
+	TxLen := len(msg.TransactionTypes)
+	if TxLen >= 65535 || len(msg.ShortIDs) != TxLen || len(msg.TransactionResults) != TxLen {
+		return ErrInvalidCompactBlock
+	}
+
+	nd.statusLock.Lock()
+	if status, has := nd.statusMap[ID]; has {
+		if status.Height < msg.Header.Height {
+			status.Height = msg.Header.Height
+		}
+	}
+	nd.statusLock.Unlock()
+
+	Height := nd.cn.Provider().Height()
+	if msg.Header.Height != Height+1 {
+		return nil
+	}
+
+	b := &types.Block{
+		Header:                msg.Header,
+		TransactionTypes:      msg.TransactionTypes,
+		Transactions:          make([]types.Transaction, TxLen),
+		TransactionSignatures: make([][]common.Signature, TxLen),
+		TransactionResults:    msg.TransactionResults,
+		Signatures:            msg.Signatures,
+	}
+	missing := map[uint16]bool{}
+	if TxLen > 0 {
+		for i, id := range msg.ShortIDs {
+			var found *txpool.PoolItem
+			for _, item := range nd.txpool.GetByShortID(id) {
+				if item.TxType == msg.TransactionTypes[i] {
+					found = item
+					break
+				}
+			}
+			if found != nil {
+				b.Transactions[i] = found.Transaction
+				b.TransactionSignatures[i] = found.Signatures
+			} else {
+				missing[uint16(i)] = true
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nd.completeCompactBlock(ID, b)
+	}
+	if nd.requestTimer.Exist(msg.Header.Height) {
+		return nil
+	}
+
+	nd.csync.Lock()
+	for h := range nd.csync.pendingMap {
+		if h <= Height {
+			delete(nd.csync.pendingMap, h)
+		}
+	}
+	nd.csync.pendingMap[msg.Header.Height] = &pendingCompact{
+		ID:       ID,
+		block:    b,
+		shortIDs: msg.ShortIDs,
+		missing:  missing,
+	}
+	nd.csync.Unlock()
+
+	Indexes := make([]uint16, 0, len(missing))
+	for i := 0; i < TxLen; i++ {
+		if missing[uint16(i)] {
+			Indexes = append(Indexes, uint16(i))
+		}
+	}
+	var SenderPublicHash common.PublicHash
+	copy(SenderPublicHash[:], []byte(ID))
+	nd.sendMessage(PriorityBlock, SenderPublicHash, &CompactTxRequestMessage{
+		Height:    msg.Header.Height,
+		BlockHash: encoding.Hash(msg.Header),
+		Indexes:   Indexes,
+	})
+	nd.requestTimer.Add(msg.Header.Height, 2*time.Second, ID)
+	return nil
+}
+
+func (nd *Node) handleCompactTxRequest(SenderPublicHash common.PublicHash, msg *CompactTxRequestMessage) error {
+	bs, err := CompactTxPacket(msg, nd.cn.Provider())
+	if err != nil {
+		return err
+	}
+	if bs != nil {
+		nd.sendMessagePacket(PriorityBlock, SenderPublicHash, bs)
+	}
+	return nil
+}
+
+func (nd *Node) handleCompactTxMessage(ID string, msg *CompactTxMessage) error {
+	nd.csync.Lock()
+	pc, has := nd.csync.pendingMap[msg.Height]
+	if !has || pc.ID != ID {
+		nd.csync.Unlock()
+		return nil
+	}
+	delete(nd.csync.pendingMap, msg.Height)
+	nd.csync.Unlock()
+
+	txs := msg.Transactions
+	if len(msg.Indexes) != len(txs.Txs) || len(msg.Indexes) != len(pc.missing) {
+		return ErrInvalidCompactBlock
+	}
+	ChainID := nd.cn.Provider().ChainID()
+	b := pc.block
+	for i, idx := range msg.Indexes {
+		if !pc.missing[idx] || txs.Types[i] != b.TransactionTypes[idx] {
+			return ErrInvalidCompactBlock
+		}
+		TxHash := chain.HashTransactionByType(ChainID, txs.Types[i], txs.Txs[i])
+		if ShortTxID(TxHash) != pc.shortIDs[idx] {
+			return ErrInvalidCompactBlock
+		}
+		b.Transactions[idx] = txs.Txs[i]
+		b.TransactionSignatures[idx] = txs.Signatures[i]
+		delete(pc.missing, idx)
+	}
+	return nd.completeCompactBlock(ID, b)
+}
+
+// completeCompactBlock adds the reconstructed block when it matches the level root of the header
+// It requests the full block when short ids are collided with other transactions in the pool
+func (nd *Node) completeCompactBlock(ID string, b *types.Block) error {
+	ChainID := nd.cn.Provider().ChainID()
+	TxHashes := make([]hash.Hash256, 0, len(b.Transactions)+1)
+	TxHashes = append(TxHashes, b.Header.PrevHash)
+	for i, tx := range b.Transactions {
+		TxHashes = append(TxHashes, chain.HashTransactionByType(ChainID, b.TransactionTypes[i], tx))
+	}
+	if h, err := chain.BuildLevelRoot(TxHashes); err != nil {
+		return err
+	} else if h != b.Header.LevelRootHash {
+		var SenderPublicHash common.PublicHash
+		copy(SenderPublicHash[:], []byte(ID))
+		nd.sendRequestBlockTo(SenderPublicHash, b.Header.Height, 1)
+		return nil
+	}
+	return nd.addBlock(ID, b)
+}
@@ -0,0 +1,12 @@
+package dht
+
+import "errors"
+
+// dht errors
+var (
+	ErrInvalidRecordAddress   = errors.New("invalid record address")
+	ErrInvalidRecordSignature = errors.New("invalid record signature")
+	ErrInvalidRecordChainID   = errors.New("invalid record chain id")
+	ErrSelfRecord             = errors.New("self record")
+	ErrFullBucket             = errors.New("full bucket")
+)
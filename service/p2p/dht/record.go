@@ -0,0 +1,62 @@
+package dht
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/key"
+)
+
+// Record is the address of the node that is signed by the node itself
+type Record struct {
+	ChainID    uint8
+	PublicHash common.PublicHash
+	Address    string
+	Seq        uint64
+	Signature  common.Signature
+}
+
+// NewRecord returns the record of the address that is signed by the key
+// The sequence is the current time, so the latest record supersedes the previous one
+func NewRecord(ChainID uint8, k key.Key, Address string) (*Record, error) {
+	r := &Record{
+		ChainID:    ChainID,
+		PublicHash: common.NewPublicHash(k.PublicKey()),
+		Address:    Address,
+		Seq:        uint64(time.Now().UnixNano()),
+	}
+	sig, err := k.Sign(r.Hash())
+	if err != nil {
+		return nil, err
+	}
+	r.Signature = sig
+	return r, nil
+}
+
+// Hash returns the hash of the record except the signature
+func (r *Record) Hash() hash.Hash256 {
+	var buffer bytes.Buffer
+	buffer.WriteByte(r.ChainID)
+	buffer.Write(r.PublicHash[:])
+	buffer.Write(binutil.LittleEndian.Uint64ToBytes(r.Seq))
+	buffer.WriteString(r.Address)
+	return hash.Hash(buffer.Bytes())
+}
+
+// Verify checks that the record is signed by the owner of the public hash
+func (r *Record) Verify() error {
+	if len(r.Address) == 0 || len(r.Address) > MaxAddressLength {
+		return ErrInvalidRecordAddress
+	}
+	pubkey, err := common.RecoverPubkey(r.Hash(), r.Signature)
+	if err != nil {
+		return err
+	}
+	if common.NewPublicHash(pubkey) != r.PublicHash {
+		return ErrInvalidRecordSignature
+	}
+	return nil
+}
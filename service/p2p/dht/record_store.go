@@ -0,0 +1,70 @@
+package dht
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/backend/buntdb_driver/buntdb"
+	"github.com/fletaio/fleta_v1/encoding"
+)
+
+const recordKeyPrefix = "record:"
+
+// recordStore persists records, so the table is restored after restart without seed nodes
+type recordStore struct {
+	db *buntdb.DB
+}
+
+func newRecordStore(dbpath string) (*recordStore, error) {
+	os.MkdirAll(filepath.Dir(dbpath), os.ModePerm)
+
+	db, err := buntdb.Open(dbpath)
+	if err != nil {
+		return nil, err
+	}
+	return &recordStore{
+		db: db,
+	}, nil
+}
+
+// Load returns stored records
+func (st *recordStore) Load() ([]*Record, error) {
+	records := []*Record{}
+	if err := st.db.View(func(txn *buntdb.Tx) error {
+		return txn.Ascend("", func(key string, value string) bool {
+			if !strings.HasPrefix(key, recordKeyPrefix) {
+				return true
+			}
+			var r Record
+			if err := encoding.Unmarshal([]byte(value), &r); err == nil {
+				records = append(records, &r)
+			}
+			return true
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Save stores the record
+func (st *recordStore) Save(r *Record) {
+	bs, err := encoding.Marshal(r)
+	if err != nil {
+		return
+	}
+	st.db.Update(func(txn *buntdb.Tx) error {
+		_, _, err := txn.Set(recordKeyPrefix+r.PublicHash.String(), string(bs), nil)
+		return err
+	})
+}
+
+// Delete removes the record of the node
+func (st *recordStore) Delete(PublicHash common.PublicHash) {
+	st.db.Update(func(txn *buntdb.Tx) error {
+		_, err := txn.Delete(recordKeyPrefix + PublicHash.String())
+		return err
+	})
+}
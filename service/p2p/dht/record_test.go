@@ -0,0 +1,62 @@
+package dht
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common/key"
+)
+
+func newTestKey(t *testing.T) key.Key {
+	k, err := key.NewMemoryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// signTestRecord returns the record of the sequence that is signed by the key
+func signTestRecord(t *testing.T, k key.Key, Address string, Seq uint64) *Record {
+	r, err := NewRecord(testChainID, k, Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Seq = Seq
+	sig, err := k.Sign(r.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Signature = sig
+	return r
+}
+
+func TestRecordVerify(t *testing.T) {
+	k := newTestKey(t)
+	r, err := NewRecord(testChainID, k, "1.2.3.4:41000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *Record)
+		err    error
+	}{
+		{"address", func(r *Record) { r.Address = "6.6.6.6:41000" }, ErrInvalidRecordSignature},
+		{"seq", func(r *Record) { r.Seq++ }, ErrInvalidRecordSignature},
+		{"chain id", func(r *Record) { r.ChainID++ }, ErrInvalidRecordSignature},
+		{"public hash", func(r *Record) { r.PublicHash[0] ^= 0x01 }, ErrInvalidRecordSignature},
+		{"empty address", func(r *Record) { r.Address = "" }, ErrInvalidRecordAddress},
+		{"long address", func(r *Record) { r.Address = strings.Repeat("a", MaxAddressLength+1) }, ErrInvalidRecordAddress},
+	}
+	for _, tt := range tests {
+		v := *r
+		tt.modify(&v)
+		if err := v.Verify(); err != tt.err {
+			t.Errorf("%v: expected %v but %v", tt.name, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package dht
+
+import (
+	crand "crypto/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+)
+
+// table settings
+const (
+	BucketSize       = 16
+	BucketCount      = common.PublicHashSize * 8
+	MaxFails         = 3
+	MaxAddressLength = 253
+)
+
+type entry struct {
+	record   *Record
+	lastSeen time.Time
+	fails    int
+}
+
+// Table is the Kademlia routing table of node records that is keyed by the public hash
+// The bucket of the record is decided by the length of the common prefix between the public hash and the self
+type Table struct {
+	sync.Mutex
+	chainID     uint8
+	self        common.PublicHash
+	buckets     [BucketCount][]*entry
+	refreshedAt [BucketCount]time.Time
+	store       *recordStore
+}
+
+// NewTable returns a Table that loads and persists records at the path
+func NewTable(ChainID uint8, self common.PublicHash, dbpath string) (*Table, error) {
+	st, err := newRecordStore(dbpath)
+	if err != nil {
+		return nil, err
+	}
+	tb := &Table{
+		chainID: ChainID,
+		self:    self,
+		store:   st,
+	}
+	records, err := st.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if _, err := tb.Add(r); err != nil {
+			st.Delete(r.PublicHash)
+		}
+	}
+	return tb, nil
+}
+
+// Add verifies and inserts the record, it returns true when the record is new or newer than the stored one
+// The record is dropped when the bucket is full of live entries, Kademlia prefers long-lived nodes
+func (tb *Table) Add(r *Record) (bool, error) {
+	if r.ChainID != tb.chainID {
+		return false, ErrInvalidRecordChainID
+	}
+	if r.PublicHash == tb.self {
+		return false, ErrSelfRecord
+	}
+	if err := r.Verify(); err != nil {
+		return false, err
+	}
+
+	tb.Lock()
+	defer tb.Unlock()
+
+	idx := tb.bucketIndex(r.PublicHash)
+	bucket := tb.buckets[idx]
+	for i, e := range bucket {
+		if e.record.PublicHash == r.PublicHash {
+			if e.record.Seq >= r.Seq {
+				return false, nil
+			}
+			e.record = r
+			tb.buckets[idx] = append(append(bucket[:i:i], bucket[i+1:]...), e)
+			tb.store.Save(r)
+			return true, nil
+		}
+	}
+	e := &entry{
+		record:   r,
+		lastSeen: time.Now(),
+	}
+	if len(bucket) >= BucketSize {
+		victim := -1
+		for i, v := range bucket {
+			if v.fails >= MaxFails {
+				victim = i
+				break
+			}
+		}
+		if victim < 0 {
+			return false, ErrFullBucket
+		}
+		tb.store.Delete(bucket[victim].record.PublicHash)
+		bucket = append(bucket[:victim:victim], bucket[victim+1:]...)
+	}
+	tb.buckets[idx] = append(bucket, e)
+	tb.store.Save(r)
+	return true, nil
+}
+
+// Seen marks the node is alive, the node moves to the tail of the bucket
+func (tb *Table) Seen(PublicHash common.PublicHash) {
+	tb.Lock()
+	defer tb.Unlock()
+
+	idx := tb.bucketIndex(PublicHash)
+	bucket := tb.buckets[idx]
+	for i, e := range bucket {
+		if e.record.PublicHash == PublicHash {
+			e.lastSeen = time.Now()
+			e.fails = 0
+			tb.buckets[idx] = append(append(bucket[:i:i], bucket[i+1:]...), e)
+			return
+		}
+	}
+}
+
+// Fail counts the failure of the dial, the node is removed after MaxFails times
+func (tb *Table) Fail(PublicHash common.PublicHash) {
+	tb.Lock()
+	defer tb.Unlock()
+
+	idx := tb.bucketIndex(PublicHash)
+	bucket := tb.buckets[idx]
+	for i, e := range bucket {
+		if e.record.PublicHash == PublicHash {
+			e.fails++
+			if e.fails >= MaxFails {
+				tb.buckets[idx] = append(bucket[:i:i], bucket[i+1:]...)
+				tb.store.Delete(PublicHash)
+			}
+			return
+		}
+	}
+}
+
+// Remove deletes the record of the node
+func (tb *Table) Remove(PublicHash common.PublicHash) {
+	tb.Lock()
+	defer tb.Unlock()
+
+	idx := tb.bucketIndex(PublicHash)
+	bucket := tb.buckets[idx]
+	for i, e := range bucket {
+		if e.record.PublicHash == PublicHash {
+			tb.buckets[idx] = append(bucket[:i:i], bucket[i+1:]...)
+			tb.store.Delete(PublicHash)
+			return
+		}
+	}
+}
+
+// Get returns the record of the node
+func (tb *Table) Get(PublicHash common.PublicHash) (*Record, bool) {
+	tb.Lock()
+	defer tb.Unlock()
+
+	for _, e := range tb.buckets[tb.bucketIndex(PublicHash)] {
+		if e.record.PublicHash == PublicHash {
+			return e.record, true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of records
+func (tb *Table) Len() int {
+	tb.Lock()
+	defer tb.Unlock()
+
+	count := 0
+	for _, bucket := range tb.buckets {
+		count += len(bucket)
+	}
+	return count
+}
+
+// Records returns all records of the table
+func (tb *Table) Records() []*Record {
+	tb.Lock()
+	defer tb.Unlock()
+
+	list := []*Record{}
+	for _, bucket := range tb.buckets {
+		for _, e := range bucket {
+			list = append(list, e.record)
+		}
+	}
+	return list
+}
+
+// Closest returns at most n records that are closest to the target by the XOR distance
+func (tb *Table) Closest(Target common.PublicHash, n int) []*Record {
+	list := tb.Records()
+	sort.Slice(list, func(i, j int) bool {
+		return Closer(Target, list[i].PublicHash, list[j].PublicHash)
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// RefreshTargets returns random targets of buckets that are not refreshed during the interval
+// Buckets after the deepest non-empty bucket are skipped because they cannot be filled
+func (tb *Table) RefreshTargets(Interval time.Duration) []common.PublicHash {
+	tb.Lock()
+	defer tb.Unlock()
+
+	deepest := 0
+	for i, bucket := range tb.buckets {
+		if len(bucket) > 0 {
+			deepest = i
+		}
+	}
+	now := time.Now()
+	targets := []common.PublicHash{}
+	for i := 0; i <= deepest+1 && i < BucketCount; i++ {
+		if now.Sub(tb.refreshedAt[i]) < Interval {
+			continue
+		}
+		tb.refreshedAt[i] = now
+		targets = append(targets, tb.randomTarget(i))
+	}
+	return targets
+}
+
+// randomTarget returns a random public hash that has the common prefix of the length with the self
+func (tb *Table) randomTarget(prefix int) common.PublicHash {
+	var target common.PublicHash
+	crand.Read(target[:])
+	for i := 0; i < prefix; i++ {
+		mask := byte(0x80) >> uint(i%8)
+		target[i/8] = (target[i/8] &^ mask) | (tb.self[i/8] & mask)
+	}
+	mask := byte(0x80) >> uint(prefix%8)
+	target[prefix/8] = (target[prefix/8] &^ mask) | (^tb.self[prefix/8] & mask)
+	return target
+}
+
+func (tb *Table) bucketIndex(PublicHash common.PublicHash) int {
+	idx := CommonPrefixLength(tb.self, PublicHash)
+	if idx >= BucketCount {
+		idx = BucketCount - 1
+	}
+	return idx
+}
+
+// CommonPrefixLength returns the number of leading bits that are same
+func CommonPrefixLength(a common.PublicHash, b common.PublicHash) int {
+	for i := range a {
+		if x := a[i] ^ b[i]; x != 0 {
+			n := i * 8
+			for x&0x80 == 0 {
+				x <<= 1
+				n++
+			}
+			return n
+		}
+	}
+	return BucketCount
+}
+
+// Closer returns a is closer to the target than b by the XOR distance
+func Closer(Target common.PublicHash, a common.PublicHash, b common.PublicHash) bool {
+	for i := range Target {
+		da := a[i] ^ Target[i]
+		db := b[i] ^ Target[i]
+		if da != db {
+			return da < db
+		}
+	}
+	return false
+}
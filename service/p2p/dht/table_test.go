@@ -0,0 +1,211 @@
+package dht
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/key"
+)
+
+const testChainID = 1
+
+func newTestTable(t *testing.T, self common.PublicHash) *Table {
+	tb, err := NewTable(testChainID, self, filepath.Join(t.TempDir(), "dht"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tb
+}
+
+// keysOfBucket returns keys of which public hashes are placed at the bucket of the table
+func keysOfBucket(t *testing.T, tb *Table, idx int, Count int) []key.Key {
+	keys := []key.Key{}
+	for len(keys) < Count {
+		k := newTestKey(t)
+		if tb.bucketIndex(common.NewPublicHash(k.PublicKey())) == idx {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func TestCommonPrefixLength(t *testing.T) {
+	var a common.PublicHash
+	tests := []struct {
+		index int
+		bit   byte
+		want  int
+	}{
+		{0, 0x80, 0},
+		{0, 0x01, 7},
+		{1, 0x40, 9},
+		{common.PublicHashSize - 1, 0x01, BucketCount - 1},
+	}
+	for _, tt := range tests {
+		var b common.PublicHash
+		b[tt.index] = tt.bit
+		if n := CommonPrefixLength(a, b); n != tt.want {
+			t.Errorf("expected %v but %v", tt.want, n)
+		}
+	}
+	if n := CommonPrefixLength(a, a); n != BucketCount {
+		t.Fatalf("invalid length of the same hash: %v", n)
+	}
+}
+
+func TestTableBucketPlacement(t *testing.T) {
+	self := common.NewPublicHash(newTestKey(t).PublicKey())
+	tb := newTestTable(t, self)
+	for i := 0; i < 20; i++ {
+		r := signTestRecord(t, newTestKey(t), "1.2.3.4:41000", 1)
+		if _, err := tb.Add(r); err != nil && err != ErrFullBucket {
+			t.Fatal(err)
+		}
+		idx := CommonPrefixLength(self, r.PublicHash)
+		found := false
+		for _, e := range tb.buckets[idx] {
+			if e.record.PublicHash == r.PublicHash {
+				found = true
+			}
+		}
+		if _, has := tb.Get(r.PublicHash); has != found {
+			t.Fatalf("the record is not placed at the bucket %v", idx)
+		}
+	}
+
+	selfKey := newTestKey(t)
+	tb = newTestTable(t, common.NewPublicHash(selfKey.PublicKey()))
+	if _, err := tb.Add(signTestRecord(t, selfKey, "1.2.3.4:41000", 1)); err != ErrSelfRecord {
+		t.Fatalf("the self record is added: %v", err)
+	}
+	r := signTestRecord(t, newTestKey(t), "1.2.3.4:41000", 1)
+	r.ChainID = testChainID + 1
+	if _, err := tb.Add(r); err != ErrInvalidRecordChainID {
+		t.Fatalf("the record of the other chain is added: %v", err)
+	}
+}
+
+func TestTableEviction(t *testing.T) {
+	self := common.NewPublicHash(newTestKey(t).PublicKey())
+	tb := newTestTable(t, self)
+	keys := keysOfBucket(t, tb, 0, BucketSize+1)
+	for _, k := range keys[:BucketSize] {
+		if _, err := tb.Add(signTestRecord(t, k, "1.2.3.4:41000", 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	extra := signTestRecord(t, keys[BucketSize], "1.2.3.4:41000", 1)
+	if _, err := tb.Add(extra); err != ErrFullBucket {
+		t.Fatalf("the live entry is evicted: %v", err)
+	}
+
+	// failures under MaxFails do not make the entry a victim and Seen resets them
+	victim := common.NewPublicHash(keys[0].PublicKey())
+	for i := 0; i < MaxFails-1; i++ {
+		tb.Fail(victim)
+	}
+	tb.Seen(victim)
+	tb.Fail(victim)
+	if _, err := tb.Add(extra); err != ErrFullBucket {
+		t.Fatalf("the entry under MaxFails is evicted: %v", err)
+	}
+	if _, has := tb.Get(victim); !has {
+		t.Fatal("the entry under MaxFails is removed")
+	}
+
+	for i := 0; i < MaxFails; i++ {
+		tb.Fail(victim)
+	}
+	if _, has := tb.Get(victim); has {
+		t.Fatal("the entry is not removed after MaxFails")
+	}
+	if isNew, err := tb.Add(extra); err != nil || !isNew {
+		t.Fatalf("the record is not added to the evicted bucket: %v", err)
+	}
+	if tb.Len() != BucketSize {
+		t.Fatalf("invalid table length: %v", tb.Len())
+	}
+}
+
+func TestTableSeqSupersession(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "dht")
+	self := common.NewPublicHash(newTestKey(t).PublicKey())
+	tb, err := NewTable(testChainID, self, dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := newTestKey(t)
+	pubhash := common.NewPublicHash(k.PublicKey())
+
+	tests := []struct {
+		address string
+		seq     uint64
+		isNew   bool
+		stored  string
+	}{
+		{"1.1.1.1:41000", 10, true, "1.1.1.1:41000"},
+		{"2.2.2.2:41000", 10, false, "1.1.1.1:41000"},
+		{"3.3.3.3:41000", 9, false, "1.1.1.1:41000"},
+		{"4.4.4.4:41000", 11, true, "4.4.4.4:41000"},
+	}
+	for _, tt := range tests {
+		isNew, err := tb.Add(signTestRecord(t, k, tt.address, tt.seq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isNew != tt.isNew {
+			t.Errorf("%v of seq %v: expected new %v", tt.address, tt.seq, tt.isNew)
+		}
+		if r, has := tb.Get(pubhash); !has || r.Address != tt.stored {
+			t.Errorf("%v of seq %v: expected stored %v", tt.address, tt.seq, tt.stored)
+		}
+	}
+
+	// the forged record of the higher sequence does not supersede
+	forged := signTestRecord(t, newTestKey(t), "6.6.6.6:41000", 100)
+	forged.PublicHash = pubhash
+	if _, err := tb.Add(forged); err != ErrInvalidRecordSignature {
+		t.Fatalf("the forged record is added: %v", err)
+	}
+	tb.store.db.Close()
+
+	tb, err = NewTable(testChainID, self, dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, has := tb.Get(pubhash); !has || r.Address != "4.4.4.4:41000" || r.Seq != 11 {
+		t.Fatalf("the latest record is not restored: %+v", r)
+	}
+}
+
+func TestTableClosest(t *testing.T) {
+	self := common.NewPublicHash(newTestKey(t).PublicKey())
+	tb := newTestTable(t, self)
+	for i := 0; i < 10; i++ {
+		if _, err := tb.Add(signTestRecord(t, newTestKey(t), "1.2.3.4:41000", 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Target := common.NewPublicHash(newTestKey(t).PublicKey())
+	list := tb.Closest(Target, 5)
+	if len(list) != 5 {
+		t.Fatalf("invalid count: %v", len(list))
+	}
+	for i := 1; i < len(list); i++ {
+		if Closer(Target, list[i].PublicHash, list[i-1].PublicHash) {
+			t.Fatalf("not sorted by the distance at %v", i)
+		}
+	}
+	for _, r := range tb.Records() {
+		if Closer(Target, r.PublicHash, list[len(list)-1].PublicHash) {
+			found := false
+			for _, v := range list {
+				found = found || v.PublicHash == r.PublicHash
+			}
+			if !found {
+				t.Fatal("the closer record is omitted")
+			}
+		}
+	}
+}
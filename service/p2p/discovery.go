@@ -0,0 +1,141 @@
+package p2p
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/rlog"
+	"github.com/fletaio/fleta_v1/service/p2p/dht"
+)
+
+// discovery settings
+const (
+	DiscoveryInterval      = 30 * time.Second
+	BucketRefreshInterval  = 10 * time.Minute
+	DiscoveryAlpha         = 3
+	MaxRecordsPerMessage   = dht.BucketSize + 1
+	MaxDiscoveryCandidates = dht.BucketSize
+)
+
+// selfRecord returns the signed record of the advertised address
+// The record is not made when the address does not have a dialable host
+func (ms *NodeMesh) selfRecord() *dht.Record {
+	Address := ms.AdvertiseAddress()
+	host, _, err := net.SplitHostPort(Address)
+	if err != nil {
+		return nil
+	}
+	if ip := net.ParseIP(host); len(host) == 0 || (ip != nil && ip.IsUnspecified()) {
+		return nil
+	}
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	if ms.myRecord != nil && ms.myRecord.Address == Address {
+		return ms.myRecord
+	}
+	r, err := dht.NewRecord(ms.chainID, ms.key, Address)
+	if err != nil {
+		rlog.Println("[dht]", err)
+		return nil
+	}
+	ms.myRecord = r
+	return r
+}
+
+// runDiscovery bootstraps by stored records and refreshes buckets by lookups to connected peers
+func (ms *NodeMesh) runDiscovery() {
+	ms.addCandidates(ms.dht.Records())
+	for {
+		time.Sleep(DiscoveryInterval)
+
+		for _, Target := range ms.dht.RefreshTargets(BucketRefreshInterval) {
+			ms.lookup(Target)
+		}
+	}
+}
+
+// lookup sends the find node message to connected peers that are closest to the target
+func (ms *NodeMesh) lookup(Target common.PublicHash) {
+	pubhashes := []common.PublicHash{}
+	for _, p := range ms.Peers() {
+		if !ms.PeerCapabilities(p.ID()).Has(CapDiscovery) {
+			continue
+		}
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(p.ID()))
+		pubhashes = append(pubhashes, pubhash)
+	}
+	sort.Slice(pubhashes, func(i, j int) bool {
+		return dht.Closer(Target, pubhashes[i], pubhashes[j])
+	})
+	if len(pubhashes) > DiscoveryAlpha {
+		pubhashes = pubhashes[:DiscoveryAlpha]
+	}
+	bs := MessageToPacket(&FindNodeMessage{Target: Target})
+	for _, pubhash := range pubhashes {
+		ms.SendTo(pubhash, bs)
+	}
+}
+
+// handleFindNode responds the self record and the records that are closest to the target
+func (ms *NodeMesh) handleFindNode(ID string, msg *FindNodeMessage) {
+	records := []dht.Record{}
+	if r := ms.selfRecord(); r != nil {
+		records = append(records, *r)
+	}
+	for _, r := range ms.dht.Closest(msg.Target, dht.BucketSize) {
+		if string(r.PublicHash[:]) != ID {
+			records = append(records, *r)
+		}
+	}
+	var pubhash common.PublicHash
+	copy(pubhash[:], []byte(ID))
+	ms.SendTo(pubhash, MessageToPacket(&NodesMessage{Records: records}))
+}
+
+// handleNodes adds verified records to the table and passes new ones to the node pool to be dialed
+func (ms *NodeMesh) handleNodes(ID string, msg *NodesMessage) error {
+	if len(msg.Records) > MaxRecordsPerMessage {
+		return ErrTooManyNodeRecords
+	}
+	added := []*dht.Record{}
+	for i := range msg.Records {
+		r := &msg.Records[i]
+		if ms.nodePoolManager.IsBan(string(r.PublicHash[:])) {
+			continue
+		}
+		if isNew, err := ms.dht.Add(r); err != nil {
+			if err != dht.ErrFullBucket && err != dht.ErrSelfRecord {
+				rlog.Println("[dht]", err)
+			}
+		} else if isNew {
+			added = append(added, r)
+		}
+	}
+	ms.addCandidates(added)
+	return nil
+}
+
+// addCandidates passes records of nodes that are not connected to the node pool
+func (ms *NodeMesh) addCandidates(records []*dht.Record) {
+	ips := []string{}
+	hashs := []string{}
+	for _, r := range records {
+		ID := string(r.PublicHash[:])
+		if ms.GetPeer(ID) != nil {
+			continue
+		}
+		ips = append(ips, r.Address)
+		hashs = append(hashs, ID)
+		if len(ips) >= MaxDiscoveryCandidates {
+			break
+		}
+	}
+	if len(ips) > 0 {
+		ms.AddPeerList(ips, hashs)
+	}
+}
@@ -11,4 +11,13 @@ var (
 	ErrSelfConnection             = errors.New("self connection")
 	ErrInvalidUTXO                = errors.New("invalid UTXO")
 	ErrTooManyTrasactionInMessage = errors.New("too many transaction in message")
+	ErrInvalidSecureRecord        = errors.New("invalid secure record")
+	ErrInvalidHeaderMessage       = errors.New("invalid header message")
+	ErrMismatchedHeaderHash       = errors.New("mismatched header hash")
+	ErrBannedPeer                 = errors.New("banned peer")
+	ErrTooLargePacket             = errors.New("too large packet")
+	ErrInvalidCompactBlock        = errors.New("invalid compact block")
+	ErrTooManyNodeRecords         = errors.New("too many node records")
+	ErrIncompatibleProtocol       = errors.New("incompatible protocol")
+	ErrNotNegotiatedMessage       = errors.New("not negotiated message")
 )
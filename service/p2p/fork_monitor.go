@@ -0,0 +1,179 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/rlog"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// MaxForkEvidences is the maximum number of evidences that are kept by the fork monitor
+const MaxForkEvidences = 1000
+
+// MaxForkWebhookQueue is the maximum number of evidences that wait to be posted to the webhook, evidences are dropped when it is full
+const MaxForkWebhookQueue = 100
+
+// ForkEvidence is the evidence of the conflicting hash at the height that is sent by the peer
+type ForkEvidence struct {
+	Height    uint32            `json:"height"`
+	OurHash   hash.Hash256      `json:"our_hash"`
+	TheirHash hash.Hash256      `json:"their_hash"`
+	Peer      common.PublicHash `json:"peer"`
+	Source    string            `json:"source"`
+	Timestamp uint64            `json:"timestamp"`
+}
+
+// BanResult is the temporary ban of the peer
+type BanResult struct {
+	Peer      common.PublicHash `json:"peer"`
+	ExpiredAt uint64            `json:"expired_at"`
+}
+
+// ForkMonitor records fork evidences from peers, alerts them and bans the peers temporarily
+type ForkMonitor struct {
+	types.ServiceBase
+	sync.Mutex
+	webhookURL  string
+	banDuration time.Duration
+	evidences   []*ForkEvidence
+	as          *apiserver.APIServer
+	ms          *NodeMesh
+	client      *http.Client
+	webhookQ    chan *ForkEvidence
+	webhookOnce sync.Once
+}
+
+// NewForkMonitor returns a ForkMonitor
+func NewForkMonitor(WebhookURL string, BanDuration time.Duration) *ForkMonitor {
+	if BanDuration == 0 {
+		BanDuration = 24 * time.Hour
+	}
+	fm := &ForkMonitor{
+		webhookURL:  WebhookURL,
+		banDuration: BanDuration,
+		evidences:   []*ForkEvidence{},
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		webhookQ: make(chan *ForkEvidence, MaxForkWebhookQueue),
+	}
+	return fm
+}
+
+// Name returns the name of the service
+func (fm *ForkMonitor) Name() string {
+	return "fleta.forkmonitor"
+}
+
+// Init called when initialize service
+func (fm *ForkMonitor) Init(pm types.ProcessManager, cn types.Provider) error {
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		fm.as = v
+		as, err := v.JRPC("fork")
+		if err != nil {
+			return err
+		}
+		as.Set("evidences", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return fm.Evidences(), nil
+		})
+		as.Set("bans", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			return fm.Bans(), nil
+		})
+	}
+	return nil
+}
+
+// Evidences returns the recorded evidences
+func (fm *ForkMonitor) Evidences() []*ForkEvidence {
+	fm.Lock()
+	defer fm.Unlock()
+
+	return append([]*ForkEvidence{}, fm.evidences...)
+}
+
+// Bans returns the peers that are banned temporarily
+func (fm *ForkMonitor) Bans() []*BanResult {
+	fm.Lock()
+	ms := fm.ms
+	fm.Unlock()
+
+	list := []*BanResult{}
+	if ms == nil {
+		return list
+	}
+	for pubhash, expiry := range ms.BanList() {
+		list = append(list, &BanResult{
+			Peer:      pubhash,
+			ExpiredAt: uint64(expiry),
+		})
+	}
+	return list
+}
+
+func (fm *ForkMonitor) setNodeMesh(ms *NodeMesh) {
+	fm.Lock()
+	defer fm.Unlock()
+
+	fm.ms = ms
+}
+
+// Report records the evidence, alerts it and bans the peer
+func (fm *ForkMonitor) Report(ev *ForkEvidence) {
+	fm.Lock()
+	fm.evidences = append(fm.evidences, ev)
+	if len(fm.evidences) > MaxForkEvidences {
+		fm.evidences = fm.evidences[len(fm.evidences)-MaxForkEvidences:]
+	}
+	ms := fm.ms
+	fm.Unlock()
+
+	rlog.Println("ForkDetected", ev.Source, ev.Height, ev.Peer.String(), ev.OurHash.String(), ev.TheirHash.String())
+	if fm.as != nil {
+		fm.as.Publish("fork", ev)
+	}
+	if len(fm.webhookURL) > 0 {
+		fm.webhookOnce.Do(func() {
+			go fm.runWebhook()
+		})
+		select {
+		case fm.webhookQ <- ev:
+		default:
+			rlog.Println("[forkmonitor] webhook queue is full", ev.Height, ev.Peer.String())
+		}
+	}
+	if ms != nil {
+		ms.TemporaryBan(string(ev.Peer[:]), fm.banDuration)
+	}
+}
+
+// runWebhook posts queued evidences one by one, so peers cannot make unbounded requests by sending conflicting headers
+func (fm *ForkMonitor) runWebhook() {
+	for ev := range fm.webhookQ {
+		fm.postWebhook(ev)
+	}
+}
+
+func (fm *ForkMonitor) postWebhook(ev *ForkEvidence) {
+	bs, err := json.Marshal(ev)
+	if err != nil {
+		rlog.Println("[forkmonitor]", err)
+		return
+	}
+	res, err := fm.client.Post(fm.webhookURL, "application/json", bytes.NewReader(bs))
+	if err != nil {
+		rlog.Println("[forkmonitor]", err)
+		return
+	}
+	res.Body.Close()
+}
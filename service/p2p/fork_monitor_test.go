@@ -0,0 +1,50 @@
+package p2p
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForkMonitorWebhookQueue(t *testing.T) {
+	var inflight int32
+	var maxInflight int32
+	var posted int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInflight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInflight, m, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&inflight, -1)
+		atomic.AddInt32(&posted, 1)
+	}))
+	defer srv.Close()
+
+	fm := NewForkMonitor(srv.URL, time.Hour)
+	for i := 0; i < MaxForkWebhookQueue*3; i++ {
+		fm.Report(&ForkEvidence{Height: uint32(i)})
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fm.webhookQ) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if v := atomic.LoadInt32(&maxInflight); v != 1 {
+		t.Fatalf("webhooks are posted concurrently: %v", v)
+	}
+	if v := atomic.LoadInt32(&posted); v > MaxForkWebhookQueue+1 {
+		t.Fatalf("evidences beyond the queue are posted: %v", v)
+	}
+	if len(fm.Evidences()) != MaxForkWebhookQueue*3 {
+		t.Fatalf("evidences are not recorded: %v", len(fm.Evidences()))
+	}
+}
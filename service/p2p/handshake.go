@@ -0,0 +1,291 @@
+package p2p
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/encoding"
+)
+
+// protocol versions
+const (
+	LegacyProtocolVersion = 1
+	MinProtocolVersion    = 1
+	ProtocolVersion       = 2
+)
+
+// HandshakeVersion is written to the marker of the challenge, the responder replies the hello when it is 2 or above
+const HandshakeVersion = 2
+
+// helloResponseFlag is written in place of secureResponseFlag when the observed address and the hello follow the bind address
+const helloResponseFlag = 0xFE
+
+// MaxHelloSize is the maximum size of the encoded hello
+const MaxHelloSize = 1024
+
+// DefaultUserAgent is the user agent that is sent when it is not set
+const DefaultUserAgent = "fleta"
+
+// Capability is the set of message groups that the peer understands
+type Capability uint64
+
+// capabilities of message groups
+const (
+	CapHeaderSync Capability = 1 << iota
+	CapCompactBlock
+	CapTxInv
+	CapDiscovery
+)
+
+// LocalCapabilities is the capabilities of the Node
+const LocalCapabilities = CapHeaderSync | CapCompactBlock | CapTxInv | CapDiscovery
+
+// Has returns the capability set has all of the capabilities or not
+func (c Capability) Has(v Capability) bool {
+	return c&v == v
+}
+
+func (c Capability) String() string {
+	names := []string{}
+	for _, v := range []struct {
+		cap  Capability
+		name string
+	}{
+		{CapHeaderSync, "headersync"},
+		{CapCompactBlock, "compactblock"},
+		{CapTxInv, "txinv"},
+		{CapDiscovery, "discovery"},
+	} {
+		if c.Has(v.cap) {
+			names = append(names, v.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// messageCapability returns the capability that is required to exchange the message
+func messageCapability(m interface{}) Capability {
+	switch m.(type) {
+	case *HeaderRequestMessage, *HeaderMessage, *BatchRequestMessage:
+		return CapHeaderSync
+	case *CompactBlockMessage, *CompactTxRequestMessage, *CompactTxMessage:
+		return CapCompactBlock
+	case *TxInvMessage, *TxGetDataMessage:
+		return CapTxInv
+	case *FindNodeMessage, *NodesMessage:
+		return CapDiscovery
+	default:
+		return 0
+	}
+}
+
+// Hello is sent in the handshake to negotiate the protocol version and capabilities
+type Hello struct {
+	MinVersion   uint16
+	MaxVersion   uint16
+	Capabilities uint64
+	UserAgent    string
+	Height       uint32
+}
+
+// PeerHello is the negotiated result of the handshake with the peer
+type PeerHello struct {
+	Version      uint16
+	Capabilities Capability
+	UserAgent    string
+	Height       uint32
+}
+
+// legacyPeerHello returns the result for the peer that does not send the hello
+func legacyPeerHello() *PeerHello {
+	return &PeerHello{
+		Version: LegacyProtocolVersion,
+	}
+}
+
+// negotiateHello returns the highest version that both sides support and capabilities of the peer
+func negotiateHello(local *Hello, remote *Hello) (*PeerHello, error) {
+	Version := local.MaxVersion
+	if remote.MaxVersion < Version {
+		Version = remote.MaxVersion
+	}
+	if Version < local.MinVersion || Version < remote.MinVersion {
+		return nil, ErrIncompatibleProtocol
+	}
+	return &PeerHello{
+		Version:      Version,
+		Capabilities: Capability(remote.Capabilities),
+		UserAgent:    remote.UserAgent,
+		Height:       remote.Height,
+	}, nil
+}
+
+// challengeVersion returns the version of the marked challenge, zero when the challenge is not marked
+func challengeVersion(req []byte) uint8 {
+	if !IsMarkedChallenge(req) {
+		return 0
+	}
+	return req[1+len(secureChallengeMarker)]
+}
+
+// SetCapabilities sets the capabilities that are sent to peers
+func (ms *NodeMesh) SetCapabilities(c Capability) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.capabilities = c
+}
+
+// SetUserAgent sets the user agent that is sent to peers
+func (ms *NodeMesh) SetUserAgent(UserAgent string) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.userAgent = UserAgent
+}
+
+// SetHeightProvider sets the function that returns the best height that is sent to peers
+func (ms *NodeMesh) SetHeightProvider(fn func() uint32) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.heightFunc = fn
+}
+
+// PeerHello returns the negotiated result of the handshake with the peer
+func (ms *NodeMesh) PeerHello(ID string) *PeerHello {
+	ms.Lock()
+	defer ms.Unlock()
+
+	if ph, has := ms.helloMap[ID]; has {
+		return ph
+	}
+	return legacyPeerHello()
+}
+
+// PeerCapabilities returns capabilities of the peer
+func (ms *NodeMesh) PeerCapabilities(ID string) Capability {
+	return ms.PeerHello(ID).Capabilities
+}
+
+func (ms *NodeMesh) setPeerHello(ID string, ph *PeerHello) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.helloMap[ID] = ph
+}
+
+// clearPeerHello removes the result of the handshake when the peer does not have another connection
+func (ms *NodeMesh) clearPeerHello(ID string) {
+	ms.Lock()
+	defer ms.Unlock()
+
+	_, hasC := ms.clientPeerMap[ID]
+	_, hasS := ms.serverPeerMap[ID]
+	if !hasC && !hasS {
+		delete(ms.helloMap, ID)
+	}
+}
+
+func (ms *NodeMesh) localHello() *Hello {
+	ms.Lock()
+	hello := &Hello{
+		MinVersion:   MinProtocolVersion,
+		MaxVersion:   ProtocolVersion,
+		Capabilities: uint64(ms.capabilities),
+		UserAgent:    ms.userAgent,
+	}
+	fn := ms.heightFunc
+	ms.Unlock()
+
+	if len(hello.UserAgent) == 0 {
+		hello.UserAgent = DefaultUserAgent
+	}
+	if fn != nil {
+		hello.Height = fn()
+	}
+	return hello
+}
+
+// helloHash returns the hash that is signed by the hello, it covers the observed ip so it cannot be rewritten on the path
+func helloHash(req []byte, observed []byte, bs []byte) hash.Hash256 {
+	data := make([]byte, 0, len(req)+1+len(observed)+len(bs))
+	data = append(data, req...)
+	data = append(data, byte(uint8(len(observed))))
+	data = append(data, observed...)
+	data = append(data, bs...)
+	return hash.Hash(data)
+}
+
+// writeHello writes the observed ip of the other side and the signed hello
+func (ms *NodeMesh) writeHello(conn net.Conn, req []byte) error {
+	// reports the ip of the other side that is observed, so the node behind NAT discovers its external ip
+	var observed []byte
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		observed = []byte(addr.IP.String())
+	}
+	if _, err := conn.Write(append([]byte{byte(uint8(len(observed)))}, observed...)); err != nil {
+		return err
+	}
+
+	bs, err := encoding.Marshal(ms.localHello())
+	if err != nil {
+		return err
+	}
+	sig, err := ms.key.Sign(helloHash(req, observed, bs))
+	if err != nil {
+		return err
+	}
+	var buffer bytes.Buffer
+	buffer.Write(binutil.LittleEndian.Uint16ToBytes(uint16(len(bs))))
+	buffer.Write(bs)
+	buffer.Write(sig[:])
+	if _, err := conn.Write(buffer.Bytes()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readHello reads the observed ip and the hello that are signed by the peer
+func readHello(conn net.Conn, req []byte, pubhash common.PublicHash) (string, *Hello, error) {
+	bs := make([]byte, 1)
+	if _, err := FillBytes(conn, bs); err != nil {
+		return "", nil, err
+	}
+	observed := make([]byte, uint8(bs[0]))
+	if _, err := FillBytes(conn, observed); err != nil {
+		return "", nil, err
+	}
+
+	Len, _, err := ReadUint16(conn)
+	if err != nil {
+		return "", nil, err
+	}
+	if Len > MaxHelloSize {
+		return "", nil, ErrInvalidHandshake
+	}
+	bs = make([]byte, Len)
+	if _, err := FillBytes(conn, bs); err != nil {
+		return "", nil, err
+	}
+	var sig common.Signature
+	if _, err := FillBytes(conn, sig[:]); err != nil {
+		return "", nil, err
+	}
+	pubkey, err := common.RecoverPubkey(helloHash(req, observed, bs), sig)
+	if err != nil {
+		return "", nil, err
+	}
+	if common.NewPublicHash(pubkey) != pubhash {
+		return "", nil, ErrInvalidHandshake
+	}
+	hello := &Hello{}
+	if err := encoding.Unmarshal(bs, hello); err != nil {
+		return "", nil, err
+	}
+	return string(observed), hello, nil
+}
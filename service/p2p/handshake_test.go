@@ -0,0 +1,354 @@
+package p2p
+
+import (
+	crand "crypto/rand"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/key"
+)
+
+const testHandshakeChainID = 1
+
+func newTestHandshakeMesh(t *testing.T, BindAddress string) *NodeMesh {
+	k, err := key.NewMemoryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &NodeMesh{
+		BindAddress:  BindAddress,
+		chainID:      testHandshakeChainID,
+		key:          k,
+		myPublicHash: common.NewPublicHash(k.PublicKey()),
+		voter:        newAddressVoter(),
+		capabilities: LocalCapabilities,
+		helloMap:     map[string]*PeerHello{},
+	}
+}
+
+func newLegacyChallenge(t *testing.T) []byte {
+	req := make([]byte, 40)
+	if _, err := crand.Read(req[:32]); err != nil {
+		t.Fatal(err)
+	}
+	req[0] = testHandshakeChainID
+	// the random bytes must not be the marker of the new version
+	req[1] = 0
+	binutil.LittleEndian.PutUint64(req[32:], uint64(time.Now().UnixNano()))
+	return req
+}
+
+// legacyRecvHandshake responds the challenge as the old version that signs the challenge and writes the bind address
+func legacyRecvHandshake(conn net.Conn, k key.Key, BindAddress string) error {
+	req := make([]byte, 40)
+	if _, err := FillBytes(conn, req); err != nil {
+		return err
+	}
+	sig, err := k.Sign(hash.Hash(req))
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(sig[:]); err != nil {
+		return err
+	}
+	if _, err := conn.Write(append([]byte{byte(len(BindAddress))}, []byte(BindAddress)...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// legacySendHandshake challenges as the old version and returns the public hash and the bind address of the responder
+func legacySendHandshake(conn net.Conn, req []byte) (common.PublicHash, string, error) {
+	if _, err := conn.Write(req); err != nil {
+		return common.PublicHash{}, "", err
+	}
+	var sig common.Signature
+	if _, err := FillBytes(conn, sig[:]); err != nil {
+		return common.PublicHash{}, "", err
+	}
+	bs := make([]byte, 1)
+	if _, err := FillBytes(conn, bs); err != nil {
+		return common.PublicHash{}, "", err
+	}
+	bs = make([]byte, bs[0])
+	if _, err := FillBytes(conn, bs); err != nil {
+		return common.PublicHash{}, "", err
+	}
+	pubkey, err := common.RecoverPubkey(hash.Hash(req), sig)
+	if err != nil {
+		return common.PublicHash{}, "", err
+	}
+	return common.NewPublicHash(pubkey), string(bs), nil
+}
+
+func TestHandshakeNewToNew(t *testing.T) {
+	client := newTestHandshakeMesh(t, "1.1.1.1:41000")
+	server := newTestHandshakeMesh(t, "2.2.2.2:41000")
+	server.SetCapabilities(CapHeaderSync | CapTxInv)
+	server.SetUserAgent("server")
+	server.SetHeightProvider(func() uint32 { return 77 })
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	// the server challenges first and the client challenges after like the mesh
+	serverHS, err := NewSecureHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serverRes *handshakeResult
+	errCh := make(chan error, 1)
+	go func() {
+		res, err := server.sendHandshake(c2, serverHS)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		serverRes = res
+		errCh <- server.recvHandshake(c2, serverHS)
+	}()
+	hs, err := NewSecureHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.recvHandshake(c1, hs); err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.sendHandshake(c1, hs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if res.PublicHash != server.myPublicHash || serverRes.PublicHash != client.myPublicHash {
+		t.Fatal("invalid public hash")
+	}
+	if res.BindAddress != "2.2.2.2:41000" || serverRes.BindAddress != "1.1.1.1:41000" {
+		t.Fatalf("invalid bind address: %v %v", res.BindAddress, serverRes.BindAddress)
+	}
+	if !hs.IsSecure() || !serverHS.IsSecure() {
+		t.Fatal("the secure transport is not negotiated")
+	}
+	if serverRes.Hello.Capabilities != LocalCapabilities {
+		t.Fatalf("invalid capabilities of the client: %v", serverRes.Hello.Capabilities)
+	}
+	ph := res.Hello
+	if ph.Version != ProtocolVersion || ph.Capabilities != CapHeaderSync|CapTxInv || ph.UserAgent != "server" || ph.Height != 77 {
+		t.Fatalf("invalid hello: %+v", ph)
+	}
+}
+
+func TestHandshakeNewToLegacy(t *testing.T) {
+	client := newTestHandshakeMesh(t, "1.1.1.1:41000")
+	legacy := newTestHandshakeMesh(t, "")
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- legacyRecvHandshake(c2, legacy.key, "3.3.3.3:41000")
+	}()
+	hs, err := NewSecureHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.sendHandshake(c1, hs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if res.PublicHash != legacy.myPublicHash || res.BindAddress != "3.3.3.3:41000" {
+		t.Fatalf("invalid result: %+v", res)
+	}
+	if hs.IsSecure() {
+		t.Fatal("the secure transport is negotiated with the old version")
+	}
+	if res.Hello.Version != LegacyProtocolVersion || res.Hello.Capabilities != 0 {
+		t.Fatalf("invalid hello of the old version: %+v", res.Hello)
+	}
+	for _, m := range []interface{}{&HeaderRequestMessage{}, &CompactBlockMessage{}, &TxInvMessage{}, &FindNodeMessage{}} {
+		if res.Hello.Capabilities.Has(messageCapability(m)) {
+			t.Fatalf("the old version has the capability of %T", m)
+		}
+	}
+}
+
+func TestHandshakeLegacyToNew(t *testing.T) {
+	server := newTestHandshakeMesh(t, "2.2.2.2:41000")
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		hs, err := NewSecureHandshake()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- server.recvHandshake(c2, hs)
+		c2.Close()
+	}()
+	pubhash, BindAddress, err := legacySendHandshake(c1, newLegacyChallenge(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the responder must not write the hello to the old version, the write fails on the closed pipe otherwise
+	c1.Close()
+	if err := <-errCh; err != nil {
+		t.Fatalf("the responder wrote more than the old version reads: %v", err)
+	}
+	if pubhash != server.myPublicHash || BindAddress != "2.2.2.2:41000" {
+		t.Fatalf("invalid response: %v %v", pubhash, BindAddress)
+	}
+}
+
+func TestHandshakeSecureOnlyToNew(t *testing.T) {
+	server := newTestHandshakeMesh(t, "2.2.2.2:41000")
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		hs, err := NewSecureHandshake()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- server.recvHandshake(c2, hs)
+	}()
+	// the version that supports the secure transport without the hello
+	hs, err := NewSecureHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := newLegacyChallenge(t)
+	hs.MarkChallenge(req)
+	req[1+len(secureChallengeMarker)] = SecureTransportVersion
+	if _, err := c1.Write(req); err != nil {
+		t.Fatal(err)
+	}
+	var sig common.Signature
+	if _, err := FillBytes(c1, sig[:]); err != nil {
+		t.Fatal(err)
+	}
+	bs := make([]byte, 1)
+	if _, err := FillBytes(c1, bs); err != nil {
+		t.Fatal(err)
+	}
+	if bs[0] != secureResponseFlag {
+		t.Fatalf("invalid response flag: %x", bs[0])
+	}
+	var ephPubKey common.PublicKey
+	if _, err := FillBytes(c1, ephPubKey[:]); err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := common.RecoverPubkey(hs.PeerResponseHash(req, ephPubKey), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.NewPublicHash(pubkey) != server.myPublicHash {
+		t.Fatal("invalid response signature")
+	}
+	if _, err := FillBytes(c1, bs); err != nil {
+		t.Fatal(err)
+	}
+	bs = make([]byte, bs[0])
+	if _, err := FillBytes(c1, bs); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != "2.2.2.2:41000" {
+		t.Fatalf("invalid bind address: %v", string(bs))
+	}
+}
+
+func TestHelloObservedIPSigned(t *testing.T) {
+	server := newTestHandshakeMesh(t, "2.2.2.2:41000")
+	req := make([]byte, 40)
+	if _, err := crand.Read(req); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		server.writeHello(conn, req)
+	}()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(conn)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(data []byte) (string, error) {
+		r, w := net.Pipe()
+		defer r.Close()
+		go func() {
+			defer w.Close()
+			w.Write(data)
+		}()
+		observed, _, err := readHello(r, req, server.myPublicHash)
+		return observed, err
+	}
+	if observed, err := read(data); err != nil {
+		t.Fatal(err)
+	} else if observed != "127.0.0.1" {
+		t.Fatalf("invalid observed ip: %v", observed)
+	}
+
+	// an on-path attacker rewrites the observed ip
+	tampered := append([]byte{}, data...)
+	copy(tampered[1:], []byte("127.0.0.2"))
+	if _, err := read(tampered); err != ErrInvalidHandshake {
+		t.Fatalf("accepted the rewritten observed ip: %v", err)
+	}
+}
+
+func TestNegotiateHello(t *testing.T) {
+	tests := []struct {
+		local   Hello
+		remote  Hello
+		version uint16
+		err     error
+	}{
+		{Hello{MinVersion: 1, MaxVersion: 2}, Hello{MinVersion: 1, MaxVersion: 2}, 2, nil},
+		{Hello{MinVersion: 1, MaxVersion: 3}, Hello{MinVersion: 1, MaxVersion: 2}, 2, nil},
+		{Hello{MinVersion: 1, MaxVersion: 2}, Hello{MinVersion: 2, MaxVersion: 5}, 2, nil},
+		{Hello{MinVersion: 3, MaxVersion: 4}, Hello{MinVersion: 1, MaxVersion: 2}, 0, ErrIncompatibleProtocol},
+		{Hello{MinVersion: 1, MaxVersion: 2}, Hello{MinVersion: 3, MaxVersion: 4}, 0, ErrIncompatibleProtocol},
+	}
+	for i, tt := range tests {
+		ph, err := negotiateHello(&tt.local, &tt.remote)
+		if err != tt.err {
+			t.Errorf("%v: expected %v but %v", i, tt.err, err)
+			continue
+		}
+		if err == nil && ph.Version != tt.version {
+			t.Errorf("%v: expected version %v but %v", i, tt.version, ph.Version)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package p2p
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/rlog"
+	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+)
+
+// MaxHeadersPerMessage is the maximum number of headers in a HeaderMessage
+const MaxHeadersPerMessage = 500
+
+// MaxBlocksPerBatch is the maximum number of blocks in a response of the BatchRequestMessage
+const MaxBlocksPerBatch = 50
+
+const (
+	maxBatchesPerPeer    = 2
+	maxBatchWindow       = 16
+	headerRequestTimeout = 10 * time.Second
+	defaultPeerRate      = 10.0
+)
+
+// HeaderValidator validates the signatures of the header before the block is connected
+type HeaderValidator interface {
+	ValidateHeaderSignature(bh *types.Header, sigs []common.Signature) error
+	HeaderHorizon() uint32
+}
+
+type batchRequest struct {
+	ID          string
+	Count       uint32
+	RequestedAt time.Time
+}
+
+// headerSync keeps the validated header chain that is ahead of the connected chain
+// Bodies are downloaded in parallel from peers by the batches of the header chain
+type headerSync struct {
+	sync.Mutex
+	hashMap     map[uint32]hash.Hash256
+	lastHeight  uint32
+	lastHash    hash.Hash256
+	requestID   string
+	requestedAt time.Time
+	rateMap     map[string]float64
+	batchMap    map[uint32]*batchRequest
+}
+
+func newHeaderSync() *headerSync {
+	return &headerSync{
+		hashMap:  map[uint32]hash.Hash256{},
+		rateMap:  map[string]float64{},
+		batchMap: map[uint32]*batchRequest{},
+	}
+}
+
+// rebase drops headers that are connected already and restarts from the chain when the header chain is behind it
+func (hs *headerSync) rebase(cp types.Provider) {
+	Height, LastHash := cp.LastStatus()
+	if hs.lastHeight <= Height {
+		hs.hashMap = map[uint32]hash.Hash256{}
+		hs.lastHeight = Height
+		hs.lastHash = LastHash
+		return
+	}
+	for h := range hs.hashMap {
+		if h <= Height {
+			delete(hs.hashMap, h)
+		}
+	}
+}
+
+func (hs *headerSync) updateRate(ID string, Count uint32, elapsed time.Duration) {
+	if elapsed <= 0 {
+		elapsed = time.Millisecond
+	}
+	rate := float64(Count) / elapsed.Seconds()
+	if old, has := hs.rateMap[ID]; has {
+		rate = old*0.7 + rate*0.3
+	}
+	hs.rateMap[ID] = rate
+}
+
+func (hs *headerSync) peerRate(ID string) float64 {
+	if rate, has := hs.rateMap[ID]; has {
+		return rate
+	}
+	return defaultPeerRate
+}
+
+func (hs *headerSync) removePeer(ID string) {
+	delete(hs.rateMap, ID)
+	for h, br := range hs.batchMap {
+		if br.ID == ID {
+			delete(hs.batchMap, h)
+		}
+	}
+	if hs.requestID == ID {
+		hs.requestID = ""
+	}
+}
+
+func (nd *Node) peerHeights() map[string]uint32 {
+	nd.statusLock.Lock()
+	defer nd.statusLock.Unlock()
+
+	heightMap := map[string]uint32{}
+	for ID, status := range nd.statusMap {
+		if nd.ms.PeerCapabilities(ID).Has(CapHeaderSync) {
+			heightMap[ID] = status.Height
+		}
+	}
+	return heightMap
+}
+
+// tryRequestHeaders requests the next headers to the best peer when the chain is far behind
+func (nd *Node) tryRequestHeaders() {
+	hv, is := nd.cn.Consensus().(HeaderValidator)
+	if !is {
+		return
+	}
+
+	var bestID string
+	var bestHeight uint32
+	for ID, Height := range nd.peerHeights() {
+		if bestHeight < Height {
+			bestID = ID
+			bestHeight = Height
+		}
+	}
+
+	nd.hsync.Lock()
+	defer nd.hsync.Unlock()
+
+	nd.hsync.rebase(nd.cn.Provider())
+	if bestHeight <= nd.hsync.lastHeight+MaxBlocksPerBatch {
+		return
+	}
+	if len(nd.hsync.requestID) > 0 && time.Since(nd.hsync.requestedAt) < headerRequestTimeout {
+		return
+	}
+	Horizon := hv.HeaderHorizon()
+	if nd.hsync.lastHeight >= Horizon {
+		return
+	}
+	if bestHeight > Horizon {
+		bestHeight = Horizon
+	}
+	Count := bestHeight - nd.hsync.lastHeight
+	if Count > MaxHeadersPerMessage {
+		Count = MaxHeadersPerMessage
+	}
+	nd.hsync.requestID = bestID
+	nd.hsync.requestedAt = time.Now()
+
+	var TargetPublicHash common.PublicHash
+	copy(TargetPublicHash[:], []byte(bestID))
+	nd.sendMessage(PriorityBlock, TargetPublicHash, &HeaderRequestMessage{
+		Height: nd.hsync.lastHeight + 1,
+		Count:  uint16(Count),
+	})
+}
+
+func (nd *Node) handleHeaderRequest(SenderPublicHash common.PublicHash, msg *HeaderRequestMessage) error {
+	bs, err := HeaderPacket(msg, nd.cn.Provider())
+	if err != nil {
+		return err
+	}
+	if bs != nil {
+		nd.sendMessagePacket(PriorityBlock, SenderPublicHash, bs)
+	}
+	return nil
+}
+
+// HeaderPacket returns the packet of the HeaderMessage for the request
+// It returns nil when the provider does not have the requested height
+func HeaderPacket(msg *HeaderRequestMessage, provider types.Provider) ([]byte, error) {
+	Height := provider.Height()
+	if msg.Height == 0 || msg.Height > Height {
+		return nil, nil
+	}
+	Count := uint32(msg.Count)
+	if Count > MaxHeadersPerMessage {
+		Count = MaxHeadersPerMessage
+	}
+	sm := &HeaderMessage{
+		Headers:    []*types.Header{},
+		Signatures: [][]common.Signature{},
+	}
+	for i := uint32(0); i < Count && msg.Height+i <= Height; i++ {
+		bh, err := provider.Header(msg.Height + i)
+		if err != nil {
+			return nil, err
+		}
+		sigs, err := provider.Signatures(msg.Height + i)
+		if err != nil {
+			return nil, err
+		}
+		sm.Headers = append(sm.Headers, bh)
+		sm.Signatures = append(sm.Signatures, sigs)
+	}
+	return MessageToPacket(sm), nil
+}
+
+func (nd *Node) handleHeaderMessage(ID string, msg *HeaderMessage) error {
+	hv, is := nd.cn.Consensus().(HeaderValidator)
+	if !is {
+		return nil
+	}
+	if len(msg.Headers) > MaxHeadersPerMessage || len(msg.Headers) != len(msg.Signatures) {
+		return ErrInvalidHeaderMessage
+	}
+
+	nd.hsync.Lock()
+	defer nd.hsync.Unlock()
+
+	if nd.hsync.requestID != ID {
+		return nil
+	}
+	nd.hsync.requestID = ""
+	nd.hsync.rebase(nd.cn.Provider())
+
+	ChainID := nd.cn.Provider().ChainID()
+	Horizon := hv.HeaderHorizon()
+	for i, bh := range msg.Headers {
+		if bh.Height <= nd.hsync.lastHeight {
+			continue
+		}
+		if bh.Height > Horizon {
+			break
+		}
+		if bh.ChainID != ChainID {
+			return chain.ErrInvalidChainID
+		}
+		if bh.Height != nd.hsync.lastHeight+1 {
+			return chain.ErrInvalidHeight
+		}
+		if bh.PrevHash != nd.hsync.lastHash {
+			return chain.ErrInvalidPrevHash
+		}
+		if err := hv.ValidateHeaderSignature(bh, msg.Signatures[i]); err != nil {
+			return err
+		}
+		h := encoding.Hash(bh)
+		nd.hsync.hashMap[bh.Height] = h
+		nd.hsync.lastHeight = bh.Height
+		nd.hsync.lastHash = h
+	}
+	return nil
+}
+
+func (nd *Node) handleBatchRequest(SenderPublicHash common.PublicHash, msg *BatchRequestMessage) error {
+	bs, err := BatchBlockPacket(msg, nd.cn.Provider())
+	if err != nil {
+		return err
+	}
+	if bs != nil {
+		nd.sendMessagePacket(PriorityBlock, SenderPublicHash, bs)
+	}
+	return nil
+}
+
+// BatchBlockPacket returns the packet of the BlockMessage for the batch request
+// It returns nil when the provider does not have the requested height
+func BatchBlockPacket(msg *BatchRequestMessage, provider types.Provider) ([]byte, error) {
+	Height := provider.Height()
+	if msg.Height == 0 || msg.Height > Height {
+		return nil, nil
+	}
+	Count := uint32(msg.Count)
+	if Count > MaxBlocksPerBatch {
+		Count = MaxBlocksPerBatch
+	}
+	list := make([]*types.Block, 0, Count)
+	for i := uint32(0); i < Count && msg.Height+i <= Height; i++ {
+		b, err := provider.Block(msg.Height + i)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return MessageToPacket(&BlockMessage{
+		Blocks: list,
+	}), nil
+}
+
+// checkHeaderHash returns an error when the block does not match the validated header chain
+func (nd *Node) checkHeaderHash(b *types.Block) error {
+	nd.hsync.Lock()
+	defer nd.hsync.Unlock()
+
+	if h, has := nd.hsync.hashMap[b.Header.Height]; has {
+		if h != encoding.Hash(b.Header) {
+			return ErrMismatchedHeaderHash
+		}
+	}
+	return nil
+}
+
+// onBatchReceived updates the throughput of the peer that sent the batch
+func (nd *Node) onBatchReceived(ID string, blocks []*types.Block) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	nd.hsync.Lock()
+	defer nd.hsync.Unlock()
+
+	Height := blocks[0].Header.Height
+	if br, has := nd.hsync.batchMap[Height]; has && br.ID == ID {
+		nd.hsync.updateRate(ID, uint32(len(blocks)), time.Since(br.RequestedAt))
+		delete(nd.hsync.batchMap, Height)
+	}
+}
+
+// onBatchExpired lowers the throughput of the peer and releases the batch to be assigned again
+func (nd *Node) onBatchExpired(Height uint32, ID string) {
+	nd.hsync.Lock()
+	defer nd.hsync.Unlock()
+
+	if br, has := nd.hsync.batchMap[Height]; has && br.ID == ID {
+		nd.hsync.rateMap[ID] = nd.hsync.peerRate(ID) / 2
+		delete(nd.hsync.batchMap, Height)
+		rlog.Println("BatchExpired", Height, br.Count)
+	}
+}
+
+// tryRequestBatches assigns the batches of the header chain to peers ordered by their throughput
+// It returns false when the header chain is not far enough ahead, then blocks are requested by the legacy way
+func (nd *Node) tryRequestBatches() bool {
+	heightMap := nd.peerHeights()
+
+	nd.hsync.Lock()
+	defer nd.hsync.Unlock()
+
+	cp := nd.cn.Provider()
+	nd.hsync.rebase(cp)
+	Height := cp.Height()
+	if nd.hsync.lastHeight < Height+MaxBlocksPerBatch {
+		return false
+	}
+
+	inflightMap := map[string]int{}
+	for h, br := range nd.hsync.batchMap {
+		if h+br.Count-1 <= Height {
+			delete(nd.hsync.batchMap, h)
+			continue
+		}
+		inflightMap[br.ID]++
+	}
+	IDs := make([]string, 0, len(heightMap))
+	for ID := range heightMap {
+		IDs = append(IDs, ID)
+	}
+	sort.Slice(IDs, func(i, j int) bool {
+		return nd.hsync.peerRate(IDs[i]) > nd.hsync.peerRate(IDs[j])
+	})
+
+	for q := uint32(0); q < maxBatchWindow; q++ {
+		Start := Height + 1 + q*MaxBlocksPerBatch
+		if Start > nd.hsync.lastHeight {
+			break
+		}
+		if _, has := nd.hsync.batchMap[Start]; has {
+			continue
+		}
+		if nd.requestTimer.Exist(Start) {
+			continue
+		}
+		Count := uint32(MaxBlocksPerBatch)
+		if Start+Count-1 > nd.hsync.lastHeight {
+			Count = nd.hsync.lastHeight - Start + 1
+		}
+		var selectedID string
+		for _, ID := range IDs {
+			if heightMap[ID] >= Start+Count-1 && inflightMap[ID] < maxBatchesPerPeer {
+				selectedID = ID
+				break
+			}
+		}
+		if len(selectedID) == 0 {
+			break
+		}
+		inflightMap[selectedID]++
+		nd.hsync.batchMap[Start] = &batchRequest{
+			ID:          selectedID,
+			Count:       Count,
+			RequestedAt: time.Now(),
+		}
+
+		var TargetPublicHash common.PublicHash
+		copy(TargetPublicHash[:], []byte(selectedID))
+		nd.sendMessage(PriorityBlock, TargetPublicHash, &BatchRequestMessage{
+			Height: Start,
+			Count:  uint16(Count),
+		})
+		Timeout := 2*time.Second + time.Duration(Count)*100*time.Millisecond
+		for i := uint32(0); i < Count; i++ {
+			nd.requestTimer.Add(Start+i, Timeout, selectedID)
+		}
+	}
+	return true
+}
@@ -7,16 +7,27 @@ import (
 	"github.com/fletaio/fleta_v1/common/hash"
 	"github.com/fletaio/fleta_v1/core/types"
 	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/service/p2p/dht"
 )
 
 // message types
 var (
-	StatusMessageType          = types.DefineHashedType("p2p.StatusMessage")
-	RequestMessageType         = types.DefineHashedType("p2p.RequestMessage")
-	BlockMessageType           = types.DefineHashedType("p2p.BlockMessage")
-	TransactionMessageType     = types.DefineHashedType("p2p.TransactionMessage")
-	PeerListMessageType        = types.DefineHashedType("p2p.PeerListMessage")
-	RequestPeerListMessageType = types.DefineHashedType("p2p.RequestPeerListMessage")
+	StatusMessageType           = types.DefineHashedType("p2p.StatusMessage")
+	RequestMessageType          = types.DefineHashedType("p2p.RequestMessage")
+	BlockMessageType            = types.DefineHashedType("p2p.BlockMessage")
+	TransactionMessageType      = types.DefineHashedType("p2p.TransactionMessage")
+	PeerListMessageType         = types.DefineHashedType("p2p.PeerListMessage")
+	RequestPeerListMessageType  = types.DefineHashedType("p2p.RequestPeerListMessage")
+	HeaderRequestMessageType    = types.DefineHashedType("p2p.HeaderRequestMessage")
+	HeaderMessageType           = types.DefineHashedType("p2p.HeaderMessage")
+	BatchRequestMessageType     = types.DefineHashedType("p2p.BatchRequestMessage")
+	CompactBlockMessageType     = types.DefineHashedType("p2p.CompactBlockMessage")
+	CompactTxRequestMessageType = types.DefineHashedType("p2p.CompactTxRequestMessage")
+	CompactTxMessageType        = types.DefineHashedType("p2p.CompactTxMessage")
+	TxInvMessageType            = types.DefineHashedType("p2p.TxInvMessage")
+	TxGetDataMessageType        = types.DefineHashedType("p2p.TxGetDataMessage")
+	FindNodeMessageType         = types.DefineHashedType("p2p.FindNodeMessage")
+	NodesMessageType            = types.DefineHashedType("p2p.NodesMessage")
 )
 
 func init() {
@@ -128,3 +139,64 @@ type PeerListMessage struct {
 // RequestPeerListMessage is a request message for a peer list
 type RequestPeerListMessage struct {
 }
+
+// FindNodeMessage used to request records of nodes that are closest to the target
+type FindNodeMessage struct {
+	Target common.PublicHash
+}
+
+// NodesMessage used to send signed records of nodes
+type NodesMessage struct {
+	Records []dht.Record //MAXLEN : MaxRecordsPerMessage
+}
+
+// HeaderRequestMessage used to request headers to a peer
+type HeaderRequestMessage struct {
+	Height uint32
+	Count  uint16
+}
+
+// HeaderMessage used to send headers and their signatures to a peer
+type HeaderMessage struct {
+	Headers    []*types.Header      //MAXLEN : MaxHeadersPerMessage
+	Signatures [][]common.Signature //MAXLEN : MaxHeadersPerMessage
+}
+
+// BatchRequestMessage used to request a batch of blocks that is larger than the RequestMessage
+type BatchRequestMessage struct {
+	Height uint32
+	Count  uint16
+}
+
+// CompactBlockMessage used to relay a new block by short transaction ids instead of transactions
+type CompactBlockMessage struct {
+	Header             types.Header
+	TransactionTypes   []uint16           //MAXLEN : 65535
+	ShortIDs           []uint64           //MAXLEN : 65535
+	TransactionResults []uint8            //MAXLEN : 65535
+	Signatures         []common.Signature //MAXLEN : 255
+}
+
+// CompactTxRequestMessage used to request missing transactions of the compact block
+type CompactTxRequestMessage struct {
+	Height    uint32
+	BlockHash hash.Hash256
+	Indexes   []uint16 //MAXLEN : 65535
+}
+
+// CompactTxMessage used to send missing transactions of the compact block
+type CompactTxMessage struct {
+	Height       uint32
+	Indexes      []uint16 //MAXLEN : 65535
+	Transactions TransactionMessage
+}
+
+// TxInvMessage used to announce hashes of transactions to a peer
+type TxInvMessage struct {
+	Hashes []hash.Hash256 //MAXLEN : MaxTxInvPerMessage
+}
+
+// TxGetDataMessage used to request transactions of announced hashes to a peer
+type TxGetDataMessage struct {
+	Hashes []hash.Hash256 //MAXLEN : MaxTxInvPerMessage
+}
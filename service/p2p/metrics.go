@@ -0,0 +1,17 @@
+package p2p
+
+import (
+	"github.com/fletaio/fleta_v1/common/metrics"
+)
+
+// metrics of node meshes
+var (
+	inboundPeers  = metrics.NewGauge("fleta_p2p_peers", "The number of connected peers", "direction", "inbound")
+	outboundPeers = metrics.NewGauge("fleta_p2p_peers", "The number of connected peers", "direction", "outbound")
+	recvBytes     = metrics.NewCounter("fleta_p2p_bytes_total", "The number of bytes that are transferred with peers", "direction", "in")
+	sentBytes     = metrics.NewCounter("fleta_p2p_bytes_total", "The number of bytes that are transferred with peers", "direction", "out")
+	recvPackets   = metrics.NewCounter("fleta_p2p_packets_total", "The number of packets that are transferred with peers", "direction", "in")
+	sentPackets   = metrics.NewCounter("fleta_p2p_packets_total", "The number of packets that are transferred with peers", "direction", "out")
+	droppedPacket = metrics.NewCounter("fleta_p2p_dropped_packets_total", "The number of packets that are dropped because send queues of peers are full")
+	droppedSend   = metrics.NewCounter("fleta_p2p_dropped_sends_total", "The number of messages that are dropped because the send queue of the node is full")
+)
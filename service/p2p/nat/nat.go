@@ -0,0 +1,225 @@
+package nat
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nat errors
+var (
+	ErrInvalidNATSpec      = errors.New("invalid nat spec")
+	ErrNotFoundGateway     = errors.New("not found gateway")
+	ErrInvalidResponse     = errors.New("invalid nat response")
+	ErrNotSupportedMapping = errors.New("not supported port mapping")
+)
+
+// NAT is a port mapper of the network address translator
+type NAT interface {
+	// ExternalIP returns the external ip address of the gateway
+	ExternalIP() (net.IP, error)
+	// AddPortMapping maps the external port to the internal port and returns the mapped external port
+	AddPortMapping(Protocol string, ExternalPort int, InternalPort int, Name string, Lifetime time.Duration) (int, error)
+	// DeletePortMapping removes the port mapping
+	DeletePortMapping(Protocol string, ExternalPort int, InternalPort int) error
+	String() string
+}
+
+// Parse returns the NAT of the spec, it is one of "none", "any", "upnp", "pmp", "pmp:<gateway ip>" and "extip:<ip>"
+// "any" discovers UPnP first and NAT-PMP after, "extip" uses the external ip without port mapping
+func Parse(spec string) (NAT, error) {
+	var (
+		parts = strings.SplitN(spec, ":", 2)
+		mech  = strings.ToLower(parts[0])
+		ip    net.IP
+	)
+	if len(parts) > 1 {
+		ip = net.ParseIP(parts[1])
+		if ip == nil {
+			return nil, ErrInvalidNATSpec
+		}
+	}
+	switch mech {
+	case "", "none", "off":
+		return nil, nil
+	case "any", "auto", "on":
+		return Any(), nil
+	case "upnp":
+		return UPnP(), nil
+	case "pmp", "natpmp", "nat-pmp":
+		if ip == nil {
+			return PMP(nil), nil
+		}
+		return NewPMP(ip), nil
+	case "extip", "ip":
+		if ip == nil {
+			return nil, ErrInvalidNATSpec
+		}
+		return ExtIP(ip), nil
+	default:
+		return nil, ErrInvalidNATSpec
+	}
+}
+
+// ExtIP is the external ip that is configured explicitly without port mapping
+type ExtIP net.IP
+
+// ExternalIP returns the configured ip
+func (n ExtIP) ExternalIP() (net.IP, error) {
+	return net.IP(n), nil
+}
+
+// AddPortMapping returns the external port as it is
+func (n ExtIP) AddPortMapping(Protocol string, ExternalPort int, InternalPort int, Name string, Lifetime time.Duration) (int, error) {
+	return ExternalPort, nil
+}
+
+// DeletePortMapping does nothing
+func (n ExtIP) DeletePortMapping(Protocol string, ExternalPort int, InternalPort int) error {
+	return nil
+}
+
+func (n ExtIP) String() string {
+	return "extip:" + net.IP(n).String()
+}
+
+// Any returns the NAT that discovers UPnP and NAT-PMP at the first use
+func Any() NAT {
+	return &autodisc{
+		what: "any",
+		discover: func() (NAT, error) {
+			if n, err := discoverUPnP(2 * time.Second); err == nil {
+				return n, nil
+			}
+			return discoverPMP()
+		},
+	}
+}
+
+// UPnP returns the NAT that discovers the UPnP internet gateway device at the first use
+func UPnP() NAT {
+	return &autodisc{
+		what: "upnp",
+		discover: func() (NAT, error) {
+			return discoverUPnP(2 * time.Second)
+		},
+	}
+}
+
+// PMP returns the NAT-PMP of the gateway, it guesses the gateway at the first use when the gateway is nil
+func PMP(gateway net.IP) NAT {
+	if gateway != nil {
+		return NewPMP(gateway)
+	}
+	return &autodisc{
+		what:     "pmp",
+		discover: discoverPMP,
+	}
+}
+
+// autodisc defers the discovery until the first use
+type autodisc struct {
+	sync.Mutex
+	what     string
+	discover func() (NAT, error)
+	found    NAT
+}
+
+func (n *autodisc) get() (NAT, error) {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.found != nil {
+		return n.found, nil
+	}
+	found, err := n.discover()
+	if err != nil {
+		return nil, err
+	}
+	n.found = found
+	return found, nil
+}
+
+// ExternalIP returns the external ip address of the discovered gateway
+func (n *autodisc) ExternalIP() (net.IP, error) {
+	found, err := n.get()
+	if err != nil {
+		return nil, err
+	}
+	return found.ExternalIP()
+}
+
+// AddPortMapping adds the port mapping to the discovered gateway
+func (n *autodisc) AddPortMapping(Protocol string, ExternalPort int, InternalPort int, Name string, Lifetime time.Duration) (int, error) {
+	found, err := n.get()
+	if err != nil {
+		return 0, err
+	}
+	return found.AddPortMapping(Protocol, ExternalPort, InternalPort, Name, Lifetime)
+}
+
+// DeletePortMapping removes the port mapping from the discovered gateway
+func (n *autodisc) DeletePortMapping(Protocol string, ExternalPort int, InternalPort int) error {
+	found, err := n.get()
+	if err != nil {
+		return err
+	}
+	return found.DeletePortMapping(Protocol, ExternalPort, InternalPort)
+}
+
+func (n *autodisc) String() string {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.found != nil {
+		return n.found.String()
+	}
+	return n.what
+}
+
+// potentialGateways returns the first addresses of private networks of local interfaces
+func potentialGateways() []net.IP {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	gws := []net.IP{}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, is := addr.(*net.IPNet)
+			if !is {
+				continue
+			}
+			ip4 := ipnet.IP.To4()
+			if ip4 == nil || !isPrivateIPv4(ip4) {
+				continue
+			}
+			gw := ip4.Mask(ipnet.Mask)
+			gw[3] |= 1
+			gws = append(gws, gw)
+		}
+	}
+	return gws
+}
+
+func isPrivateIPv4(ip net.IP) bool {
+	switch {
+	case ip[0] == 10:
+		return true
+	case ip[0] == 172 && ip[1]&0xF0 == 16:
+		return true
+	case ip[0] == 192 && ip[1] == 168:
+		return true
+	default:
+		return false
+	}
+}
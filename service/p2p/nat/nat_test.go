@@ -0,0 +1,186 @@
+package nat
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/binutil"
+)
+
+// runPMPStandIn serves NAT-PMP requests on the local udp port as a gateway of 203.0.113.7
+func runPMPStandIn(t *testing.T) (*net.UDPAddr, map[uint16]uint16, func()) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapped := map[uint16]uint16{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 64)
+		for {
+			Len, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:Len]
+			switch {
+			case Len == 2 && req[1] == pmpOpExternalAddress:
+				res := make([]byte, 12)
+				res[1] = pmpResponseOffset
+				copy(res[8:], net.IPv4(203, 0, 113, 7).To4())
+				conn.WriteToUDP(res, addr)
+			case Len == 12 && (req[1] == pmpOpMapTCP || req[1] == pmpOpMapUDP):
+				InternalPort := binutil.BigEndian.Uint16(req[4:])
+				ExternalPort := binutil.BigEndian.Uint16(req[6:])
+				Lifetime := binutil.BigEndian.Uint32(req[8:])
+				if Lifetime == 0 {
+					delete(mapped, InternalPort)
+				} else {
+					// the stand-in maps the next port of the suggested one
+					ExternalPort++
+					mapped[InternalPort] = ExternalPort
+				}
+				res := make([]byte, 16)
+				res[1] = pmpResponseOffset + req[1]
+				binutil.BigEndian.PutUint16(res[8:], InternalPort)
+				binutil.BigEndian.PutUint16(res[10:], ExternalPort)
+				binutil.BigEndian.PutUint32(res[12:], Lifetime)
+				conn.WriteToUDP(res, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr), mapped, func() {
+		conn.Close()
+		<-done
+	}
+}
+
+func TestPMP(t *testing.T) {
+	addr, mapped, closer := runPMPStandIn(t)
+	n := NewPMPWithAddress(addr)
+
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(203, 0, 113, 7)) {
+		t.Fatalf("unexpected external ip %v", ip)
+	}
+	port, err := n.AddPortMapping("tcp", 31000, 31000, "fleta", 20*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 31001 {
+		t.Fatalf("unexpected mapped port %v", port)
+	}
+	if err := n.DeletePortMapping("tcp", port, 31000); err != nil {
+		t.Fatal(err)
+	}
+	closer()
+	if len(mapped) != 0 {
+		t.Fatalf("mapping is not deleted %v", mapped)
+	}
+}
+
+const igdDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+	<device>
+		<deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+		<deviceList>
+			<device>
+				<deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+				<deviceList>
+					<device>
+						<deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+						<serviceList>
+							<service>
+								<serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+								<controlURL>/ctl/IPConn</controlURL>
+							</service>
+						</serviceList>
+					</device>
+				</deviceList>
+			</device>
+		</deviceList>
+	</device>
+</root>`
+
+func TestUPnP(t *testing.T) {
+	actions := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rootDesc.xml":
+			w.Write([]byte(igdDescription))
+		case "/ctl/IPConn":
+			action := r.Header.Get("SOAPAction")
+			actions = append(actions, action)
+			body, _ := ioutil.ReadAll(r.Body)
+			switch {
+			case strings.HasSuffix(action, `#GetExternalIPAddress"`):
+				w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>` +
+					`<u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">` +
+					`<NewExternalIPAddress>198.51.100.3</NewExternalIPAddress>` +
+					`</u:GetExternalIPAddressResponse></s:Body></s:Envelope>`))
+			case strings.HasSuffix(action, `#AddPortMapping"`):
+				if !strings.Contains(string(body), "<NewExternalPort>31000</NewExternalPort>") || !strings.Contains(string(body), "<NewProtocol>TCP</NewProtocol>") {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			case strings.HasSuffix(action, `#DeletePortMapping"`):
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	n, err := NewUPnPIGD(srv.URL + "/rootDesc.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip, err := n.ExternalIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(198, 51, 100, 3)) {
+		t.Fatalf("unexpected external ip %v", ip)
+	}
+	if port, err := n.AddPortMapping("tcp", 31000, 31000, "fleta", 20*time.Minute); err != nil {
+		t.Fatal(err)
+	} else if port != 31000 {
+		t.Fatalf("unexpected mapped port %v", port)
+	}
+	if err := n.DeletePortMapping("tcp", 31000, 31000); err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 3 {
+		t.Fatalf("unexpected actions %v", actions)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if n, err := Parse("none"); err != nil || n != nil {
+		t.Fatal(n, err)
+	}
+	if n, err := Parse("extip:1.2.3.4"); err != nil {
+		t.Fatal(err)
+	} else if ip, _ := n.ExternalIP(); !ip.Equal(net.IPv4(1, 2, 3, 4)) {
+		t.Fatal(ip)
+	}
+	if n, err := Parse("pmp:192.168.0.1"); err != nil || n.String() != "pmp:192.168.0.1" {
+		t.Fatal(n, err)
+	}
+	if _, err := Parse("extip"); err != ErrInvalidNATSpec {
+		t.Fatal(err)
+	}
+	if _, err := Parse("unknown"); err != ErrInvalidNATSpec {
+		t.Fatal(err)
+	}
+}
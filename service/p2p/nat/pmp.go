@@ -0,0 +1,146 @@
+package nat
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/binutil"
+)
+
+// PMPPort is the port of the NAT-PMP server of the gateway
+const PMPPort = 5351
+
+const (
+	pmpOpExternalAddress = 0
+	pmpOpMapUDP          = 1
+	pmpOpMapTCP          = 2
+	pmpResponseOffset    = 128
+)
+
+// NATPMP is the client of the NAT-PMP (RFC 6886) of the gateway
+type NATPMP struct {
+	addr    *net.UDPAddr
+	timeout time.Duration
+}
+
+// NewPMP returns the NAT-PMP client of the gateway
+func NewPMP(gateway net.IP) *NATPMP {
+	return NewPMPWithAddress(&net.UDPAddr{IP: gateway, Port: PMPPort})
+}
+
+// NewPMPWithAddress returns the NAT-PMP client of the server address
+func NewPMPWithAddress(addr *net.UDPAddr) *NATPMP {
+	return &NATPMP{
+		addr:    addr,
+		timeout: 250 * time.Millisecond,
+	}
+}
+
+// ExternalIP returns the external ip address of the gateway
+func (n *NATPMP) ExternalIP() (net.IP, error) {
+	res, err := n.call([]byte{0, pmpOpExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(res[8], res[9], res[10], res[11]), nil
+}
+
+// AddPortMapping maps the external port to the internal port and returns the mapped external port
+func (n *NATPMP) AddPortMapping(Protocol string, ExternalPort int, InternalPort int, Name string, Lifetime time.Duration) (int, error) {
+	if Lifetime <= 0 {
+		return 0, ErrNotSupportedMapping
+	}
+	return n.mapPort(Protocol, ExternalPort, InternalPort, uint32(Lifetime/time.Second))
+}
+
+// DeletePortMapping removes the port mapping
+func (n *NATPMP) DeletePortMapping(Protocol string, ExternalPort int, InternalPort int) error {
+	_, err := n.mapPort(Protocol, 0, InternalPort, 0)
+	return err
+}
+
+func (n *NATPMP) String() string {
+	return "pmp:" + n.addr.IP.String()
+}
+
+func (n *NATPMP) mapPort(Protocol string, ExternalPort int, InternalPort int, Lifetime uint32) (int, error) {
+	var op byte
+	switch strings.ToLower(Protocol) {
+	case "tcp":
+		op = pmpOpMapTCP
+	case "udp":
+		op = pmpOpMapUDP
+	default:
+		return 0, ErrNotSupportedMapping
+	}
+	req := make([]byte, 12)
+	req[1] = op
+	binutil.BigEndian.PutUint16(req[4:], uint16(InternalPort))
+	binutil.BigEndian.PutUint16(req[6:], uint16(ExternalPort))
+	binutil.BigEndian.PutUint32(req[8:], Lifetime)
+	res, err := n.call(req, 16)
+	if err != nil {
+		return 0, err
+	}
+	if int(binutil.BigEndian.Uint16(res[8:])) != InternalPort {
+		return 0, ErrInvalidResponse
+	}
+	return int(binutil.BigEndian.Uint16(res[10:])), nil
+}
+
+// call sends the request and waits the response by the retransmission of the RFC
+func (n *NATPMP) call(req []byte, ResponseSize int) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, n.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res := make([]byte, 16)
+	timeout := n.timeout
+	for i := 0; i < 4; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		Len, err := conn.Read(res)
+		if err != nil {
+			if ne, is := err.(net.Error); is && ne.Timeout() {
+				timeout *= 2
+				continue
+			}
+			return nil, err
+		}
+		if Len < ResponseSize || res[0] != 0 || res[1] != req[1]+pmpResponseOffset {
+			return nil, ErrInvalidResponse
+		}
+		if code := binutil.BigEndian.Uint16(res[2:]); code != 0 {
+			return nil, &pmpError{code: code}
+		}
+		return res[:Len], nil
+	}
+	return nil, ErrNotFoundGateway
+}
+
+type pmpError struct {
+	code uint16
+}
+
+func (e *pmpError) Error() string {
+	return "nat-pmp result code " + strconv.Itoa(int(e.code))
+}
+
+// discoverPMP returns the NAT-PMP of the first guessed gateway that responds
+func discoverPMP() (NAT, error) {
+	for _, gw := range potentialGateways() {
+		n := NewPMP(gw)
+		n.timeout = 100 * time.Millisecond
+		if _, err := n.ExternalIP(); err == nil {
+			n.timeout = 250 * time.Millisecond
+			return n, nil
+		}
+	}
+	return nil, ErrNotFoundGateway
+}
@@ -0,0 +1,257 @@
+package nat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ssdpAddress = "239.255.255.250:1900"
+
+// UPnPIGD is the client of the WANIPConnection or WANPPPConnection service of the UPnP internet gateway device
+type UPnPIGD struct {
+	serviceType string
+	controlURL  string
+	localIP     net.IP
+	client      *http.Client
+}
+
+// NewUPnPIGD returns the client of the internet gateway device that is described at the location
+func NewUPnPIGD(Location string) (*UPnPIGD, error) {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	res, err := client.Get(Location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var root upnpRoot
+	if err := xml.NewDecoder(res.Body).Decode(&root); err != nil {
+		return nil, err
+	}
+	svc := root.Device.findConnectionService()
+	if svc == nil {
+		return nil, ErrNotFoundGateway
+	}
+	base := root.URLBase
+	if len(base) == 0 {
+		base = Location
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	ctrlURL, err := baseURL.Parse(svc.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+	localIP, err := localIPTo(baseURL.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &UPnPIGD{
+		serviceType: svc.ServiceType,
+		controlURL:  ctrlURL.String(),
+		localIP:     localIP,
+		client:      client,
+	}, nil
+}
+
+// ExternalIP returns the external ip address of the gateway
+func (n *UPnPIGD) ExternalIP() (net.IP, error) {
+	var res struct {
+		NewExternalIPAddress string
+	}
+	if err := n.soap("GetExternalIPAddress", nil, &res); err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(strings.TrimSpace(res.NewExternalIPAddress))
+	if ip == nil {
+		return nil, ErrInvalidResponse
+	}
+	return ip, nil
+}
+
+// AddPortMapping maps the external port to the internal port and returns the mapped external port
+func (n *UPnPIGD) AddPortMapping(Protocol string, ExternalPort int, InternalPort int, Name string, Lifetime time.Duration) (int, error) {
+	args := []upnpArg{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(ExternalPort)},
+		{"NewProtocol", strings.ToUpper(Protocol)},
+		{"NewInternalPort", strconv.Itoa(InternalPort)},
+		{"NewInternalClient", n.localIP.String()},
+		{"NewEnabled", "1"},
+		{"NewPortMappingDescription", Name},
+		{"NewLeaseDuration", strconv.Itoa(int(Lifetime / time.Second))},
+	}
+	if err := n.soap("AddPortMapping", args, nil); err != nil {
+		return 0, err
+	}
+	return ExternalPort, nil
+}
+
+// DeletePortMapping removes the port mapping
+func (n *UPnPIGD) DeletePortMapping(Protocol string, ExternalPort int, InternalPort int) error {
+	args := []upnpArg{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(ExternalPort)},
+		{"NewProtocol", strings.ToUpper(Protocol)},
+	}
+	return n.soap("DeletePortMapping", args, nil)
+}
+
+func (n *UPnPIGD) String() string {
+	return "upnp:" + n.controlURL
+}
+
+type upnpArg struct {
+	Name  string
+	Value string
+}
+
+func (n *UPnPIGD) soap(Action string, args []upnpArg, result interface{}) error {
+	var buffer bytes.Buffer
+	buffer.WriteString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + Action + ` xmlns:u="` + n.serviceType + `">`)
+	for _, arg := range args {
+		buffer.WriteString("<" + arg.Name + ">")
+		xml.EscapeText(&buffer, []byte(arg.Value))
+		buffer.WriteString("</" + arg.Name + ">")
+	}
+	buffer.WriteString(`</u:` + Action + `></s:Body></s:Envelope>`)
+
+	req, err := http.NewRequest("POST", n.controlURL, &buffer)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+n.serviceType+`#`+Action+`"`)
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return ErrInvalidResponse
+	}
+	if result == nil {
+		return nil
+	}
+	var env struct {
+		Body struct {
+			Inner []byte `xml:",innerxml"`
+		}
+	}
+	if err := xml.Unmarshal(body, &env); err != nil {
+		return err
+	}
+	return xml.Unmarshal(env.Body.Inner, result)
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Services   []upnpService `xml:"serviceList>service"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+}
+
+func (d *upnpDevice) findConnectionService() *upnpService {
+	for i, svc := range d.Services {
+		if strings.Contains(svc.ServiceType, ":WANIPConnection:") || strings.Contains(svc.ServiceType, ":WANPPPConnection:") {
+			return &d.Services[i]
+		}
+	}
+	for i := range d.Devices {
+		if svc := d.Devices[i].findConnectionService(); svc != nil {
+			return svc
+		}
+	}
+	return nil
+}
+
+// localIPTo returns the local ip address that is used to reach the host
+func localIPTo(Host string) (net.IP, error) {
+	if _, _, err := net.SplitHostPort(Host); err != nil {
+		Host = net.JoinHostPort(Host, "80")
+	}
+	conn, err := net.Dial("udp", Host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+// discoverUPnP searches the internet gateway device by SSDP
+func discoverUPnP(timeout time.Duration) (NAT, error) {
+	addr, err := net.ResolveUDPAddr("udp4", ssdpAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, st := range []string{
+		"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+		"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+	} {
+		req := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + ssdpAddress + "\r\n" +
+			"ST: " + st + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 2\r\n\r\n"
+		if _, err := conn.WriteTo([]byte(req), addr); err != nil {
+			return nil, err
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 2048)
+	for time.Now().Before(deadline) {
+		conn.SetReadDeadline(deadline)
+		Len, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:Len])), nil)
+		if err != nil {
+			continue
+		}
+		Location := res.Header.Get("Location")
+		res.Body.Close()
+		if len(Location) == 0 {
+			continue
+		}
+		if n, err := NewUPnPIGD(Location); err == nil {
+			return n, nil
+		}
+	}
+	return nil, ErrNotFoundGateway
+}
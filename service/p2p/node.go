@@ -5,11 +5,13 @@ import (
 	"log"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluele/gcache"
 
 	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
 	"github.com/fletaio/fleta_v1/common/hash"
 	"github.com/fletaio/fleta_v1/common/key"
 	"github.com/fletaio/fleta_v1/common/queue"
@@ -18,6 +20,8 @@ import (
 	"github.com/fletaio/fleta_v1/core/txpool"
 	"github.com/fletaio/fleta_v1/core/types"
 	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/process/vault"
+	"github.com/fletaio/fleta_v1/service/p2p/nat"
 	"github.com/fletaio/fleta_v1/service/p2p/peer"
 )
 
@@ -31,6 +35,10 @@ type Node struct {
 	myPublicHash common.PublicHash
 	requestTimer *RequestTimer
 	requestLock  sync.RWMutex
+	hsync        *headerSync
+	csync        *compactSync
+	tgossip      *txGossip
+	fm           *ForkMonitor
 	blockQ       *queue.SortedQueue
 	statusMap    map[string]*Status
 	txpool       *txpool.TransactionPool
@@ -38,7 +46,8 @@ type Node struct {
 	txWaitQ      *queue.LinkedQueue
 	txSendQ      *queue.Queue
 	recvChan     chan *RecvMessageItem
-	sendChan     chan *SendMessageItem
+	sendQ        *queue.PriorityQueue
+	sendDropped  uint64
 	singleCache  gcache.Cache
 	batchCache   gcache.Cache
 	isRunning    bool
@@ -52,6 +61,9 @@ func NewNode(key key.Key, SeedNodeMap map[common.PublicHash]string, cn *chain.Ch
 		key:          key,
 		cn:           cn,
 		myPublicHash: common.NewPublicHash(key.PublicKey()),
+		hsync:        newHeaderSync(),
+		csync:        newCompactSync(),
+		tgossip:      newTxGossip(),
 		blockQ:       queue.NewSortedQueue(),
 		statusMap:    map[string]*Status{},
 		txpool:       txpool.NewTransactionPool(),
@@ -59,11 +71,14 @@ func NewNode(key key.Key, SeedNodeMap map[common.PublicHash]string, cn *chain.Ch
 		txWaitQ:      queue.NewLinkedQueue(),
 		txSendQ:      queue.NewQueue(),
 		recvChan:     make(chan *RecvMessageItem, 1000),
-		sendChan:     make(chan *SendMessageItem, 1000),
+		sendQ:        queue.NewPriorityQueue(PriorityCount, MaxSendQueueLen),
 		singleCache:  gcache.New(500).LRU().Build(),
 		batchCache:   gcache.New(500).LRU().Build(),
 	}
 	nd.ms = NewNodeMesh(cn.Provider().ChainID(), key, SeedNodeMap, nd, peerStorePath)
+	nd.ms.SetHeightProvider(func() uint32 {
+		return nd.cn.Provider().Height()
+	})
 	nd.requestTimer = NewRequestTimer(nd)
 	nd.txQ.AddGroup(60 * time.Second)
 	nd.txQ.AddGroup(600 * time.Second)
@@ -73,6 +88,87 @@ func NewNode(key key.Key, SeedNodeMap map[common.PublicHash]string, cn *chain.Ch
 	return nd
 }
 
+// SetForkMonitor sets the fork monitor that records fork evidences and bans the peers
+func (nd *Node) SetForkMonitor(fm *ForkMonitor) {
+	nd.fm = fm
+	fm.setNodeMesh(nd.ms)
+}
+
+// SetPenaltyConfig updates the penalty config of the peer reputation
+func (nd *Node) SetPenaltyConfig(config *PenaltyConfig) {
+	nd.ms.SetPenaltyConfig(config)
+}
+
+// SetAdvertiseAddress sets the address that is advertised to peers
+func (nd *Node) SetAdvertiseAddress(Address string) {
+	nd.ms.SetAdvertiseAddress(Address)
+}
+
+// SetUserAgent sets the user agent that is sent to peers in the handshake
+func (nd *Node) SetUserAgent(UserAgent string) {
+	nd.ms.SetUserAgent(UserAgent)
+}
+
+// SetNAT sets the NAT that maps the port of the node
+func (nd *Node) SetNAT(n nat.NAT) {
+	nd.ms.SetNAT(n)
+}
+
+// SetBandwidthConfig updates bandwidth caps of the node and its peers
+func (nd *Node) SetBandwidthConfig(config BandwidthConfig) {
+	nd.ms.SetBandwidthConfig(config)
+}
+
+// SetTxPoolConfig updates limits of the transaction pool
+func (nd *Node) SetTxPoolConfig(config txpool.Config) {
+	nd.txpool.SetConfig(config)
+}
+
+// BandwidthInfo returns the bandwidth config and traffic counters of the node and its peers
+func (nd *Node) BandwidthInfo() *BandwidthInfo {
+	info := nd.ms.BandwidthInfo()
+	info.SendQueue = nd.sendQ.Size()
+	info.SendDropped = atomic.LoadUint64(&nd.sendDropped)
+	return info
+}
+
+// SetPeerAdmin sets the peer admin that serves the peer management api
+func (nd *Node) SetPeerAdmin(pa *PeerAdmin) {
+	pa.setNode(nd)
+}
+
+// PeerInfos returns the informations of connected peers with their reputation
+func (nd *Node) PeerInfos() []*PeerInfo {
+	list := nd.ms.PeerInfos()
+	nd.statusLock.Lock()
+	for _, pi := range list {
+		if status, has := nd.statusMap[string(pi.PublicHash[:])]; has {
+			pi.Height = status.Height
+		}
+	}
+	nd.statusLock.Unlock()
+	return list
+}
+
+// PeerCount returns the number of connected peers
+func (nd *Node) PeerCount() int {
+	return len(nd.ms.Peers())
+}
+
+// BestPeerHeight returns the highest height that is reported by connected peers
+func (nd *Node) BestPeerHeight() uint32 {
+	nd.statusLock.Lock()
+	defer nd.statusLock.Unlock()
+
+	var best uint32
+	for _, status := range nd.statusMap {
+		if best < status.Height {
+			best = status.Height
+		}
+	}
+	return best
+}
+
 // Init initializes node
 func (nd *Node) Init() error {
 	fc := encoding.Factory("message")
@@ -82,6 +178,16 @@ func (nd *Node) Init() error {
 	fc.Register(TransactionMessageType, &TransactionMessage{})
 	fc.Register(PeerListMessageType, &PeerListMessage{})
 	fc.Register(RequestPeerListMessageType, &RequestPeerListMessage{})
+	fc.Register(HeaderRequestMessageType, &HeaderRequestMessage{})
+	fc.Register(HeaderMessageType, &HeaderMessage{})
+	fc.Register(BatchRequestMessageType, &BatchRequestMessage{})
+	fc.Register(CompactBlockMessageType, &CompactBlockMessage{})
+	fc.Register(CompactTxRequestMessageType, &CompactTxRequestMessage{})
+	fc.Register(CompactTxMessageType, &CompactTxMessage{})
+	fc.Register(TxInvMessageType, &TxInvMessage{})
+	fc.Register(TxGetDataMessageType, &TxGetDataMessage{})
+	fc.Register(FindNodeMessageType, &FindNodeMessage{})
+	fc.Register(NodesMessageType, &NodesMessage{})
 	return nil
 }
 
@@ -94,6 +200,7 @@ func (nd *Node) Close() {
 	defer nd.Unlock()
 
 	nd.isClose = true
+	nd.sendQ.Close()
 	nd.cn.Close()
 }
 
@@ -124,6 +231,7 @@ func (nd *Node) Run(BindAddress string) {
 	nd.Unlock()
 
 	go nd.ms.Run(BindAddress)
+	go nd.ms.runDiscovery()
 	go nd.requestTimer.Run()
 
 	WorkerCount := 1
@@ -166,10 +274,10 @@ func (nd *Node) Run(BindAddress string) {
 					}
 					item := v.(*TxMsgItem)
 					if err := nd.addTx(ctw, item.TxHash, item.Type, item.Tx, item.Sigs); err != nil {
-						if err != ErrInvalidUTXO && err != txpool.ErrExistTransaction && err != txpool.ErrExistTransactionSeq && err != txpool.ErrTooFarSeq && err != txpool.ErrPastSeq {
+						if !IsLocalTxError(err) {
 							rlog.Println("TransactionError", item.TxHash.String(), err.Error())
 							if len(item.PeerID) > 0 {
-								nd.ms.AddBadPoint(item.PeerID, 1)
+								nd.ms.Penalize(item.PeerID, MisbehaviorInvalidTx)
 							}
 						}
 						continue
@@ -186,28 +294,21 @@ func (nd *Node) Run(BindAddress string) {
 	go func() {
 		for !nd.isClose {
 			if nd.ms.HasPeer() {
-				msg := &TransactionMessage{
-					Types:      []uint16{},
-					Txs:        []types.Transaction{},
-					Signatures: [][]common.Signature{},
-				}
+				items := []*TxMsgItem{}
 				for {
 					v := nd.txSendQ.Pop()
 					if v == nil {
 						break
 					}
-					m := v.(*TxMsgItem)
-					msg.Types = append(msg.Types, m.Type)
-					msg.Txs = append(msg.Txs, m.Tx)
-					msg.Signatures = append(msg.Signatures, m.Sigs)
-					if len(msg.Types) >= 800 {
+					items = append(items, v.(*TxMsgItem))
+					if len(items) >= MaxTxsPerMessage {
 						break
 					}
 				}
-				if len(msg.Types) > 0 {
-					//log.Println("Send.TransactionMessage", len(msg.Types))
-					nd.broadcastMessage(1, msg)
+				if len(items) > 0 {
+					nd.announceTxs(items)
 				}
+				nd.retryTxRequests()
 			}
 			time.Sleep(100 * time.Millisecond)
 		}
@@ -227,6 +328,9 @@ func (nd *Node) Run(BindAddress string) {
 				}
 				if err := nd.handlePeerMessage(item.PeerID, m); err != nil {
 					log.Println("handlePeerMessage", err)
+					if mb := misbehaviorOf(m, err); mb != 0 {
+						nd.ms.Penalize(item.PeerID, mb)
+					}
 					nd.ms.RemovePeer(item.PeerID)
 					break
 				}
@@ -236,10 +340,12 @@ func (nd *Node) Run(BindAddress string) {
 
 	for i := 0; i < 2; i++ {
 		go func() {
-			for item := range nd.sendChan {
-				if nd.isClose {
-					break
+			for !nd.isClose {
+				v := nd.sendQ.PopWait()
+				if v == nil {
+					return
 				}
+				item := v.(*SendMessageItem)
 				var EmptyHash common.PublicHash
 				if bytes.Equal(item.Target[:], EmptyHash[:]) {
 					nd.ms.BroadcastPacket(item.Packet)
@@ -256,6 +362,7 @@ func (nd *Node) Run(BindAddress string) {
 
 	go func() {
 		for !nd.isClose {
+			nd.tryRequestHeaders()
 			nd.tryRequestBlocks()
 			time.Sleep(500 * time.Millisecond)
 		}
@@ -266,6 +373,7 @@ func (nd *Node) Run(BindAddress string) {
 		hasItem := false
 		TargetHeight := uint64(nd.cn.Provider().Height() + 1)
 		Count := 0
+		var lastBlock *types.Block
 		item := nd.blockQ.PopUntil(TargetHeight)
 		for item != nil {
 			b := item.(*types.Block)
@@ -285,6 +393,7 @@ func (nd *Node) Run(BindAddress string) {
 				break
 			}
 			nd.cleanPool(b)
+			lastBlock = b
 			if nd.cn.Provider().Height()%100 == 0 {
 				rlog.Println("Node", nd.myPublicHash.String(), nd.cn.Provider().Height(), "BlockConnected", b.Header.Generator.String(), b.Header.Height)
 			}
@@ -298,6 +407,9 @@ func (nd *Node) Run(BindAddress string) {
 		}
 		nd.Unlock()
 
+		if lastBlock != nil {
+			nd.relayCompactBlock(lastBlock)
+		}
 		if hasItem {
 			nd.broadcastStatus()
 			nd.tryRequestBlocks()
@@ -313,14 +425,17 @@ func (nd *Node) Run(BindAddress string) {
 
 // OnTimerExpired called when rquest expired
 func (nd *Node) OnTimerExpired(height uint32, value string) {
+	nd.onBatchExpired(height, value)
 	nd.tryRequestBlocks()
 }
 
 // OnConnected called when peer connected
 func (nd *Node) OnConnected(p peer.Peer) {
+	ph := nd.ms.PeerHello(p.ID())
 	nd.statusLock.Lock()
-	nd.statusMap[p.ID()] = &Status{}
+	nd.statusMap[p.ID()] = &Status{Height: ph.Height}
 	nd.statusLock.Unlock()
+	nd.tgossip.addPeer(p.ID(), ph.Capabilities.Has(CapTxInv))
 
 	cp := nd.cn.Provider()
 	height, lastHash := cp.LastStatus()
@@ -330,6 +445,10 @@ func (nd *Node) OnConnected(p peer.Peer) {
 		LastHash: lastHash,
 	}
 	p.SendPacket(MessageToPacket(nm))
+	if ph.Capabilities.Has(CapDiscovery) {
+		// the lookup of the self fills the nearest buckets and tells the record of the peer
+		p.SendPacket(MessageToPacket(&FindNodeMessage{Target: nd.myPublicHash}))
+	}
 }
 
 // OnDisconnected called when peer disconnected
@@ -337,8 +456,12 @@ func (nd *Node) OnDisconnected(p peer.Peer) {
 	nd.statusLock.Lock()
 	delete(nd.statusMap, p.ID())
 	nd.statusLock.Unlock()
+	nd.tgossip.removePeer(p.ID())
 
 	nd.requestTimer.RemovesByValue(p.ID())
+	nd.hsync.Lock()
+	nd.hsync.removePeer(p.ID())
+	nd.hsync.Unlock()
 	go nd.tryRequestBlocks()
 }
 
@@ -355,6 +478,10 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 	var SenderPublicHash common.PublicHash
 	copy(SenderPublicHash[:], []byte(ID))
 
+	if c := messageCapability(m); c != 0 && !nd.ms.PeerCapabilities(ID).Has(c) {
+		return ErrNotNegotiatedMessage
+	}
+
 	switch msg := m.(type) {
 	case *RequestMessage:
 		nd.statusLock.Lock()
@@ -383,7 +510,7 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 		if err != nil {
 			return err
 		}
-		nd.sendMessagePacket(0, SenderPublicHash, bs)
+		nd.sendMessagePacket(PriorityBlock, SenderPublicHash, bs)
 		return nil
 	case *StatusMessage:
 		nd.statusLock.Lock()
@@ -417,22 +544,21 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 				return err
 			}
 			if h != msg.LastHash {
-				//TODO : critical error signal
 				rlog.Println(chain.ErrFoundForkedBlock, ID, h.String(), msg.LastHash.String(), msg.Height)
-				nd.ms.RemovePeer(ID)
+				nd.reportFork(ID, msg.Height, h, msg.LastHash, "status")
 			}
 		}
 		return nil
 	case *BlockMessage:
 		for _, b := range msg.Blocks {
-			if err := nd.addBlock(b); err != nil {
-				if err == chain.ErrFoundForkedBlock {
-					//TODO : critical error signal
+			if err := nd.addBlock(ID, b); err != nil {
+				if err == chain.ErrFoundForkedBlock || err == ErrMismatchedHeaderHash {
 					nd.ms.RemovePeer(ID)
 				}
 				return err
 			}
 		}
+		nd.onBatchReceived(ID, msg.Blocks)
 
 		if len(msg.Blocks) > 0 {
 			nd.statusLock.Lock()
@@ -450,7 +576,7 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 		if nd.txWaitQ.Size() > 200000 {
 			return txpool.ErrTransactionPoolOverflowed
 		}
-		if len(msg.Types) > 800 {
+		if len(msg.Types) > MaxTxsPerMessage {
 			return ErrTooManyTrasactionInMessage
 		}
 		ChainID := nd.cn.Provider().ChainID()
@@ -458,6 +584,8 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 			tx := msg.Txs[i]
 			sigs := msg.Signatures[i]
 			TxHash := chain.HashTransactionByType(ChainID, t, tx)
+			nd.tgossip.markKnown(ID, TxHash)
+			nd.tgossip.received(TxHash)
 			if !nd.txpool.IsExist(TxHash) {
 				nd.txWaitQ.Push(TxHash, &TxMsgItem{
 					TxHash: TxHash,
@@ -469,12 +597,33 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 			}
 		}
 		return nil
+	case *HeaderRequestMessage:
+		return nd.handleHeaderRequest(SenderPublicHash, msg)
+	case *HeaderMessage:
+		return nd.handleHeaderMessage(ID, msg)
+	case *BatchRequestMessage:
+		return nd.handleBatchRequest(SenderPublicHash, msg)
+	case *CompactBlockMessage:
+		return nd.handleCompactBlockMessage(ID, msg)
+	case *CompactTxRequestMessage:
+		return nd.handleCompactTxRequest(SenderPublicHash, msg)
+	case *CompactTxMessage:
+		return nd.handleCompactTxMessage(ID, msg)
+	case *TxInvMessage:
+		return nd.handleTxInvMessage(ID, msg)
+	case *TxGetDataMessage:
+		return nd.handleTxGetDataMessage(ID, msg)
 	case *PeerListMessage:
 		nd.ms.AddPeerList(msg.Ips, msg.Hashs)
 		return nil
 	case *RequestPeerListMessage:
 		nd.ms.SendPeerList(ID)
 		return nil
+	case *FindNodeMessage:
+		nd.ms.handleFindNode(ID, msg)
+		return nil
+	case *NodesMessage:
+		return nd.ms.handleNodes(ID, msg)
 	default:
 		panic(ErrUnknownMessage) //TEMP
 		return ErrUnknownMessage
@@ -482,18 +631,76 @@ func (nd *Node) handlePeerMessage(ID string, m interface{}) error {
 	return nil
 }
 
-func (nd *Node) addBlock(b *types.Block) error {
+// IsLocalTxError returns the error of adding the transaction is caused by the state of the local chain and the pool, so the peer that relayed it should not be penalized
+func IsLocalTxError(err error) bool {
+	switch err {
+	case ErrInvalidUTXO,
+		txpool.ErrExistTransaction,
+		txpool.ErrExistTransactionSeq,
+		txpool.ErrTooFarSeq,
+		txpool.ErrPastSeq,
+		txpool.ErrTransactionPoolOverflowed,
+		txpool.ErrReplaceUnderpriced,
+		txpool.ErrTooManyAddressTransactions:
+		return true
+	default:
+		return false
+	}
+}
+
+// misbehaviorOf returns the misbehavior of the peer that is the cause of the error of the message handling
+func misbehaviorOf(m interface{}, err error) Misbehavior {
+	switch m.(type) {
+	case *BlockMessage, *HeaderMessage, *CompactBlockMessage, *CompactTxMessage:
+		if err == ErrInvalidHeaderMessage {
+			return MisbehaviorOversizedMessage
+		}
+		return MisbehaviorInvalidBlock
+	case *TransactionMessage, *TxInvMessage, *TxGetDataMessage:
+		if err == ErrTooManyTrasactionInMessage {
+			return MisbehaviorOversizedMessage
+		}
+	case *NodesMessage:
+		if err == ErrTooManyNodeRecords {
+			return MisbehaviorOversizedMessage
+		}
+	}
+	return 0
+}
+
+// reportFork reports the conflicting hash of the peer to the fork monitor, it just drops the peer without the monitor
+func (nd *Node) reportFork(ID string, Height uint32, OurHash hash.Hash256, TheirHash hash.Hash256, Source string) {
+	if nd.fm == nil {
+		nd.ms.RemovePeer(ID)
+		return
+	}
+	var pubhash common.PublicHash
+	copy(pubhash[:], []byte(ID))
+	nd.fm.Report(&ForkEvidence{
+		Height:    Height,
+		OurHash:   OurHash,
+		TheirHash: TheirHash,
+		Peer:      pubhash,
+		Source:    Source,
+		Timestamp: uint64(time.Now().UnixNano()),
+	})
+}
+
+func (nd *Node) addBlock(ID string, b *types.Block) error {
 	cp := nd.cn.Provider()
 	if b.Header.Height <= cp.Height() {
 		h, err := cp.Hash(b.Header.Height)
 		if err != nil {
 			return err
 		}
-		if h != encoding.Hash(b.Header) {
-			//TODO : critical error signal
+		if TheirHash := encoding.Hash(b.Header); h != TheirHash {
+			nd.reportFork(ID, b.Header.Height, h, TheirHash, "block")
 			return chain.ErrFoundForkedBlock
 		}
 	} else {
+		if err := nd.checkHeaderHash(b); err != nil {
+			return err
+		}
 		if item := nd.blockQ.FindOrInsert(b, uint64(b.Header.Height)); item != nil {
 			old := item.(*types.Block)
 			if encoding.Hash(old.Header) != encoding.Hash(b.Header) {
@@ -546,9 +753,6 @@ func (nd *Node) PushTx(tx types.Transaction, sigs []common.Signature) error {
 }
 
 func (nd *Node) addTx(ctw types.LoaderWrapper, TxHash hash.Hash256, t uint16, tx types.Transaction, sigs []common.Signature) error {
-	if nd.txpool.Size() > 65535 {
-		return txpool.ErrTransactionPoolOverflowed
-	}
 	cp := nd.cn.Provider()
 	if nd.txpool.IsExist(TxHash) {
 		return txpool.ErrExistTransaction
@@ -578,13 +782,22 @@ func (nd *Node) addTx(ctw types.LoaderWrapper, TxHash hash.Hash256, t uint16, tx
 	if err := tx.Validate(p, ctw, signers); err != nil {
 		return err
 	}
-	if err := nd.txpool.Push(t, TxHash, tx, sigs, signers); err != nil {
+	var Fee *amount.Amount
+	if ftx, is := tx.(vault.FeeTransaction); is {
+		Fee = ftx.Fee(p, ctw)
+	}
+	removed, err := nd.txpool.Push(t, TxHash, tx, sigs, signers, Fee)
+	for _, item := range removed {
+		nd.txQ.Remove(string(item.TxHash[:]))
+	}
+	if err != nil {
 		return err
 	}
 	nd.txQ.Push(string(TxHash[:]), &TxMsgItem{
-		Type: t,
-		Tx:   tx,
-		Sigs: sigs,
+		TxHash: TxHash,
+		Type:   t,
+		Tx:     tx,
+		Sigs:   sigs,
 	})
 	return nil
 }
@@ -593,6 +806,10 @@ func (nd *Node) tryRequestBlocks() {
 	nd.requestLock.Lock()
 	defer nd.requestLock.Unlock()
 
+	if nd.tryRequestBatches() {
+		return
+	}
+
 	Height := nd.cn.Provider().Height()
 	for q := uint32(0); q < 10; q++ {
 		BaseHeight := Height + q*10
@@ -662,3 +879,12 @@ func (nd *Node) TxPoolList() []*txpool.PoolItem {
 func (nd *Node) GetTxFromTXPool(TxHash hash.Hash256) *txpool.PoolItem {
 	return nd.txpool.Get(TxHash)
 }
+
+// RemoveTxFromTXPool removes the tx and following txs of the same address from txpool
+func (nd *Node) RemoveTxFromTXPool(TxHash hash.Hash256) []*txpool.PoolItem {
+	removed := nd.txpool.Drop(TxHash)
+	for _, item := range removed {
+		nd.txQ.Remove(string(item.TxHash[:]))
+	}
+	return removed
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/fletaio/fleta_v1/common/key"
 	"github.com/fletaio/fleta_v1/common/rlog"
 	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/service/p2p/dht"
+	"github.com/fletaio/fleta_v1/service/p2p/nat"
 	"github.com/fletaio/fleta_v1/service/p2p/nodepoolmanage"
 	"github.com/fletaio/fleta_v1/service/p2p/peer"
 )
@@ -28,17 +30,28 @@ type Handler interface {
 // NodeMesh is a mesh for networking between nodes
 type NodeMesh struct {
 	sync.Mutex
-	BindAddress     string
-	chainID         uint8
-	key             key.Key
-	handler         Handler
-	myPublicHash    common.PublicHash
-	nodeSet         map[common.PublicHash]string
-	peerIDs         []string
-	badPointMap     map[string]int
-	clientPeerMap   map[string]peer.Peer
-	serverPeerMap   map[string]peer.Peer
-	nodePoolManager nodepoolmanage.Manager
+	BindAddress      string
+	chainID          uint8
+	key              key.Key
+	handler          Handler
+	myPublicHash     common.PublicHash
+	nodeSet          map[common.PublicHash]string
+	peerIDs          []string
+	rep              *Reputation
+	clientPeerMap    map[string]peer.Peer
+	serverPeerMap    map[string]peer.Peer
+	nodePoolManager  nodepoolmanage.Manager
+	voter            *addressVoter
+	nat              nat.NAT
+	natAddress       string
+	advertiseAddress string
+	dht              *dht.Table
+	myRecord         *dht.Record
+	bw               *Bandwidth
+	capabilities     Capability
+	userAgent        string
+	heightFunc       func() uint32
+	helloMap         map[string]*PeerHello
 }
 
 // NewNodeMesh returns a NodeMesh
@@ -50,7 +63,11 @@ func NewNodeMesh(ChainID uint8, key key.Key, SeedNodeMap map[common.PublicHash]s
 		myPublicHash:  common.NewPublicHash(key.PublicKey()),
 		nodeSet:       map[common.PublicHash]string{},
 		peerIDs:       []string{},
-		badPointMap:   map[string]int{},
+		rep:           NewReputation(nil),
+		voter:         newAddressVoter(),
+		bw:            NewBandwidth(),
+		capabilities:  LocalCapabilities,
+		helloMap:      map[string]*PeerHello{},
 		clientPeerMap: map[string]peer.Peer{},
 		serverPeerMap: map[string]peer.Peer{},
 	}
@@ -59,6 +76,11 @@ func NewNodeMesh(ChainID uint8, key key.Key, SeedNodeMap map[common.PublicHash]s
 		panic(err)
 	}
 	ms.nodePoolManager = manager
+	tb, err := dht.NewTable(ChainID, ms.myPublicHash, peerStorePath+"_dht")
+	if err != nil {
+		panic(err)
+	}
+	ms.dht = tb
 	ms.nodePoolManager.Ban(string(ms.myPublicHash[:]))
 
 	for PubHash, v := range SeedNodeMap {
@@ -70,6 +92,12 @@ func NewNodeMesh(ChainID uint8, key key.Key, SeedNodeMap map[common.PublicHash]s
 // Run starts the node mesh
 func (ms *NodeMesh) Run(BindAddress string) {
 	ms.BindAddress = BindAddress
+	ms.Lock()
+	n := ms.nat
+	ms.Unlock()
+	if n != nil {
+		go ms.runPortMapping(n)
+	}
 	for PubHash, v := range ms.nodeSet {
 		if PubHash != ms.myPublicHash {
 			go func(pubhash common.PublicHash, NetAddr string) {
@@ -96,16 +124,8 @@ func (ms *NodeMesh) Run(BindAddress string) {
 	}
 	go func() {
 		for {
-			time.Sleep(10 * time.Second)
-			ms.Lock()
-			for ID, point := range ms.badPointMap {
-				if point <= 1 {
-					delete(ms.badPointMap, ID)
-				} else {
-					ms.badPointMap[ID] = point - 1
-				}
-			}
-			ms.Unlock()
+			time.Sleep(time.Minute)
+			ms.rep.Prune()
 		}
 	}()
 	if err := ms.server(BindAddress); err != nil {
@@ -139,14 +159,24 @@ func (ms *NodeMesh) Peers() []peer.Peer {
 	return peers
 }
 
-// AddBadPoint adds bad points to to the peer
-func (ms *NodeMesh) AddBadPoint(ID string, Point int) {
-	ms.Lock()
-	ms.badPointMap[ID] += Point
-	ms.Unlock()
+// SetPenaltyConfig updates the penalty config of the reputation
+func (ms *NodeMesh) SetPenaltyConfig(config *PenaltyConfig) {
+	ms.rep.SetConfig(config)
+}
 
-	if ms.badPointMap[ID] >= 100 {
-		ms.RemovePeer(ID)
+// Penalize adds the penalty of the misbehavior to the peer and bans it when the score reaches the threshold
+// Whitelisted peers are exempt from penalties
+func (ms *NodeMesh) Penalize(ID string, m Misbehavior) {
+	if ms.nodePoolManager.IsWhitelisted(ID) {
+		return
+	}
+	score, ban := ms.rep.Penalize(ID, m)
+	if ban {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(ID))
+		rlog.Println("PeerBanned", pubhash.String(), m.String(), score)
+		ms.rep.Reset(ID)
+		ms.TemporaryBan(ID, ms.rep.Config().BanDuration)
 	}
 }
 
@@ -163,7 +193,6 @@ func (ms *NodeMesh) RemovePeer(ID string) {
 	}
 	if hasClient || hasServer {
 		ms.updatePeerIDs()
-		delete(ms.badPointMap, ID)
 	}
 	ms.Unlock()
 
@@ -189,6 +218,8 @@ func (ms *NodeMesh) updatePeerIDs() {
 	}
 	sort.Strings(peerIDs)
 	ms.peerIDs = peerIDs
+	inboundPeers.Set(float64(len(ms.serverPeerMap)))
+	outboundPeers.Set(float64(len(ms.clientPeerMap)))
 }
 
 func (ms *NodeMesh) removePeerInMap(ID string, peerMap map[string]peer.Peer) {
@@ -275,6 +306,107 @@ func (ms *NodeMesh) BroadcastPacket(bs []byte) {
 	}
 }
 
+// TemporaryBan disconnects the peer and bans it for the duration, whitelisted peers are just disconnected
+func (ms *NodeMesh) TemporaryBan(ID string, d time.Duration) {
+	if ms.nodePoolManager.IsWhitelisted(ID) {
+		ms.RemovePeer(ID)
+		return
+	}
+	ms.nodePoolManager.TemporaryBan(ID, d)
+	ms.RemovePeer(ID)
+}
+
+// Ban removes the peer from the whitelist, disconnects it and bans it for the duration
+// The ban never expires when the duration is not positive
+func (ms *NodeMesh) Ban(ID string, d time.Duration) {
+	ms.nodePoolManager.RemoveWhitelist(ID)
+	ms.TemporaryBan(ID, d)
+}
+
+// BanList returns the expiries of temporarily banned peers
+func (ms *NodeMesh) BanList() map[common.PublicHash]int64 {
+	list := map[common.PublicHash]int64{}
+	for ID, expiry := range ms.nodePoolManager.BanList() {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(ID))
+		list[pubhash] = expiry
+	}
+	return list
+}
+
+// Unban removes the ban of the peer and clears its score
+func (ms *NodeMesh) Unban(ID string) {
+	ms.nodePoolManager.Unban(ID)
+	ms.rep.Reset(ID)
+}
+
+// Whitelist adds the peer to the whitelist that is exempt from penalties and bans
+func (ms *NodeMesh) Whitelist(ID string) {
+	ms.nodePoolManager.Whitelist(ID)
+	ms.rep.Reset(ID)
+}
+
+// RemoveWhitelist removes the peer from the whitelist
+func (ms *NodeMesh) RemoveWhitelist(ID string) {
+	ms.nodePoolManager.RemoveWhitelist(ID)
+}
+
+// WhitelistList returns the peers in the whitelist
+func (ms *NodeMesh) WhitelistList() []common.PublicHash {
+	list := []common.PublicHash{}
+	for _, ID := range ms.nodePoolManager.WhitelistList() {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(ID))
+		list = append(list, pubhash)
+	}
+	return list
+}
+
+// PeerInfos returns the informations of connected peers with their reputation
+func (ms *NodeMesh) PeerInfos() []*PeerInfo {
+	type peerItem struct {
+		p       peer.Peer
+		inbound bool
+	}
+	itemMap := map[string]*peerItem{}
+	ms.Lock()
+	for _, p := range ms.clientPeerMap {
+		itemMap[p.ID()] = &peerItem{p: p}
+	}
+	for _, p := range ms.serverPeerMap {
+		if _, has := itemMap[p.ID()]; !has {
+			itemMap[p.ID()] = &peerItem{p: p, inbound: true}
+		}
+	}
+	ms.Unlock()
+
+	list := make([]*PeerInfo, 0, len(itemMap))
+	for ID, item := range itemMap {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(ID))
+		pi := &PeerInfo{
+			PublicHash:    pubhash,
+			Name:          item.p.Name(),
+			Inbound:       item.inbound,
+			ConnectedTime: item.p.ConnectedTime(),
+			Score:         ms.rep.Score(ID),
+			Whitelisted:   ms.nodePoolManager.IsWhitelisted(ID),
+		}
+		ph := ms.PeerHello(ID)
+		pi.ProtocolVersion = ph.Version
+		pi.UserAgent = ph.UserAgent
+		pi.Capabilities = ph.Capabilities.String()
+		if m := ms.rep.LastMisbehavior(ID); m != 0 {
+			pi.LastMisbehavior = m.String()
+		}
+		list = append(list, pi)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].PublicHash.String() < list[j].PublicHash.String()
+	})
+	return list
+}
+
 func (ms *NodeMesh) RequestConnect(Address string, TargetPubHash common.PublicHash) {
 	go ms.client(Address, TargetPubHash)
 }
@@ -310,41 +442,62 @@ func (ms *NodeMesh) client(Address string, TargetPubHash common.PublicHash) erro
 		ms.nodePoolManager.Ban(string(TargetPubHash[:]))
 		return ErrSelfConnection
 	}
+	if ms.nodePoolManager.IsBan(string(TargetPubHash[:])) {
+		return ErrBannedPeer
+	}
 
 	conn, err := net.DialTimeout("tcp", Address, 10*time.Second)
 	if err != nil {
+		ms.dht.Fail(TargetPubHash)
 		return err
 	}
 	defer conn.Close()
 
 	start := time.Now()
-	if err := ms.recvHandshake(conn); err != nil {
+	hs, err := NewSecureHandshake()
+	if err != nil {
+		return err
+	}
+	// the peer is not authenticated until the response of the challenge is recovered to the target,
+	// so failures before it are counted to the address instead of the target
+	if err := ms.recvHandshake(conn, hs); err != nil {
 		rlog.Println("[recvHandshake]", err)
+		ms.dht.Fail(TargetPubHash)
 		return err
 	}
-	pubhash, bindAddress, err := ms.sendHandshake(conn)
+	res, err := ms.sendHandshake(conn, hs)
 	if err != nil {
 		rlog.Println("[sendHandshake]", err)
+		ms.dht.Fail(TargetPubHash)
 		return err
 	}
+	pubhash := res.PublicHash
 	if pubhash == ms.myPublicHash {
-		ms.nodePoolManager.Ban(string(TargetPubHash[:]))
+		ms.nodePoolManager.RemovePeerAddress(string(TargetPubHash[:]), Address)
 		ms.nodePoolManager.Ban(string(pubhash[:]))
 		return ErrSelfConnection
 	}
 	if pubhash != TargetPubHash {
+		ms.nodePoolManager.RemovePeerAddress(string(TargetPubHash[:]), Address)
+		ms.dht.Fail(TargetPubHash)
 		return common.ErrInvalidPublicHash
 	}
-	//duration := time.Since(start)
-	var ipAddress string
-	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-		ipAddress = addr.IP.String()
-	}
-	ipAddress += bindAddress
-
 	ID := string(pubhash[:])
-	//ms.nodePoolManager.NewNode(ipAddress, ID, duration)
-	p := NewTCPAsyncPeer(conn, ID, pubhash.String(), start.UnixNano())
+	if len(res.Observed) > 0 {
+		ms.voter.Vote(ID, conn.RemoteAddr(), res.Observed)
+	}
+	ms.dht.Seen(pubhash)
+	ms.setPeerHello(ID, res.Hello)
+	var pconn net.Conn = conn
+	if hs.IsSecure() {
+		codec, err := hs.NewSecureCodec(true)
+		if err != nil {
+			return err
+		}
+		pconn = NewSecureConn(conn, codec)
+	}
+	p := NewTCPAsyncPeer(pconn, ID, pubhash.String(), start.UnixNano())
+	p.SetBandwidth(ms.bw.Peer(ID))
 
 	ms.Lock()
 	old, has := ms.clientPeerMap[ID]
@@ -379,30 +532,45 @@ func (ms *NodeMesh) server(BindAddress string) error {
 			defer conn.Close()
 
 			start := time.Now()
-			pubhash, bindAddress, err := ms.sendHandshake(conn)
+			hs, err := NewSecureHandshake()
+			if err != nil {
+				return
+			}
+			res, err := ms.sendHandshake(conn, hs)
 			if err != nil {
 				rlog.Println("[sendHandshake]", err)
 				return
 			}
+			pubhash := res.PublicHash
 			if pubhash == ms.myPublicHash {
 				ms.nodePoolManager.RemovePeer(string(pubhash[:]))
 				ms.nodePoolManager.Ban(string(pubhash[:]))
 				return
 			}
-			if err := ms.recvHandshake(conn); err != nil {
+			if err := ms.recvHandshake(conn, hs); err != nil {
 				rlog.Println("[recvHandshakeAck]", err)
+				ms.Penalize(string(pubhash[:]), MisbehaviorHandshakeFailure)
 				return
 			}
-			//duration := time.Since(start)
-			var ipAddress string
-			if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-				ipAddress = addr.IP.String()
+			if ms.nodePoolManager.IsBan(string(pubhash[:])) {
+				return
 			}
-			ipAddress += bindAddress
-
+			// inbound peers do not vote because anyone can connect to the node as many identities
 			ID := string(pubhash[:])
-			//ms.nodePoolManager.NewNode(ipAddress, ID, duration)
-			p := NewTCPAsyncPeer(conn, ID, pubhash.String(), start.UnixNano())
+			if Address := resolvePeerAddress(conn.RemoteAddr(), res.BindAddress); len(Address) > 0 {
+				ms.nodePoolManager.AddPeerList([]string{Address}, []string{ID})
+			}
+			ms.setPeerHello(ID, res.Hello)
+			var pconn net.Conn = conn
+			if hs.IsSecure() {
+				codec, err := hs.NewSecureCodec(false)
+				if err != nil {
+					return
+				}
+				pconn = NewSecureConn(conn, codec)
+			}
+			p := NewTCPAsyncPeer(pconn, ID, pubhash.String(), start.UnixNano())
+			p.SetBandwidth(ms.bw.Peer(ID))
 
 			log.Println("ConnectedFrom", pubhash.String())
 
@@ -428,12 +596,16 @@ func (ms *NodeMesh) server(BindAddress string) error {
 func (ms *NodeMesh) handleConnection(p peer.Peer) error {
 	// rlog.Println("Node", common.NewPublicHash(ms.key.PublicKey()).String(), "Node Connected", p.Name())
 
+	defer ms.clearPeerHello(p.ID())
 	ms.handler.OnConnected(p)
 	defer ms.handler.OnDisconnected(p)
 
 	for {
 		bs, err := p.ReadPacket()
 		if err != nil {
+			if err == ErrTooLargePacket {
+				ms.Penalize(p.ID(), MisbehaviorOversizedMessage)
+			}
 			return err
 		}
 		if err := ms.handler.OnRecv(p, bs); err != nil {
@@ -442,7 +614,7 @@ func (ms *NodeMesh) handleConnection(p peer.Peer) error {
 	}
 }
 
-func (ms *NodeMesh) recvHandshake(conn net.Conn) error {
+func (ms *NodeMesh) recvHandshake(conn net.Conn, hs *SecureHandshake) error {
 	//rlog.Println("recvHandshake")
 	req := make([]byte, 40)
 	if _, err := FillBytes(conn, req); err != nil {
@@ -461,14 +633,31 @@ func (ms *NodeMesh) recvHandshake(conn net.Conn) error {
 		return ErrInvalidHandshake
 	}
 	//rlog.Println("sendHandshakeAck")
-	h := hash.Hash(req)
+	h := hs.ResponseHash(req)
 	if sig, err := ms.key.Sign(h); err != nil {
 		return err
 	} else if _, err := conn.Write(sig[:]); err != nil {
 		return err
 	}
+	hasHello := challengeVersion(req) >= HandshakeVersion
+	if IsMarkedChallenge(req) {
+		ephPubKey := hs.EphemeralPublicKey()
+		flag := byte(secureResponseFlag)
+		if hasHello {
+			flag = helloResponseFlag
+		}
+		if _, err := conn.Write([]byte{flag}); err != nil {
+			return err
+		}
+		if _, err := conn.Write(ephPubKey[:]); err != nil {
+			return err
+		}
+	}
 
-	ba := []byte(ms.BindAddress)
+	ba := []byte(ms.AdvertiseAddress())
+	if len(ba) >= helloResponseFlag {
+		ba = ba[:helloResponseFlag-1]
+	}
 	length := byte(uint8(len(ba)))
 	if _, err := conn.Write([]byte{length}); err != nil {
 		return err
@@ -476,41 +665,84 @@ func (ms *NodeMesh) recvHandshake(conn net.Conn) error {
 	if _, err := conn.Write(ba); err != nil {
 		return err
 	}
+	if hasHello {
+		if err := ms.writeHello(conn, req); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (ms *NodeMesh) sendHandshake(conn net.Conn) (common.PublicHash, string, error) {
+// handshakeResult is the result of the handshake that is responded by the peer
+type handshakeResult struct {
+	PublicHash  common.PublicHash
+	BindAddress string
+	Observed    string
+	Hello       *PeerHello
+}
+
+func (ms *NodeMesh) sendHandshake(conn net.Conn, hs *SecureHandshake) (*handshakeResult, error) {
 	//rlog.Println("sendHandshake")
 	req := make([]byte, 40)
 	if _, err := crand.Read(req[:32]); err != nil {
-		return common.PublicHash{}, "", err
+		return nil, err
 	}
 	req[0] = ms.chainID
+	hs.MarkChallenge(req)
 	binutil.LittleEndian.PutUint64(req[32:], uint64(time.Now().UnixNano()))
 	if _, err := conn.Write(req); err != nil {
-		return common.PublicHash{}, "", err
+		return nil, err
 	}
 	//rlog.Println("recvHandshakeAsk")
 	var sig common.Signature
 	if _, err := FillBytes(conn, sig[:]); err != nil {
-		return common.PublicHash{}, "", err
+		return nil, err
 	}
-	pubkey, err := common.RecoverPubkey(hash.Hash(req), sig)
-	if err != nil {
-		return common.PublicHash{}, "", err
-	}
-	pubhash := common.NewPublicHash(pubkey)
+	h := hash.Hash(req)
 
 	bs := make([]byte, 1)
 	if _, err := FillBytes(conn, bs); err != nil {
-		return common.PublicHash{}, "", err
+		return nil, err
+	}
+	hasHello := bs[0] == helloResponseFlag
+	if bs[0] == secureResponseFlag || hasHello {
+		var ephPubKey common.PublicKey
+		if _, err := FillBytes(conn, ephPubKey[:]); err != nil {
+			return nil, err
+		}
+		h = hs.PeerResponseHash(req, ephPubKey)
+		hs.SetPeerEphemeralPublicKey(ephPubKey)
+		if _, err := FillBytes(conn, bs); err != nil {
+			return nil, err
+		}
+	}
+	pubkey, err := common.RecoverPubkey(h, sig)
+	if err != nil {
+		return nil, err
 	}
+	pubhash := common.NewPublicHash(pubkey)
+
 	length := uint8(bs[0])
 	bs = make([]byte, length)
 	if _, err := FillBytes(conn, bs); err != nil {
-		return common.PublicHash{}, "", err
+		return nil, err
 	}
-	bindAddres := string(bs)
-
-	return pubhash, bindAddres, nil
+	res := &handshakeResult{
+		PublicHash:  pubhash,
+		BindAddress: string(bs),
+		Hello:       legacyPeerHello(),
+	}
+	if hasHello {
+		observed, hello, err := readHello(conn, req, pubhash)
+		if err != nil {
+			return nil, err
+		}
+		ph, err := negotiateHello(ms.localHello(), hello)
+		if err != nil {
+			return nil, err
+		}
+		res.Observed = observed
+		res.Hello = ph
+	}
+	return res, nil
 }
@@ -1,49 +1,64 @@
 package p2p
 
 import (
+	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/queue"
 )
 
-func (nd *Node) sendMessage(Priority int, Target common.PublicHash, m interface{}) {
+// pushSendItem waits while the send queue is full, so producers are slowed down
+// The message is dropped and counted when the queue is not drained until the timeout, so producers are not blocked forever
+func (nd *Node) pushSendItem(Priority Priority, item *SendMessageItem) {
+	if err := nd.sendQ.Push(item, int(Priority), 1, NodeSendQueueTimeout); err == queue.ErrQueueClosed {
+		return
+	} else if err != nil {
+		log.Println("pushSendItem", Priority.String(), err)
+		atomic.AddUint64(&nd.sendDropped, 1)
+		droppedSend.Inc()
+	}
+}
+
+func (nd *Node) sendMessage(Priority Priority, Target common.PublicHash, m interface{}) {
 	if _, is := m.([]byte); is {
 		panic("")
 	}
 
-	nd.sendChan <- &SendMessageItem{
+	nd.pushSendItem(Priority, &SendMessageItem{
 		Target: Target,
 		Packet: MessageToPacket(m),
-	}
+	})
 }
 
-func (nd *Node) sendMessagePacket(Priority int, Target common.PublicHash, bs []byte) {
-	nd.sendChan <- &SendMessageItem{
+func (nd *Node) sendMessagePacket(Priority Priority, Target common.PublicHash, bs []byte) {
+	nd.pushSendItem(Priority, &SendMessageItem{
 		Target: Target,
 		Packet: bs,
-	}
+	})
 }
 
-func (nd *Node) broadcastMessage(Priority int, m interface{}) {
+func (nd *Node) broadcastMessage(Priority Priority, m interface{}) {
 	if _, is := m.([]byte); is {
 		panic("")
 	}
 
-	nd.sendChan <- &SendMessageItem{
+	nd.pushSendItem(Priority, &SendMessageItem{
 		Packet: MessageToPacket(m),
-	}
+	})
 }
 
-func (nd *Node) exceptCastMessage(Priority int, Target common.PublicHash, m interface{}) {
+func (nd *Node) exceptCastMessage(Priority Priority, Target common.PublicHash, m interface{}) {
 	if _, is := m.([]byte); is {
 		panic("")
 	}
 
-	nd.sendChan <- &SendMessageItem{
+	nd.pushSendItem(Priority, &SendMessageItem{
 		Target: Target,
 		Packet: MessageToPacket(m),
 		Except: true,
-	}
+	})
 }
 
 func (nd *Node) sendStatusTo(TargetPubHash common.PublicHash) error {
@@ -58,7 +73,7 @@ func (nd *Node) sendStatusTo(TargetPubHash common.PublicHash) error {
 		Height:   height,
 		LastHash: lastHash,
 	}
-	nd.sendMessage(0, TargetPubHash, nm)
+	nd.sendMessage(PriorityBlock, TargetPubHash, nm)
 	return nil
 }
 
@@ -83,7 +98,7 @@ func (nd *Node) sendRequestBlockTo(TargetPubHash common.PublicHash, Height uint3
 		Height: Height,
 		Count:  Count,
 	}
-	nd.sendMessage(0, TargetPubHash, nm)
+	nd.sendMessage(PriorityBlock, TargetPubHash, nm)
 	for i := uint32(0); i < uint32(Count); i++ {
 		nd.requestTimer.Add(Height+i, 2*time.Second, string(TargetPubHash[:]))
 	}
@@ -22,8 +22,16 @@ type Manager interface {
 	AddPeerList(ips []string, hashs []string)
 	GetPeerList() (ips []string, hashs []string)
 	RemovePeer(hash string)
+	RemovePeerAddress(hash string, addr string)
 	Ban(hash string)
 	Unban(Hash string)
+	TemporaryBan(hash string, d time.Duration)
+	IsBan(hash string) bool
+	BanList() map[string]int64
+	Whitelist(hash string)
+	RemoveWhitelist(hash string)
+	IsWhitelisted(hash string) bool
+	WhitelistList() []string
 }
 
 type nodeMesh interface {
@@ -42,6 +50,7 @@ type nodePoolManage struct {
 	peerStorage        storage.PeerStorage
 	nodeMesh           nodeMesh
 	BanPeerInfos       *BanAlways
+	bans               *storage.BanStore
 	myPublicHash       common.PublicHash
 
 	putPeerListLock sync.Mutex
@@ -61,11 +70,16 @@ func NewNodePoolManage(StorePath string, nodeMesh nodeMesh, pubhash common.Publi
 	if err != nil {
 		return nil, err
 	}
+	bs, err := storage.NewBanStore(StorePath + "_ban")
+	if err != nil {
+		return nil, err
+	}
 	pm := &nodePoolManage{
 		nodes:        ns,
 		nodeMesh:     nodeMesh,
 		myPublicHash: pubhash,
 		BanPeerInfos: NewBanAlways(),
+		bans:         bs,
 	}
 	pm.peerStorage = storage.NewPeerStorage(pm.checkClosePeer)
 	go pm.rotatePeer()
@@ -109,6 +123,17 @@ func (pm *nodePoolManage) RemovePeer(hash string) {
 	pm.nodes.Delete(hash)
 }
 
+// RemovePeerAddress removes the peer when it is stored with the address
+// It is used to drop the stale or forged address of the peer without punishing the peer
+func (pm *nodePoolManage) RemovePeerAddress(hash string, addr string) {
+	pm.putPeerListLock.Lock()
+	defer pm.putPeerListLock.Unlock()
+
+	if ci, has := pm.nodes.Load(hash); has && ci.Address == addr {
+		pm.nodes.Delete(hash)
+	}
+}
+
 func (pm *nodePoolManage) AddPeerList(ips []string, hashs []string) {
 	pm.putPeerListLock.Lock()
 	defer pm.putPeerListLock.Unlock()
@@ -166,7 +191,7 @@ func (pm *nodePoolManage) appendPeerStorage() {
 			pm.addConnectedConn(p)
 			continue
 		}
-		if !pm.BanPeerInfos.IsBan(p.Hash) {
+		if !pm.IsBan(p.Hash) {
 			var ph common.PublicHash
 			copy(ph[:], []byte(p.Hash))
 			pm.nodeMesh.RequestConnect(p.Address, ph)
@@ -233,4 +258,52 @@ func (pm *nodePoolManage) Ban(hash string) {
 
 func (pm *nodePoolManage) Unban(Hash string) {
 	pm.BanPeerInfos.Delete(Hash)
+	pm.bans.Delete(Hash)
+}
+
+// TemporaryBan bans the peer for the duration and removes it from the peer store
+// The ban is persisted, so the peer is not re-dialed after restart until it expires
+// The ban never expires when the duration is not positive
+func (pm *nodePoolManage) TemporaryBan(hash string, d time.Duration) {
+	if d > 0 {
+		pm.bans.Add(hash, time.Now().Add(d).UnixNano())
+	} else {
+		pm.bans.Add(hash, storage.PermanentBan)
+	}
+	pm.nodes.Delete(hash)
+	pm.nodeMesh.RemovePeer(hash)
+}
+
+// IsBan returns the peer is banned or not, whitelisted peers are not banned temporarily
+func (pm *nodePoolManage) IsBan(hash string) bool {
+	if pm.BanPeerInfos.IsBan(hash) {
+		return true
+	}
+	return !pm.bans.IsWhitelisted(hash) && pm.bans.IsBan(hash)
+}
+
+// BanList returns the expiries of temporarily banned peers
+func (pm *nodePoolManage) BanList() map[string]int64 {
+	return pm.bans.List()
+}
+
+// Whitelist adds the peer to the whitelist that is exempt from temporary bans
+func (pm *nodePoolManage) Whitelist(hash string) {
+	pm.bans.AddWhitelist(hash)
+	pm.bans.Delete(hash)
+}
+
+// RemoveWhitelist removes the peer from the whitelist
+func (pm *nodePoolManage) RemoveWhitelist(hash string) {
+	pm.bans.DeleteWhitelist(hash)
+}
+
+// IsWhitelisted returns the peer is in the whitelist or not
+func (pm *nodePoolManage) IsWhitelisted(hash string) bool {
+	return pm.bans.IsWhitelisted(hash)
+}
+
+// WhitelistList returns the peers in the whitelist
+func (pm *nodePoolManage) WhitelistList() []string {
+	return pm.bans.Whitelist()
 }
@@ -0,0 +1,167 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// PeerAdmin serves the api to inspect peers and manage their bans and the whitelist
+type PeerAdmin struct {
+	types.ServiceBase
+	sync.Mutex
+	nd *Node
+}
+
+// NewPeerAdmin returns a PeerAdmin
+func NewPeerAdmin() *PeerAdmin {
+	pa := &PeerAdmin{}
+	return pa
+}
+
+// Name returns the name of the service
+func (pa *PeerAdmin) Name() string {
+	return "fleta.peeradmin"
+}
+
+// Init called when initialize service
+func (pa *PeerAdmin) Init(pm types.ProcessManager, cn types.Provider) error {
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		as, err := v.JRPC("p2p")
+		if err != nil {
+			return err
+		}
+		as.Set("peers", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			nd := pa.node()
+			if nd == nil {
+				return []*PeerInfo{}, nil
+			}
+			return nd.PeerInfos(), nil
+		})
+		as.Set("bans", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			nd := pa.node()
+			list := []*BanResult{}
+			if nd == nil {
+				return list, nil
+			}
+			for pubhash, expiry := range nd.ms.BanList() {
+				list = append(list, &BanResult{
+					Peer:      pubhash,
+					ExpiredAt: uint64(expiry),
+				})
+			}
+			return list, nil
+		})
+		as.Set("ban", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 && arg.Len() != 2 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			pubhash, err := pa.publicHashArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			var d time.Duration
+			if arg.Len() == 2 {
+				sec, err := arg.Uint32(1)
+				if err != nil {
+					return nil, err
+				}
+				d = time.Duration(sec) * time.Second
+			}
+			nd := pa.node()
+			if nd == nil {
+				return nil, ErrNotExistPeer
+			}
+			nd.ms.Ban(string(pubhash[:]), d)
+			return nil, nil
+		})
+		as.Set("unban", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			pubhash, err := pa.publicHashArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			nd := pa.node()
+			if nd == nil {
+				return nil, ErrNotExistPeer
+			}
+			nd.ms.Unban(string(pubhash[:]))
+			return nil, nil
+		})
+		as.Set("whitelist", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			nd := pa.node()
+			if arg.Len() == 0 {
+				if nd == nil {
+					return []common.PublicHash{}, nil
+				}
+				return nd.ms.WhitelistList(), nil
+			}
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			pubhash, err := pa.publicHashArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			if nd == nil {
+				return nil, ErrNotExistPeer
+			}
+			nd.ms.Whitelist(string(pubhash[:]))
+			return nil, nil
+		})
+		as.Set("unwhitelist", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			pubhash, err := pa.publicHashArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			nd := pa.node()
+			if nd == nil {
+				return nil, ErrNotExistPeer
+			}
+			nd.ms.RemoveWhitelist(string(pubhash[:]))
+			return nil, nil
+		})
+		as.Set("bandwidth", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			nd := pa.node()
+			if nd == nil {
+				return &BandwidthInfo{Peers: map[string]TrafficInfo{}}, nil
+			}
+			return nd.BandwidthInfo(), nil
+		})
+	}
+	return nil
+}
+
+func (pa *PeerAdmin) setNode(nd *Node) {
+	pa.Lock()
+	defer pa.Unlock()
+
+	pa.nd = nd
+}
+
+func (pa *PeerAdmin) node() *Node {
+	pa.Lock()
+	defer pa.Unlock()
+
+	return pa.nd
+}
+
+func (pa *PeerAdmin) publicHashArgument(arg *apiserver.Argument) (common.PublicHash, error) {
+	str, err := arg.String(0)
+	if err != nil {
+		return common.PublicHash{}, err
+	}
+	return common.ParsePublicHash(str)
+}
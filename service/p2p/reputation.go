@@ -0,0 +1,216 @@
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Misbehavior is the kind of the misbehavior of the peer
+type Misbehavior int
+
+// misbehaviors
+const (
+	MisbehaviorInvalidBlock     = Misbehavior(1)
+	MisbehaviorInvalidTx        = Misbehavior(2)
+	MisbehaviorOversizedMessage = Misbehavior(3)
+	MisbehaviorHandshakeFailure = Misbehavior(4)
+)
+
+func (m Misbehavior) String() string {
+	switch m {
+	case MisbehaviorInvalidBlock:
+		return "invalid_block"
+	case MisbehaviorInvalidTx:
+		return "invalid_tx"
+	case MisbehaviorOversizedMessage:
+		return "oversized_message"
+	case MisbehaviorHandshakeFailure:
+		return "handshake_failure"
+	default:
+		return "unknown"
+	}
+}
+
+// PenaltyConfig is the penalty points of misbehaviors and the ban policy of the reputation
+// A negative penalty point is replaced by the default point and zero disables the penalty of the misbehavior
+type PenaltyConfig struct {
+	InvalidBlock     int
+	InvalidTx        int
+	OversizedMessage int
+	HandshakeFailure int
+	BanThreshold     int
+	HalfLife         time.Duration
+	BanDuration      time.Duration
+}
+
+// DefaultPenaltyConfig returns the default penalty config
+func DefaultPenaltyConfig() *PenaltyConfig {
+	return &PenaltyConfig{
+		InvalidBlock:     50,
+		InvalidTx:        1,
+		OversizedMessage: 20,
+		HandshakeFailure: 10,
+		BanThreshold:     100,
+		HalfLife:         10 * time.Minute,
+		BanDuration:      24 * time.Hour,
+	}
+}
+
+// Point returns the penalty point of the misbehavior
+func (pc *PenaltyConfig) Point(m Misbehavior) int {
+	switch m {
+	case MisbehaviorInvalidBlock:
+		return pc.InvalidBlock
+	case MisbehaviorInvalidTx:
+		return pc.InvalidTx
+	case MisbehaviorOversizedMessage:
+		return pc.OversizedMessage
+	case MisbehaviorHandshakeFailure:
+		return pc.HandshakeFailure
+	default:
+		return 0
+	}
+}
+
+// fillDefault fills negative penalty points and zero values of the ban policy by the default config
+func (pc *PenaltyConfig) fillDefault() {
+	def := DefaultPenaltyConfig()
+	if pc.InvalidBlock < 0 {
+		pc.InvalidBlock = def.InvalidBlock
+	}
+	if pc.InvalidTx < 0 {
+		pc.InvalidTx = def.InvalidTx
+	}
+	if pc.OversizedMessage < 0 {
+		pc.OversizedMessage = def.OversizedMessage
+	}
+	if pc.HandshakeFailure < 0 {
+		pc.HandshakeFailure = def.HandshakeFailure
+	}
+	if pc.BanThreshold <= 0 {
+		pc.BanThreshold = def.BanThreshold
+	}
+	if pc.HalfLife == 0 {
+		pc.HalfLife = def.HalfLife
+	}
+	if pc.BanDuration == 0 {
+		pc.BanDuration = def.BanDuration
+	}
+}
+
+type peerScore struct {
+	score     float64
+	updatedAt time.Time
+	last      Misbehavior
+}
+
+// Reputation keeps the penalty scores of peers that decay by the half life
+type Reputation struct {
+	sync.Mutex
+	config   *PenaltyConfig
+	scoreMap map[string]*peerScore
+}
+
+// NewReputation returns a Reputation
+func NewReputation(config *PenaltyConfig) *Reputation {
+	rp := &Reputation{
+		scoreMap: map[string]*peerScore{},
+	}
+	rp.SetConfig(config)
+	return rp
+}
+
+// SetConfig updates the penalty config, the default config is used when it is nil
+func (rp *Reputation) SetConfig(config *PenaltyConfig) {
+	pc := DefaultPenaltyConfig()
+	if config != nil {
+		*pc = *config
+	}
+	pc.fillDefault()
+
+	rp.Lock()
+	defer rp.Unlock()
+
+	rp.config = pc
+}
+
+// Config returns the penalty config
+func (rp *Reputation) Config() *PenaltyConfig {
+	rp.Lock()
+	defer rp.Unlock()
+
+	return rp.config
+}
+
+// Penalize adds the penalty of the misbehavior to the peer and returns the decayed score and it reaches the ban threshold or not
+func (rp *Reputation) Penalize(ID string, m Misbehavior) (float64, bool) {
+	rp.Lock()
+	defer rp.Unlock()
+
+	now := time.Now()
+	ps, has := rp.scoreMap[ID]
+	if !has {
+		ps = &peerScore{
+			updatedAt: now,
+		}
+		rp.scoreMap[ID] = ps
+	}
+	ps.score = rp.decayed(ps, now) + float64(rp.config.Point(m))
+	ps.updatedAt = now
+	ps.last = m
+	return ps.score, ps.score >= float64(rp.config.BanThreshold)
+}
+
+// Score returns the decayed score of the peer
+func (rp *Reputation) Score(ID string) float64 {
+	rp.Lock()
+	defer rp.Unlock()
+
+	ps, has := rp.scoreMap[ID]
+	if !has {
+		return 0
+	}
+	return rp.decayed(ps, time.Now())
+}
+
+// LastMisbehavior returns the last misbehavior of the peer
+func (rp *Reputation) LastMisbehavior(ID string) Misbehavior {
+	rp.Lock()
+	defer rp.Unlock()
+
+	ps, has := rp.scoreMap[ID]
+	if !has {
+		return 0
+	}
+	return ps.last
+}
+
+// Reset clears the score of the peer
+func (rp *Reputation) Reset(ID string) {
+	rp.Lock()
+	defer rp.Unlock()
+
+	delete(rp.scoreMap, ID)
+}
+
+// Prune removes the scores that are decayed enough
+func (rp *Reputation) Prune() {
+	rp.Lock()
+	defer rp.Unlock()
+
+	now := time.Now()
+	for ID, ps := range rp.scoreMap {
+		if rp.decayed(ps, now) < 1 {
+			delete(rp.scoreMap, ID)
+		}
+	}
+}
+
+func (rp *Reputation) decayed(ps *peerScore, now time.Time) float64 {
+	elapsed := now.Sub(ps.updatedAt)
+	if elapsed <= 0 {
+		return ps.score
+	}
+	return ps.score * math.Pow(0.5, float64(elapsed)/float64(rp.config.HalfLife))
+}
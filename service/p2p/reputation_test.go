@@ -0,0 +1,42 @@
+package p2p
+
+import (
+	"testing"
+)
+
+func TestReputationPenaltyConfig(t *testing.T) {
+	rp := NewReputation(nil)
+	if *rp.Config() != *DefaultPenaltyConfig() {
+		t.Fatalf("invalid default config: %+v", rp.Config())
+	}
+
+	rp.SetConfig(&PenaltyConfig{
+		InvalidBlock:     -1,
+		InvalidTx:        0,
+		OversizedMessage: 100,
+		HandshakeFailure: -1,
+	})
+	def := DefaultPenaltyConfig()
+	pc := rp.Config()
+	if pc.InvalidBlock != def.InvalidBlock || pc.HandshakeFailure != def.HandshakeFailure {
+		t.Fatalf("negative points are not filled by the default: %+v", pc)
+	}
+	if pc.InvalidTx != 0 || pc.OversizedMessage != 100 {
+		t.Fatalf("configured points are changed: %+v", pc)
+	}
+	if pc.BanThreshold != def.BanThreshold || pc.HalfLife != def.HalfLife || pc.BanDuration != def.BanDuration {
+		t.Fatalf("ban policy is not filled by the default: %+v", pc)
+	}
+
+	for i := 0; i < def.BanThreshold*2; i++ {
+		if _, ban := rp.Penalize("peer", MisbehaviorInvalidTx); ban {
+			t.Fatal("banned by the disabled penalty")
+		}
+	}
+	if rp.Score("peer") != 0 {
+		t.Fatalf("disabled penalty is scored: %v", rp.Score("peer"))
+	}
+	if _, ban := rp.Penalize("peer", MisbehaviorOversizedMessage); !ban {
+		t.Fatal("not banned at the threshold")
+	}
+}
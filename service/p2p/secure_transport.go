@@ -0,0 +1,279 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"net"
+	"sync"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/binutil"
+	ecrypto "github.com/fletaio/fleta_v1/common/crypto"
+	"github.com/fletaio/fleta_v1/common/hash"
+)
+
+// SecureTransportVersion is the version of the encrypted transport that is negotiated in the handshake
+const SecureTransportVersion = 1
+
+// MaxSecureRecordSize is the maximum plaintext size of a record of the encrypted transport
+const MaxSecureRecordSize = 65536
+
+// secureResponseFlag is written in place of the length of the bind address, which is limited below it
+const secureResponseFlag = 0xFF
+
+// secureSignatureFlag is set to the recovery id of the response signature that is followed by the ephemeral public key
+const secureSignatureFlag = 0x80
+
+var secureChallengeMarker = []byte{0xF1, 0xE7, 0xA5}
+
+// SecureHandshake negotiates the encrypted transport in the challenge-response handshake
+// Each side marks its challenge with the version and replies the signed ephemeral public key
+// when the challenge of the other side is marked, so peers of the old version still connect in cleartext
+type SecureHandshake struct {
+	ephKey     *ecdsa.PrivateKey
+	ephPubKey  common.PublicKey
+	peerPubKey []byte
+	peerMarked bool
+}
+
+// NewSecureHandshake returns a SecureHandshake with a new ephemeral key
+func NewSecureHandshake() (*SecureHandshake, error) {
+	ephKey, err := ecrypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	hs := &SecureHandshake{
+		ephKey: ephKey,
+	}
+	copy(hs.ephPubKey[:], ecrypto.CompressPubkey(&ephKey.PublicKey))
+	return hs, nil
+}
+
+// MarkChallenge writes the version marker to the random area of the challenge
+func (hs *SecureHandshake) MarkChallenge(req []byte) {
+	copy(req[1:], secureChallengeMarker)
+	req[1+len(secureChallengeMarker)] = HandshakeVersion
+}
+
+// IsMarkedChallenge returns the challenge supports the encrypted transport or not
+func IsMarkedChallenge(req []byte) bool {
+	if len(req) < 2+len(secureChallengeMarker) {
+		return false
+	}
+	if !bytes.Equal(req[1:1+len(secureChallengeMarker)], secureChallengeMarker) {
+		return false
+	}
+	return req[1+len(secureChallengeMarker)] >= SecureTransportVersion
+}
+
+// ResponseHash returns the hash to sign for the response of the challenge of the other side
+// It binds the ephemeral public key to the node key when the challenge is marked
+func (hs *SecureHandshake) ResponseHash(req []byte) hash.Hash256 {
+	hs.peerMarked = IsMarkedChallenge(req)
+	if !hs.peerMarked {
+		return hash.Hash(req)
+	}
+	return hs.ephemeralHash(req, hs.ephPubKey)
+}
+
+// MarkResponseSignature marks the response signature that is followed by the ephemeral public key
+// It is used by the fixed size handshake that has no room for the response flag
+func MarkResponseSignature(sig *common.Signature) {
+	sig[common.SignatureSize-1] |= secureSignatureFlag
+}
+
+// UnmarkResponseSignature clears the mark of the response signature and returns it was marked or not
+func UnmarkResponseSignature(sig *common.Signature) bool {
+	marked := sig[common.SignatureSize-1]&secureSignatureFlag != 0
+	sig[common.SignatureSize-1] &^= secureSignatureFlag
+	return marked
+}
+
+// EphemeralPublicKey returns the ephemeral public key of the handshake
+func (hs *SecureHandshake) EphemeralPublicKey() common.PublicKey {
+	return hs.ephPubKey
+}
+
+// PeerResponseHash returns the hash that is signed by the other side for the response of the challenge
+func (hs *SecureHandshake) PeerResponseHash(req []byte, PeerEphPubKey common.PublicKey) hash.Hash256 {
+	return hs.ephemeralHash(req, PeerEphPubKey)
+}
+
+// SetPeerEphemeralPublicKey sets the ephemeral public key of the other side that is verified by the response
+func (hs *SecureHandshake) SetPeerEphemeralPublicKey(PeerEphPubKey common.PublicKey) {
+	hs.peerPubKey = PeerEphPubKey[:]
+}
+
+// IsSecure returns both sides support the encrypted transport or not
+func (hs *SecureHandshake) IsSecure() bool {
+	return hs.peerMarked && len(hs.peerPubKey) > 0
+}
+
+func (hs *SecureHandshake) ephemeralHash(req []byte, EphPubKey common.PublicKey) hash.Hash256 {
+	bs := make([]byte, 0, len(req)+1+common.PublicKeySize)
+	bs = append(bs, req...)
+	bs = append(bs, SecureTransportVersion)
+	bs = append(bs, EphPubKey[:]...)
+	return hash.Hash(bs)
+}
+
+// NewSecureCodec derives the session keys by ECDH of the ephemeral keys and returns the codec
+// IsInitiator should be true for the side that dialed the connection
+func (hs *SecureHandshake) NewSecureCodec(IsInitiator bool) (*SecureCodec, error) {
+	if !hs.IsSecure() {
+		return nil, ErrInvalidHandshake
+	}
+	pub, err := ecrypto.DecompressPubkey(hs.peerPubKey)
+	if err != nil {
+		return nil, err
+	}
+	X, _ := ecrypto.S256().ScalarMult(pub.X, pub.Y, hs.ephKey.D.Bytes())
+	shared := make([]byte, 32)
+	xs := X.Bytes()
+	copy(shared[32-len(xs):], xs)
+
+	var initPub, respPub []byte
+	if IsInitiator {
+		initPub, respPub = hs.ephPubKey[:], hs.peerPubKey
+	} else {
+		initPub, respPub = hs.peerPubKey, hs.ephPubKey[:]
+	}
+	base := hash.Hash(append(append(append([]byte{}, shared...), initPub...), respPub...))
+	initKey := hash.Hash(append(append([]byte{}, base[:]...), 'i'))
+	respKey := hash.Hash(append(append([]byte{}, base[:]...), 'r'))
+
+	var sendKey, recvKey hash.Hash256
+	if IsInitiator {
+		sendKey, recvKey = initKey, respKey
+	} else {
+		sendKey, recvKey = respKey, initKey
+	}
+	sendAEAD, err := newAEAD(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	recvAEAD, err := newAEAD(recvKey)
+	if err != nil {
+		return nil, err
+	}
+	return &SecureCodec{
+		sendAEAD: sendAEAD,
+		recvAEAD: recvAEAD,
+	}, nil
+}
+
+func newAEAD(key hash.Hash256) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SecureCodec seals and opens records with the session keys
+// Each direction uses its own key and the record sequence as the nonce, so reordered or replayed records are rejected
+type SecureCodec struct {
+	sendLock sync.Mutex
+	recvLock sync.Mutex
+	sendAEAD cipher.AEAD
+	recvAEAD cipher.AEAD
+	sendSeq  uint64
+	recvSeq  uint64
+}
+
+// Seal encrypts the plaintext as the next record
+func (sc *SecureCodec) Seal(bs []byte) []byte {
+	sc.sendLock.Lock()
+	defer sc.sendLock.Unlock()
+
+	nonce := make([]byte, sc.sendAEAD.NonceSize())
+	binutil.BigEndian.PutUint64(nonce[len(nonce)-8:], sc.sendSeq)
+	sc.sendSeq++
+	return sc.sendAEAD.Seal(nil, nonce, bs, nil)
+}
+
+// Open decrypts the next record
+func (sc *SecureCodec) Open(bs []byte) ([]byte, error) {
+	sc.recvLock.Lock()
+	defer sc.recvLock.Unlock()
+
+	nonce := make([]byte, sc.recvAEAD.NonceSize())
+	binutil.BigEndian.PutUint64(nonce[len(nonce)-8:], sc.recvSeq)
+	data, err := sc.recvAEAD.Open(nil, nonce, bs, nil)
+	if err != nil {
+		return nil, ErrInvalidSecureRecord
+	}
+	sc.recvSeq++
+	return data, nil
+}
+
+// Overhead returns the size difference between a record and its plaintext
+func (sc *SecureCodec) Overhead() int {
+	return sc.sendAEAD.Overhead()
+}
+
+// SecureConn wraps the connection with the length-prefixed records of the SecureCodec
+type SecureConn struct {
+	net.Conn
+	writeLock sync.Mutex
+	codec     *SecureCodec
+	readBuf   []byte
+}
+
+// NewSecureConn returns a SecureConn
+func NewSecureConn(conn net.Conn, codec *SecureCodec) *SecureConn {
+	return &SecureConn{
+		Conn:  conn,
+		codec: codec,
+	}
+}
+
+// Read reads the plaintext of the records
+func (c *SecureConn) Read(b []byte) (int, error) {
+	if len(c.readBuf) == 0 {
+		Len, _, err := ReadUint32(c.Conn)
+		if err != nil {
+			return 0, err
+		}
+		if Len > uint32(MaxSecureRecordSize+c.codec.Overhead()) {
+			return 0, ErrInvalidSecureRecord
+		}
+		bs := make([]byte, Len)
+		if _, err := FillBytes(c.Conn, bs); err != nil {
+			return 0, err
+		}
+		data, err := c.codec.Open(bs)
+		if err != nil {
+			return 0, err
+		}
+		c.readBuf = data
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+// Write writes the data as the records
+func (c *SecureConn) Write(b []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	written := 0
+	for written < len(b) {
+		end := written + MaxSecureRecordSize
+		if end > len(b) {
+			end = len(b)
+		}
+		sealed := c.codec.Seal(b[written:end])
+		bs := make([]byte, 4+len(sealed))
+		binutil.LittleEndian.PutUint32(bs, uint32(len(sealed)))
+		copy(bs[4:], sealed)
+		if _, err := c.Conn.Write(bs); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
@@ -0,0 +1,265 @@
+package p2p
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/common/key"
+)
+
+type testHandshakeSide struct {
+	key    key.Key
+	hs     *SecureHandshake
+	marked bool
+}
+
+func newTestHandshakeSide(t *testing.T, marked bool) *testHandshakeSide {
+	k, err := key.NewMemoryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs, err := NewSecureHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testHandshakeSide{
+		key:    k,
+		hs:     hs,
+		marked: marked,
+	}
+}
+
+func (s *testHandshakeSide) challenge(t *testing.T) []byte {
+	req := make([]byte, 40)
+	if _, err := crand.Read(req[:32]); err != nil {
+		t.Fatal(err)
+	}
+	if s.marked {
+		s.hs.MarkChallenge(req)
+	}
+	return req
+}
+
+// respond signs the challenge as the new version when it is marked, otherwise as the old version
+func (s *testHandshakeSide) respond(t *testing.T, req []byte) (common.Signature, *common.PublicKey) {
+	if !s.marked {
+		sig, err := s.key.Sign(hash.Hash(req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig, nil
+	}
+	sig, err := s.key.Sign(s.hs.ResponseHash(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsMarkedChallenge(req) {
+		return sig, nil
+	}
+	ephPubKey := s.hs.EphemeralPublicKey()
+	return sig, &ephPubKey
+}
+
+func (s *testHandshakeSide) verify(t *testing.T, req []byte, sig common.Signature, ephPubKey *common.PublicKey) common.PublicHash {
+	h := hash.Hash(req)
+	if ephPubKey != nil {
+		h = s.hs.PeerResponseHash(req, *ephPubKey)
+		s.hs.SetPeerEphemeralPublicKey(*ephPubKey)
+	}
+	pubkey, err := common.RecoverPubkey(h, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return common.NewPublicHash(pubkey)
+}
+
+func handshakeTestSides(t *testing.T, a *testHandshakeSide, b *testHandshakeSide) {
+	reqA := a.challenge(t)
+	sig, eph := b.respond(t, reqA)
+	if a.verify(t, reqA, sig, eph) != common.NewPublicHash(b.key.PublicKey()) {
+		t.Fatal("invalid response of b")
+	}
+	reqB := b.challenge(t)
+	sig, eph = a.respond(t, reqB)
+	if b.verify(t, reqB, sig, eph) != common.NewPublicHash(a.key.PublicKey()) {
+		t.Fatal("invalid response of a")
+	}
+}
+
+func newTestCodecs(t *testing.T) (*SecureCodec, *SecureCodec) {
+	a := newTestHandshakeSide(t, true)
+	b := newTestHandshakeSide(t, true)
+	handshakeTestSides(t, a, b)
+	if !a.hs.IsSecure() || !b.hs.IsSecure() {
+		t.Fatal("the secure transport is not negotiated")
+	}
+	ca, err := a.hs.NewSecureCodec(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := b.hs.NewSecureCodec(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, cb
+}
+
+func TestSecureCodecRoundTrip(t *testing.T) {
+	ca, cb := newTestCodecs(t)
+	for i := 0; i < 3; i++ {
+		msg := []byte{byte(i), 1, 2, 3}
+		if data, err := cb.Open(ca.Seal(msg)); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data, msg) {
+			t.Fatal("invalid plaintext from the initiator")
+		}
+		if data, err := ca.Open(cb.Seal(msg)); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data, msg) {
+			t.Fatal("invalid plaintext from the responder")
+		}
+	}
+}
+
+func TestSecureCodecNonce(t *testing.T) {
+	ca, cb := newTestCodecs(t)
+	msg := []byte("same plaintext")
+	first := ca.Seal(msg)
+	second := ca.Seal(msg)
+	if bytes.Equal(first, second) {
+		t.Fatal("the nonce is reused for the same plaintext")
+	}
+	if _, err := cb.Open(second); err != ErrInvalidSecureRecord {
+		t.Fatalf("opened the reordered record: %v", err)
+	}
+	if _, err := cb.Open(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cb.Open(first); err != ErrInvalidSecureRecord {
+		t.Fatalf("opened the replayed record: %v", err)
+	}
+	if _, err := cb.Open(second); err != nil {
+		t.Fatal(err)
+	}
+	// the record of the own direction is not opened by the reflection
+	reflected := cb.Seal(msg)
+	if _, err := cb.Open(reflected); err != ErrInvalidSecureRecord {
+		t.Fatalf("opened the reflected record: %v", err)
+	}
+}
+
+func TestSecureCodecTampering(t *testing.T) {
+	ca, cb := newTestCodecs(t)
+	record := ca.Seal([]byte("tampered"))
+	for i := range record {
+		bs := append([]byte{}, record...)
+		bs[i] ^= 0x01
+		if _, err := cb.Open(bs); err != ErrInvalidSecureRecord {
+			t.Fatalf("opened the record that is tampered at %v: %v", i, err)
+		}
+	}
+	if _, err := cb.Open(record[:len(record)-1]); err != ErrInvalidSecureRecord {
+		t.Fatalf("opened the truncated record: %v", err)
+	}
+	if _, err := cb.Open(record); err != nil {
+		t.Fatalf("the tampered records advanced the sequence: %v", err)
+	}
+}
+
+func TestSecureHandshakeLegacyPeer(t *testing.T) {
+	a := newTestHandshakeSide(t, true)
+	b := newTestHandshakeSide(t, false)
+	handshakeTestSides(t, a, b)
+	if a.hs.IsSecure() {
+		t.Fatal("the secure transport is negotiated with the old version")
+	}
+	if _, err := a.hs.NewSecureCodec(true); err != ErrInvalidHandshake {
+		t.Fatalf("the codec is created with the old version: %v", err)
+	}
+}
+
+func TestSecureHandshakeDowngrade(t *testing.T) {
+	a := newTestHandshakeSide(t, true)
+	b := newTestHandshakeSide(t, true)
+	bPubHash := common.NewPublicHash(b.key.PublicKey())
+
+	// the attacker strips the marker from the challenge
+	req := a.challenge(t)
+	stripped := append([]byte{}, req...)
+	copy(stripped[1:], []byte{0, 0, 0, 0})
+	sig, eph := b.respond(t, stripped)
+	if eph != nil {
+		t.Fatal("responded the ephemeral key to the stripped challenge")
+	}
+	if a.verify(t, req, sig, nil) == bPubHash {
+		t.Fatal("the response of the stripped challenge is accepted")
+	}
+
+	// the attacker strips the ephemeral key from the response
+	req = a.challenge(t)
+	sig, eph = b.respond(t, req)
+	if eph == nil {
+		t.Fatal("the ephemeral key is not responded")
+	}
+	if a.verify(t, req, sig, nil) == bPubHash {
+		t.Fatal("the response without the ephemeral key is accepted")
+	}
+
+	// the attacker replaces the ephemeral key
+	other, err := NewSecureHandshake()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPubKey := other.EphemeralPublicKey()
+	if a.verify(t, req, sig, &otherPubKey) == bPubHash {
+		t.Fatal("the replaced ephemeral key is accepted")
+	}
+}
+
+func TestResponseSignatureMark(t *testing.T) {
+	for _, v := range []byte{0, 1} {
+		var sig common.Signature
+		sig[common.SignatureSize-1] = v
+		if UnmarkResponseSignature(&sig) {
+			t.Fatal("the signature is marked")
+		}
+		MarkResponseSignature(&sig)
+		if !UnmarkResponseSignature(&sig) {
+			t.Fatal("the signature is not marked")
+		}
+		if sig[common.SignatureSize-1] != v {
+			t.Fatal("the recovery id is changed")
+		}
+	}
+}
+
+func TestSecureConn(t *testing.T) {
+	ca, cb := newTestCodecs(t)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	sa := NewSecureConn(c1, ca)
+	sb := NewSecureConn(c2, cb)
+
+	msg := make([]byte, MaxSecureRecordSize*2+100)
+	if _, err := crand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if _, err := sa.Write(msg); err != nil {
+			t.Error(err)
+		}
+	}()
+	data := make([]byte, len(msg))
+	if _, err := io.ReadFull(sb, data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, msg) {
+		t.Fatal("invalid data")
+	}
+}
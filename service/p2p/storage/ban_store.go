@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fletaio/fleta_v1/core/backend/buntdb_driver/buntdb"
+)
+
+// whitelistKeyPrefix separates the whitelist from the bans that are keyed by the hash of the peer
+const whitelistKeyPrefix = "white:"
+
+// PermanentBan is the expiry of the ban that never expires
+const PermanentBan = int64(math.MaxInt64)
+
+// BanStore keeps the bans and the whitelist of peers, so they are kept after restart
+type BanStore struct {
+	sync.Mutex
+	db           *buntdb.DB
+	expiryMap    map[string]int64
+	whitelistMap map[string]bool
+}
+
+// NewBanStore returns a BanStore that is stored at the path
+func NewBanStore(dbpath string) (*BanStore, error) {
+	os.MkdirAll(filepath.Dir(dbpath), os.ModePerm)
+
+	db, err := buntdb.Open(dbpath)
+	if err != nil {
+		return nil, err
+	}
+	bs := &BanStore{
+		db:           db,
+		expiryMap:    map[string]int64{},
+		whitelistMap: map[string]bool{},
+	}
+	now := time.Now().UnixNano()
+	expired := []string{}
+	if err := db.View(func(txn *buntdb.Tx) error {
+		return txn.Ascend("", func(key string, value string) bool {
+			if strings.HasPrefix(key, whitelistKeyPrefix) {
+				bs.whitelistMap[key[len(whitelistKeyPrefix):]] = true
+				return true
+			}
+			expiry, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || expiry <= now {
+				expired = append(expired, key)
+			} else {
+				bs.expiryMap[key] = expiry
+			}
+			return true
+		})
+	}); err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		db.Update(func(txn *buntdb.Tx) error {
+			for _, key := range expired {
+				txn.Delete(key)
+			}
+			return nil
+		})
+	}
+	return bs, nil
+}
+
+// Add bans the peer until the expiry
+func (bs *BanStore) Add(Hash string, expiry int64) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	if old, has := bs.expiryMap[Hash]; has && old >= expiry {
+		return
+	}
+	bs.expiryMap[Hash] = expiry
+	bs.db.Update(func(txn *buntdb.Tx) error {
+		if _, _, err := txn.Set(Hash, strconv.FormatInt(expiry, 10), nil); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// Delete removes the ban of the peer
+func (bs *BanStore) Delete(Hash string) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	if _, has := bs.expiryMap[Hash]; !has {
+		return
+	}
+	delete(bs.expiryMap, Hash)
+	bs.db.Update(func(txn *buntdb.Tx) error {
+		txn.Delete(Hash)
+		return nil
+	})
+}
+
+// IsBan returns the peer is banned now or not
+func (bs *BanStore) IsBan(Hash string) bool {
+	bs.Lock()
+	expiry, has := bs.expiryMap[Hash]
+	bs.Unlock()
+
+	if !has {
+		return false
+	}
+	if expiry <= time.Now().UnixNano() {
+		bs.Delete(Hash)
+		return false
+	}
+	return true
+}
+
+// List returns the expiries of banned peers
+func (bs *BanStore) List() map[string]int64 {
+	bs.Lock()
+	defer bs.Unlock()
+
+	now := time.Now().UnixNano()
+	list := map[string]int64{}
+	for Hash, expiry := range bs.expiryMap {
+		if expiry > now {
+			list[Hash] = expiry
+		}
+	}
+	return list
+}
+
+// AddWhitelist adds the peer to the whitelist
+func (bs *BanStore) AddWhitelist(Hash string) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	if bs.whitelistMap[Hash] {
+		return
+	}
+	bs.whitelistMap[Hash] = true
+	bs.db.Update(func(txn *buntdb.Tx) error {
+		if _, _, err := txn.Set(whitelistKeyPrefix+Hash, "1", nil); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// DeleteWhitelist removes the peer from the whitelist
+func (bs *BanStore) DeleteWhitelist(Hash string) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	if !bs.whitelistMap[Hash] {
+		return
+	}
+	delete(bs.whitelistMap, Hash)
+	bs.db.Update(func(txn *buntdb.Tx) error {
+		txn.Delete(whitelistKeyPrefix + Hash)
+		return nil
+	})
+}
+
+// IsWhitelisted returns the peer is in the whitelist or not
+func (bs *BanStore) IsWhitelisted(Hash string) bool {
+	bs.Lock()
+	defer bs.Unlock()
+
+	return bs.whitelistMap[Hash]
+}
+
+// Whitelist returns the peers in the whitelist
+func (bs *BanStore) Whitelist() []string {
+	bs.Lock()
+	defer bs.Unlock()
+
+	list := make([]string, 0, len(bs.whitelistMap))
+	for Hash := range bs.whitelistMap {
+		list = append(list, Hash)
+	}
+	return list
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fletaio/fleta_v1/core/backend/buntdb_driver/buntdb"
+)
+
+func TestBanStoreReload(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "nodes_ban")
+
+	// bans that are stored by the hash of the peer are kept after restart
+	db, err := buntdb.Open(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UnixNano()
+	if err := db.Update(func(txn *buntdb.Tx) error {
+		if _, _, err := txn.Set("banned", strconv.FormatInt(now+int64(time.Hour), 10), nil); err != nil {
+			return err
+		}
+		if _, _, err := txn.Set("expired", strconv.FormatInt(now-1, 10), nil); err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	bs, err := NewBanStore(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bs.IsBan("banned") {
+		t.Fatal("the stored ban is not loaded")
+	}
+	if bs.IsBan("expired") {
+		t.Fatal("the expired ban is loaded")
+	}
+	bs.Add("added", PermanentBan)
+	bs.AddWhitelist("white")
+	bs.db.Close()
+
+	bs, err = NewBanStore(dbpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.db.Close()
+	if !bs.IsBan("banned") || !bs.IsBan("added") {
+		t.Fatalf("bans are not kept: %v", bs.List())
+	}
+	if bs.IsBan("white") || !bs.IsWhitelisted("white") {
+		t.Fatal("the whitelist is not kept")
+	}
+	if len(bs.List()) != 2 {
+		t.Fatalf("invalid bans: %v", bs.List())
+	}
+}
@@ -11,6 +11,21 @@ type Status struct {
 	Height uint32
 }
 
+// PeerInfo represents the connected peer with its reputation
+type PeerInfo struct {
+	PublicHash      common.PublicHash `json:"public_hash"`
+	Name            string            `json:"name"`
+	Inbound         bool              `json:"inbound"`
+	ConnectedTime   int64             `json:"connected_time"`
+	Height          uint32            `json:"height"`
+	Score           float64           `json:"score"`
+	LastMisbehavior string            `json:"last_misbehavior,omitempty"`
+	Whitelisted     bool              `json:"whitelisted"`
+	ProtocolVersion uint16            `json:"protocol_version"`
+	UserAgent       string            `json:"user_agent"`
+	Capabilities    string            `json:"capabilities"`
+}
+
 // TxMsgItem used to store transaction message
 type TxMsgItem struct {
 	TxHash hash.Hash256
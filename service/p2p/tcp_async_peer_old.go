@@ -3,6 +3,7 @@ package p2p
 import (
 	"log"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -11,6 +12,9 @@ import (
 	"github.com/fletaio/fleta_v1/core/types"
 )
 
+// MaxPacketSize is the maximum size of the packet body that is read from the peer
+const MaxPacketSize = 128 * 1024 * 1024
+
 // TCPAsyncPeer manages send and recv of the connection
 type TCPAsyncPeer struct {
 	conn          net.Conn
@@ -20,7 +24,10 @@ type TCPAsyncPeer struct {
 	connectedTime int64
 	pingCount     uint64
 	pingType      uint16
-	writeQ        *queue.Queue
+	writeQ        *queue.PriorityQueue
+	bw            *PeerBandwidth
+	closeOnce     sync.Once
+	fullSince     int64
 }
 
 // NewTCPAsyncPeer returns a TCPAsyncPeer
@@ -33,7 +40,7 @@ func NewTCPAsyncPeer(conn net.Conn, ID string, Name string, connectedTime int64)
 		id:            ID,
 		name:          Name,
 		connectedTime: connectedTime,
-		writeQ:        queue.NewQueue(),
+		writeQ:        queue.NewPriorityQueue(PriorityCount, MaxPeerSendQueueSize),
 		pingType:      types.DefineHashedType("p2p.PingMessage"),
 	}
 
@@ -65,6 +72,9 @@ func NewTCPAsyncPeer(conn net.Conn, ID string, Name string, connectedTime int64)
 					break
 				}
 				bs := v.([]byte)
+				if p.bw != nil {
+					p.bw.WaitUpload(len(bs))
+				}
 				if err := p.conn.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
 					log.Println(p.name, "SendPacket.SetWriteDeadline", err)
 					p.Close()
@@ -92,10 +102,20 @@ func (p *TCPAsyncPeer) Name() string {
 	return p.name
 }
 
+// SetBandwidth sets the bandwidth that limits and counts the traffic of the peer
+func (p *TCPAsyncPeer) SetBandwidth(bw *PeerBandwidth) {
+	p.bw = bw
+}
+
 // Close closes TCPAsyncPeer
 func (p *TCPAsyncPeer) Close() {
 	p.isClose = true
 	p.conn.Close()
+	p.closeOnce.Do(func() {
+		if p.bw != nil {
+			p.bw.Release()
+		}
+	})
 }
 
 // IsClosed returns it is closed or not
@@ -115,6 +135,8 @@ func (p *TCPAsyncPeer) ReadPacket() ([]byte, error) {
 			} else {
 				if Len, _, err := ReadUint32(p.conn); err != nil {
 					return nil, err
+				} else if Len > MaxPacketSize {
+					return nil, ErrTooLargePacket
 				} else {
 					bs := make([]byte, 6+Len)
 					binutil.LittleEndian.PutUint16(bs, t)
@@ -122,6 +144,9 @@ func (p *TCPAsyncPeer) ReadPacket() ([]byte, error) {
 					if _, err := FillBytes(p.conn, bs[6:]); err != nil {
 						return nil, err
 					}
+					if p.bw != nil {
+						p.bw.WaitDownload(len(bs))
+					}
 					return bs, nil
 				}
 			}
@@ -129,9 +154,32 @@ func (p *TCPAsyncPeer) ReadPacket() ([]byte, error) {
 	}
 }
 
-// SendPacket sends packet to the WebsocketPeer
+// SendPacket queues the packet by its priority without waiting, so a slow peer does not block senders of other peers
+// The packet is dropped and counted when the send queue is full, the peer is closed when the queue is kept full until the timeout
 func (p *TCPAsyncPeer) SendPacket(bs []byte) {
-	p.writeQ.Push(bs)
+	if p.isClose {
+		return
+	}
+	if err := p.writeQ.TryPush(bs, int(PacketPriority(bs)), len(bs)); err != nil {
+		if p.bw != nil {
+			p.bw.Drop()
+		}
+		now := time.Now().UnixNano()
+		if atomic.CompareAndSwapInt64(&p.fullSince, 0, now) {
+			return
+		}
+		if time.Duration(now-atomic.LoadInt64(&p.fullSince)) >= SendQueueTimeout {
+			log.Println(p.name, "SendPacket.Push", err)
+			p.Close()
+		}
+		return
+	}
+	atomic.StoreInt64(&p.fullSince, 0)
+}
+
+// QueuedBytes returns the size of packets that are waiting to be sent
+func (p *TCPAsyncPeer) QueuedBytes() int {
+	return p.writeQ.Weight()
 }
 
 // ConnectedTime returns peer connected time
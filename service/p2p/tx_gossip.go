@@ -0,0 +1,329 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluele/gcache"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/types"
+)
+
+// tx gossip limits
+const (
+	MaxTxInvPerMessage     = 1000
+	MaxTxsPerMessage       = 800
+	MaxKnownTxsPerPeer     = 50000
+	TxInvRatePerSecond     = 4000
+	TxGetDataRatePerSecond = 4000
+	TxGetDataTimeout       = 10 * time.Second
+	MaxTxAnnouncers        = 4
+)
+
+// rateLimiter is a token bucket that is refilled by the rate per second
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(rate),
+		burst:  float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// allow consumes tokens for the count and returns the allowed count
+func (rl *rateLimiter) allow(Count int) int {
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+	if float64(Count) > rl.tokens {
+		Count = int(rl.tokens)
+	}
+	rl.tokens -= float64(Count)
+	return Count
+}
+
+type txGossipPeer struct {
+	known       gcache.Cache
+	supportsInv bool
+	invLimiter  *rateLimiter
+	getLimiter  *rateLimiter
+}
+
+// txRequest is the transaction that is requested to the peer
+// Other peers that announced it are kept to request it again when the peer does not respond
+type txRequest struct {
+	ID          string
+	RequestedAt time.Time
+	announcers  []string
+}
+
+// txGossip keeps inventories that are known by peers and transactions that are requested to peers
+type txGossip struct {
+	sync.Mutex
+	peerMap    map[string]*txGossipPeer
+	requestMap map[hash.Hash256]*txRequest
+	expiredAt  time.Time
+}
+
+func newTxGossip() *txGossip {
+	return &txGossip{
+		peerMap:    map[string]*txGossipPeer{},
+		requestMap: map[hash.Hash256]*txRequest{},
+	}
+}
+
+func (tg *txGossip) addPeer(ID string, supportsInv bool) {
+	tg.Lock()
+	defer tg.Unlock()
+
+	tg.peerMap[ID] = &txGossipPeer{
+		known:       gcache.New(MaxKnownTxsPerPeer).LRU().Build(),
+		supportsInv: supportsInv,
+		invLimiter:  newRateLimiter(TxInvRatePerSecond),
+		getLimiter:  newRateLimiter(TxGetDataRatePerSecond),
+	}
+}
+
+func (tg *txGossip) removePeer(ID string) {
+	tg.Lock()
+	defer tg.Unlock()
+
+	delete(tg.peerMap, ID)
+}
+
+func (tg *txGossip) peer(ID string) *txGossipPeer {
+	tg.Lock()
+	defer tg.Unlock()
+
+	return tg.peerMap[ID]
+}
+
+func (tg *txGossip) markKnown(ID string, TxHash hash.Hash256) {
+	if gp := tg.peer(ID); gp != nil {
+		gp.known.Set(TxHash, true)
+	}
+}
+
+// request returns true when the transaction should be requested to the peer
+// When it is already requested, the peer is kept as an announcer to request it again
+func (tg *txGossip) request(TxHash hash.Hash256, ID string, now time.Time) bool {
+	tg.Lock()
+	defer tg.Unlock()
+
+	if req, has := tg.requestMap[TxHash]; has {
+		if req.ID == ID || len(req.announcers) >= MaxTxAnnouncers {
+			return false
+		}
+		for _, v := range req.announcers {
+			if v == ID {
+				return false
+			}
+		}
+		req.announcers = append(req.announcers, ID)
+		return false
+	}
+	if len(tg.requestMap) >= MaxKnownTxsPerPeer {
+		return false
+	}
+	tg.requestMap[TxHash] = &txRequest{
+		ID:          ID,
+		RequestedAt: now,
+		announcers:  []string{},
+	}
+	return true
+}
+
+func (tg *txGossip) received(TxHash hash.Hash256) {
+	tg.Lock()
+	defer tg.Unlock()
+
+	delete(tg.requestMap, TxHash)
+}
+
+// expireRequests returns transactions to request again to the next announcer of them by the peer
+// Requests that do not have a connected announcer are dropped
+func (tg *txGossip) expireRequests(now time.Time) map[string][]hash.Hash256 {
+	tg.Lock()
+	defer tg.Unlock()
+
+	retryMap := map[string][]hash.Hash256{}
+	if now.Sub(tg.expiredAt) < time.Second {
+		return retryMap
+	}
+	tg.expiredAt = now
+	for TxHash, req := range tg.requestMap {
+		if now.Sub(req.RequestedAt) < TxGetDataTimeout {
+			continue
+		}
+		req.ID = ""
+		for len(req.announcers) > 0 {
+			ID := req.announcers[0]
+			req.announcers = req.announcers[1:]
+			if _, has := tg.peerMap[ID]; has {
+				req.ID = ID
+				break
+			}
+		}
+		if len(req.ID) == 0 {
+			delete(tg.requestMap, TxHash)
+			continue
+		}
+		req.RequestedAt = now
+		retryMap[req.ID] = append(retryMap[req.ID], TxHash)
+	}
+	return retryMap
+}
+
+// retryTxRequests requests transactions that are not responded in time to other peers that announced them
+func (nd *Node) retryTxRequests() {
+	for ID, list := range nd.tgossip.expireRequests(time.Now()) {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(ID))
+		for len(list) > 0 {
+			Count := len(list)
+			if Count > MaxTxInvPerMessage {
+				Count = MaxTxInvPerMessage
+			}
+			nd.sendMessage(PriorityTransaction, pubhash, &TxGetDataMessage{
+				Hashes: list[:Count],
+			})
+			list = list[Count:]
+		}
+	}
+}
+
+// announceTxs sends the inventories of items to peers that do not know them
+// Peers that do not announce inventories are served by full transactions like the FormulatorNode
+func (nd *Node) announceTxs(items []*TxMsgItem) {
+	type target struct {
+		ID          string
+		gp          *txGossipPeer
+		supportsInv bool
+	}
+	targets := []*target{}
+	nd.tgossip.Lock()
+	for ID, gp := range nd.tgossip.peerMap {
+		targets = append(targets, &target{ID: ID, gp: gp, supportsInv: gp.supportsInv})
+	}
+	nd.tgossip.Unlock()
+
+	for _, tg := range targets {
+		var pubhash common.PublicHash
+		copy(pubhash[:], []byte(tg.ID))
+
+		inv := &TxInvMessage{
+			Hashes: []hash.Hash256{},
+		}
+		msg := &TransactionMessage{
+			Types:      []uint16{},
+			Txs:        []types.Transaction{},
+			Signatures: [][]common.Signature{},
+		}
+		for _, item := range items {
+			if item.PeerID == tg.ID || tg.gp.known.Has(item.TxHash) {
+				continue
+			}
+			tg.gp.known.Set(item.TxHash, true)
+			if tg.supportsInv {
+				inv.Hashes = append(inv.Hashes, item.TxHash)
+			} else {
+				msg.Types = append(msg.Types, item.Type)
+				msg.Txs = append(msg.Txs, item.Tx)
+				msg.Signatures = append(msg.Signatures, item.Sigs)
+			}
+		}
+		if len(inv.Hashes) > 0 {
+			nd.sendMessage(PriorityTransaction, pubhash, inv)
+		}
+		if len(msg.Types) > 0 {
+			nd.sendMessage(PriorityTransaction, pubhash, msg)
+		}
+	}
+}
+
+func (nd *Node) handleTxInvMessage(ID string, msg *TxInvMessage) error {
+	if len(msg.Hashes) > MaxTxInvPerMessage {
+		return ErrTooManyTrasactionInMessage
+	}
+	gp := nd.tgossip.peer(ID)
+	if gp == nil {
+		return nil
+	}
+	nd.tgossip.Lock()
+	Count := gp.invLimiter.allow(len(msg.Hashes))
+	nd.tgossip.Unlock()
+
+	req := &TxGetDataMessage{
+		Hashes: []hash.Hash256{},
+	}
+	now := time.Now()
+	for _, TxHash := range msg.Hashes[:Count] {
+		gp.known.Set(TxHash, true)
+		if nd.txpool.IsExist(TxHash) || !nd.tgossip.request(TxHash, ID, now) {
+			continue
+		}
+		req.Hashes = append(req.Hashes, TxHash)
+	}
+	if len(req.Hashes) > 0 {
+		var SenderPublicHash common.PublicHash
+		copy(SenderPublicHash[:], []byte(ID))
+		nd.sendMessage(PriorityTransaction, SenderPublicHash, req)
+	}
+	return nil
+}
+
+func (nd *Node) handleTxGetDataMessage(ID string, msg *TxGetDataMessage) error {
+	if len(msg.Hashes) > MaxTxInvPerMessage {
+		return ErrTooManyTrasactionInMessage
+	}
+	gp := nd.tgossip.peer(ID)
+	if gp == nil {
+		return nil
+	}
+	nd.tgossip.Lock()
+	Count := gp.getLimiter.allow(len(msg.Hashes))
+	nd.tgossip.Unlock()
+
+	var SenderPublicHash common.PublicHash
+	copy(SenderPublicHash[:], []byte(ID))
+
+	sm := &TransactionMessage{
+		Types:      []uint16{},
+		Txs:        []types.Transaction{},
+		Signatures: [][]common.Signature{},
+	}
+	for _, TxHash := range msg.Hashes[:Count] {
+		item := nd.txpool.Get(TxHash)
+		if item == nil {
+			continue
+		}
+		gp.known.Set(TxHash, true)
+		sm.Types = append(sm.Types, item.TxType)
+		sm.Txs = append(sm.Txs, item.Transaction)
+		sm.Signatures = append(sm.Signatures, item.Signatures)
+		if len(sm.Types) >= MaxTxsPerMessage {
+			nd.sendMessage(PriorityTransaction, SenderPublicHash, sm)
+			sm = &TransactionMessage{
+				Types:      []uint16{},
+				Txs:        []types.Transaction{},
+				Signatures: [][]common.Signature{},
+			}
+		}
+	}
+	if len(sm.Types) > 0 {
+		nd.sendMessage(PriorityTransaction, SenderPublicHash, sm)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fletaio/fleta_v1/common/hash"
+)
+
+func TestTxGossipRequestFallback(t *testing.T) {
+	tg := newTxGossip()
+	tg.addPeer("a", true)
+	tg.addPeer("b", true)
+	tg.addPeer("c", true)
+	TxHash := hash.Hash([]byte("tx"))
+	other := hash.Hash([]byte("other"))
+
+	now := time.Now()
+	if !tg.request(TxHash, "a", now) {
+		t.Fatal("the first announcement is not requested")
+	}
+	if tg.request(TxHash, "b", now) || tg.request(TxHash, "c", now) || tg.request(TxHash, "b", now) {
+		t.Fatal("the requested transaction is requested again")
+	}
+	if !tg.request(other, "a", now) {
+		t.Fatal("the other transaction is not requested")
+	}
+	tg.received(other)
+
+	if retryMap := tg.expireRequests(now.Add(TxGetDataTimeout / 2)); len(retryMap) != 0 {
+		t.Fatalf("retried before the timeout: %v", retryMap)
+	}
+
+	// the disconnected announcer is skipped
+	tg.removePeer("b")
+	now = now.Add(TxGetDataTimeout)
+	retryMap := tg.expireRequests(now)
+	if len(retryMap) != 1 || len(retryMap["c"]) != 1 || retryMap["c"][0] != TxHash {
+		t.Fatalf("invalid fallback: %v", retryMap)
+	}
+
+	// the request is dropped when there is no announcer to fall back
+	now = now.Add(TxGetDataTimeout)
+	if retryMap := tg.expireRequests(now); len(retryMap) != 0 {
+		t.Fatalf("retried without the announcer: %v", retryMap)
+	}
+	if _, has := tg.requestMap[TxHash]; has {
+		t.Fatal("the request is not dropped")
+	}
+	if !tg.request(TxHash, "a", now) {
+		t.Fatal("the dropped transaction is not requested again")
+	}
+}
@@ -18,6 +18,7 @@ type WebsocketPeer struct {
 	isClose       bool
 	connectedTime int64
 	pingCount     uint64
+	codec         *SecureCodec
 }
 
 // NewWebsocketPeer returns a WebsocketPeer
@@ -57,6 +58,13 @@ func NewWebsocketPeer(conn *websocket.Conn, ID string, Name string, connectedTim
 	return p
 }
 
+// NewSecureWebsocketPeer returns a WebsocketPeer that encrypts packets by the codec
+func NewSecureWebsocketPeer(conn *websocket.Conn, ID string, Name string, connectedTime int64, codec *SecureCodec) *WebsocketPeer {
+	p := NewWebsocketPeer(conn, ID, Name, connectedTime)
+	p.codec = codec
+	return p
+}
+
 // ID returns the id of the peer
 func (p *WebsocketPeer) ID() string {
 	return p.id
@@ -84,6 +92,9 @@ func (p *WebsocketPeer) ReadPacket() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.codec != nil {
+		return p.codec.Open(rb)
+	}
 	return rb, nil
 }
 
@@ -101,6 +112,9 @@ func (p *WebsocketPeer) SendPacket(bs []byte) {
 		p.Close()
 		return
 	}
+	if p.codec != nil {
+		bs = p.codec.Seal(bs)
+	}
 	if err := p.conn.WriteMessage(websocket.BinaryMessage, bs); err != nil {
 		log.Println(p.name, "SendPacket", err)
 		p.Close()
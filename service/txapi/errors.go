@@ -0,0 +1,41 @@
+package txapi
+
+import (
+	"errors"
+
+	"github.com/fletaio/fleta_v1/common/factory"
+	"github.com/fletaio/fleta_v1/core/txpool"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// errors
+var (
+	ErrTooLargeRawTransaction  = errors.New("too large raw transaction")
+	ErrInvalidRawTransaction   = errors.New("invalid raw transaction")
+	ErrInvalidSignatureCount   = errors.New("invalid signature count")
+	ErrNotTransaction          = errors.New("not transaction")
+	ErrNotStructTransaction    = errors.New("not struct transaction")
+	ErrMissingTransactionField = errors.New("missing transaction field")
+	ErrUnknownTransactionField = errors.New("unknown transaction field")
+	ErrUnsignedTransaction     = errors.New("unsigned transaction")
+	ErrNodeNotReady            = errors.New("node not ready")
+)
+
+func init() {
+	apiserver.RegisterErrorCode(ErrTooLargeRawTransaction, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrInvalidRawTransaction, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrInvalidSignatureCount, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrNotTransaction, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrMissingTransactionField, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrUnknownTransactionField, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(ErrUnsignedTransaction, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(factory.ErrUnknownType, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(factory.ErrAmbiguousType, apiserver.CodeInvalidParams)
+	apiserver.RegisterErrorCode(txpool.ErrExistTransaction, apiserver.CodeRejected)
+	apiserver.RegisterErrorCode(txpool.ErrExistTransactionSeq, apiserver.CodeRejected)
+	apiserver.RegisterErrorCode(txpool.ErrTransactionPoolOverflowed, apiserver.CodeRejected)
+	apiserver.RegisterErrorCode(txpool.ErrPastSeq, apiserver.CodeRejected)
+	apiserver.RegisterErrorCode(txpool.ErrTooFarSeq, apiserver.CodeRejected)
+	apiserver.RegisterErrorCode(txpool.ErrReplaceUnderpriced, apiserver.CodeRejected)
+	apiserver.RegisterErrorCode(txpool.ErrTooManyAddressTransactions, apiserver.CodeRejected)
+}
@@ -0,0 +1,144 @@
+package txapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+)
+
+// MaxRawTransactionSize is the maximum size of the raw transaction
+const MaxRawTransactionSize = 1024 * 1024
+
+// MaxRawSignatureCount is the maximum number of signatures of the raw transaction
+const MaxRawSignatureCount = 255
+
+// EncodeRawTransaction returns the raw transaction that is consisted of the type, the transaction and signatures
+// It is same with the encoding of the transaction in the block, the unsigned raw transaction is returned when sigs is nil
+func EncodeRawTransaction(t uint16, tx types.Transaction, sigs []common.Signature) ([]byte, error) {
+	var buffer bytes.Buffer
+	enc := encoding.NewEncoder(&buffer)
+	if err := enc.EncodeUint16(t); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(tx); err != nil {
+		return nil, err
+	}
+	if sigs != nil {
+		if err := enc.EncodeArrayLen(len(sigs)); err != nil {
+			return nil, err
+		}
+		for _, sig := range sigs {
+			if err := enc.Encode(sig); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeRawTransaction returns the type, the transaction and signatures of the raw transaction
+// Signatures are nil when the raw transaction is unsigned
+func DecodeRawTransaction(bs []byte) (uint16, types.Transaction, []common.Signature, error) {
+	if len(bs) > MaxRawTransactionSize {
+		return 0, nil, nil, ErrTooLargeRawTransaction
+	}
+	r := bytes.NewReader(bs)
+	dec := encoding.NewDecoder(r)
+	t, err := dec.DecodeUint16()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	tx, err := newTransaction(t)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if err := dec.Decode(tx); err != nil {
+		return 0, nil, nil, err
+	}
+	if r.Len() == 0 {
+		return t, tx, nil, nil
+	}
+	SigLen, err := dec.DecodeArrayLen()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if SigLen < 0 || SigLen > MaxRawSignatureCount {
+		return 0, nil, nil, ErrInvalidSignatureCount
+	}
+	sigs := make([]common.Signature, 0, SigLen)
+	for i := 0; i < SigLen; i++ {
+		var sig common.Signature
+		if err := dec.Decode(&sig); err != nil {
+			return 0, nil, nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	if r.Len() != 0 {
+		return 0, nil, nil, ErrInvalidRawTransaction
+	}
+	return t, tx, sigs, nil
+}
+
+// UnmarshalTransactionJSON returns the transaction of the type from json fields
+// Fields are matched to keys of MarshalJSON of the transaction, so all fields should be given
+func UnmarshalTransactionJSON(t uint16, data []byte) (types.Transaction, error) {
+	tx, err := newTransaction(t)
+	if err != nil {
+		return nil, err
+	}
+	mp := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &mp); err != nil {
+		return nil, err
+	}
+	keyMap := map[string]json.RawMessage{}
+	for k, v := range mp {
+		keyMap[fieldKey(k)] = v
+	}
+	rv := reflect.ValueOf(tx).Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrNotStructTransaction
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		key := fieldKey(f.Name)
+		v, has := keyMap[key]
+		if !has {
+			return nil, ErrMissingTransactionField
+		}
+		if err := json.Unmarshal(v, rv.Field(i).Addr().Interface()); err != nil {
+			return nil, err
+		}
+		delete(keyMap, key)
+	}
+	if len(keyMap) > 0 {
+		return nil, ErrUnknownTransactionField
+	}
+	return tx, nil
+}
+
+// fieldKey returns the comparable form of the field name and the json key, Timestamp_ and timestamp, KeyHash and key_hash are same
+func fieldKey(name string) string {
+	return strings.ToLower(strings.Replace(name, "_", "", -1))
+}
+
+func newTransaction(t uint16) (types.Transaction, error) {
+	fc := encoding.Factory("transaction")
+	v, err := fc.Create(t)
+	if err != nil {
+		return nil, err
+	}
+	tx, is := v.(types.Transaction)
+	if !is {
+		return nil, ErrNotTransaction
+	}
+	return tx, nil
+}
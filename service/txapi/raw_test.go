@@ -0,0 +1,136 @@
+package txapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/process/vault"
+)
+
+const testTransferType = 0xFF01
+
+func init() {
+	if err := encoding.Factory("transaction").Register(testTransferType, &vault.Transfer{}); err != nil {
+		panic(err)
+	}
+}
+
+func testTransfer() *vault.Transfer {
+	return &vault.Transfer{
+		Timestamp_: 1000,
+		Seq_:       2,
+		From_:      common.NewAddress(1, 2, 0),
+		To:         common.NewAddress(3, 4, 0),
+		Amount:     amount.NewCoinAmount(10, 5),
+	}
+}
+
+func checkTransfer(t *testing.T, a *vault.Transfer, b *vault.Transfer) {
+	if a.Timestamp_ != b.Timestamp_ || a.Seq_ != b.Seq_ || a.From_ != b.From_ || a.To != b.To || !a.Amount.Equal(b.Amount) {
+		t.Fatalf("invalid transaction: %+v, %+v", a, b)
+	}
+}
+
+func TestDecodeRawTransaction(t *testing.T) {
+	tx := testTransfer()
+
+	bs, err := EncodeRawTransaction(testTransferType, tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp, dtx, sigs, err := DecodeRawTransaction(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp != testTransferType {
+		t.Fatalf("invalid type: %v", tp)
+	}
+	if sigs != nil {
+		t.Fatalf("signatures of the unsigned transaction: %v", sigs)
+	}
+	checkTransfer(t, tx, dtx.(*vault.Transfer))
+
+	var sig common.Signature
+	for i := range sig {
+		sig[i] = byte(i)
+	}
+	bs, err = EncodeRawTransaction(testTransferType, tx, []common.Signature{sig, sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp, dtx, sigs, err = DecodeRawTransaction(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp != testTransferType {
+		t.Fatalf("invalid type: %v", tp)
+	}
+	if len(sigs) != 2 || sigs[0] != sig || sigs[1] != sig {
+		t.Fatalf("invalid signatures: %v", sigs)
+	}
+	checkTransfer(t, tx, dtx.(*vault.Transfer))
+
+	if _, _, _, err := DecodeRawTransaction(append(bs, 0)); err != ErrInvalidRawTransaction {
+		t.Fatalf("decoded the raw transaction that has trailing bytes: %v", err)
+	}
+	if _, _, _, err := DecodeRawTransaction(make([]byte, MaxRawTransactionSize+1)); err != ErrTooLargeRawTransaction {
+		t.Fatalf("decoded the too large raw transaction: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	enc := encoding.NewEncoder(&buffer)
+	if err := enc.EncodeUint16(0xFF00); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := DecodeRawTransaction(buffer.Bytes()); err == nil {
+		t.Fatal("decoded the raw transaction of the unknown type")
+	}
+}
+
+func TestUnmarshalTransactionJSON(t *testing.T) {
+	tx := testTransfer()
+
+	data, err := tx.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utx, err := UnmarshalTransactionJSON(testTransferType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTransfer(t, tx, utx.(*vault.Transfer))
+
+	mp := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &mp); err != nil {
+		t.Fatal(err)
+	}
+	delete(mp, "amount")
+	missing, err := json.Marshal(mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalTransactionJSON(testTransferType, missing); err != ErrMissingTransactionField {
+		t.Fatalf("unmarshaled the transaction that has the missing field: %v", err)
+	}
+
+	mp["amount"] = json.RawMessage(`"10.5"`)
+	mp["memo"] = json.RawMessage(`"unknown"`)
+	unknown, err := json.Marshal(mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalTransactionJSON(testTransferType, unknown); err != ErrUnknownTransactionField {
+		t.Fatalf("unmarshaled the transaction that has the unknown field: %v", err)
+	}
+
+	if _, err := UnmarshalTransactionJSON(0xFF00, data); err == nil {
+		t.Fatal("unmarshaled the transaction of the unknown type")
+	}
+}
@@ -0,0 +1,224 @@
+package txapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// Node defines functions of the node that receives transactions
+type Node interface {
+	AddTx(tx types.Transaction, sigs []common.Signature) error
+}
+
+// TxAPI serves the api to encode and submit transactions that are signed outside of the node
+type TxAPI struct {
+	types.ServiceBase
+	sync.Mutex
+	cn types.Provider
+	nd Node
+}
+
+// NewTxAPI returns a TxAPI
+func NewTxAPI() *TxAPI {
+	s := &TxAPI{}
+	return s
+}
+
+// Name returns the name of the service
+func (s *TxAPI) Name() string {
+	return "fleta.txapi"
+}
+
+// SetNode sets the node that receives transactions
+func (s *TxAPI) SetNode(nd Node) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.nd = nd
+}
+
+func (s *TxAPI) node() Node {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.nd
+}
+
+// EncodeResult is the result of tx.encode
+type EncodeResult struct {
+	Type     uint16       `json:"type"`
+	TypeName string       `json:"type_name"`
+	Payload  string       `json:"payload"`
+	TxHash   hash.Hash256 `json:"tx_hash"`
+}
+
+// Init called when initialize service
+func (s *TxAPI) Init(pm types.ProcessManager, cn types.Provider) error {
+	s.cn = cn
+
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		as, err := v.JRPC("tx")
+		if err != nil {
+			return err
+		}
+		as.Set("send", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 && arg.Len() != 2 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			t, tx, sigs, err := s.rawArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			if arg.Len() == 2 {
+				if sigs != nil {
+					return nil, ErrInvalidRawTransaction
+				}
+				str, err := arg.String(1)
+				if err != nil {
+					return nil, err
+				}
+				if err := json.Unmarshal([]byte(str), &sigs); err != nil {
+					return nil, err
+				}
+			}
+			if len(sigs) == 0 {
+				return nil, ErrUnsignedTransaction
+			}
+			nd := s.node()
+			if nd == nil {
+				return nil, ErrNodeNotReady
+			}
+			if err := nd.AddTx(tx, sigs); err != nil {
+				return nil, err
+			}
+			return chain.HashTransactionByType(s.cn.ChainID(), t, tx), nil
+		})
+		as.Set("decode", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			t, tx, sigs, err := s.rawArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			bs, err := tx.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			mp := map[string]interface{}{}
+			if err := json.Unmarshal(bs, &mp); err != nil {
+				return nil, err
+			}
+			name, err := encoding.Factory("transaction").TypeName(t)
+			if err != nil {
+				return nil, err
+			}
+			if sigs == nil {
+				sigs = []common.Signature{}
+			}
+			mp["type"] = name
+			mp["tx_hash"] = chain.HashTransactionByType(s.cn.ChainID(), t, tx).String()
+			mp["sigs"] = sigs
+			return mp, nil
+		})
+		as.Set("encode", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 2 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			fc := encoding.Factory("transaction")
+			t, err := arg.Uint16(0)
+			if err != nil {
+				name, err := arg.String(0)
+				if err != nil {
+					return nil, err
+				}
+				t, err = fc.TypeByName(name)
+				if err != nil {
+					return nil, err
+				}
+			}
+			name, err := fc.TypeName(t)
+			if err != nil {
+				return nil, err
+			}
+			str, err := arg.String(1)
+			if err != nil {
+				return nil, err
+			}
+			tx, err := UnmarshalTransactionJSON(t, []byte(str))
+			if err != nil {
+				return nil, err
+			}
+			bs, err := EncodeRawTransaction(t, tx, nil)
+			if err != nil {
+				return nil, err
+			}
+			return &EncodeResult{
+				Type:     t,
+				TypeName: name,
+				Payload:  hex.EncodeToString(bs),
+				TxHash:   chain.HashTransactionByType(s.cn.ChainID(), t, tx),
+			}, nil
+		})
+		rawParam := &apiserver.RESTParam{
+			Name:        "raw",
+			In:          apiserver.RESTParamBody,
+			Description: "the hex of the signed raw transaction",
+		}
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodPost,
+			Path:    "/v1/txs",
+			RPC:     "tx.send",
+			Summary: "submits the signed raw transaction",
+			Params:  []*apiserver.RESTParam{rawParam},
+			Result:  apiserver.StringSchema("the hash of the transaction"),
+		}); err != nil {
+			return err
+		}
+		if err := v.REST(&apiserver.RESTRoute{
+			Method:  http.MethodPost,
+			Path:    "/v1/txs/decode",
+			RPC:     "tx.decode",
+			Summary: "decodes the raw transaction",
+			Params:  []*apiserver.RESTParam{rawParam},
+			Result: &apiserver.Schema{
+				Type: "object",
+				Properties: map[string]*apiserver.Schema{
+					"type":    apiserver.StringSchema("the type name"),
+					"tx_hash": apiserver.StringSchema(""),
+					"sigs":    apiserver.ArraySchema(apiserver.StringSchema("")),
+				},
+				AdditionalProperties: &apiserver.Schema{},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TxAPI) rawArgument(arg *apiserver.Argument) (uint16, types.Transaction, []common.Signature, error) {
+	str, err := arg.String(0)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	bs, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return DecodeRawTransaction(bs)
+}
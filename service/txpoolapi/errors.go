@@ -0,0 +1,17 @@
+package txpoolapi
+
+import (
+	"errors"
+
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// errors
+var (
+	ErrNotExistTransaction = errors.New("not exist transaction")
+	ErrNodeNotReady        = errors.New("node not ready")
+)
+
+func init() {
+	apiserver.RegisterErrorCode(ErrNotExistTransaction, apiserver.CodeNotFound)
+}
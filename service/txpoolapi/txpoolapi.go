@@ -0,0 +1,370 @@
+package txpoolapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/fletaio/fleta_v1/common"
+	"github.com/fletaio/fleta_v1/common/amount"
+	"github.com/fletaio/fleta_v1/common/hash"
+	"github.com/fletaio/fleta_v1/core/chain"
+	"github.com/fletaio/fleta_v1/core/txpool"
+	"github.com/fletaio/fleta_v1/core/types"
+	"github.com/fletaio/fleta_v1/encoding"
+	"github.com/fletaio/fleta_v1/service/apiserver"
+)
+
+// MaxContentCount is the maximum number of transactions that returned by txpool.content
+const MaxContentCount = 1000
+
+// Node defines functions of the node that has the transaction pool
+type Node interface {
+	TxPoolList() []*txpool.PoolItem
+	GetTxFromTXPool(TxHash hash.Hash256) *txpool.PoolItem
+	RemoveTxFromTXPool(TxHash hash.Hash256) []*txpool.PoolItem
+}
+
+// TxPoolAPI serves the api to inspect and manage pending transactions of the node
+type TxPoolAPI struct {
+	types.ServiceBase
+	sync.Mutex
+	nd Node
+}
+
+// NewTxPoolAPI returns a TxPoolAPI
+func NewTxPoolAPI() *TxPoolAPI {
+	s := &TxPoolAPI{}
+	return s
+}
+
+// Name returns the name of the service
+func (s *TxPoolAPI) Name() string {
+	return "fleta.txpoolapi"
+}
+
+// SetNode sets the node that has the transaction pool
+func (s *TxPoolAPI) SetNode(nd Node) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.nd = nd
+}
+
+func (s *TxPoolAPI) node() (Node, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.nd == nil {
+		return nil, ErrNodeNotReady
+	}
+	return s.nd, nil
+}
+
+// Status is the result of txpool.status
+type Status struct {
+	Size          int            `json:"size"`
+	AccountCount  int            `json:"account_count"`
+	UTXOCount     int            `json:"utxo_count"`
+	AddressCounts map[string]int `json:"address_counts"`
+	TypeCounts    map[string]int `json:"type_counts"`
+}
+
+// Transaction is the transaction in the pool
+type Transaction struct {
+	TxHash      hash.Hash256       `json:"tx_hash"`
+	Type        string             `json:"type"`
+	From        *common.Address    `json:"from,omitempty"`
+	Seq         uint64             `json:"seq,omitempty"`
+	Fee         *amount.Amount     `json:"fee"`
+	PushedAt    int64              `json:"pushed_at"`
+	Transaction json.RawMessage    `json:"transaction"`
+	Signatures  []common.Signature `json:"sigs"`
+}
+
+// Content is the result of txpool.content, Total is the number of matched transactions
+type Content struct {
+	Transactions []*Transaction `json:"transactions"`
+	Total        int            `json:"total"`
+}
+
+// Filter is the filter of txpool.content, empty fields match all transactions
+type Filter struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+}
+
+// Init called when initialize service
+func (s *TxPoolAPI) Init(pm types.ProcessManager, cn types.Provider) error {
+	if vs, err := pm.ServiceByName("fleta.apiserver"); err != nil {
+		//ignore when not loaded
+	} else if v, is := vs.(*apiserver.APIServer); !is {
+		//ignore when not loaded
+	} else {
+		as, err := v.JRPC("txpool")
+		if err != nil {
+			return err
+		}
+		as.Set("status", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			nd, err := s.node()
+			if err != nil {
+				return nil, err
+			}
+			fc := encoding.Factory("transaction")
+			st := &Status{
+				AddressCounts: map[string]int{},
+				TypeCounts:    map[string]int{},
+			}
+			for _, item := range nd.TxPoolList() {
+				st.Size++
+				if tx, is := item.Transaction.(chain.AccountTransaction); is {
+					st.AccountCount++
+					st.AddressCounts[tx.From().String()]++
+				} else {
+					st.UTXOCount++
+				}
+				if name, err := fc.TypeName(item.TxType); err == nil {
+					st.TypeCounts[name]++
+				}
+			}
+			return st, nil
+		})
+		as.Set("content", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			filter, err := filterArgument(arg)
+			if err != nil {
+				return nil, err
+			}
+			nd, err := s.node()
+			if err != nil {
+				return nil, err
+			}
+			var From common.Address
+			if len(filter.Address) > 0 {
+				if From, err = common.ParseAddress(filter.Address); err != nil {
+					return nil, err
+				}
+			}
+			fc := encoding.Factory("transaction")
+			var Type uint16
+			if len(filter.Type) > 0 {
+				if Type, err = fc.TypeByName(filter.Type); err != nil {
+					return nil, err
+				}
+			}
+			items := []*txpool.PoolItem{}
+			for _, item := range nd.TxPoolList() {
+				if len(filter.Type) > 0 && item.TxType != Type {
+					continue
+				}
+				if len(filter.Address) > 0 {
+					if tx, is := item.Transaction.(chain.AccountTransaction); !is || tx.From() != From {
+						continue
+					}
+				}
+				items = append(items, item)
+			}
+			sort.Slice(items, func(i, j int) bool {
+				return items[i].PushedAt.Before(items[j].PushedAt)
+			})
+			result := &Content{
+				Transactions: []*Transaction{},
+				Total:        len(items),
+			}
+			for _, item := range items {
+				if len(result.Transactions) >= MaxContentCount {
+					break
+				}
+				t, err := newTransaction(item)
+				if err != nil {
+					return nil, err
+				}
+				result.Transactions = append(result.Transactions, t)
+			}
+			return result, nil
+		})
+		as.Set("get", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			TxHash, err := arg.Hash(0)
+			if err != nil {
+				return nil, err
+			}
+			nd, err := s.node()
+			if err != nil {
+				return nil, err
+			}
+			item := nd.GetTxFromTXPool(TxHash)
+			if item == nil {
+				return nil, ErrNotExistTransaction
+			}
+			return newTransaction(item)
+		})
+		as.Set("remove", func(ID interface{}, arg *apiserver.Argument) (interface{}, error) {
+			if arg.Len() != 1 {
+				return nil, apiserver.ErrInvalidArgument
+			}
+			TxHash, err := arg.Hash(0)
+			if err != nil {
+				return nil, err
+			}
+			nd, err := s.node()
+			if err != nil {
+				return nil, err
+			}
+			removed := nd.RemoveTxFromTXPool(TxHash)
+			if len(removed) == 0 {
+				return nil, ErrNotExistTransaction
+			}
+			list := []hash.Hash256{}
+			for _, item := range removed {
+				list = append(list, item.TxHash)
+			}
+			return list, nil
+		})
+		v.RequireRoles("txpool.remove", apiserver.RoleAdmin)
+		for _, route := range restRoutes() {
+			if err := v.REST(route); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filterArgument returns the filter from the filter object or from the address and the type
+func filterArgument(arg *apiserver.Argument) (*Filter, error) {
+	filter := &Filter{}
+	if arg.Len() == 0 {
+		return filter, nil
+	}
+	if arg.Len() == 1 {
+		if err := arg.Decode(0, filter); err == nil {
+			return filter, nil
+		}
+	}
+	if arg.Len() > 2 {
+		return nil, apiserver.ErrInvalidArgument
+	}
+	addr, err := optionalString(arg, 0)
+	if err != nil {
+		return nil, err
+	}
+	filter.Address = addr
+	if arg.Len() == 2 {
+		t, err := optionalString(arg, 1)
+		if err != nil {
+			return nil, err
+		}
+		filter.Type = t
+	}
+	return filter, nil
+}
+
+// optionalString returns the string of the index, null is an empty string
+func optionalString(arg *apiserver.Argument, index int) (string, error) {
+	if raw, err := arg.Raw(index); err == nil && string(raw) == "null" {
+		return "", nil
+	}
+	return arg.String(index)
+}
+
+func newTransaction(item *txpool.PoolItem) (*Transaction, error) {
+	name, err := encoding.Factory("transaction").TypeName(item.TxType)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := item.Transaction.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	t := &Transaction{
+		TxHash:      item.TxHash,
+		Type:        name,
+		Fee:         item.Fee,
+		PushedAt:    item.PushedAt.UnixNano(),
+		Transaction: bs,
+		Signatures:  item.Signatures,
+	}
+	if t.Signatures == nil {
+		t.Signatures = []common.Signature{}
+	}
+	if tx, is := item.Transaction.(chain.AccountTransaction); is {
+		From := tx.From()
+		t.From = &From
+		t.Seq = tx.Seq()
+	}
+	return t, nil
+}
+
+// restRoutes returns rest routes of the txpool namespace
+func restRoutes() []*apiserver.RESTRoute {
+	txSchema := apiserver.ObjectSchema(map[string]*apiserver.Schema{
+		"tx_hash":     apiserver.StringSchema(""),
+		"type":        apiserver.StringSchema("the type name"),
+		"from":        apiserver.StringSchema("the sender of the account model based transaction"),
+		"seq":         apiserver.IntegerSchema("int64", ""),
+		"fee":         apiserver.StringSchema("the amount"),
+		"pushed_at":   apiserver.IntegerSchema("int64", "unix nano"),
+		"transaction": apiserver.MapSchema(&apiserver.Schema{}),
+		"sigs":        apiserver.ArraySchema(apiserver.StringSchema("")),
+	})
+	hashParam := &apiserver.RESTParam{
+		Name: "hash",
+		In:   apiserver.RESTParamPath,
+	}
+	return []*apiserver.RESTRoute{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/txpool/status",
+			RPC:     "txpool.status",
+			Summary: "returns the number of pending transactions",
+			Result: apiserver.ObjectSchema(map[string]*apiserver.Schema{
+				"size":           apiserver.IntegerSchema("int32", ""),
+				"account_count":  apiserver.IntegerSchema("int32", ""),
+				"utxo_count":     apiserver.IntegerSchema("int32", ""),
+				"address_counts": apiserver.MapSchema(apiserver.IntegerSchema("int32", "")),
+				"type_counts":    apiserver.MapSchema(apiserver.IntegerSchema("int32", "")),
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/txpool/txs",
+			RPC:     "txpool.content",
+			Summary: "returns pending transactions in the order of the arrival",
+			Params: []*apiserver.RESTParam{
+				{
+					Name:        "address",
+					In:          apiserver.RESTParamQuery,
+					Description: "the sender of account model based transactions",
+				},
+				{
+					Name:        "type",
+					In:          apiserver.RESTParamQuery,
+					Description: "the type name like vault.Transfer",
+				},
+			},
+			Result: apiserver.ObjectSchema(map[string]*apiserver.Schema{
+				"transactions": apiserver.ArraySchema(txSchema),
+				"total":        apiserver.IntegerSchema("int32", "the number of matched transactions, at most 1000 are returned"),
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/txpool/txs/:hash",
+			RPC:     "txpool.get",
+			Summary: "returns the pending transaction of the hash",
+			Params:  []*apiserver.RESTParam{hashParam},
+			Result:  txSchema,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v1/txpool/txs/:hash",
+			RPC:     "txpool.remove",
+			Summary: "removes the pending transaction and following transactions of the same sender",
+			Params:  []*apiserver.RESTParam{hashParam},
+			Result:  apiserver.ArraySchema(apiserver.StringSchema("the hash of the removed transaction")),
+		},
+	}
+}